@@ -0,0 +1,265 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/console/prompt"
+	"github.com/MFAChain/mfachain/core/vm"
+)
+
+const debuggerHelp = `Commands:
+  step, s                      run the next instruction
+  continue, c                  run until the next breakpoint
+  break, b <pc> [address]      break at a program counter
+  break, b op <opcode>         break at an opcode
+  break, b address <address>   break when a call into an address starts
+  break, b sstore [slot]       break at the storage writes
+  delete, d <id>               delete a breakpoint
+  breakpoints, bp              list the breakpoints
+  state, i                     show the current instruction
+  stack                        show the stack
+  memory                       show the memory
+  storage <slot> [address]     show a storage slot
+  quit, q                      run to the end without pausing
+`
+
+// runDebugger drives an execution paused by the debugger from the terminal,
+// until it finishes or the user quits.
+func runDebugger(debugger *vm.Debugger) {
+	defer debugger.Close()
+
+	fmt.Println("EVM debugger, type 'help' for the commands")
+	state, err := debugger.State()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	printDebugState(state)
+
+	var last []string
+	for !state.Done {
+		input, err := prompt.Stdin.PromptInput("(evm) ")
+		if err != nil {
+			return
+		}
+		args := strings.Fields(input)
+		if len(args) == 0 {
+			// Repeat the last command, handy for stepping
+			if args = last; len(args) == 0 {
+				continue
+			}
+		} else {
+			prompt.Stdin.AppendHistory(input)
+			last = args
+		}
+		next, err := runDebuggerCommand(debugger, state, args)
+		switch {
+		case err == errDebuggerQuit:
+			return
+		case err != nil:
+			fmt.Println("error:", err)
+		case next != nil:
+			state = next
+			printDebugState(state)
+		}
+	}
+}
+
+// errDebuggerQuit is returned by the quit command.
+var errDebuggerQuit = errors.New("quit")
+
+// runDebuggerCommand runs a command, returning the new state of the execution
+// if it moved.
+func runDebuggerCommand(debugger *vm.Debugger, state *vm.DebugState, args []string) (*vm.DebugState, error) {
+	switch args[0] {
+	case "step", "s":
+		return debugger.Step()
+
+	case "continue", "c":
+		return debugger.Continue()
+
+	case "break", "b":
+		bp, err := parseBreakpoint(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		id, err := debugger.AddBreakpoint(bp)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("breakpoint %d set\n", id)
+
+	case "delete", "d":
+		if len(args) != 2 {
+			return nil, errors.New("usage: delete <id>")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if !debugger.RemoveBreakpoint(id) {
+			return nil, fmt.Errorf("breakpoint %d not found", id)
+		}
+
+	case "breakpoints", "bp":
+		for _, bp := range debugger.Breakpoints() {
+			fmt.Println(formatBreakpoint(bp))
+		}
+
+	case "state", "i":
+		printDebugState(state)
+
+	case "stack":
+		for i := len(state.Stack) - 1; i >= 0; i-- {
+			fmt.Printf("%04d: %#x\n", len(state.Stack)-1-i, state.Stack[i].ToInt())
+		}
+
+	case "memory":
+		for i := 0; i < len(state.Memory); i += 32 {
+			end := i + 32
+			if end > len(state.Memory) {
+				end = len(state.Memory)
+			}
+			fmt.Printf("%04x: %x\n", i, []byte(state.Memory[i:end]))
+		}
+
+	case "storage":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, errors.New("usage: storage <slot> [address]")
+		}
+		var addr *common.Address
+		if len(args) == 3 {
+			if !common.IsHexAddress(args[2]) {
+				return nil, fmt.Errorf("invalid address %q", args[2])
+			}
+			address := common.HexToAddress(args[2])
+			addr = &address
+		}
+		value, err := debugger.Storage(addr, common.HexToHash(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(value.Hex())
+
+	case "quit", "q":
+		return nil, errDebuggerQuit
+
+	case "help", "h":
+		fmt.Print(debuggerHelp)
+
+	default:
+		return nil, fmt.Errorf("unknown command %q, type 'help' for the commands", args[0])
+	}
+	return nil, nil
+}
+
+// parseBreakpoint parses the arguments of the break command.
+func parseBreakpoint(args []string) (vm.Breakpoint, error) {
+	var bp vm.Breakpoint
+	if len(args) == 0 {
+		return bp, errors.New("usage: break <pc> [address] | op <opcode> | address <address> | sstore [slot]")
+	}
+	parseAddress := func(s string) (*common.Address, error) {
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		addr := common.HexToAddress(s)
+		return &addr, nil
+	}
+	var err error
+	switch args[0] {
+	case "op":
+		if len(args) != 2 {
+			return bp, errors.New("usage: break op <opcode>")
+		}
+		bp.Op = strings.ToUpper(args[1])
+
+	case "address":
+		if len(args) != 2 {
+			return bp, errors.New("usage: break address <address>")
+		}
+		bp.Address, err = parseAddress(args[1])
+
+	case "sstore":
+		if len(args) > 2 {
+			return bp, errors.New("usage: break sstore [slot]")
+		}
+		bp.Storage = true
+		if len(args) == 2 {
+			slot := common.HexToHash(args[1])
+			bp.Slot = &slot
+		}
+
+	default:
+		if len(args) > 2 {
+			return bp, errors.New("usage: break <pc> [address]")
+		}
+		pc, err := strconv.ParseUint(args[0], 0, 64)
+		if err != nil {
+			return bp, fmt.Errorf("invalid program counter %q", args[0])
+		}
+		bp.Pc = &pc
+		if len(args) == 2 {
+			bp.Address, err = parseAddress(args[1])
+		}
+		return bp, err
+	}
+	return bp, err
+}
+
+// formatBreakpoint describes a breakpoint.
+func formatBreakpoint(bp vm.Breakpoint) string {
+	desc := fmt.Sprintf("%d:", bp.ID)
+	if bp.Pc != nil {
+		desc += fmt.Sprintf(" pc=%d", *bp.Pc)
+	}
+	if bp.Op != "" {
+		desc += " op=" + bp.Op
+	}
+	if bp.Storage || bp.Slot != nil {
+		desc += " sstore"
+	}
+	if bp.Slot != nil {
+		desc += " slot=" + bp.Slot.Hex()
+	}
+	if bp.Address != nil {
+		desc += " address=" + bp.Address.Hex()
+	}
+	return desc
+}
+
+// printDebugState prints the instruction the execution is paused at, or its
+// outcome once done.
+func printDebugState(state *vm.DebugState) {
+	if state.Done {
+		fmt.Printf("execution finished, output 0x%x\n", []byte(state.Output))
+		if state.Error != "" {
+			fmt.Printf(" error: %v\n", state.Error)
+		}
+		return
+	}
+	if state.Breakpoint != nil {
+		fmt.Printf("breakpoint %d hit\n", *state.Breakpoint)
+	}
+	fmt.Printf("%-16spc=%08d gas=%v cost=%v depth=%d address=%s\n", state.Op, state.Pc, state.Gas, state.GasCost, state.Depth, state.Address.Hex())
+}
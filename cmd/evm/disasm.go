@@ -17,6 +17,8 @@
 package main
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -26,11 +28,17 @@ import (
 	cli "gopkg.in/urfave/cli.v1"
 )
 
+var CFGFlag = cli.StringFlag{
+	Name:  "cfg",
+	Usage: "output the control flow graph instead of the listing (json or dot)",
+}
+
 var disasmCommand = cli.Command{
 	Action:    disasmCmd,
 	Name:      "disasm",
 	Usage:     "disassembles evm binary",
 	ArgsUsage: "<file>",
+	Flags:     []cli.Flag{CFGFlag},
 }
 
 func disasmCmd(ctx *cli.Context) error {
@@ -50,6 +58,32 @@ func disasmCmd(ctx *cli.Context) error {
 	}
 
 	code := strings.TrimSpace(in)
+	if format := ctx.String(CFGFlag.Name); format != "" {
+		return printCFG(code, format)
+	}
 	fmt.Printf("%v\n", code)
 	return asm.PrintDisassembled(code)
 }
+
+// printCFG prints the control flow graph of the hex encoded code.
+func printCFG(code string, format string) error {
+	bin, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(code, "0x"), "0X"))
+	if err != nil {
+		return err
+	}
+	graph := asm.NewControlFlowGraph(bin)
+
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "dot":
+		fmt.Print(graph.DOT())
+	default:
+		return fmt.Errorf("unknown control flow graph format %q, want json or dot", format)
+	}
+	return nil
+}
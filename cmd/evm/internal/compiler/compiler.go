@@ -19,12 +19,14 @@ package compiler
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/MFAChain/mfachain/core/asm"
 )
 
 func Compile(fn string, src []byte, debug bool) (string, error) {
 	compiler := asm.NewCompiler(debug)
+	compiler.SetIncludeDir(filepath.Dir(fn))
 	compiler.Feed(asm.Lex(src, debug))
 
 	bin, compileErrors := compiler.Compile()
@@ -0,0 +1,302 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/common/math"
+	"github.com/MFAChain/mfachain/consensus/clique"
+	"github.com/MFAChain/mfachain/consensus/mfa"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+//go:generate gencodec -type header -field-override headerMarshaling -out gen_header.go
+
+// header is the header of the block to build. The fields derivable from the
+// body of the block are optional, and computed when missing.
+type header struct {
+	ParentHash  common.Hash       `json:"parentHash"`
+	OmmerHash   *common.Hash      `json:"sha3Uncles"`
+	Coinbase    *common.Address   `json:"miner"`
+	Root        common.Hash       `json:"stateRoot"        gencodec:"required"`
+	TxHash      *common.Hash      `json:"transactionsRoot"`
+	ReceiptHash *common.Hash      `json:"receiptsRoot"`
+	Bloom       types.Bloom       `json:"logsBloom"`
+	Difficulty  *big.Int          `json:"difficulty"`
+	Number      *big.Int          `json:"number"           gencodec:"required"`
+	GasLimit    uint64            `json:"gasLimit"         gencodec:"required"`
+	GasUsed     uint64            `json:"gasUsed"`
+	Time        uint64            `json:"timestamp"        gencodec:"required"`
+	Extra       []byte            `json:"extraData"`
+	MixDigest   common.Hash       `json:"mixHash"`
+	Nonce       *types.BlockNonce `json:"nonce"`
+}
+
+type headerMarshaling struct {
+	Difficulty *math.HexOrDecimal256
+	Number     *math.HexOrDecimal256
+	GasLimit   math.HexOrDecimal64
+	GasUsed    math.HexOrDecimal64
+	Time       math.HexOrDecimal64
+	Extra      hexutil.Bytes
+}
+
+// bbInput is the combined input of the block builder, when read from stdin.
+type bbInput struct {
+	Header *header        `json:"header,omitempty"`
+	Ommers []string       `json:"ommers,omitempty"`
+	TxRlp  *hexutil.Bytes `json:"txs,omitempty"`
+}
+
+// bbOutput is the block built, as the RLP encoding and the hash of the block.
+type bbOutput struct {
+	Rlp  hexutil.Bytes `json:"rlp"`
+	Hash common.Hash   `json:"hash"`
+}
+
+// sealer seals the blocks built.
+type sealer func(block *types.Block) (*types.Block, error)
+
+// BuildBlock runs the block builder: it assembles the header, the transactions
+// and the ommers into a block, seals it if requested, and outputs its RLP.
+func BuildBlock(ctx *cli.Context) error {
+	// Configure the mfachain logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	baseDir := ""
+	if ctx.IsSet(OutputBasedir.Name) {
+		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
+			if err := os.MkdirAll(base, 0755); err != nil {
+				return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+			}
+			baseDir = base
+		}
+	}
+	seal, err := newSealer(ctx)
+	if err != nil {
+		return err
+	}
+	// Read the header, the ommers and the transactions, from stdin or files
+	var (
+		headerStr = ctx.String(InputHeaderFlag.Name)
+		ommersStr = ctx.String(InputOmmersFlag.Name)
+		txsStr    = ctx.String(InputTxsRlpFlag.Name)
+		inputData = &bbInput{}
+	)
+	if headerStr == stdinSelector || ommersStr == stdinSelector || txsStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+	}
+	if headerStr != stdinSelector {
+		var h header
+		if err := readJSONFile(headerStr, &h); err != nil {
+			return err
+		}
+		inputData.Header = &h
+	}
+	if inputData.Header == nil {
+		return NewError(ErrorJson, errors.New("missing header"))
+	}
+	if ommersStr != stdinSelector && ommersStr != "" {
+		if err := readJSONFile(ommersStr, &inputData.Ommers); err != nil {
+			return err
+		}
+	}
+	if txsStr != stdinSelector && txsStr != "" {
+		var txRlp hexutil.Bytes
+		if err := readJSONFile(txsStr, &txRlp); err != nil {
+			return err
+		}
+		inputData.TxRlp = &txRlp
+	}
+	block, err := inputData.toBlock()
+	if err != nil {
+		return err
+	}
+	if seal != nil {
+		if block, err = seal(block); err != nil {
+			return err
+		}
+	}
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return NewError(ErrorRlp, fmt.Errorf("failed encoding block: %v", err))
+	}
+	output := &bbOutput{Rlp: enc, Hash: block.Hash()}
+
+	switch dest := ctx.String(OutputBlockFlag.Name); dest {
+	case "stdout", "stderr":
+		b, err := json.MarshalIndent(map[string]interface{}{"block": output}, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		if dest == "stdout" {
+			os.Stdout.Write(append(b, '\n'))
+		} else {
+			os.Stderr.Write(append(b, '\n'))
+		}
+		return nil
+	default:
+		return saveFile(baseDir, dest, output)
+	}
+}
+
+// toBlock assembles the block out of the input, filling in the missing header
+// fields from the body.
+func (i *bbInput) toBlock() (*types.Block, error) {
+	ommers := make([]*types.Header, len(i.Ommers))
+	for n, str := range i.Ommers {
+		enc, err := hexutil.Decode(str)
+		if err != nil {
+			return nil, NewError(ErrorJson, fmt.Errorf("invalid ommer %d: %v", n, err))
+		}
+		ommers[n] = new(types.Header)
+		if err := rlp.DecodeBytes(enc, ommers[n]); err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("failed decoding ommer %d: %v", n, err))
+		}
+	}
+	var txs types.Transactions
+	if i.TxRlp != nil && len(*i.TxRlp) > 0 {
+		if err := rlp.DecodeBytes(*i.TxRlp, &txs); err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("failed decoding transactions: %v", err))
+		}
+	}
+	h := i.Header
+	header := &types.Header{
+		ParentHash:  h.ParentHash,
+		UncleHash:   types.CalcUncleHash(ommers),
+		Root:        h.Root,
+		TxHash:      types.DeriveSha(txs),
+		ReceiptHash: types.EmptyRootHash,
+		Bloom:       h.Bloom,
+		Difficulty:  common.Big0,
+		Number:      h.Number,
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		Time:        h.Time,
+		Extra:       h.Extra,
+		MixDigest:   h.MixDigest,
+	}
+	if h.OmmerHash != nil {
+		header.UncleHash = *h.OmmerHash
+	}
+	if h.Coinbase != nil {
+		header.Coinbase = *h.Coinbase
+	}
+	if h.TxHash != nil {
+		header.TxHash = *h.TxHash
+	}
+	if h.ReceiptHash != nil {
+		header.ReceiptHash = *h.ReceiptHash
+	}
+	if h.Difficulty != nil {
+		header.Difficulty = h.Difficulty
+	}
+	if h.Nonce != nil {
+		header.Nonce = *h.Nonce
+	}
+	return types.NewBlockWithHeader(header).WithBody(txs, ommers), nil
+}
+
+// newSealer creates the sealer requested on the command line, or nil if the
+// block isn't to be sealed.
+func newSealer(ctx *cli.Context) (sealer, error) {
+	cliqueKey := ctx.String(SealCliqueFlag.Name)
+	if cliqueKey != "" && ctx.Bool(SealMfaashFlag.Name) {
+		return nil, NewError(ErrorConfig, errors.New("both mfaash and clique sealing specified, only one may be chosen"))
+	}
+	switch {
+	case cliqueKey != "":
+		key, err := crypto.LoadECDSA(cliqueKey)
+		if err != nil {
+			return nil, NewError(ErrorConfig, fmt.Errorf("failed loading clique key: %v", err))
+		}
+		return func(block *types.Block) (*types.Block, error) {
+			return sealClique(block, key)
+		}, nil
+
+	case ctx.Bool(SealMfaashFlag.Name):
+		var engine *mfa.Ethash
+		switch mode := ctx.String(SealMfaashModeFlag.Name); mode {
+		case "normal":
+			dir := ctx.String(SealMfaashDirFlag.Name)
+			engine = mfa.New(mfa.Config{
+				CacheDir:       dir,
+				CachesInMem:    2,
+				CachesOnDisk:   3,
+				DatasetDir:     dir,
+				DatasetsInMem:  1,
+				DatasetsOnDisk: 2,
+				PowMode:        mfa.ModeNormal,
+			}, nil, false)
+		case "test":
+			engine = mfa.NewTester(nil, false)
+		case "fake":
+			engine = mfa.NewFaker()
+		default:
+			return nil, NewError(ErrorConfig, fmt.Errorf("unknown mfaash mode %q", mode))
+		}
+		return func(block *types.Block) (*types.Block, error) {
+			defer engine.Close()
+			return sealMfaash(block, engine)
+		}, nil
+	}
+	return nil, nil
+}
+
+// sealMfaash seals the block with a proof-of-work found by the given engine.
+func sealMfaash(block *types.Block, engine *mfa.Ethash) (*types.Block, error) {
+	if block.Difficulty().Sign() <= 0 {
+		return nil, NewError(ErrorSealing, errors.New("mfaash sealing requires a positive difficulty"))
+	}
+	results := make(chan *types.Block, 1)
+	if err := engine.Seal(nil, block, results, nil); err != nil {
+		return nil, NewError(ErrorSealing, fmt.Errorf("failed sealing block: %v", err))
+	}
+	return <-results, nil
+}
+
+// sealClique seals the block by signing it with the given key, the signature
+// being written over the last 65 bytes of the extra data.
+func sealClique(block *types.Block, key *ecdsa.PrivateKey) (*types.Block, error) {
+	header := block.Header()
+	if len(header.Extra) < crypto.SignatureLength {
+		return nil, NewError(ErrorSealing, fmt.Errorf("clique sealing requires at least %d bytes of extra data", crypto.SignatureLength))
+	}
+	sig, err := crypto.Sign(clique.SealHash(header).Bytes(), key)
+	if err != nil {
+		return nil, NewError(ErrorSealing, fmt.Errorf("failed signing block: %v", err))
+	}
+	copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sig)
+	return block.WithSeal(header), nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/math"
+	"github.com/MFAChain/mfachain/consensus"
+	"github.com/MFAChain/mfachain/consensus/misc"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/params"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/tests"
+)
+
+// Prestate is the state the transactions are applied on: the accounts and the
+// environment of the block.
+type Prestate struct {
+	Env stEnv             `json:"env"`
+	Pre core.GenesisAlloc `json:"pre"`
+}
+
+// ExecutionResult contains the roots, receipts and gas usage of the block made
+// of the included transactions, and the reasons of the rejected ones.
+type ExecutionResult struct {
+	StateRoot   common.Hash         `json:"stateRoot"`
+	TxRoot      common.Hash         `json:"txRoot"`
+	ReceiptRoot common.Hash         `json:"receiptRoot"`
+	LogsHash    common.Hash         `json:"logsHash"`
+	Bloom       types.Bloom         `json:"logsBloom"`
+	GasUsed     math.HexOrDecimal64 `json:"gasUsed"`
+	Receipts    types.Receipts      `json:"receipts"`
+	Rejected    []*rejectedTx       `json:"rejected,omitempty"`
+}
+
+// rejectedTx is a transaction which couldn't be included in the block.
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Err   string `json:"error"`
+}
+
+type ommer struct {
+	Delta   uint64         `json:"delta"`
+	Address common.Address `json:"address"`
+}
+
+//go:generate gencodec -type stEnv -field-override stEnvMarshaling -out gen_stenv.go
+
+type stEnv struct {
+	Coinbase    common.Address                      `json:"currentCoinbase"   gencodec:"required"`
+	Difficulty  *big.Int                            `json:"currentDifficulty" gencodec:"required"`
+	GasLimit    uint64                              `json:"currentGasLimit"   gencodec:"required"`
+	Number      uint64                              `json:"currentNumber"     gencodec:"required"`
+	Timestamp   uint64                              `json:"currentTimestamp"  gencodec:"required"`
+	BlockHashes map[math.HexOrDecimal64]common.Hash `json:"blockHashes,omitempty"`
+	Ommers      []ommer                             `json:"ommers,omitempty"`
+}
+
+type stEnvMarshaling struct {
+	Coinbase   common.UnprefixedAddress
+	Difficulty *math.HexOrDecimal256
+	GasLimit   math.HexOrDecimal64
+	Number     math.HexOrDecimal64
+	Timestamp  math.HexOrDecimal64
+}
+
+// envChain is a chain context serving headers made of the block hashes of the
+// env, so BLOCKHASH is evaluated just like on a real chain. Hashes missing from
+// the env evaluate to zero.
+type envChain struct {
+	hashes map[math.HexOrDecimal64]common.Hash
+}
+
+// Engine implements core.ChainContext, there's no engine as the author of the
+// block is always given.
+func (c *envChain) Engine() consensus.Engine {
+	return nil
+}
+
+// GetHeader implements core.ChainContext, returning a header only made of its
+// number and parent hash.
+func (c *envChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if have, ok := c.hashes[math.HexOrDecimal64(number)]; !ok || have != hash || number == 0 {
+		return nil
+	}
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		ParentHash: c.hashes[math.HexOrDecimal64(number-1)],
+	}
+}
+
+// tracerFn returns the tracer of a transaction, given its index in the input,
+// along with a function releasing it, or a nil tracer if the transaction isn't
+// traced.
+type tracerFn func(txIndex int, txHash common.Hash) (vm.Tracer, func() error, error)
+
+// Apply applies a set of transactions to a pre-state, rejecting the ones which
+// can't be included in the block, and credits the mining rewards unless the
+// reward is negative.
+func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig, txs types.Transactions, miningReward int64, getTracerFn tracerFn) (*state.StateDB, *ExecutionResult, error) {
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), pre.Pre, false)
+
+	header := &types.Header{
+		Coinbase:   pre.Env.Coinbase,
+		Difficulty: pre.Env.Difficulty,
+		GasLimit:   pre.Env.GasLimit,
+		Number:     new(big.Int).SetUint64(pre.Env.Number),
+		Time:       pre.Env.Timestamp,
+	}
+	if pre.Env.Number > 0 {
+		header.ParentHash = pre.Env.BlockHashes[math.HexOrDecimal64(pre.Env.Number-1)]
+	}
+	var (
+		chain    = &envChain{hashes: pre.Env.BlockHashes}
+		gaspool  = new(core.GasPool).AddGas(pre.Env.GasLimit)
+		gasUsed  uint64
+		included types.Transactions
+		receipts types.Receipts
+		rejected []*rejectedTx
+	)
+	// If the DAO fork is supported and happens at this block, apply it before
+	// the transactions, like the state processor does
+	if chainConfig.DAOForkSupport && chainConfig.DAOForkBlock != nil && chainConfig.DAOForkBlock.Cmp(header.Number) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, tx := range txs {
+		tracer, release, err := getTracerFn(i, tx.Hash())
+		if err != nil {
+			return nil, nil, err
+		}
+		vmConfig.Tracer = tracer
+		vmConfig.Debug = tracer != nil
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(included))
+
+		var (
+			snapshot = statedb.Snapshot()
+			gas      = gaspool.Gas()
+		)
+		receipt, err := core.ApplyTransaction(chainConfig, chain, &pre.Env.Coinbase, gaspool, statedb, header, tx, &gasUsed, vmConfig)
+		if release != nil {
+			if err := release(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			*gaspool = core.GasPool(gas)
+
+			log.Info("Rejected transaction", "index", i, "hash", tx.Hash(), "err", err)
+			rejected = append(rejected, &rejectedTx{i, err.Error()})
+			continue
+		}
+		included = append(included, tx)
+		receipts = append(receipts, receipt)
+	}
+	eip158 := chainConfig.IsEIP158(header.Number)
+	statedb.IntermediateRoot(eip158)
+
+	if miningReward >= 0 {
+		// The reward is credited even if zero, as it touches the coinbase, which
+		// matters if there are no transactions or the coinbase self-destructed
+		var (
+			blockReward = big.NewInt(miningReward)
+			minerReward = new(big.Int).Set(blockReward)
+			perOmmer    = new(big.Int).Div(blockReward, big.NewInt(32))
+		)
+		for _, ommer := range pre.Env.Ommers {
+			// The miner gets 1/32 of the reward per ommer, the ommer (8-delta)/8
+			minerReward.Add(minerReward, perOmmer)
+
+			reward := big.NewInt(8)
+			reward.Sub(reward, new(big.Int).SetUint64(ommer.Delta))
+			reward.Mul(reward, blockReward)
+			reward.Div(reward, big.NewInt(8))
+			statedb.AddBalance(ommer.Address, reward)
+		}
+		statedb.AddBalance(pre.Env.Coinbase, minerReward)
+	}
+	root, err := statedb.Commit(eip158)
+	if err != nil {
+		return nil, nil, NewError(ErrorEVM, fmt.Errorf("could not commit state: %v", err))
+	}
+	logs, err := rlp.EncodeToBytes(statedb.Logs())
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &ExecutionResult{
+		StateRoot:   root,
+		TxRoot:      types.DeriveSha(included),
+		ReceiptRoot: types.DeriveSha(receipts),
+		LogsHash:    crypto.Keccak256Hash(logs),
+		Bloom:       types.CreateBloom(receipts),
+		GasUsed:     math.HexOrDecimal64(gasUsed),
+		Receipts:    receipts,
+		Rejected:    rejected,
+	}
+	if result.Receipts == nil {
+		result.Receipts = types.Receipts{}
+	}
+	return statedb, result, nil
+}
@@ -0,0 +1,378 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/params"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/tests"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Exit codes of the tool, telling the failures apart.
+const (
+	ErrorEVM              = 2
+	ErrorVMConfig         = 3
+	ErrorMissingBlockhash = 4
+	ErrorConfig           = 5
+	ErrorSealing          = 6
+
+	ErrorJson = 10
+	ErrorIO   = 11
+	ErrorRlp  = 12
+
+	stdinSelector = "stdin"
+)
+
+// NumberedError is an error carrying the exit code of the tool.
+type NumberedError struct {
+	errorCode int
+	err       error
+}
+
+// NewError wraps an error with the exit code of the tool.
+func NewError(errorCode int, err error) *NumberedError {
+	return &NumberedError{errorCode, err}
+}
+
+func (n *NumberedError) Error() string {
+	return fmt.Sprintf("ERROR(%d): %v", n.errorCode, n.err.Error())
+}
+
+// ExitCode implements cli.ExitCoder.
+func (n *NumberedError) ExitCode() int {
+	return n.errorCode
+}
+
+// input is the combined input of the tool, when read from stdin.
+type input struct {
+	Alloc core.GenesisAlloc `json:"alloc,omitempty"`
+	Env   *stEnv            `json:"env,omitempty"`
+	Txs   []*txWithKey      `json:"txs,omitempty"`
+}
+
+// Main runs the state transition tool: it applies the transactions to the
+// prestate and outputs the post-state alloc and the execution result.
+func Main(ctx *cli.Context) error {
+	// Configure the mfachain logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	var (
+		err     error
+		tracer  vm.Tracer
+		baseDir = ""
+	)
+	var getTracer tracerFn
+
+	// If user specified a basedir, make sure it exists
+	if ctx.IsSet(OutputBasedir.Name) {
+		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
+			if err := os.MkdirAll(base, 0755); err != nil {
+				return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+			}
+			baseDir = base
+		}
+	}
+	if ctx.Bool(TraceFlag.Name) {
+		// Configure the EVM logger
+		logConfig := &vm.LogConfig{
+			DisableStack:  ctx.Bool(TraceDisableStackFlag.Name),
+			DisableMemory: ctx.Bool(TraceDisableMemoryFlag.Name),
+			Debug:         true,
+		}
+		getTracer = func(txIndex int, txHash common.Hash) (vm.Tracer, func() error, error) {
+			traceFile, err := os.Create(filepath.Join(baseDir, fmt.Sprintf("trace-%d-%v.jsonl", txIndex, txHash.String())))
+			if err != nil {
+				return nil, nil, NewError(ErrorIO, fmt.Errorf("failed creating trace-file: %v", err))
+			}
+			return vm.NewJSONLogger(logConfig, traceFile), traceFile.Close, nil
+		}
+	} else {
+		getTracer = func(txIndex int, txHash common.Hash) (vm.Tracer, func() error, error) {
+			return tracer, nil, nil
+		}
+	}
+	// We need to load three things: alloc, env and transactions. May be either in
+	// stdin input or in files. Check if anything needs to be read from stdin
+	var (
+		prestate Prestate
+		txs      types.Transactions // txs to apply
+		allocStr = ctx.String(InputAllocFlag.Name)
+
+		envStr    = ctx.String(InputEnvFlag.Name)
+		txStr     = ctx.String(InputTxsFlag.Name)
+		inputData = &input{}
+	)
+	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+	}
+	if allocStr != stdinSelector {
+		if err := readJSONFile(allocStr, &inputData.Alloc); err != nil {
+			return err
+		}
+	}
+	prestate.Pre = inputData.Alloc
+
+	// Set the block environment
+	if envStr != stdinSelector {
+		var env stEnv
+		if err := readJSONFile(envStr, &env); err != nil {
+			return err
+		}
+		inputData.Env = &env
+	}
+	if inputData.Env == nil {
+		return NewError(ErrorJson, errors.New("missing env"))
+	}
+	prestate.Env = *inputData.Env
+
+	vmConfig := vm.Config{
+		Tracer: tracer,
+		Debug:  (tracer != nil),
+	}
+	// Construct the chainconfig
+	var chainConfig *params.ChainConfig
+	if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
+		return NewError(ErrorVMConfig, fmt.Errorf("failed constructing chain configuration: %v", err))
+	} else {
+		// Copy the config, as the chain id is set on it
+		config := *cConf
+		chainConfig = &config
+		vmConfig.ExtraEips = extraEips
+	}
+	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+
+	var txsWithKeys []*txWithKey
+	if txStr != stdinSelector {
+		if err := readJSONFile(txStr, &txsWithKeys); err != nil {
+			return err
+		}
+	} else {
+		txsWithKeys = inputData.Txs
+	}
+	// We may have to sign the transactions.
+	signer := types.MakeSigner(chainConfig, big.NewInt(int64(prestate.Env.Number)))
+
+	if txs, err = signUnsignedTransactions(txsWithKeys, signer); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed signing transactions: %v", err))
+	}
+	// Iterate over all the tests, run them and aggregate the results
+	s, result, err := prestate.Apply(vmConfig, chainConfig, txs, ctx.Int64(RewardFlag.Name), getTracer)
+	if err != nil {
+		return err
+	}
+	// Dump the excution result
+	collector := make(Alloc)
+	dump := s.RawDump(false, false, true)
+	for addr, acc := range dump.Accounts {
+		collector.onAccount(addr, acc)
+	}
+	// Encode the body of the block, the included transactions
+	rejected := make(map[int]bool, len(result.Rejected))
+	for _, tx := range result.Rejected {
+		rejected[tx.Index] = true
+	}
+	var included types.Transactions
+	for i, tx := range txs {
+		if !rejected[i] {
+			included = append(included, tx)
+		}
+	}
+	body, err := rlp.EncodeToBytes(included)
+	if err != nil {
+		return NewError(ErrorRlp, fmt.Errorf("failed encoding transactions: %v", err))
+	}
+	return dispatchOutput(ctx, baseDir, result, collector, body)
+}
+
+// readJSONFile decodes a JSON input file.
+func readJSONFile(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s file: %v", name, err))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshaling %s file: %v", name, err))
+	}
+	return nil
+}
+
+// txWithKey is a helper-struct, to allow us to use the types.Transaction along with
+// a `secretKey`-field, for input
+type txWithKey struct {
+	key *ecdsa.PrivateKey
+	tx  *types.Transaction
+}
+
+func (t *txWithKey) UnmarshalJSON(input []byte) error {
+	// Read the secretKey, if present
+	type sKey struct {
+		Key *common.Hash `json:"secretKey"`
+	}
+	var key sKey
+	if err := json.Unmarshal(input, &key); err != nil {
+		return err
+	}
+	if key.Key != nil {
+		k := key.Key.Hex()[2:]
+		ecdsaKey, err := crypto.HexToECDSA(k)
+		if err != nil {
+			return err
+		}
+		t.key = ecdsaKey
+	}
+	// Now, read the transaction itself
+	var tx types.Transaction
+	if err := json.Unmarshal(input, &tx); err != nil {
+		return err
+	}
+	t.tx = &tx
+	return nil
+}
+
+// signUnsignedTransactions converts the input txs to canonical transactions.
+//
+// The transactions can either be signed, or unsigned with zero r, s and v
+// values and the `secretKey` set, in which case they are signed here with the
+// given key.
+//
+// To manage this, we read the transactions twice, first trying to read the secretKeys,
+// and secondly to read them with the standard tx json format
+func signUnsignedTransactions(txs []*txWithKey, signer types.Signer) (types.Transactions, error) {
+	var signedTxs []*types.Transaction
+	for i, txWithKey := range txs {
+		tx := txWithKey.tx
+		key := txWithKey.key
+		v, r, s := tx.RawSignatureValues()
+		if key != nil && v.BitLen()+r.BitLen()+s.BitLen() == 0 {
+			// This transaction needs to be signed
+			signed, err := types.SignTx(tx, signer, key)
+			if err != nil {
+				return nil, NewError(ErrorJson, fmt.Errorf("tx %d: failed to sign tx: %v", i, err))
+			}
+			signedTxs = append(signedTxs, signed)
+		} else {
+			// Already signed
+			signedTxs = append(signedTxs, tx)
+		}
+	}
+	return signedTxs, nil
+}
+
+// Alloc is the post-state alloc, in the genesis alloc format.
+type Alloc map[common.Address]core.GenesisAccount
+
+// onAccount adds an account of the state dump to the alloc.
+func (g Alloc) onAccount(addr common.Address, dumpAccount state.DumpAccount) {
+	balance, _ := new(big.Int).SetString(dumpAccount.Balance, 10)
+	var storage map[common.Hash]common.Hash
+	if dumpAccount.Storage != nil {
+		storage = make(map[common.Hash]common.Hash)
+		for k, v := range dumpAccount.Storage {
+			storage[k] = common.HexToHash(v)
+		}
+	}
+	genesisAccount := core.GenesisAccount{
+		Code:    common.FromHex(dumpAccount.Code),
+		Storage: storage,
+		Balance: balance,
+		Nonce:   dumpAccount.Nonce,
+	}
+	g[addr] = genesisAccount
+}
+
+// saveFile marshalls the object to the given file
+func saveFile(baseDir, filename string, data interface{}) error {
+	b, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	location := filepath.Join(baseDir, filename)
+	if err = ioutil.WriteFile(location, b, 0644); err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed writing output: %v", err))
+	}
+	log.Info("Wrote file", "file", location)
+	return nil
+}
+
+// dispatchOutput writes the output data to either stderr or stdout, or to the specified
+// files
+func dispatchOutput(ctx *cli.Context, baseDir string, result *ExecutionResult, alloc Alloc, body hexutil.Bytes) error {
+	stdOutObject := make(map[string]interface{})
+	stdErrObject := make(map[string]interface{})
+	dispatch := func(baseDir, fName, name string, obj interface{}) error {
+		switch fName {
+		case "stdout":
+			stdOutObject[name] = obj
+		case "stderr":
+			stdErrObject[name] = obj
+		default: // save to file
+			if err := saveFile(baseDir, fName, obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := dispatch(baseDir, ctx.String(OutputAllocFlag.Name), "alloc", alloc); err != nil {
+		return err
+	}
+	if err := dispatch(baseDir, ctx.String(OutputResultFlag.Name), "result", result); err != nil {
+		return err
+	}
+	if name := ctx.String(OutputBodyFlag.Name); name != "" {
+		if err := dispatch(baseDir, name, "body", body); err != nil {
+			return err
+		}
+	}
+	if len(stdOutObject) > 0 {
+		b, err := json.MarshalIndent(stdOutObject, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		os.Stdout.Write(append(b, '\n'))
+	}
+	if len(stdErrObject) > 0 {
+		b, err := json.MarshalIndent(stdErrObject, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		os.Stderr.Write(append(b, '\n'))
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/MFAChain/mfachain/cmd/evm/internal/t8ntool"
 	"github.com/MFAChain/mfachain/cmd/utils"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -34,7 +35,7 @@ var (
 
 	DebugFlag = cli.BoolFlag{
 		Name:  "debug",
-		Usage: "output full trace logs",
+		Usage: "output full trace logs, stepping through the execution from a terminal",
 	}
 	MemProfileFlag = cli.StringFlag{
 		Name:  "memprofile",
@@ -124,8 +125,58 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	ProfileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "write an opcode level gas profile of the execution to the given file (pprof format)",
+	}
+	ProfileFlameGraphFlag = cli.StringFlag{
+		Name:  "profile.flamegraph",
+		Usage: "write an opcode level gas profile of the execution to the given file (JSON flame graph)",
+	}
 )
 
+var stateTransitionCommand = cli.Command{
+	Name:    "transition",
+	Aliases: []string{"t8n"},
+	Usage:   "executes a full state transition",
+	Action:  t8ntool.Main,
+	Flags: []cli.Flag{
+		t8ntool.TraceFlag,
+		t8ntool.TraceDisableMemoryFlag,
+		t8ntool.TraceDisableStackFlag,
+		t8ntool.OutputBasedir,
+		t8ntool.OutputAllocFlag,
+		t8ntool.OutputResultFlag,
+		t8ntool.OutputBodyFlag,
+		t8ntool.InputAllocFlag,
+		t8ntool.InputEnvFlag,
+		t8ntool.InputTxsFlag,
+		t8ntool.ForknameFlag,
+		t8ntool.ChainIDFlag,
+		t8ntool.RewardFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
+var blockBuilderCommand = cli.Command{
+	Name:    "block-builder",
+	Aliases: []string{"b11r"},
+	Usage:   "builds a block",
+	Action:  t8ntool.BuildBlock,
+	Flags: []cli.Flag{
+		t8ntool.OutputBasedir,
+		t8ntool.OutputBlockFlag,
+		t8ntool.InputHeaderFlag,
+		t8ntool.InputOmmersFlag,
+		t8ntool.InputTxsRlpFlag,
+		t8ntool.SealCliqueFlag,
+		t8ntool.SealMfaashFlag,
+		t8ntool.SealMfaashDirFlag,
+		t8ntool.SealMfaashModeFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
 func init() {
 	app.Flags = []cli.Flag{
 		BenchFlag,
@@ -150,12 +201,17 @@ func init() {
 		DisableMemoryFlag,
 		DisableStackFlag,
 		EVMInterpreterFlag,
+		ProfileFlag,
+		ProfileFlameGraphFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
 		disasmCommand,
 		runCommand,
 		stateTestCommand,
+		blockTestCommand,
+		stateTransitionCommand,
+		blockBuilderCommand,
 	}
 	cli.CommandHelpTemplate = utils.OriginCommandHelpTemplate
 }
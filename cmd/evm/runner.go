@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -38,6 +39,7 @@ import (
 	"github.com/MFAChain/mfachain/core/vm/runtime"
 	"github.com/MFAChain/mfachain/log"
 	"github.com/MFAChain/mfachain/params"
+	"github.com/mattn/go-isatty"
 	cli "gopkg.in/urfave/cli.v1"
 )
 
@@ -116,6 +118,7 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer        vm.Tracer
 		debugLogger   *vm.StructLogger
+		profiler      *vm.Profiler
 		statedb       *state.StateDB
 		chainConfig   *params.ChainConfig
 		sender        = common.BytesToAddress([]byte("sender"))
@@ -130,6 +133,12 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		debugLogger = vm.NewStructLogger(logconfig)
 	}
+	if ctx.GlobalString(ProfileFlag.Name) != "" || ctx.GlobalString(ProfileFlameGraphFlag.Name) != "" {
+		if tracer != nil {
+			return errors.New("profiling can't be combined with --json or --debug")
+		}
+		profiler = vm.NewProfiler()
+	}
 	if ctx.GlobalString(GenesisFlag.Name) != "" {
 		gen := readGenesis(ctx.GlobalString(GenesisFlag.Name))
 		genesisConfig = gen
@@ -214,6 +223,10 @@ func runCmd(ctx *cli.Context) error {
 			EVMInterpreter: ctx.GlobalString(EVMInterpreterFlag.Name),
 		},
 	}
+	if profiler != nil {
+		runtimeConfig.EVMConfig.Tracer = profiler
+		runtimeConfig.EVMConfig.Debug = true
+	}
 
 	if cpuProfilePath := ctx.GlobalString(CPUProfileFlag.Name); cpuProfilePath != "" {
 		f, err := os.Create(cpuProfilePath)
@@ -263,6 +276,25 @@ func runCmd(ctx *cli.Context) error {
 	}
 
 	bench := ctx.GlobalBool(BenchFlag.Name)
+
+	// Step through the execution interactively if debugging from a terminal
+	if ctx.GlobalBool(DebugFlag.Name) && tracer == debugLogger && !bench && isatty.IsTerminal(os.Stdin.Fd()) {
+		debugger := vm.NewDebugger()
+		runtimeConfig.EVMConfig.Debugger = debugger
+
+		run := execFunc
+		execFunc = func() ([]byte, uint64, error) {
+			done := make(chan struct{})
+			go func() {
+				runDebugger(debugger)
+				close(done)
+			}()
+			output, gasLeft, err := run()
+			debugger.Finish(output, err)
+			<-done
+			return output, gasLeft, err
+		}
+	}
 	output, leftOverGas, stats, err := timedExec(bench, execFunc)
 
 	if ctx.GlobalBool(DumpFlag.Name) {
@@ -284,6 +316,12 @@ func runCmd(ctx *cli.Context) error {
 		f.Close()
 	}
 
+	if profiler != nil {
+		if err := writeProfile(ctx, profiler); err != nil {
+			return err
+		}
+	}
+
 	if ctx.GlobalBool(DebugFlag.Name) {
 		if debugLogger != nil {
 			fmt.Fprintln(os.Stderr, "#### TRACE ####")
@@ -309,3 +347,28 @@ allocated bytes: %d
 
 	return nil
 }
+
+// writeProfile writes the opcode level gas profile of the execution to the
+// files requested.
+func writeProfile(ctx *cli.Context, profiler *vm.Profiler) error {
+	if path := ctx.GlobalString(ProfileFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("could not create gas profile: %v", err)
+		}
+		defer f.Close()
+		if err := profiler.WritePprof(f); err != nil {
+			return fmt.Errorf("could not write gas profile: %v", err)
+		}
+	}
+	if path := ctx.GlobalString(ProfileFlameGraphFlag.Name); path != "" {
+		out, err := json.Marshal(profiler.FlameGraph())
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, out, 0644); err != nil {
+			return fmt.Errorf("could not write gas flame graph: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,549 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MFAChain/mfachain/cmd/utils"
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state/snapshot"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/remotedb"
+	"github.com/MFAChain/mfachain/node"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	freezerDictFlag = cli.StringFlag{
+		Name:  "dict",
+		Usage: "Zstd dictionary file to compress the freezer tables with",
+	}
+	repairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "Repair the ancient store if it is found corrupted",
+	}
+	repairSourceFlag = cli.StringFlag{
+		Name:  "repair.source",
+		Usage: "HTTP RPC endpoint of a node serving the remote database API to refetch corrupted items from",
+	}
+	repairSecretFlag = cli.StringFlag{
+		Name:  "repair.secret",
+		Usage: "Secret to authenticate with on the repair source",
+	}
+	ancientTableFlag = cli.StringFlag{
+		Name:  "table",
+		Usage: "Ancient table to operate on instead of the key-value store",
+	}
+	prefixFlag = cli.StringFlag{
+		Name:  "prefix",
+		Usage: "Only consider the keys with this prefix (hex with 0x prefix, or raw string)",
+	}
+	startFlag = cli.StringFlag{
+		Name:  "start",
+		Usage: "Key (or ancient item number) to start iterating from",
+	}
+	limitFlag = cli.IntFlag{
+		Name:  "limit",
+		Usage: "Maximum number of entries to iterate over (0 = no limit)",
+	}
+
+	// databaseFlags are the flags needed to open the chain database.
+	databaseFlags = []cli.Flag{
+		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.CacheFlag,
+		utils.SyncModeFlag,
+		utils.RopstenFlag,
+		utils.RinkebyFlag,
+		utils.GoerliFlag,
+		utils.LegacyTestnetFlag,
+	}
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level database operations",
+		ArgsUsage: "",
+		Category:  "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(freezerRecompress),
+				Name:      "freezer-recompress",
+				Usage:     "Rewrite ancient tables with a new compression codec",
+				ArgsUsage: "<codec> [<table>...]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.LegacyTestnetFlag,
+					freezerDictFlag,
+				},
+				Description: `
+The freezer-recompress command rewrites the given tables of the ancient store
+(all of them if none is specified) with the given compression codec, one of
+"none", "snappy" or "zstd". A zstd dictionary can be supplied via --dict.
+
+The node must not be running. The command can be safely interrupted, tables are
+only switched over to the new codec once fully rewritten.`,
+			},
+			{
+				Action:    utils.MigrateFlags(verifyAncients),
+				Name:      "verify-ancients",
+				Usage:     "Check the integrity of the ancient store",
+				ArgsUsage: "",
+				Flags:     append(databaseFlags, repairFlag, repairSourceFlag, repairSecretFlag),
+				Description: `
+The verify-ancients command cross checks the indices of the ancient tables with
+their data files, then verifies every ancient item: headers must hash to their
+canonical hashes and link to their parents, bodies and receipts must match the
+roots in their headers and total difficulties must add up.
+
+With --repair, corrupted items are refetched from the node given by
+--repair.source if any. Otherwise, or if refetching fails, the chain is rewound
+to the last intact block and the rest is resynced by the node on its next run.
+
+The node must not be running.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbGet),
+				Name:      "get",
+				Usage:     "Show the value of a database key or ancient item",
+				ArgsUsage: "<key> | --table <table> <number>",
+				Flags:     append(databaseFlags, ancientTableFlag),
+				Description: `
+The get command prints the value stored in the key-value store under the given
+key, either hex encoded with a 0x prefix or a raw string. With --table, the item
+with the given number of the ancient table is printed instead.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbPut),
+				Name:      "put",
+				Usage:     "Store a value under a database key",
+				ArgsUsage: "<key> <value>",
+				Flags:     databaseFlags,
+				Description: `
+The put command stores the hex encoded value under the given key, hex encoded
+with a 0x prefix or a raw string. Only the key-value store can be modified, the
+ancient store is append-only.
+
+The node must not be running. Use with great care, no checks are done on the
+values written.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbDelete),
+				Name:      "delete",
+				Usage:     "Delete a database key",
+				ArgsUsage: "<key>",
+				Flags:     databaseFlags,
+				Description: `
+The delete command deletes the given key, hex encoded with a 0x prefix or a raw
+string, from the key-value store. Corrupted ancient items can be dropped with
+verify-ancients --repair instead.
+
+The node must not be running. Use with great care.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbIterate),
+				Name:      "iterate",
+				Usage:     "Print the entries of the database",
+				ArgsUsage: "",
+				Flags:     append(databaseFlags, prefixFlag, startFlag, limitFlag, ancientTableFlag),
+				Description: `
+The iterate command prints the keys and values of the key-value store with the
+given --prefix, starting at the --start key. With --table, the items of the
+ancient table are printed instead, starting at the --start item number.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbStats),
+				Name:      "stats",
+				Usage:     "Show the number and size distribution of database entries",
+				ArgsUsage: "",
+				Flags:     append(databaseFlags, prefixFlag, ancientTableFlag),
+				Description: `
+The stats command counts the entries of the key-value store with the given
+--prefix, categorized by key schema, along with a histogram of their sizes. With
+--table, the items of the ancient table are counted instead.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dbMetadata),
+				Name:      "metadata",
+				Usage:     "Show the chain markers stored in the database",
+				ArgsUsage: "",
+				Flags:     databaseFlags,
+				Description: `
+The metadata command shows the database version, the head markers of the chain,
+the state of the snapshot, the transaction index and history tails and the
+positions of the ancient store.`,
+			},
+		},
+	}
+)
+
+// ancientPath returns the path of the ancient store of the full node database.
+func ancientPath(stack *node.Node, config *mfachainConfig) string {
+	path := config.Eth.DatabaseFreezer
+	switch {
+	case path == "":
+		path = filepath.Join(stack.ResolvePath("chaindata"), "ancient")
+	case !filepath.IsAbs(path):
+		path = config.Node.ResolvePath(path)
+	}
+	return path
+}
+
+func freezerRecompress(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	codec, tables := ctx.Args().First(), ctx.Args().Tail()
+	if len(tables) == 0 {
+		tables = rawdb.FreezerTables()
+	}
+	var dict []byte
+	if file := ctx.String(freezerDictFlag.Name); file != "" {
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read dictionary: %v", err)
+		}
+		dict = blob
+	}
+	stack, config := makeConfigNode(ctx)
+	defer stack.Close()
+
+	path := ancientPath(stack, &config)
+	if !common.FileExist(path) {
+		utils.Fatalf("Ancient database missing: %s", path)
+	}
+	for _, table := range tables {
+		if err := rawdb.RecompressFreezerTable(path, table, codec, dict); err != nil {
+			utils.Fatalf("Failed to recompress table %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+func verifyAncients(ctx *cli.Context) error {
+	stack, config := makeConfigNode(ctx)
+	defer stack.Close()
+
+	path := ancientPath(stack, &config)
+	if !common.FileExist(path) {
+		utils.Fatalf("Ancient database missing: %s", path)
+	}
+	// Check the indices first, corrupted ones can't even be opened safely
+	good, err := rawdb.CheckAncientIndices(path)
+	if err != nil {
+		log.Error("Ancient indices corrupted", "items", good, "err", err)
+	}
+	chain, db := utils.MakeChain(ctx, stack, !ctx.Bool(repairFlag.Name))
+	defer db.Close()
+
+	frozen, err := db.Ancients()
+	if err != nil {
+		utils.Fatalf("Failed to retrieve ancient item count: %v", err)
+	}
+	if good > frozen {
+		good = frozen
+	}
+	verified, err := rawdb.VerifyAncients(db, 0, good)
+	if err == nil && verified == frozen {
+		log.Info("Ancient store intact", "items", frozen)
+		return nil
+	}
+	if err != nil {
+		log.Error("Ancient store corrupted", "items", verified, "err", err)
+	}
+	if !ctx.Bool(repairFlag.Name) {
+		return fmt.Errorf("ancient store corrupted from item %d, rerun with --repair to fix it", verified)
+	}
+	// Try refetching the corrupted items from another node first
+	if source := ctx.String(repairSourceFlag.Name); source != "" {
+		remote, err := remotedb.Dial(source, ctx.String(repairSecretFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to connect to repair source: %v", err)
+		}
+		defer remote.Close()
+
+		if err := rawdb.RefetchAncients(db, remote, verified, frozen); err != nil {
+			log.Error("Failed to refetch ancient items", "err", err)
+		} else if n, err := rawdb.VerifyAncients(db, verified, frozen); err != nil {
+			log.Error("Refetched ancient items corrupted", "items", n, "err", err)
+			verified = n
+		} else {
+			log.Info("Repaired ancient store", "refetched", frozen-verified)
+			return nil
+		}
+	}
+	// Rewind the chain to the last intact block, the rest needs to be resynced
+	if verified == 0 {
+		utils.Fatalf("Genesis block corrupted, the database needs to be resynced from scratch")
+	}
+	log.Warn("Rewinding chain to the last intact ancient block", "number", verified-1)
+	if err := chain.SetHead(verified - 1); err != nil {
+		utils.Fatalf("Failed to rewind chain: %v", err)
+	}
+	return nil
+}
+
+// parseKey converts a command line argument into a database key: hex encoded if
+// it has a 0x prefix, or a raw string otherwise.
+func parseKey(arg string) []byte {
+	if !strings.HasPrefix(arg, "0x") {
+		return []byte(arg)
+	}
+	key, err := hexutil.Decode(arg)
+	if err != nil {
+		utils.Fatalf("Invalid hex key %q: %v", arg, err)
+	}
+	return key
+}
+
+// parseAncientNumber converts a command line argument into an ancient item number.
+func parseAncientNumber(arg string) uint64 {
+	number, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		utils.Fatalf("Invalid ancient item number %q: %v", arg, err)
+	}
+	return number
+}
+
+// openChainDatabase opens the chain database of the node configured by the flags.
+func openChainDatabase(ctx *cli.Context) (*node.Node, mfadb.Database) {
+	stack, _ := makeConfigNode(ctx)
+	return stack, utils.MakeChainDatabase(ctx, stack)
+}
+
+func dbGet(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	if table := ctx.String(ancientTableFlag.Name); table != "" {
+		blob, err := db.Ancient(table, parseAncientNumber(ctx.Args().First()))
+		if err != nil {
+			utils.Fatalf("Failed to retrieve ancient item: %v", err)
+		}
+		fmt.Printf("%#x\n", blob)
+		return nil
+	}
+	value, err := db.Get(parseKey(ctx.Args().First()))
+	if err != nil {
+		utils.Fatalf("Failed to retrieve key: %v", err)
+	}
+	fmt.Printf("%#x\n", value)
+	return nil
+}
+
+func dbPut(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	value, err := hexutil.Decode(ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("Invalid hex value: %v", err)
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	key := parseKey(ctx.Args().First())
+	if old, err := db.Get(key); err == nil {
+		log.Info("Overwriting previous value", "key", hexutil.Encode(key), "value", hexutil.Encode(old))
+	}
+	if err := db.Put(key, value); err != nil {
+		utils.Fatalf("Failed to store key: %v", err)
+	}
+	return nil
+}
+
+func dbDelete(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	key := parseKey(ctx.Args().First())
+	old, err := db.Get(key)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve key: %v", err)
+	}
+	log.Info("Deleting key", "key", hexutil.Encode(key), "value", hexutil.Encode(old))
+	if err := db.Delete(key); err != nil {
+		utils.Fatalf("Failed to delete key: %v", err)
+	}
+	return nil
+}
+
+func dbIterate(ctx *cli.Context) error {
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	limit := ctx.Int(limitFlag.Name)
+	if table := ctx.String(ancientTableFlag.Name); table != "" {
+		frozen, err := db.Ancients()
+		if err != nil {
+			utils.Fatalf("Failed to retrieve ancient item count: %v", err)
+		}
+		number := rawdb.AncientTail(db, table)
+		if start := ctx.String(startFlag.Name); start != "" {
+			if n := parseAncientNumber(start); n > number {
+				number = n
+			}
+		}
+		for count := 0; number < frozen && (limit == 0 || count < limit); number, count = number+1, count+1 {
+			blob, err := db.Ancient(table, number)
+			if err != nil {
+				utils.Fatalf("Failed to retrieve ancient item %d: %v", number, err)
+			}
+			fmt.Printf("%d %#x\n", number, blob)
+		}
+		return nil
+	}
+	var (
+		prefix = parseKey(ctx.String(prefixFlag.Name))
+		start  []byte
+	)
+	if arg := ctx.String(startFlag.Name); arg != "" {
+		key := parseKey(arg)
+		if !strings.HasPrefix(string(key), string(prefix)) {
+			utils.Fatalf("Start key %#x doesn't have the prefix %#x", key, prefix)
+		}
+		start = key[len(prefix):]
+	}
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for count := 0; it.Next() && (limit == 0 || count < limit); count++ {
+		fmt.Printf("%#x %#x\n", it.Key(), it.Value())
+	}
+	return it.Error()
+}
+
+func dbStats(ctx *cli.Context) error {
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	var stats []*rawdb.KeyStats
+	if table := ctx.String(ancientTableFlag.Name); table != "" {
+		s, err := rawdb.CollectAncientStats(db, table)
+		if err != nil {
+			utils.Fatalf("Failed to collect ancient statistics: %v", err)
+		}
+		stats = append(stats, s)
+	} else {
+		stats = rawdb.CollectDatabaseStats(db, parseKey(ctx.String(prefixFlag.Name)))
+	}
+	header := []string{"Category", "Count", "Size"}
+	for i := 0; i < rawdb.StatsBuckets; i++ {
+		if limit := rawdb.StatsBucketLimit(i); limit != 0 {
+			header = append(header, "< "+limit.String())
+		} else {
+			header = append(header, ">= "+rawdb.StatsBucketLimit(i-1).String())
+		}
+	}
+	var (
+		count uint64
+		size  common.StorageSize
+		rows  [][]string
+	)
+	for _, s := range stats {
+		row := []string{s.Category, strconv.FormatUint(s.Count, 10), s.Size.String()}
+		for _, n := range s.Histogram {
+			row = append(row, strconv.FormatUint(n, 10))
+		}
+		rows = append(rows, row)
+		count, size = count+s.Count, size+s.Size
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetFooter(append([]string{"Total", strconv.FormatUint(count, 10), size.String()}, make([]string, rawdb.StatsBuckets)...))
+	table.AppendBulk(rows)
+	table.Render()
+	return nil
+}
+
+func dbMetadata(ctx *cli.Context) error {
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	// marker formats a head marker along with the number of the block it points to
+	marker := func(hash common.Hash) string {
+		if hash == (common.Hash{}) {
+			return "<nil>"
+		}
+		if number := rawdb.ReadHeaderNumber(db, hash); number != nil {
+			return fmt.Sprintf("%d (%x)", *number, hash)
+		}
+		return fmt.Sprintf("unknown (%x)", hash)
+	}
+	// optional formats a number that might not be stored
+	optional := func(number *uint64) string {
+		if number == nil {
+			return "<nil>"
+		}
+		return strconv.FormatUint(*number, 10)
+	}
+	journal := rawdb.ReadSnapshotJournal(db)
+	rows := [][]string{
+		{"Database version", optional(rawdb.ReadDatabaseVersion(db))},
+		{"Head header", marker(rawdb.ReadHeadHeaderHash(db))},
+		{"Head block", marker(rawdb.ReadHeadBlockHash(db))},
+		{"Head fast block", marker(rawdb.ReadHeadFastBlockHash(db))},
+		{"Fast trie progress", strconv.FormatUint(rawdb.ReadFastTrieProgress(db), 10)},
+		{"Snapshot root", rawdb.ReadSnapshotRoot(db).Hex()},
+		{"Snapshot journal", common.StorageSize(len(journal)).String()},
+		{"Snapshot generator", snapshot.ParseGeneratorStatus(journal)},
+		{"Transaction index tail", optional(rawdb.ReadTxIndexTail(db))},
+		{"Fast transaction lookup limit", optional(rawdb.ReadFastTxLookupLimit(db))},
+		{"History tail", strconv.FormatUint(rawdb.ReadHistoryTail(db), 10)},
+	}
+	if frozen, err := db.Ancients(); err == nil {
+		rows = append(rows, []string{"Ancient items", strconv.FormatUint(frozen, 10)})
+		for _, table := range rawdb.FreezerTables() {
+			size, _ := db.AncientSize(table)
+			rows = append(rows, []string{fmt.Sprintf("Ancient %s", table), fmt.Sprintf("tail %d, size %v", rawdb.AncientTail(db, table), common.StorageSize(size))})
+		}
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Field", "Value"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	table.Render()
+	return nil
+}
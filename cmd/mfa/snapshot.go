@@ -0,0 +1,558 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MFAChain/mfachain/cmd/utils"
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/state/snapshot"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/trie"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:      "snapshot",
+		Usage:     "Operations on the state snapshot",
+		ArgsUsage: "",
+		Category:  "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Name:      "export",
+				Usage:     "Export a state from the snapshot into a file",
+				ArgsUsage: "<root> <filename>",
+				Flags:     databaseFlags,
+				Description: `
+The snapshot export command writes the accounts, storage slots and contract
+codes of the state with the given root (or "latest" for the state of the head
+block) into a versioned streaming file, trailed by the state root. If the file
+ends with .gz, the output is gzipped.
+
+The state root is regenerated while exporting, so a corrupted snapshot can't be
+exported. The snapshot is opened read-only and must be fully generated; it is
+never regenerated by the export. The node must not be running.`,
+			},
+			{
+				Action:    utils.MigrateFlags(importSnapshot),
+				Name:      "import",
+				Usage:     "Import a state exported into a file",
+				ArgsUsage: "<filename>",
+				Flags:     databaseFlags,
+				Description: `
+The snapshot import command rebuilds the state trie and the snapshot from a file
+written by snapshot export, verifying the storage roots of all the accounts and
+the state root. Any previous snapshot is dropped.
+
+If the local chain contains a block with the imported state root, the chain head
+is moved to it so the node can resume from there. The node must not be running.`,
+			},
+			{
+				Action:    utils.MigrateFlags(verifySnapshot),
+				Name:      "verify",
+				Usage:     "Verify the snapshot against the state trie",
+				ArgsUsage: "[<root>]",
+				Flags:     databaseFlags,
+				Description: `
+The snapshot verify command regenerates the storage roots of all the accounts
+and the state root from the snapshot data, and compares them with the ones of
+the state trie with the given root (by default the state of the head block).
+
+The snapshot is opened as is: a corrupted journal or an interrupted generation
+is reported, but not repaired. The node must not be running.`,
+			},
+			{
+				Action:    utils.MigrateFlags(dumpSnapshot),
+				Name:      "dump",
+				Usage:     "Dump the accounts and storage of the snapshot as JSON",
+				ArgsUsage: "[<root>]",
+				Flags:     append(databaseFlags, utils.ExcludeCodeFlag, utils.ExcludeStorageFlag, startFlag, limitFlag),
+				Description: `
+The snapshot dump command streams the accounts of the state with the given root
+(by default the state of the head block) from the snapshot, as one JSON object
+per line in account hash order, starting at the --start account (address or
+account hash).
+
+Accounts and storage slots are keyed by their address and slot if the preimages
+are known, by their hashes otherwise.`,
+			},
+			{
+				Action:    utils.MigrateFlags(inspectSnapshotAccount),
+				Name:      "inspect-account",
+				Usage:     "Compare an account in the snapshot and in the state trie",
+				ArgsUsage: "<address> [<root>]",
+				Flags:     append(databaseFlags, limitFlag),
+				Description: `
+The snapshot inspect-account command shows the fields and storage slots of an
+account in the snapshot and in the state trie side by side, flagging the values
+which differ. The state with the given root (by default the state of the head
+block) is inspected, showing at most --limit storage slots.`,
+			},
+		},
+	}
+)
+
+// headBlockHeader retrieves the header of the head block of the database.
+func headBlockHeader(db mfadb.Reader) *types.Header {
+	hash := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		utils.Fatalf("Head block missing")
+	}
+	header := rawdb.ReadHeader(db, hash, *number)
+	if header == nil {
+		utils.Fatalf("Head block header missing")
+	}
+	return header
+}
+
+// parseStateRoot parses a state root argument, the state of the head block being
+// used if it's empty or "latest".
+func parseStateRoot(arg string, head *types.Header) common.Hash {
+	if arg == "" || arg == "latest" {
+		return head.Root
+	}
+	if len(strings.TrimPrefix(arg, "0x")) != 2*common.HashLength {
+		utils.Fatalf("Invalid state root %q", arg)
+	}
+	return common.HexToHash(arg)
+}
+
+// openSnapshot opens the snapshot of the database for inspection, failing if it
+// doesn't contain the state with the given root.
+func openSnapshot(db mfadb.Database, head *types.Header, root common.Hash) (*snapshot.Tree, error) {
+	snaptree, err := snapshot.Open(db, trie.NewDatabase(db), 256, head.Root)
+	if err != nil {
+		return nil, err
+	}
+	if snaptree.Snapshot(root) == nil {
+		return nil, fmt.Errorf("no snapshot of state %x", root)
+	}
+	return snaptree, nil
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	head := headBlockHeader(db)
+	root := parseStateRoot(ctx.Args().First(), head)
+	snaptree, err := openSnapshot(db, head, root)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshot: %v", err)
+	}
+	fn := ctx.Args().Get(1)
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		utils.Fatalf("Failed to create export file: %v", err)
+	}
+	defer fh.Close()
+
+	var (
+		writer           = bufio.NewWriter(fh)
+		out    io.Writer = writer
+		gz     *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(writer)
+		out = gz
+	}
+	start := time.Now()
+	log.Info("Exporting state", "root", root, "file", fn)
+	if err := snapshot.ExportState(snaptree, root, out); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			utils.Fatalf("Export error: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	fn := ctx.Args().First()
+	fh, err := os.Open(fn)
+	if err != nil {
+		utils.Fatalf("Failed to open import file: %v", err)
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			utils.Fatalf("Failed to open import file: %v", err)
+		}
+	}
+	start := time.Now()
+	root, err := snapshot.ImportState(db, reader)
+	if err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
+	// Move the chain head to the block of the imported state, if known
+	if header := findStateBlock(db, root); header != nil {
+		log.Info("Moving chain head to imported state", "number", header.Number, "hash", header.Hash())
+		rawdb.WriteHeadBlockHash(db, header.Hash())
+		if fast := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadFastBlockHash(db)); fast == nil || *fast < header.Number.Uint64() {
+			rawdb.WriteHeadFastBlockHash(db, header.Hash())
+		}
+	} else {
+		log.Warn("No local block with the imported state, import the chain up to it", "root", root)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// findStateBlock searches the canonical chain backwards from the head header for
+// a block with the given state root and its body present.
+func findStateBlock(db mfadb.Reader, root common.Hash) *types.Header {
+	number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	if number == nil {
+		return nil
+	}
+	for n := *number + 1; n > 0; n-- {
+		hash := rawdb.ReadCanonicalHash(db, n-1)
+		header := rawdb.ReadHeader(db, hash, n-1)
+		if header == nil {
+			return nil
+		}
+		if header.Root == root && rawdb.HasBody(db, hash, n-1) {
+			return header
+		}
+	}
+	return nil
+}
+
+func verifySnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	head := headBlockHeader(db)
+	root := parseStateRoot(ctx.Args().First(), head)
+	snaptree, err := openSnapshot(db, head, root)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshot: %v", err)
+	}
+	start := time.Now()
+	log.Info("Verifying snapshot", "root", root)
+	if err := snapshot.VerifyState(snaptree, root); err != nil {
+		utils.Fatalf("Snapshot verification failed: %v", err)
+	}
+	fmt.Printf("Snapshot of state %x verified in %v\n", root, time.Since(start))
+	return nil
+}
+
+func dumpSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	head := headBlockHeader(db)
+	root := parseStateRoot(ctx.Args().First(), head)
+	snaptree, err := openSnapshot(db, head, root)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshot: %v", err)
+	}
+	var origin common.Hash
+	if arg := ctx.String(startFlag.Name); arg != "" {
+		switch key := common.FromHex(arg); len(key) {
+		case common.AddressLength:
+			origin = crypto.Keccak256Hash(key)
+		case common.HashLength:
+			origin = common.BytesToHash(key)
+		default:
+			utils.Fatalf("Invalid start account %q", arg)
+		}
+	}
+	accIt, err := snaptree.AccountIterator(root, origin)
+	if err != nil {
+		utils.Fatalf("Failed to iterate snapshot: %v", err)
+	}
+	defer accIt.Release()
+
+	var (
+		excludeCode    = ctx.Bool(utils.ExcludeCodeFlag.Name)
+		excludeStorage = ctx.Bool(utils.ExcludeStorageFlag.Name)
+		limit          = ctx.Int(limitFlag.Name)
+		out            = json.NewEncoder(os.Stdout)
+		count          int
+	)
+	out.Encode(struct {
+		Root common.Hash `json:"root"`
+	}{root})
+
+	for accIt.Next() {
+		account, err := snapshot.FullAccount(accIt.Account())
+		if err != nil {
+			utils.Fatalf("Invalid account %x: %v", accIt.Hash(), err)
+		}
+		dump := state.DumpAccount{
+			Balance:  account.Balance.String(),
+			Nonce:    account.Nonce,
+			Root:     common.Bytes2Hex(account.Root),
+			CodeHash: common.Bytes2Hex(account.CodeHash),
+		}
+		if preimage := rawdb.ReadPreimage(db, accIt.Hash()); len(preimage) == common.AddressLength {
+			addr := common.BytesToAddress(preimage)
+			dump.Address = &addr
+		} else {
+			dump.SecureKey = accIt.Hash().Bytes()
+		}
+		if hash := common.BytesToHash(account.CodeHash); !excludeCode && hash != emptyCodeHash {
+			code, err := db.Get(hash.Bytes())
+			if err != nil {
+				utils.Fatalf("Missing code %x of account %x", hash, accIt.Hash())
+			}
+			dump.Code = common.Bytes2Hex(code)
+		}
+		if !excludeStorage && common.BytesToHash(account.Root) != types.EmptyRootHash {
+			dump.Storage = make(map[common.Hash]string)
+
+			storageIt, err := snaptree.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				utils.Fatalf("Failed to iterate storage of account %x: %v", accIt.Hash(), err)
+			}
+			for storageIt.Next() {
+				key := storageIt.Hash()
+				if preimage := rawdb.ReadPreimage(db, key); len(preimage) == common.HashLength {
+					key = common.BytesToHash(preimage)
+				}
+				dump.Storage[key] = common.Bytes2Hex(slotValue(storageIt.Slot()))
+			}
+			err = storageIt.Error()
+			storageIt.Release()
+			if err != nil {
+				utils.Fatalf("Failed to iterate storage of account %x: %v", accIt.Hash(), err)
+			}
+		}
+		out.Encode(dump)
+		if count++; limit > 0 && count >= limit {
+			break
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		utils.Fatalf("Failed to iterate snapshot: %v", err)
+	}
+	return nil
+}
+
+func inspectSnapshotAccount(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 2 {
+		utils.Fatalf("This command requires an address and an optional state root.")
+	}
+	if !common.IsHexAddress(ctx.Args().First()) {
+		utils.Fatalf("Invalid address %q", ctx.Args().First())
+	}
+	stack, db := openChainDatabase(ctx)
+	defer stack.Close()
+	defer db.Close()
+
+	var (
+		addr   = common.HexToAddress(ctx.Args().First())
+		hash   = crypto.Keccak256Hash(addr.Bytes())
+		head   = headBlockHeader(db)
+		root   = parseStateRoot(ctx.Args().Get(1), head)
+		triedb = trie.NewDatabase(db)
+	)
+	// Retrieve the account from the state trie
+	accTrie, err := trie.NewSecure(root, triedb)
+	if err != nil {
+		utils.Fatalf("Failed to open state trie: %v", err)
+	}
+	var trieAcc *state.Account
+	blob, err := accTrie.TryGet(addr.Bytes())
+	if err != nil {
+		utils.Fatalf("Failed to read account from the trie: %v", err)
+	}
+	if blob != nil {
+		trieAcc = new(state.Account)
+		if err := rlp.DecodeBytes(blob, trieAcc); err != nil {
+			utils.Fatalf("Invalid account in the trie: %v", err)
+		}
+	}
+	// Retrieve the account from the snapshot, showing the trie only if unavailable
+	var (
+		snaptree *snapshot.Tree
+		snapAcc  *snapshot.Account
+	)
+	if snaptree, err = openSnapshot(db, head, root); err == nil {
+		snapAcc, err = snaptree.Snapshot(root).Account(hash)
+	}
+	if err != nil {
+		log.Warn("Snapshot unavailable", "err", err)
+		snaptree = nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Field", "Snapshot", "Trie", ""})
+	table.SetAutoWrapText(false)
+
+	row := func(field, snapValue, trieValue string) {
+		var flag string
+		if snaptree == nil {
+			snapValue = "-"
+		} else if snapValue != trieValue {
+			flag = "MISMATCH"
+		}
+		table.Append([]string{field, snapValue, trieValue, flag})
+	}
+	var snapFields, trieFields [4]string
+	if snapAcc != nil {
+		snapFields = [4]string{fmt.Sprint(snapAcc.Nonce), snapAcc.Balance.String(), types.EmptyRootHash.Hex(), emptyCodeHash.Hex()}
+		if len(snapAcc.Root) > 0 {
+			snapFields[2] = common.BytesToHash(snapAcc.Root).Hex()
+		}
+		if len(snapAcc.CodeHash) > 0 {
+			snapFields[3] = common.BytesToHash(snapAcc.CodeHash).Hex()
+		}
+	}
+	if trieAcc != nil {
+		trieFields = [4]string{fmt.Sprint(trieAcc.Nonce), trieAcc.Balance.String(), trieAcc.Root.Hex(), common.BytesToHash(trieAcc.CodeHash).Hex()}
+	}
+	row("Exists", fmt.Sprint(snapAcc != nil), fmt.Sprint(trieAcc != nil))
+	for i, field := range []string{"Nonce", "Balance", "Storage root", "Code hash"} {
+		row(field, snapFields[i], trieFields[i])
+	}
+	// Compare the storage slots, iterating both in hash order
+	var (
+		snapSlots, trieSlots storageIterator = nopStorageIterator{}, nopStorageIterator{}
+		limit                                = ctx.Int(limitFlag.Name)
+	)
+	if snaptree != nil && snapAcc != nil {
+		it, err := snaptree.StorageIterator(root, hash, common.Hash{})
+		if err != nil {
+			utils.Fatalf("Failed to iterate snapshot storage: %v", err)
+		}
+		defer it.Release()
+		snapSlots = it
+	}
+	if trieAcc != nil && trieAcc.Root != types.EmptyRootHash {
+		stTrie, err := trie.NewSecure(trieAcc.Root, triedb)
+		if err != nil {
+			utils.Fatalf("Failed to open storage trie: %v", err)
+		}
+		trieSlots = &trieStorageIterator{it: trie.NewIterator(stTrie.NodeIterator(nil))}
+	}
+	snapNext, trieNext := snapSlots.Next(), trieSlots.Next()
+	for count := 0; (snapNext || trieNext) && (limit == 0 || count < limit); count++ {
+		var (
+			key                  common.Hash
+			snapValue, trieValue string
+		)
+		switch {
+		case !trieNext || (snapNext && bytes.Compare(snapSlots.Hash().Bytes(), trieSlots.Hash().Bytes()) < 0):
+			key, snapValue = snapSlots.Hash(), common.Bytes2Hex(slotValue(snapSlots.Slot()))
+			snapNext = snapSlots.Next()
+		case !snapNext || bytes.Compare(snapSlots.Hash().Bytes(), trieSlots.Hash().Bytes()) > 0:
+			key, trieValue = trieSlots.Hash(), common.Bytes2Hex(slotValue(trieSlots.Slot()))
+			trieNext = trieSlots.Next()
+		default:
+			key = snapSlots.Hash()
+			snapValue, trieValue = common.Bytes2Hex(slotValue(snapSlots.Slot())), common.Bytes2Hex(slotValue(trieSlots.Slot()))
+			snapNext, trieNext = snapSlots.Next(), trieSlots.Next()
+		}
+		row("Slot "+key.Hex(), snapValue, trieValue)
+	}
+	if err := snapSlots.Error(); err != nil {
+		utils.Fatalf("Failed to iterate snapshot storage: %v", err)
+	}
+	if err := trieSlots.Error(); err != nil {
+		utils.Fatalf("Failed to iterate trie storage: %v", err)
+	}
+	fmt.Printf("Account %s (hash %x) in state %x\n", addr.Hex(), hash, root)
+	table.Render()
+	return nil
+}
+
+// emptyCodeHash is the known hash of the empty EVM bytecode.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// slotValue decodes the RLP encoded value of a storage slot, returning the raw
+// value if it's not valid RLP.
+func slotValue(blob []byte) []byte {
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return blob
+	}
+	return content
+}
+
+// storageIterator is the subset of the snapshot storage iterator methods used
+// to walk the storage slots of an account.
+type storageIterator interface {
+	Next() bool
+	Error() error
+	Hash() common.Hash
+	Slot() []byte
+}
+
+// nopStorageIterator is a storageIterator over no slots.
+type nopStorageIterator struct{}
+
+func (nopStorageIterator) Next() bool        { return false }
+func (nopStorageIterator) Error() error      { return nil }
+func (nopStorageIterator) Hash() common.Hash { return common.Hash{} }
+func (nopStorageIterator) Slot() []byte      { return nil }
+
+// trieStorageIterator is a storageIterator over the leaves of a storage trie.
+type trieStorageIterator struct {
+	it *trie.Iterator
+}
+
+func (it *trieStorageIterator) Next() bool        { return it.it.Next() }
+func (it *trieStorageIterator) Error() error      { return it.it.Err }
+func (it *trieStorageIterator) Hash() common.Hash { return common.BytesToHash(it.it.Key) }
+func (it *trieStorageIterator) Slot() []byte      { return it.it.Value }
@@ -21,9 +21,13 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 
@@ -34,6 +38,7 @@ import (
 	"github.com/MFAChain/mfachain/crypto"
 	"github.com/MFAChain/mfachain/mfadb"
 	"github.com/MFAChain/mfachain/internal/debug"
+	"github.com/MFAChain/mfachain/internal/era"
 	"github.com/MFAChain/mfachain/log"
 	"github.com/MFAChain/mfachain/node"
 	"github.com/MFAChain/mfachain/rlp"
@@ -237,6 +242,170 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	return nil
 }
 
+// ExportHistory exports the canonical chain segment between first and last into
+// era archives in the given directory, one per epoch of era.MaxEra1Size blocks.
+// The blocks, receipts and total difficulties are read directly from the
+// database, so the exported history must not be pruned.
+func ExportHistory(db mfadb.Database, dir string, network string, first uint64, last uint64) error {
+	if first > last {
+		return fmt.Errorf("invalid range: first block %d after last %d", first, last)
+	}
+	if tail := rawdb.ReadHistoryTail(db); first < tail {
+		return fmt.Errorf("history before block %d pruned", tail)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	log.Info("Exporting history", "dir", dir, "first", first, "last", last)
+
+	for start := first; start <= last; {
+		var (
+			epoch = start / era.MaxEra1Size
+			end   = (epoch+1)*era.MaxEra1Size - 1
+		)
+		if end > last {
+			end = last
+		}
+		if err := exportEpoch(db, dir, network, epoch, start, end); err != nil {
+			return err
+		}
+		start = end + 1
+	}
+	log.Info("Exported history", "dir", dir)
+	return nil
+}
+
+// exportEpoch writes the blocks between start and end, all within the same
+// epoch, into an era archive.
+func exportEpoch(db mfadb.Database, dir string, network string, epoch uint64, start uint64, end uint64) error {
+	tmp := filepath.Join(dir, fmt.Sprintf("%s-%05d.era1.tmp", network, epoch))
+	fh, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer fh.Close()
+
+	builder := era.NewBuilder(fh)
+	for number := start; number <= end; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			return fmt.Errorf("block %d not found", number)
+		}
+		var (
+			header   = rawdb.ReadHeaderRLP(db, hash, number)
+			body     = rawdb.ReadBodyRLP(db, hash, number)
+			receipts = rawdb.ReadReceiptsRLP(db, hash, number)
+			td       = rawdb.ReadTd(db, hash, number)
+		)
+		if len(header) == 0 || len(body) == 0 || len(receipts) == 0 || td == nil {
+			return fmt.Errorf("block %d incomplete", number)
+		}
+		if err := builder.AddRLP(header, body, receipts, number, hash, td); err != nil {
+			return err
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		return err
+	}
+	if err := fh.Sync(); err != nil {
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	name := era.Filename(network, int(epoch), root)
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	log.Info("Exported era archive", "file", name, "first", start, "last", end, "root", root)
+	return nil
+}
+
+// ImportHistory imports the era archives of the given network found in a
+// directory into the ancient store. The archives are verified before import and
+// must extend the local chain, already present blocks are checked and skipped.
+func ImportHistory(chain *core.BlockChain, dir string, network string) error {
+	files, err := filepath.Glob(filepath.Join(dir, network+"-*.era1"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no era archives of network %s found in %s", network, dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := importEpoch(chain, file); err != nil {
+			return fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// importEpoch verifies and imports a single era archive.
+func importEpoch(chain *core.BlockChain, file string) error {
+	e, err := era.Open(file)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	log.Info("Importing era archive", "file", filepath.Base(file), "first", e.Start(), "count", e.Count())
+	if err := e.Verify(); err != nil {
+		return err
+	}
+	var (
+		blocks   = make(types.Blocks, 0, importBatchSize)
+		receipts = make([]types.Receipts, 0, importBatchSize)
+		tds      = make([]*big.Int, 0, importBatchSize)
+	)
+	flush := func() error {
+		if len(blocks) == 0 {
+			return nil
+		}
+		headers := make([]*types.Header, len(blocks))
+		for i, block := range blocks {
+			headers[i] = block.Header()
+		}
+		if n, err := chain.InsertHeaderChain(headers, 100); err != nil {
+			return fmt.Errorf("invalid header %d: %v", headers[n].Number, err)
+		}
+		if n, err := chain.InsertReceiptChain(blocks, receipts, math.MaxUint64); err != nil {
+			return fmt.Errorf("invalid block %d: %v", blocks[n].Number(), err)
+		}
+		for i, block := range blocks {
+			if td := chain.GetTd(block.Hash(), block.NumberU64()); td == nil || td.Cmp(tds[i]) != 0 {
+				return fmt.Errorf("block %d: total difficulty mismatch: have %v, want %v", block.Number(), td, tds[i])
+			}
+		}
+		blocks, receipts, tds = blocks[:0], receipts[:0], tds[:0]
+		return nil
+	}
+	head := chain.CurrentFastBlock().NumberU64()
+	for number := e.Start(); number < e.Start()+e.Count(); number++ {
+		block, rs, td, err := e.GetBlockByNumber(number)
+		if err != nil {
+			return err
+		}
+		// Check the blocks already present against the local chain
+		if number <= head {
+			if local := chain.GetHeaderByNumber(number); local == nil || local.Hash() != block.Hash() {
+				return fmt.Errorf("block %d conflicts with the local chain", number)
+			}
+			continue
+		}
+		blocks, receipts, tds = append(blocks, block), append(receipts, rs), append(tds, td)
+		if len(blocks) == importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 func ImportPreimages(db mfadb.Database, fn string) error {
 	log.Info("Importing preimages", "file", fn)
@@ -147,6 +147,22 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble')",
+	}
+	RemoteDBServeFlag = cli.BoolFlag{
+		Name:  "remotedb.serve",
+		Usage: "Serve the chain database to read-only replicas (requires --remotedb.secret and the 'remotedb' RPC module)",
+	}
+	RemoteDBPrimaryFlag = cli.StringFlag{
+		Name:  "remotedb.primary",
+		Usage: "HTTP RPC endpoint of a primary node to run on as a read-only replica",
+	}
+	RemoteDBSecretFlag = cli.StringFlag{
+		Name:  "remotedb.secret",
+		Usage: "Shared secret authenticating read-only replicas to their primary",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -234,6 +250,10 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index by-hash for (default = index all blocks)",
 		Value: 0,
 	}
+	HistoryCutoffFlag = cli.Uint64Flag{
+		Name:  "history.cutoff",
+		Usage: "Block number below which ancient block bodies and receipts are deleted (default = keep all)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -334,6 +354,21 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote transactions to survive node restarts (disabled if empty)",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteRejournalFlag = cli.DurationFlag{
+		Name:  "txpool.remoterejournal",
+		Usage: "Time interval to regenerate the remote transaction journal",
+		Value: core.DefaultTxPoolConfig.RemoteRejournal,
+	}
+	TxPoolRemoteJournalSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.remotejournalslots",
+		Usage: "Maximum number of remote transactions to persist in the journal",
+		Value: core.DefaultTxPoolConfig.RemoteJournalSlots,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -399,6 +434,10 @@ var (
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 	}
+	ParallelTxWorkersFlag = cli.IntFlag{
+		Name:  "parallel.workers",
+		Usage: "Number of workers executing block transactions in parallel during import (0 = sequential)",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -446,6 +485,11 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerMaxBundleTxsFlag = cli.IntFlag{
+		Name:  "miner.bundletxs",
+		Usage: "Maximum number of transactions accepted in a bundle",
+		Value: eth.DefaultConfig.Miner.MaxBundleTxs,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -1206,6 +1250,10 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		cfg.DBEngine = ctx.GlobalString(DBEngineFlag.Name)
+	}
+
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
@@ -1305,6 +1353,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteRejournalFlag.Name) {
+		cfg.RemoteRejournal = ctx.GlobalDuration(TxPoolRemoteRejournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalSlotsFlag.Name) {
+		cfg.RemoteJournalSlots = ctx.GlobalUint64(TxPoolRemoteJournalSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1389,6 +1446,9 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerMaxBundleTxsFlag.Name) {
+		cfg.MaxBundleTxs = ctx.GlobalInt(MinerMaxBundleTxsFlag.Name)
+	}
 }
 
 func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
@@ -1504,6 +1564,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
 	}
+	if ctx.GlobalIsSet(RemoteDBServeFlag.Name) {
+		cfg.RemoteDBServe = ctx.GlobalBool(RemoteDBServeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RemoteDBPrimaryFlag.Name) {
+		cfg.RemoteDBPrimary = ctx.GlobalString(RemoteDBPrimaryFlag.Name)
+	}
+	if ctx.GlobalIsSet(RemoteDBSecretFlag.Name) {
+		cfg.RemoteDBSecret = ctx.GlobalString(RemoteDBSecretFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1514,9 +1583,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.GlobalIsSet(ParallelTxWorkersFlag.Name) {
+		cfg.ParallelTxWorkers = ctx.GlobalInt(ParallelTxWorkersFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(HistoryCutoffFlag.Name) {
+		cfg.HistoryCutoff = ctx.GlobalUint64(HistoryCutoffFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1757,6 +1832,21 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 	return genesis
 }
 
+// NetworkName returns the name of the network selected by the command line
+// flags, used to name its era archives.
+func NetworkName(ctx *cli.Context) string {
+	switch {
+	case ctx.GlobalBool(LegacyTestnetFlag.Name) || ctx.GlobalBool(RopstenFlag.Name):
+		return "ropsten"
+	case ctx.GlobalBool(RinkebyFlag.Name):
+		return "rinkeby"
+	case ctx.GlobalBool(GoerliFlag.Name):
+		return "goerli"
+	default:
+		return "mainnet"
+	}
+}
+
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *node.Node, readOnly bool) (chain *core.BlockChain, chainDb mfadb.Database) {
 	var err error
@@ -1793,6 +1883,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readOnly bool) (chain *core.B
 		TrieDirtyDisabled:   ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieTimeLimit:       eth.DefaultConfig.TrieTimeout,
 		SnapshotLimit:       eth.DefaultConfig.SnapshotCache,
+		ParallelTxWorkers:   ctx.GlobalInt(ParallelTxWorkersFlag.Name),
 	}
 	if !ctx.GlobalIsSet(SnapshotFlag.Name) {
 		cache.SnapshotLimit = 0 // Disabled
@@ -0,0 +1,136 @@
+// Copyright 2020 The MFA Authors
+
+//
+// This is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/consensus/mfa"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/internal/era"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// newHistoryTestChain creates a blockchain on top of a database with a freezer
+// in a fresh directory.
+func newHistoryTestChain(t *testing.T, gspec *core.Genesis) (*core.BlockChain, mfadb.Database, string) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), dir, "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	gspec.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, mfa.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	return chain, db, dir
+}
+
+// Tests that chain history exported into era archives can be imported into the
+// ancient store of another node.
+func TestHistoryExportImport(t *testing.T) {
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{address: {Balance: big.NewInt(1000000000)}}}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+		count   = era.MaxEra1Size + 100
+	)
+	blocks, receipts := core.GenerateChain(gspec.Config, genesis, mfa.NewFaker(), gendb, count, func(i int, block *core.BlockGen) {
+		if i%100 != 0 {
+			return
+		}
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	// Import the chain into the ancient store of a source node and export it
+	source, sourcedb, sourcedir := newHistoryTestChain(t, gspec)
+	defer os.RemoveAll(sourcedir)
+	defer sourcedb.Close()
+	defer source.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := source.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := source.InsertReceiptChain(blocks, receipts, uint64(count)); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp era dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ExportHistory(sourcedb, dir, "test", 0, uint64(count)); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "test-*.era1"))
+	if len(files) != 2 {
+		t.Fatalf("archive count mismatch: have %d, want %d", len(files), 2)
+	}
+	// Import the archives into a fresh node and check the history
+	chain, db, chaindir := newHistoryTestChain(t, gspec)
+	defer os.RemoveAll(chaindir)
+	defer db.Close()
+	defer chain.Stop()
+
+	if err := ImportHistory(chain, dir, "test"); err != nil {
+		t.Fatalf("failed to import history: %v", err)
+	}
+	if head := chain.CurrentFastBlock().NumberU64(); head != uint64(count) {
+		t.Fatalf("head mismatch: have %d, want %d", head, count)
+	}
+	if frozen, _ := db.Ancients(); frozen != uint64(count)+1 {
+		t.Fatalf("ancient item count mismatch: have %d, want %d", frozen, count+1)
+	}
+	for i, block := range blocks {
+		if have := rawdb.ReadCanonicalHash(db, block.NumberU64()); have != block.Hash() {
+			t.Fatalf("block %d: hash mismatch: have %x, want %x", block.NumberU64(), have, block.Hash())
+		}
+		if have := rawdb.ReadRawReceipts(db, block.Hash(), block.NumberU64()); types.DeriveSha(have) != types.DeriveSha(receipts[i]) {
+			t.Fatalf("block %d: receipts mismatch", block.NumberU64())
+		}
+	}
+	// Importing again should skip the present blocks
+	if err := ImportHistory(chain, dir, "test"); err != nil {
+		t.Fatalf("failed to reimport history: %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceMapEntry is the source range an instruction was generated from, as
+// found in the source maps of solc.
+type SourceMapEntry struct {
+	Start  int  // Byte offset of the range in the source file
+	Length int  // Byte length of the range
+	File   int  // Index of the source file, -1 if generated by the compiler
+	Jump   byte // Whether a jump goes into ('i') or out of ('o') a function, or '-'
+}
+
+// ParseSourceMap decodes a compressed solc source map, one entry per instruction
+// of the code. Omitted fields are inherited from the previous entry.
+func ParseSourceMap(srcmap string) ([]SourceMapEntry, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		items   = strings.Split(srcmap, ";")
+		entries = make([]SourceMapEntry, len(items))
+		prev    = SourceMapEntry{File: -1, Jump: '-'}
+	)
+	for i, item := range items {
+		entry := prev
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			switch j {
+			case 0, 1, 2:
+				n, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("invalid source map entry %d: %v", i, err)
+				}
+				switch j {
+				case 0:
+					entry.Start = n
+				case 1:
+					entry.Length = n
+				case 2:
+					entry.File = n
+				}
+			case 3:
+				if len(field) != 1 || strings.IndexByte("io-", field[0]) < 0 {
+					return nil, fmt.Errorf("invalid source map entry %d: bad jump type %q", i, field)
+				}
+				entry.Jump = field[0]
+			}
+			// Later fields (modifier depth) are not needed
+		}
+		entries[i], prev = entry, entry
+	}
+	return entries, nil
+}
+
+// SourceFile is a source file a contract was compiled from.
+type SourceFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// SourceLocation is the position in the sources of the code an instruction was
+// generated from, along with its enclosing contract and function.
+type SourceLocation struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Contract string `json:"contract,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// SourceMapper maps the program counters of a compiled code to the locations
+// in its sources.
+type SourceMapper struct {
+	entries []SourceMapEntry
+	instrs  []int // Index of the instruction starting at each pc, -1 within push data
+	sources []*sourceIndex
+}
+
+// NewSourceMapper creates a mapper for the given code, its solc source map and
+// the source files, in the order of the file indexes of the source map.
+func NewSourceMapper(code []byte, srcmap string, sources []SourceFile) (*SourceMapper, error) {
+	entries, err := ParseSourceMap(srcmap)
+	if err != nil {
+		return nil, err
+	}
+	m := &SourceMapper{
+		entries: entries,
+		instrs:  make([]int, len(code)),
+		sources: make([]*sourceIndex, len(sources)),
+	}
+	for pc, n := 0, 0; pc < len(code); n++ {
+		m.instrs[pc] = n
+		next := pc + 1
+		if op := code[pc]; op >= 0x60 && op <= 0x7f { // PUSH1..PUSH32
+			next += int(op - 0x5f)
+		}
+		for pc++; pc < next && pc < len(code); pc++ {
+			m.instrs[pc] = -1
+		}
+	}
+	for i, source := range sources {
+		m.sources[i] = newSourceIndex(source)
+	}
+	return m, nil
+}
+
+// Locate returns the source location of the instruction at the given program
+// counter, or nil if it's not mapped to any source.
+func (m *SourceMapper) Locate(pc uint64) *SourceLocation {
+	if pc >= uint64(len(m.instrs)) || m.instrs[pc] < 0 || m.instrs[pc] >= len(m.entries) {
+		return nil
+	}
+	entry := m.entries[m.instrs[pc]]
+	if entry.File < 0 || entry.File >= len(m.sources) {
+		return nil
+	}
+	return m.sources[entry.File].locate(entry.Start)
+}
+
+var (
+	// contractRegexp matches the declarations of contracts, libraries and interfaces.
+	contractRegexp = regexp.MustCompile(`\b(?:contract|library|interface)\s+([A-Za-z_$][\w$]*)`)
+
+	// functionRegexp matches the declarations of functions, including the special
+	// and unnamed ones, and modifiers.
+	functionRegexp = regexp.MustCompile(`\b(?:(?:function|modifier)\s+([A-Za-z_$][\w$]*)|function\s*\(|(constructor|fallback|receive)\s*\()`)
+)
+
+// sourceScope is the body of a contract or a function in a source file.
+type sourceScope struct {
+	name       string
+	start, end int
+}
+
+// sourceIndex is a source file indexed for the lookup of the locations.
+type sourceIndex struct {
+	name      string
+	lines     []int // Offsets of the line starts
+	contracts []sourceScope
+	functions []sourceScope
+}
+
+func newSourceIndex(source SourceFile) *sourceIndex {
+	index := &sourceIndex{name: source.Name, lines: []int{0}}
+	for i := 0; i < len(source.Content); i++ {
+		if source.Content[i] == '\n' {
+			index.lines = append(index.lines, i+1)
+		}
+	}
+	code := maskSource(source.Content)
+	for _, match := range contractRegexp.FindAllStringSubmatchIndex(code, -1) {
+		if end := scopeEnd(code, match[1]); end > 0 {
+			index.contracts = append(index.contracts, sourceScope{code[match[2]:match[3]], match[0], end})
+		}
+	}
+	for _, match := range functionRegexp.FindAllStringSubmatchIndex(code, -1) {
+		name := "fallback"
+		switch {
+		case match[2] >= 0:
+			name = code[match[2]:match[3]]
+		case match[4] >= 0:
+			name = code[match[4]:match[5]]
+		}
+		if end := scopeEnd(code, match[1]); end > 0 {
+			index.functions = append(index.functions, sourceScope{name, match[0], end})
+		}
+	}
+	return index
+}
+
+// locate returns the location of a byte offset of the source.
+func (s *sourceIndex) locate(offset int) *SourceLocation {
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+	return &SourceLocation{
+		File:     s.name,
+		Line:     line + 1,
+		Column:   offset - s.lines[line] + 1,
+		Contract: innermostScope(s.contracts, offset),
+		Function: innermostScope(s.functions, offset),
+	}
+}
+
+// innermostScope returns the name of the narrowest scope containing an offset.
+func innermostScope(scopes []sourceScope, offset int) string {
+	var best *sourceScope
+	for i := range scopes {
+		if scopes[i].start <= offset && offset < scopes[i].end {
+			if best == nil || scopes[i].start >= best.start {
+				best = &scopes[i]
+			}
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.name
+}
+
+// scopeEnd returns the offset following the closing brace of the body of the
+// declaration at the given offset, or 0 if the declaration has no body.
+func scopeEnd(code string, offset int) int {
+	var parens, braces int
+	for i := offset; i < len(code); i++ {
+		switch code[i] {
+		case '(':
+			parens++
+		case ')':
+			parens--
+		case ';':
+			if parens == 0 && braces == 0 {
+				return 0
+			}
+		case '{':
+			braces++
+		case '}':
+			if braces--; braces == 0 {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// maskSource blanks the comments and the string literals of a source, so they
+// are not mistaken for code, preserving the offsets of the rest.
+func maskSource(source string) string {
+	code := []byte(source)
+	for i := 0; i < len(code); i++ {
+		var end int
+		switch {
+		case strings.HasPrefix(source[i:], "//"):
+			if end = strings.IndexByte(source[i:], '\n'); end < 0 {
+				end = len(source) - i
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			if end = strings.Index(source[i+2:], "*/"); end < 0 {
+				end = len(source) - i
+			} else {
+				end += 4
+			}
+		case code[i] == '"' || code[i] == '\'':
+			for end = 1; i+end < len(source) && source[i+end] != source[i]; end++ {
+				if source[i+end] == '\\' {
+					end++
+				}
+			}
+			end++
+		default:
+			continue
+		}
+		if i+end > len(code) {
+			end = len(code) - i
+		}
+		for j := i; j < i+end; j++ {
+			if code[j] != '\n' {
+				code[j] = ' '
+			}
+		}
+		i += end - 1
+	}
+	return string(code)
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSourceMap(t *testing.T) {
+	entries, err := ParseSourceMap("1:2:0:i;:9;;4::-1:-;5:6:1:o:2")
+	if err != nil {
+		t.Fatalf("failed to parse source map: %v", err)
+	}
+	want := []SourceMapEntry{
+		{Start: 1, Length: 2, File: 0, Jump: 'i'},
+		{Start: 1, Length: 9, File: 0, Jump: 'i'},
+		{Start: 1, Length: 9, File: 0, Jump: 'i'},
+		{Start: 4, Length: 9, File: -1, Jump: '-'},
+		{Start: 5, Length: 6, File: 1, Jump: 'o'},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("source map mismatch:\nhave %+v\nwant %+v", entries, want)
+	}
+	for _, srcmap := range []string{"1:x", "1:2:0:z"} {
+		if _, err := ParseSourceMap(srcmap); err == nil {
+			t.Errorf("source map %q: expected error", srcmap)
+		}
+	}
+}
+
+func TestSourceMapper(t *testing.T) {
+	source := `pragma solidity >0.0.0;
+/* contract Fake { function fake() {} } */
+contract Store {
+    uint x;
+    string s = "}{";
+
+    function set(uint v) public {
+        x = v; // {
+    }
+}
+`
+	var (
+		body   = strings.Index(source, "x = v")
+		value  = strings.Index(source, "v; //")
+		store  = strings.Index(source, "contract Store")
+		srcmap = fmt.Sprintf("%d:5:0;%d:1:0;;%d:100:0;::-1", body, value, store)
+	)
+	// PUSH1 0x01 PUSH1 0x00 SSTORE JUMPDEST STOP
+	mapper, err := NewSourceMapper([]byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x5b, 0x00}, srcmap, []SourceFile{{Name: "store.sol", Content: source}})
+	if err != nil {
+		t.Fatalf("failed to create source mapper: %v", err)
+	}
+	tests := []struct {
+		pc   uint64
+		want *SourceLocation
+	}{
+		{0, &SourceLocation{File: "store.sol", Line: 8, Column: 9, Contract: "Store", Function: "set"}},
+		{1, nil}, // Push data
+		{2, &SourceLocation{File: "store.sol", Line: 8, Column: 13, Contract: "Store", Function: "set"}},
+		{4, &SourceLocation{File: "store.sol", Line: 8, Column: 13, Contract: "Store", Function: "set"}},
+		{5, &SourceLocation{File: "store.sol", Line: 3, Column: 1, Contract: "Store"}},
+		{6, nil}, // Generated code
+		{7, nil}, // Out of the code
+	}
+	for _, tt := range tests {
+		if have := mapper.Locate(tt.pc); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("pc %d: location mismatch: have %+v, want %+v", tt.pc, have, tt.want)
+		}
+	}
+}
@@ -682,6 +682,56 @@ func (c *Clique) Close() error {
 	return nil
 }
 
+// SafeHeader implements consensus.FinalityReader. A block is considered safe
+// once a signer other than its own sealer has built on top of it.
+func (c *Clique) SafeHeader(chain consensus.ChainReader, head *types.Header) *types.Header {
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil
+	}
+	threshold := 2
+	if len(snap.Signers) < threshold {
+		threshold = len(snap.Signers)
+	}
+	return c.settledHeader(chain, head, threshold)
+}
+
+// FinalizedHeader implements consensus.FinalityReader. A block is considered
+// final once a majority of the authorized signers have sealed it or one of its
+// descendants, since replacing it would require their collusion.
+func (c *Clique) FinalizedHeader(chain consensus.ChainReader, head *types.Header) *types.Header {
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil
+	}
+	return c.settledHeader(chain, head, len(snap.Signers)/2+1)
+}
+
+// settledHeader walks back from head and returns the first header for which the
+// blocks from it up to head were sealed by at least threshold distinct signers.
+// The walk is bounded by the checkpoint interval, beyond which nil is returned.
+func (c *Clique) settledHeader(chain consensus.ChainReader, head *types.Header, threshold int) *types.Header {
+	sealers := make(map[common.Address]struct{})
+	for header := head; header != nil; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+		// The genesis block is settled by definition
+		if header.Number.Uint64() == 0 {
+			return header
+		}
+		signer, err := ecrecover(header, c.signatures)
+		if err != nil {
+			return nil
+		}
+		sealers[signer] = struct{}{}
+		if len(sealers) >= threshold {
+			return header
+		}
+		if head.Number.Uint64()-header.Number.Uint64() >= checkpointInterval {
+			return nil
+		}
+	}
+	return nil
+}
+
 // APIs implements consensus.Engine, returning the user facing RPC API to allow
 // controlling the signer voting.
 func (c *Clique) APIs(chain consensus.ChainReader) []rpc.API {
@@ -116,6 +116,19 @@ type Engine interface {
 	Close() error
 }
 
+// FinalityReader is an optional interface of consensus engines that can tell
+// which blocks of the local chain are settled. It backs the "safe" and
+// "finalized" block tags of the RPC API.
+type FinalityReader interface {
+	// SafeHeader returns the most recent ancestor of head that is unlikely to
+	// be reorged under normal network conditions, or nil if there is none.
+	SafeHeader(chain ChainReader, head *types.Header) *types.Header
+
+	// FinalizedHeader returns the most recent ancestor of head that the engine
+	// considers irreversible, or nil if there is none.
+	FinalizedHeader(chain ChainReader, head *types.Header) *types.Header
+}
+
 // PoW is a consensus engine based on proof-of-work.
 type PoW interface {
 	Engine
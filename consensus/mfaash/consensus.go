@@ -36,6 +36,12 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// Confirmation depths of the safe and finalized block tags.
+const (
+	safeBlockDepth      = 12 // Number of confirmations after which a block is reported as safe
+	finalizedBlockDepth = 64 // Number of confirmations after which a block is reported as finalized
+)
+
 // Ethash proof-of-work protocol constants.
 var (
 	FrontierBlockReward       = big.NewInt(5e+18) // Block reward in wei for successfully mining a block
@@ -586,6 +592,29 @@ func (mfa *Ethash) FinalizeAndAssemble(chain consensus.ChainReader, header *type
 	return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
+// SafeHeader implements consensus.FinalityReader, returning the canonical header
+// buried safeBlockDepth blocks below head. Proof-of-work has no finality of its
+// own, so this is a purely probabilistic guarantee.
+func (mfa *Ethash) SafeHeader(chain consensus.ChainReader, head *types.Header) *types.Header {
+	return confirmedHeader(chain, head, safeBlockDepth)
+}
+
+// FinalizedHeader implements consensus.FinalityReader, returning the canonical
+// header buried finalizedBlockDepth blocks below head.
+func (mfa *Ethash) FinalizedHeader(chain consensus.ChainReader, head *types.Header) *types.Header {
+	return confirmedHeader(chain, head, finalizedBlockDepth)
+}
+
+// confirmedHeader returns the canonical header the given number of blocks below
+// head, or the genesis header if the chain is not long enough yet.
+func confirmedHeader(chain consensus.ChainReader, head *types.Header, depth uint64) *types.Header {
+	number := head.Number.Uint64()
+	if number < depth {
+		return chain.GetHeaderByNumber(0)
+	}
+	return chain.GetHeaderByNumber(number - depth)
+}
+
 // SealHash returns the hash of a block prior to it being sealed.
 func (mfa *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
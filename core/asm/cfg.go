@@ -0,0 +1,235 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/core/vm"
+)
+
+// Instruction is a disassembled EVM instruction.
+type Instruction struct {
+	Pc  uint64        `json:"pc"`
+	Op  string        `json:"op"`
+	Arg hexutil.Bytes `json:"arg,omitempty"`
+}
+
+// BasicBlock is a sequence of instructions executed in order: it is only entered
+// at its first instruction and only left after its last one.
+type BasicBlock struct {
+	Start        uint64        `json:"start"`
+	End          uint64        `json:"end"` // Program counter of the last instruction
+	Instructions []Instruction `json:"instructions"`
+
+	Jump        *uint64 `json:"jump,omitempty"`        // Destination of the jump ending the block, if pushed right before it
+	Fallthrough *uint64 `json:"fallthrough,omitempty"` // Block executed next if the block doesn't jump nor halt
+	Dynamic     bool    `json:"dynamic,omitempty"`     // Whether the destination of the jump is computed at runtime
+	InvalidJump bool    `json:"invalidJump,omitempty"` // Whether the destination of the jump isn't a JUMPDEST
+	Reachable   bool    `json:"reachable"`             // Whether the block may execute from the start of the code
+}
+
+// ControlFlowGraph is the control flow graph of some bytecode, split in basic
+// blocks linked by their jumps and fallthroughs.
+type ControlFlowGraph struct {
+	Blocks []*BasicBlock `json:"blocks"`
+
+	index map[uint64]*BasicBlock
+}
+
+// NewControlFlowGraph disassembles the bytecode and builds its control flow
+// graph. Jumps are resolved when their destination is pushed right before them.
+// Once a reachable block jumps dynamically, every JUMPDEST is deemed reachable.
+func NewControlFlowGraph(code []byte) *ControlFlowGraph {
+	g := &ControlFlowGraph{index: make(map[uint64]*BasicBlock)}
+
+	// Split the code in blocks, starting new ones at the jump destinations and
+	// after the instructions leaving the block
+	var (
+		block *BasicBlock
+		dests = make(map[uint64]bool)
+	)
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := vm.OpCode(code[pc])
+		if block != nil && op == vm.JUMPDEST {
+			g.addBlock(block)
+			block = nil
+		}
+		if block == nil {
+			block = &BasicBlock{Start: pc}
+		}
+		ins := Instruction{Pc: pc, Op: op.String()}
+		if op.IsPush() {
+			// Truncated pushes read zeroes past the end of the code
+			end := pc + 1 + uint64(op-vm.PUSH1+1)
+			if end > uint64(len(code)) {
+				end = uint64(len(code))
+			}
+			ins.Arg = hexutil.Bytes(code[pc+1 : end])
+		}
+		if op == vm.JUMPDEST {
+			dests[pc] = true
+		}
+		block.Instructions = append(block.Instructions, ins)
+		block.End = pc
+
+		pc += 1 + uint64(len(ins.Arg))
+		if op == vm.JUMP || op == vm.JUMPI || halts(op) {
+			g.addBlock(block)
+			block = nil
+		}
+	}
+	if block != nil {
+		g.addBlock(block)
+	}
+	// Link the blocks with their jumps and fallthroughs
+	for i, block := range g.Blocks {
+		last := block.Instructions[len(block.Instructions)-1]
+		op := vm.OpCode(code[last.Pc])
+
+		if op == vm.JUMP || op == vm.JUMPI {
+			if n := len(block.Instructions); n > 1 && vm.OpCode(code[block.Instructions[n-2].Pc]).IsPush() {
+				dest := new(big.Int).SetBytes(block.Instructions[n-2].Arg)
+				if dest.IsUint64() {
+					jump := dest.Uint64()
+					block.Jump = &jump
+				}
+				block.InvalidJump = block.Jump == nil || !dests[*block.Jump]
+			} else {
+				block.Dynamic = true
+			}
+		}
+		if op != vm.JUMP && !halts(op) && i+1 < len(g.Blocks) {
+			next := g.Blocks[i+1].Start
+			block.Fallthrough = &next
+		}
+	}
+	g.markReachable()
+	return g
+}
+
+// halts returns whether an opcode ends the execution. Undefined opcodes abort it.
+func halts(op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.RETURN, vm.REVERT, vm.SELFDESTRUCT:
+		return true
+	}
+	return vm.StringToOp(op.String()) != op
+}
+
+// addBlock appends a block to the graph.
+func (g *ControlFlowGraph) addBlock(block *BasicBlock) {
+	g.Blocks = append(g.Blocks, block)
+	g.index[block.Start] = block
+}
+
+// Block returns the block starting at a program counter, if any.
+func (g *ControlFlowGraph) Block(pc uint64) *BasicBlock {
+	return g.index[pc]
+}
+
+// markReachable flags the blocks reachable from the start of the code.
+func (g *ControlFlowGraph) markReachable() {
+	var (
+		queue   []*BasicBlock
+		dynamic bool
+	)
+	visit := func(block *BasicBlock) {
+		if block != nil && !block.Reachable {
+			block.Reachable = true
+			queue = append(queue, block)
+		}
+	}
+	visit(g.Block(0))
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+
+		if block.Jump != nil && !block.InvalidJump {
+			visit(g.Block(*block.Jump))
+		}
+		if block.Fallthrough != nil {
+			visit(g.Block(*block.Fallthrough))
+		}
+		// A dynamic jump may land on any jump destination
+		if block.Dynamic && !dynamic {
+			dynamic = true
+			for _, dest := range g.Blocks {
+				if dest.Instructions[0].Op == vm.JUMPDEST.String() {
+					visit(dest)
+				}
+			}
+		}
+	}
+}
+
+// DOT renders the graph in the Graphviz DOT language. Unreachable blocks are
+// dashed, and the jumps which can't be resolved lead to placeholder nodes.
+func (g *ControlFlowGraph) DOT() string {
+	var (
+		out     strings.Builder
+		dynamic bool
+		invalid bool
+	)
+	out.WriteString("digraph cfg {\n\tnode [shape=box fontname=\"monospace\"];\n")
+	for _, block := range g.Blocks {
+		var label strings.Builder
+		for _, ins := range block.Instructions {
+			if len(ins.Arg) > 0 {
+				fmt.Fprintf(&label, "%05x: %s 0x%x\\l", ins.Pc, ins.Op, []byte(ins.Arg))
+			} else {
+				fmt.Fprintf(&label, "%05x: %s\\l", ins.Pc, ins.Op)
+			}
+		}
+		style := ""
+		if !block.Reachable {
+			style = " style=dashed"
+		}
+		fmt.Fprintf(&out, "\tb%d [label=\"%s\"%s];\n", block.Start, label.String(), style)
+	}
+	for _, block := range g.Blocks {
+		// Conditional jumps are labeled with the condition leading to each block
+		var taken, notTaken string
+		if block.Instructions[len(block.Instructions)-1].Op == vm.JUMPI.String() {
+			taken, notTaken = " [label=\"true\"]", " [label=\"false\"]"
+		}
+		switch {
+		case block.Dynamic:
+			dynamic = true
+			fmt.Fprintf(&out, "\tb%d -> dynamic%s;\n", block.Start, taken)
+		case block.InvalidJump:
+			invalid = true
+			fmt.Fprintf(&out, "\tb%d -> invalid%s;\n", block.Start, taken)
+		case block.Jump != nil:
+			fmt.Fprintf(&out, "\tb%d -> b%d%s;\n", block.Start, *block.Jump, taken)
+		}
+		if block.Fallthrough != nil {
+			fmt.Fprintf(&out, "\tb%d -> b%d%s;\n", block.Start, *block.Fallthrough, notTaken)
+		}
+	}
+	if dynamic {
+		out.WriteString("\tdynamic [label=\"dynamic jump\" shape=diamond];\n")
+	}
+	if invalid {
+		out.WriteString("\tinvalid [label=\"invalid jump\" shape=diamond];\n")
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+func TestControlFlowGraph(t *testing.T) {
+	code := common.FromHex(
+		"36" + "6008" + "57" + // 00: jump to 08 if there's calldata
+			"600c" + "56" + // 04: jump to 0c
+			"00" + // 07: unreachable
+			"5b" + "80" + "56" + // 08: dynamic jump
+			"00" + // 0b: unreachable
+			"5b" + "6003" + "56" + // 0c: jump to a non JUMPDEST
+			"5b" + "60", // 10: truncated push
+	)
+	type link struct {
+		jump, next                  *uint64
+		dynamic, invalid, reachable bool
+	}
+	pc := func(pc uint64) *uint64 { return &pc }
+	want := map[uint64]link{
+		0x00: {jump: pc(0x08), next: pc(0x04), reachable: true},
+		0x04: {jump: pc(0x0c), reachable: true},
+		0x07: {},
+		0x08: {dynamic: true, reachable: true},
+		0x0b: {},
+		0x0c: {jump: pc(0x03), invalid: true, reachable: true},
+		0x10: {reachable: true},
+	}
+	g := NewControlFlowGraph(code)
+	if len(g.Blocks) != len(want) {
+		t.Fatalf("block count mismatch: have %d, want %d", len(g.Blocks), len(want))
+	}
+	equal := func(a, b *uint64) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+	}
+	for _, block := range g.Blocks {
+		link, ok := want[block.Start]
+		if !ok {
+			t.Errorf("unexpected block at %#x", block.Start)
+			continue
+		}
+		if !equal(block.Jump, link.jump) || !equal(block.Fallthrough, link.next) || block.Dynamic != link.dynamic || block.InvalidJump != link.invalid || block.Reachable != link.reachable {
+			t.Errorf("block %#x mismatch: have %+v, want %+v", block.Start, block, link)
+		}
+	}
+	if last := g.Block(0x10).Instructions[1]; last.Op != "PUSH1" || len(last.Arg) != 0 {
+		t.Errorf("truncated push mismatch: have %+v", last)
+	}
+	dot := g.DOT()
+	for _, line := range []string{
+		"\tb0 -> b8 [label=\"true\"];",
+		"\tb0 -> b4 [label=\"false\"];",
+		"\tb4 -> b12;",
+		"\tb8 -> dynamic;",
+		"\tb12 -> invalid;",
+		"\tb7 [label=\"00007: STOP\\l\" style=dashed];",
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT output missing %q:\n%s", line, dot)
+		}
+	}
+}
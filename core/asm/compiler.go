@@ -17,74 +17,144 @@
 package asm
 
 import (
+	"encoding/hex"
 	"fmt"
-	"math/big"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/common/math"
 	"github.com/MFAChain/mfachain/core/vm"
 )
 
+// maxExpansionDepth is the maximum nesting of macro expansions and includes,
+// catching the recursive ones.
+const maxExpansionDepth = 64
+
 // Compiler contains information about the parsed source
 // and holds the tokens for the program.
+//
+// Besides the opcodes, the source may contain the following:
+//
+//	label:                          defines a label, emitting a JUMPDEST
+//	PUSH <expr>|"string"            pushes a value, sized to fit it
+//	PUSH<n> <expr>                  pushes a value padded to n bytes
+//	JUMP(I) [<expr>]                jumps, pushing the destination if given
+//	#define NAME <expr>             defines a constant
+//	#include "file"                 compiles another file in place
+//	#macro name(param, ...) .. #end defines a macro
+//	%name(arg, ...)                 expands a macro
+//	#data name <hex>|"string" ...   emits raw bytes, labeled by name
+//
+// Expressions combine numbers, labels (@label), constants and macro
+// parameters with the + - * / & | ^ << >> operators and parentheses.
 type Compiler struct {
-	tokens []token
-	binary []interface{}
+	tokens  []token
+	program []*instruction
+
+	labels    map[string]uint64
+	constants map[string]expr
+	macros    map[string]*macro
 
-	labels map[string]int
+	includeDir string   // Directory the includes of the main source are relative to
+	includes   []string // Files being included, to catch recursive includes
+	depth      int      // Nesting of the macro expansions and includes
+	expansions int      // Number of macro expansions, to name their labels
 
-	pc, pos int
+	errors []error
 
 	debug bool
 }
 
+// instruction is an item of the compiled program.
+type instruction struct {
+	op    vm.OpCode // Opcode, sized during the layout for the pushes of values
+	push  bool      // Whether the instruction pushes a value or data
+	raw   bool      // Whether the instruction is a data section
+	value expr      // Value pushed, evaluated once the labels are placed
+	size  int       // Size of the value pushed
+	fixed bool      // Whether the size of the push is set by the source
+	data  []byte    // Bytes pushed, or those of a data section
+	label string    // Label placed here, if the instruction only marks one
+	tok   token     // Token the instruction was compiled from
+}
+
+// len returns the size of the instruction in the bytecode.
+func (ins *instruction) len() uint64 {
+	switch {
+	case ins.label != "":
+		return 0
+	case ins.raw:
+		return uint64(len(ins.data))
+	case ins.push:
+		return 1 + uint64(ins.size)
+	default:
+		return 1
+	}
+}
+
+// macro is a sequence of lines, parameterized by expressions.
+type macro struct {
+	params []string
+	body   [][]token
+	dir    string // Directory the includes of the body are relative to
+	tok    token
+}
+
+// scope is the context of an expanded macro: the values of its parameters and
+// the names of the labels it defines, which are unique to the expansion.
+type scope struct {
+	args   map[string]expr
+	labels map[string]string
+}
+
+// arg returns the value of a macro parameter.
+func (sc *scope) arg(name string) (expr, bool) {
+	if sc == nil {
+		return nil, false
+	}
+	arg, ok := sc.args[name]
+	return arg, ok
+}
+
+// label returns the name of a label as seen from the scope.
+func (sc *scope) label(name string) string {
+	if sc != nil {
+		if local, ok := sc.labels[name]; ok {
+			return local
+		}
+	}
+	return name
+}
+
 // newCompiler returns a new allocated compiler.
 func NewCompiler(debug bool) *Compiler {
 	return &Compiler{
-		labels: make(map[string]int),
-		debug:  debug,
+		labels:    make(map[string]uint64),
+		constants: make(map[string]expr),
+		macros:    make(map[string]*macro),
+		debug:     debug,
 	}
 }
 
+// SetIncludeDir sets the directory the includes of the main source are
+// relative to, the working directory by default.
+func (c *Compiler) SetIncludeDir(dir string) {
+	c.includeDir = dir
+}
+
 // Feed feeds tokens in to ch and are interpreted by
 // the compiler.
 //
 // feed is the first pass in the compile stage as it
-// collects the used labels in the program and keeps a
-// program counter which is used to determine the locations
-// of the jump dests. The labels can than be used in the
-// second stage to push labels and determine the right
-// position.
+// collects the tokens of the program, which are compiled
+// once the whole source is known.
 func (c *Compiler) Feed(ch <-chan token) {
-	var prev token
 	for i := range ch {
-		switch i.typ {
-		case number:
-			num := math.MustParseBig256(i.text).Bytes()
-			if len(num) == 0 {
-				num = []byte{0}
-			}
-			c.pc += len(num)
-		case stringValue:
-			c.pc += len(i.text) - 2
-		case element:
-			c.pc++
-		case labelDef:
-			c.labels[i.text] = c.pc
-			c.pc++
-		case label:
-			c.pc += 4
-			if prev.typ == element && isJump(prev.text) {
-				c.pc++
-			}
-		}
-
 		c.tokens = append(c.tokens, i)
-		prev = i
-	}
-	if c.debug {
-		fmt.Fprintln(os.Stderr, "found", len(c.labels), "labels")
 	}
 }
 
@@ -93,151 +163,514 @@ func (c *Compiler) Feed(ch <-chan token) {
 // and an error if it failed.
 //
 // compile is the second stage in the compile phase
-// which compiles the tokens to EVM instructions.
+// which compiles the tokens to EVM instructions, expanding
+// the macros and includes, then places the labels and sizes
+// the pushes referring to them.
 func (c *Compiler) Compile() (string, []error) {
-	var errors []error
-	// continue looping over the tokens until
-	// the stack has been exhausted.
-	for c.pos < len(c.tokens) {
-		if err := c.compileLine(); err != nil {
-			errors = append(errors, err)
+	c.compileLines(splitLines(c.tokens), c.includeDir, nil)
+	c.layout()
+
+	if c.debug {
+		fmt.Fprintln(os.Stderr, "found", len(c.labels), "labels")
+	}
+	var (
+		bin []byte
+		pc  uint64
+	)
+	for _, ins := range c.program {
+		if ins.label != "" {
+			continue
 		}
+		if c.debug {
+			fmt.Fprintf(os.Stderr, "%05x: %v %x\n", pc, ins.op, ins.data)
+		}
+		if !ins.raw {
+			bin = append(bin, byte(ins.op))
+		}
+		bin = append(bin, ins.data...)
+		pc += ins.len()
 	}
+	return hex.EncodeToString(bin), c.errors
+}
 
-	// turn the binary to hex
-	var bin string
-	for _, v := range c.binary {
-		switch v := v.(type) {
-		case vm.OpCode:
-			bin += fmt.Sprintf("%x", []byte{byte(v)})
-		case []byte:
-			bin += fmt.Sprintf("%x", v)
+// splitLines splits the tokens in lines, dropping the empty ones.
+func splitLines(tokens []token) [][]token {
+	var (
+		lines [][]token
+		line  []token
+	)
+	for _, tok := range tokens {
+		switch tok.typ {
+		case lineStart:
+		case lineEnd, eof:
+			if len(line) > 0 {
+				lines = append(lines, line)
+			}
+			line = nil
+		default:
+			line = append(line, tok)
 		}
 	}
-	return bin, errors
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
 }
 
-// next returns the next token and increments the
-// position.
-func (c *Compiler) next() token {
-	token := c.tokens[c.pos]
-	c.pos++
-	return token
+// lineParser reads the tokens of a line.
+type lineParser struct {
+	tokens []token
+	pos    int
 }
 
-// compileLine compiles a single line instruction e.g.
-// "push 1", "jump @label".
-func (c *Compiler) compileLine() error {
-	n := c.next()
-	if n.typ != lineStart {
-		return compileErr(n, n.typ.String(), lineStart.String())
+// done returns whether all the tokens of the line were read.
+func (p *lineParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// peek returns the next token without reading it, or a line end once done.
+func (p *lineParser) peek() token {
+	if p.done() {
+		last := p.tokens[len(p.tokens)-1]
+		return token{typ: lineEnd, lineno: last.lineno, text: lineEnd.String(), file: last.file}
+	}
+	return p.tokens[p.pos]
+}
+
+// next reads the next token, or returns a line end once done.
+func (p *lineParser) next() token {
+	tok := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return tok
+}
+
+// end checks that all the tokens of the line were read.
+func (p *lineParser) end() error {
+	if tok := p.peek(); tok.typ != lineEnd {
+		return compileErr(tok, tok.text, lineEnd.String())
 	}
+	return nil
+}
+
+// compileLines compiles a sequence of lines, gathering the macro definitions.
+func (c *Compiler) compileLines(lines [][]token, dir string, sc *scope) {
+	for i := 0; i < len(lines); i++ {
+		if !isDirective(lines[i][0], "#macro") {
+			c.addError(c.compileLine(lines[i], dir, sc))
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !isDirective(lines[end][0], "#end") {
+			end++
+		}
+		if end == len(lines) {
+			c.addError(errorf(lines[i][0], "syntax", "unterminated macro"))
+			return
+		}
+		c.addError(c.defineMacro(lines[i], lines[i+1:end], dir, sc))
+		i = end
+	}
+}
+
+// compileLine compiles a single line instruction e.g.
+// "push 1", "jump @label", "#define SIZE 32".
+func (c *Compiler) compileLine(line []token, dir string, sc *scope) error {
+	p := &lineParser{tokens: line}
 
-	lvalue := c.next()
+	lvalue := p.next()
 	switch lvalue.typ {
-	case eof:
-		return nil
 	case element:
-		if err := c.compileElement(lvalue); err != nil {
+		if err := c.compileElement(lvalue, p, sc); err != nil {
 			return err
 		}
 	case labelDef:
-		c.compileLabel()
-	case lineEnd:
-		return nil
+		if err := c.defineLabel(lvalue, sc.label(lvalue.text)); err != nil {
+			return err
+		}
+		c.emit(&instruction{op: vm.JUMPDEST, tok: lvalue})
+	case macroCall:
+		return c.expandMacro(lvalue, p, sc)
+	case directive:
+		switch strings.ToLower(lvalue.text) {
+		case "#define":
+			return c.compileDefine(p, sc)
+		case "#include":
+			return c.compileInclude(p, dir)
+		case "#data":
+			return c.compileData(p, sc)
+		default:
+			return errorf(lvalue, "syntax", "unknown directive %s", lvalue.text)
+		}
 	default:
 		return compileErr(lvalue, lvalue.text, fmt.Sprintf("%v or %v", labelDef, element))
 	}
+	return p.end()
+}
 
-	if n := c.next(); n.typ != lineEnd {
-		return compileErr(n, n.text, lineEnd.String())
-	}
+// compileElement compiles the element (push & label or both)
+// to a binary representation and may error if incorrect statements
+// where fed.
+func (c *Compiler) compileElement(element token, p *lineParser, sc *scope) error {
+	name := strings.ToUpper(element.text)
+	switch {
+	case isJump(name):
+		// jumps may push their destination first.
+		if p.peek().typ != lineEnd {
+			ins, err := c.compilePush(p, sc, 0)
+			if err != nil {
+				return err
+			}
+			c.emit(ins)
+		}
+		c.emit(&instruction{op: toBinary(name), tok: element})
 
+	case isPush(name):
+		ins, err := c.compilePush(p, sc, 0)
+		if err != nil {
+			return err
+		}
+		c.emit(ins)
+
+	default:
+		op := toBinary(name)
+		if op == vm.STOP && name != "STOP" {
+			return errorf(element, "syntax", "unknown opcode %s", element.text)
+		}
+		if op.IsPush() {
+			ins, err := c.compilePush(p, sc, int(op-vm.PUSH1)+1)
+			if err != nil {
+				return err
+			}
+			c.emit(ins)
+			return nil
+		}
+		c.emit(&instruction{op: op, tok: element})
+	}
 	return nil
 }
 
-// compileNumber compiles the number to bytes
-func (c *Compiler) compileNumber(element token) (int, error) {
-	num := math.MustParseBig256(element.text).Bytes()
-	if len(num) == 0 {
-		num = []byte{0}
+// compilePush compiles the operand of a push, of the given size or sized to fit
+// it if zero.
+func (c *Compiler) compilePush(p *lineParser, sc *scope, size int) (*instruction, error) {
+	if tok := p.peek(); tok.typ == stringValue {
+		// strings are quoted, remove them.
+		p.next()
+		value := []byte(tok.text[1 : len(tok.text)-1])
+		if len(value) == 0 || len(value) > 32 {
+			return nil, errorf(tok, "type", "unsupported string or number with size > 32")
+		}
+		if size == 0 {
+			size = len(value)
+		}
+		if len(value) > size {
+			return nil, errorf(tok, "type", "string of %d bytes doesn't fit PUSH%d", len(value), size)
+		}
+		return &instruction{op: vm.PUSH1 + vm.OpCode(size-1), push: true, size: size, fixed: true, data: common.LeftPadBytes(value, size), tok: tok}, nil
+	}
+	tok := p.peek()
+	value, err := c.parseExpr(p, sc)
+	if err != nil {
+		return nil, err
+	}
+	ins := &instruction{push: true, value: value, size: 1, tok: tok}
+	if size > 0 {
+		ins.size, ins.fixed = size, true
+	}
+	return ins, nil
+}
+
+// compileDefine compiles the definition of a constant.
+func (c *Compiler) compileDefine(p *lineParser, sc *scope) error {
+	name := p.next()
+	if name.typ != element {
+		return compileErr(name, name.text, element.String())
 	}
-	c.pushBin(num)
-	return len(num), nil
+	if _, ok := c.constants[name.text]; ok {
+		return errorf(name, "name", "constant %q already defined", name.text)
+	}
+	value, err := c.parseExpr(p, sc)
+	if err != nil {
+		return err
+	}
+	c.constants[name.text] = value
+	return p.end()
 }
 
-// compileElement compiles the element (push & label or both)
-// to a binary representation and may error if incorrect statements
-// where fed.
-func (c *Compiler) compileElement(element token) error {
-	// check for a jump. jumps must be read and compiled
-	// from right to left.
-	if isJump(element.text) {
-		rvalue := c.next()
-		switch rvalue.typ {
-		case number:
-			// TODO figure out how to return the error properly
-			c.compileNumber(rvalue)
-		case stringValue:
-			// strings are quoted, remove them.
-			c.pushBin(rvalue.text[1 : len(rvalue.text)-2])
-		case label:
-			c.pushBin(vm.PUSH4)
-			pos := big.NewInt(int64(c.labels[rvalue.text])).Bytes()
-			pos = append(make([]byte, 4-len(pos)), pos...)
-			c.pushBin(pos)
-		case lineEnd:
-			c.pos--
-		default:
-			return compileErr(rvalue, rvalue.text, "number, string or label")
+// compileInclude compiles the file included in place of the directive.
+func (c *Compiler) compileInclude(p *lineParser, dir string) error {
+	tok := p.next()
+	if tok.typ != stringValue {
+		return compileErr(tok, tok.text, stringValue.String())
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+	path := tok.text[1 : len(tok.text)-1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	for _, included := range c.includes {
+		if included == path {
+			return errorf(tok, "include", "recursive include of %s", path)
 		}
-		// push the operation
-		c.pushBin(toBinary(element.text))
-		return nil
-	} else if isPush(element.text) {
-		// handle pushes. pushes are read from left to right.
-		var value []byte
-
-		rvalue := c.next()
-		switch rvalue.typ {
+	}
+	if c.depth >= maxExpansionDepth {
+		return errorf(tok, "include", "includes nested too deep")
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errorf(tok, "include", "%v", err)
+	}
+	var tokens []token
+	for tok := range Lex(src, c.debug) {
+		tok.file = path
+		tokens = append(tokens, tok)
+	}
+	c.includes = append(c.includes, path)
+	c.depth++
+	c.compileLines(splitLines(tokens), filepath.Dir(path), nil)
+	c.depth--
+	c.includes = c.includes[:len(c.includes)-1]
+	return nil
+}
+
+// compileData compiles a data section: raw bytes labeled by a name, which are
+// not a valid jump destination.
+func (c *Compiler) compileData(p *lineParser, sc *scope) error {
+	name := p.next()
+	if name.typ != element {
+		return compileErr(name, name.text, element.String())
+	}
+	ins := &instruction{raw: true, tok: name}
+	for !p.done() {
+		tok := p.next()
+		switch tok.typ {
 		case number:
-			value = math.MustParseBig256(rvalue.text).Bytes()
-			if len(value) == 0 {
-				value = []byte{0}
+			data, err := dataBytes(tok)
+			if err != nil {
+				return err
 			}
+			ins.data = append(ins.data, data...)
 		case stringValue:
-			value = []byte(rvalue.text[1 : len(rvalue.text)-1])
-		case label:
-			value = big.NewInt(int64(c.labels[rvalue.text])).Bytes()
-			value = append(make([]byte, 4-len(value)), value...)
+			ins.data = append(ins.data, tok.text[1:len(tok.text)-1]...)
 		default:
-			return compileErr(rvalue, rvalue.text, "number, string or label")
+			return compileErr(tok, tok.text, "number or string")
 		}
+	}
+	if err := c.defineLabel(name, sc.label(name.text)); err != nil {
+		return err
+	}
+	c.emit(ins)
+	return nil
+}
 
-		if len(value) > 32 {
-			return fmt.Errorf("%d type error: unsupported string or number with size > 32", rvalue.lineno)
+// dataBytes returns the bytes of a number in a data section: hexadecimal ones
+// as written, decimal ones in as few bytes as possible.
+func dataBytes(tok token) ([]byte, error) {
+	if strings.HasPrefix(tok.text, "0x") || strings.HasPrefix(tok.text, "0X") {
+		digits := tok.text[2:]
+		if len(digits)%2 == 1 {
+			digits = "0" + digits
+		}
+		data, err := hex.DecodeString(digits)
+		if err != nil || len(data) == 0 {
+			return nil, errorf(tok, "type", "invalid number %s", tok.text)
 		}
+		return data, nil
+	}
+	value, ok := math.ParseBig256(tok.text)
+	if !ok {
+		return nil, errorf(tok, "type", "invalid number %s", tok.text)
+	}
+	if value.Sign() == 0 {
+		return []byte{0}, nil
+	}
+	return value.Bytes(), nil
+}
 
-		c.pushBin(vm.OpCode(int(vm.PUSH1) - 1 + len(value)))
-		c.pushBin(value)
-	} else {
-		c.pushBin(toBinary(element.text))
+// defineMacro compiles the definition of a macro, from its header line and its
+// body.
+func (c *Compiler) defineMacro(header []token, body [][]token, dir string, sc *scope) error {
+	if sc != nil {
+		return errorf(header[0], "syntax", "macro defined inside a macro")
+	}
+	p := &lineParser{tokens: header[1:]}
+	if len(header) == 1 {
+		return compileErr(header[0], lineEnd.String(), element.String())
+	}
+	name := p.next()
+	if name.typ != element {
+		return compileErr(name, name.text, element.String())
 	}
+	if _, ok := c.macros[name.text]; ok {
+		return errorf(name, "name", "macro %q already defined", name.text)
+	}
+	m := &macro{body: body, dir: dir, tok: name}
+	if tok := p.peek(); tok.typ == operator && tok.text == "(" {
+		p.next()
+		for {
+			param := p.next()
+			if param.typ == operator && param.text == ")" && len(m.params) == 0 {
+				break
+			}
+			if param.typ != element {
+				return compileErr(param, param.text, "parameter name")
+			}
+			m.params = append(m.params, param.text)
 
+			sep := p.next()
+			if sep.typ == operator && sep.text == ")" {
+				break
+			}
+			if sep.typ != operator || sep.text != "," {
+				return compileErr(sep, sep.text, ", or )")
+			}
+		}
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+	c.macros[name.text] = m
 	return nil
 }
 
-// compileLabel pushes a jumpdest to the binary slice.
-func (c *Compiler) compileLabel() {
-	c.pushBin(vm.JUMPDEST)
+// expandMacro compiles the body of a macro in place of its invocation.
+func (c *Compiler) expandMacro(call token, p *lineParser, sc *scope) error {
+	m, ok := c.macros[call.text]
+	if !ok {
+		return errorf(call, "name", "undefined macro %q", call.text)
+	}
+	// Evaluate the arguments in the scope of the invocation
+	var args []expr
+	if tok := p.peek(); tok.typ == operator && tok.text == "(" {
+		p.next()
+		if tok := p.peek(); tok.typ == operator && tok.text == ")" {
+			p.next()
+		} else {
+			for {
+				arg, err := c.parseExpr(p, sc)
+				if err != nil {
+					return err
+				}
+				args = append(args, arg)
+
+				sep := p.next()
+				if sep.typ == operator && sep.text == ")" {
+					break
+				}
+				if sep.typ != operator || sep.text != "," {
+					return compileErr(sep, sep.text, ", or )")
+				}
+			}
+		}
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+	if len(args) != len(m.params) {
+		return errorf(call, "syntax", "macro %q takes %d arguments, got %d", call.text, len(m.params), len(args))
+	}
+	if c.depth >= maxExpansionDepth {
+		return errorf(call, "syntax", "macro expansions nested too deep")
+	}
+	// Give the labels defined by the body names unique to this expansion
+	c.expansions++
+	inner := &scope{
+		args:   make(map[string]expr),
+		labels: make(map[string]string),
+	}
+	for i, param := range m.params {
+		inner.args[param] = args[i]
+	}
+	for _, line := range m.body {
+		switch {
+		case line[0].typ == labelDef:
+			inner.labels[line[0].text] = fmt.Sprintf("%s.%d.%s", call.text, c.expansions, line[0].text)
+		case isDirective(line[0], "#data") && len(line) > 1:
+			inner.labels[line[1].text] = fmt.Sprintf("%s.%d.%s", call.text, c.expansions, line[1].text)
+		}
+	}
+	c.depth++
+	c.compileLines(m.body, m.dir, inner)
+	c.depth--
+	return nil
 }
 
-// pushBin pushes the value v to the binary stack.
-func (c *Compiler) pushBin(v interface{}) {
-	if c.debug {
-		fmt.Printf("%d: %v\n", len(c.binary), v)
+// defineLabel declares a label, placed during the layout.
+func (c *Compiler) defineLabel(tok token, name string) error {
+	if _, ok := c.labels[name]; ok {
+		return errorf(tok, "name", "label %q already defined", tok.text)
+	}
+	c.labels[name] = 0
+	c.emit(&instruction{label: name, tok: tok})
+	return nil
+}
+
+// emit appends an instruction to the program.
+func (c *Compiler) emit(ins *instruction) {
+	c.program = append(c.program, ins)
+}
+
+// addError records an error, if any.
+func (c *Compiler) addError(err error) {
+	if err != nil {
+		c.errors = append(c.errors, err)
+	}
+}
+
+// layout places the labels and sizes the pushes of the values referring to
+// them. As the offsets of the labels depend on the sizes of the pushes before
+// them, the pushes only grow until they all fit their values.
+func (c *Compiler) layout() {
+	for {
+		c.placeLabels()
+
+		grown := false
+		for _, ins := range c.program {
+			if ins.value == nil || !ins.push || ins.fixed {
+				continue
+			}
+			// Errors are reported once the layout is done
+			if value, err := ins.value.eval(c.labels); err == nil {
+				if size := len(value.Bytes()); size > ins.size && size <= 32 {
+					ins.size, grown = size, true
+				}
+			}
+		}
+		if !grown {
+			break
+		}
+	}
+	for _, ins := range c.program {
+		if ins.value == nil || !ins.push {
+			continue
+		}
+		value, err := ins.value.eval(c.labels)
+		if err != nil {
+			c.addError(err)
+			continue
+		}
+		if len(value.Bytes()) > ins.size {
+			c.addError(errorf(ins.tok, "type", "value %#x doesn't fit PUSH%d", value, ins.size))
+			continue
+		}
+		ins.op = vm.PUSH1 + vm.OpCode(ins.size-1)
+		ins.data = common.LeftPadBytes(value.Bytes(), ins.size)
+	}
+}
+
+// placeLabels sets the offsets of the labels from the current sizes of the
+// instructions.
+func (c *Compiler) placeLabels() {
+	var pc uint64
+	for _, ins := range c.program {
+		if ins.label != "" {
+			c.labels[ins.label] = pc
+		}
+		pc += ins.len()
 	}
-	c.binary = append(c.binary, v)
 }
 
 // isPush returns whether the string op is either any of
@@ -251,20 +684,39 @@ func isJump(op string) bool {
 	return strings.ToUpper(op) == "JUMPI" || strings.ToUpper(op) == "JUMP"
 }
 
+// isDirective returns whether the token is the given directive.
+func isDirective(tok token, name string) bool {
+	return tok.typ == directive && strings.ToLower(tok.text) == name
+}
+
 // toBinary converts text to a vm.OpCode
 func toBinary(text string) vm.OpCode {
 	return vm.StringToOp(strings.ToUpper(text))
 }
 
+// position returns the location of a token, prefixed by its file if included.
+func position(tok token) string {
+	if tok.file != "" {
+		return fmt.Sprintf("%s:%d", tok.file, tok.lineno)
+	}
+	return strconv.Itoa(tok.lineno)
+}
+
+// errorf returns an error of the given kind at the location of a token.
+func errorf(tok token, kind string, format string, args ...interface{}) error {
+	return fmt.Errorf("%s %s error: %s", position(tok), kind, fmt.Sprintf(format, args...))
+}
+
 type compileError struct {
 	got  string
 	want string
 
 	lineno int
+	file   string
 }
 
 func (err compileError) Error() string {
-	return fmt.Sprintf("%d syntax error: unexpected %v, expected %v", err.lineno, err.got, err.want)
+	return fmt.Sprintf("%s syntax error: unexpected %v, expected %v", position(token{lineno: err.lineno, file: err.file}), err.got, err.want)
 }
 
 func compileErr(c token, got, want string) error {
@@ -272,5 +724,6 @@ func compileErr(c token, got, want string) error {
 		got:    got,
 		want:   want,
 		lineno: c.lineno,
+		file:   c.file,
 	}
 }
@@ -17,6 +17,10 @@
 package asm
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -30,14 +34,14 @@ func TestCompiler(t *testing.T) {
 	label:
 	PUSH @label
 `,
-			output: "5a5b6300000001",
+			output: "5a5b6001",
 		},
 		{
 			input: `
 	PUSH @label
 	label:
 `,
-			output: "63000000055b",
+			output: "60025b",
 		},
 		{
 			input: `
@@ -45,14 +49,56 @@ func TestCompiler(t *testing.T) {
 	JUMP
 	label:
 `,
-			output: "6300000006565b",
+			output: "6003565b",
 		},
 		{
 			input: `
 	JUMP @label
 	label:
 `,
-			output: "6300000006565b",
+			output: "6003565b",
+		},
+		{
+			input: `
+	PUSH "ab"
+	PUSH (1 << 8) | 0xff - 1
+	PUSH2 @label + 2 * 3
+	label:
+`,
+			output: "6161626101fe61000f5b",
+		},
+		{
+			input: `
+	#define SIZE 0x20
+	#macro store(slot, value)
+		PUSH value
+		PUSH slot
+		SSTORE
+	#end
+	#macro loop
+		top:
+		JUMP @top
+	#end
+	%store(1, SIZE * 2 + 1)
+	%loop
+	%loop
+`,
+			output: "60416001555b6005565b600956",
+		},
+		{
+			input: `
+	PUSH @msg
+	#data msg 0x0102 "ab" 3
+`,
+			output: "600201026162" + "03",
+		},
+		{
+			input: `
+	JUMP @end
+	#data padding 0x` + strings.Repeat("00", 300) + `
+	end:
+`,
+			output: "61013056" + strings.Repeat("00", 300) + "5b",
 		},
 	}
 	for _, test := range tests {
@@ -69,3 +115,77 @@ func TestCompiler(t *testing.T) {
 		}
 	}
 }
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{input: "PUSH @missing", err: `0 name error: undefined label "missing"`},
+		{input: "PUSH MISSING", err: `0 name error: undefined constant "MISSING"`},
+		{input: "FOO", err: "0 syntax error: unknown opcode FOO"},
+		{input: "PUSH 1 +", err: "0 syntax error: unexpected end of line, expected number, label, constant or ("},
+		{input: "PUSH 1 / 0", err: "0 type error: division by zero"},
+		{input: "PUSH 1 - 2", err: "0 type error: negative value"},
+		{input: "PUSH1 0x100", err: "0 type error: value 0x100 doesn't fit PUSH1"},
+		{input: "a:\na:", err: `1 name error: label "a" already defined`},
+		{input: "%missing", err: `0 name error: undefined macro "missing"`},
+		{input: "#macro m(a)\nPUSH a\n#end\n%m", err: `3 syntax error: macro "m" takes 1 arguments, got 0`},
+		{input: "#macro m\n%m\n#end\n%m", err: "1 syntax error: macro expansions nested too deep"},
+		{input: "#macro m\nSTOP", err: "0 syntax error: unterminated macro"},
+		{input: "#unknown", err: "0 syntax error: unknown directive #unknown"},
+	}
+	for _, test := range tests {
+		c := NewCompiler(false)
+		c.Feed(Lex([]byte(test.input), false))
+		_, errs := c.Compile()
+		if len(errs) != 1 || errs[0].Error() != test.err {
+			t.Errorf("input %q: error mismatch: have %v, want %v", test.input, errs, test.err)
+		}
+	}
+}
+
+func TestCompilerInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"main.asm":       "#include \"lib/util.asm\"\n%ret(VALUE)\n",
+		"lib/util.asm":   "#include \"../defs.asm\"\n#macro ret(value)\n\tPUSH value\n\tPUSH 0\n\tMSTORE\n#end\n",
+		"defs.asm":       "#define VALUE 42\n",
+		"recursive.asm":  "STOP\n#include \"recursive.asm\"\n",
+		"broken.asm":     "#include \"lib/broken.asm\"\n",
+		"lib/broken.asm": "\nPUSH @missing\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compile := func(name string) (string, []error) {
+		src, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewCompiler(false)
+		c.SetIncludeDir(dir)
+		c.Feed(Lex(src, false))
+		return c.Compile()
+	}
+	if output, errs := compile("main.asm"); len(errs) != 0 || output != "602a600052" {
+		t.Errorf("output mismatch: have %s (%v), want 602a600052", output, errs)
+	}
+	if _, errs := compile("recursive.asm"); len(errs) != 1 || !strings.Contains(errs[0].Error(), "include error: recursive include") {
+		t.Errorf("recursive include error mismatch: have %v", errs)
+	}
+	want := filepath.Join(dir, "lib/broken.asm") + `:1 name error: undefined label "missing"`
+	if _, errs := compile("broken.asm"); len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("included error mismatch: have %v, want %v", errs, want)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"math/big"
+
+	"github.com/MFAChain/mfachain/common/math"
+)
+
+// expr is a constant expression, whose value may depend on the offsets of the
+// labels.
+type expr interface {
+	eval(labels map[string]uint64) (*big.Int, error)
+}
+
+// numberExpr is a literal number.
+type numberExpr struct {
+	value *big.Int
+}
+
+func (e *numberExpr) eval(labels map[string]uint64) (*big.Int, error) {
+	return e.value, nil
+}
+
+// labelExpr is the offset of a label.
+type labelExpr struct {
+	name string
+	tok  token
+}
+
+func (e *labelExpr) eval(labels map[string]uint64) (*big.Int, error) {
+	offset, ok := labels[e.name]
+	if !ok {
+		return nil, errorf(e.tok, "name", "undefined label %q", e.tok.text)
+	}
+	return new(big.Int).SetUint64(offset), nil
+}
+
+// binaryExpr is an operation on two expressions.
+type binaryExpr struct {
+	op   string
+	x, y expr
+	tok  token
+}
+
+func (e *binaryExpr) eval(labels map[string]uint64) (*big.Int, error) {
+	x, err := e.x.eval(labels)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.y.eval(labels)
+	if err != nil {
+		return nil, err
+	}
+	z := new(big.Int)
+	switch e.op {
+	case "+":
+		z.Add(x, y)
+	case "-":
+		if x.Cmp(y) < 0 {
+			return nil, errorf(e.tok, "type", "negative value")
+		}
+		z.Sub(x, y)
+	case "*":
+		z.Mul(x, y)
+	case "/":
+		if y.Sign() == 0 {
+			return nil, errorf(e.tok, "type", "division by zero")
+		}
+		z.Div(x, y)
+	case "&":
+		z.And(x, y)
+	case "|":
+		z.Or(x, y)
+	case "^":
+		z.Xor(x, y)
+	case "<<", ">>":
+		if y.Cmp(big.NewInt(256)) >= 0 {
+			if e.op == ">>" || x.Sign() == 0 {
+				return z, nil
+			}
+			return nil, errorf(e.tok, "type", "value exceeds 256 bits")
+		}
+		if e.op == "<<" {
+			z.Lsh(x, uint(y.Uint64()))
+		} else {
+			z.Rsh(x, uint(y.Uint64()))
+		}
+	}
+	if z.BitLen() > 256 {
+		return nil, errorf(e.tok, "type", "value exceeds 256 bits")
+	}
+	return z, nil
+}
+
+// precedences are the binding powers of the binary operators, as in C.
+var precedences = map[string]int{
+	"|":  1,
+	"^":  2,
+	"&":  3,
+	"<<": 4,
+	">>": 4,
+	"+":  5,
+	"-":  5,
+	"*":  6,
+	"/":  6,
+}
+
+// parseExpr parses an expression of numbers, labels and constants.
+func (c *Compiler) parseExpr(p *lineParser, sc *scope) (expr, error) {
+	return c.parseBinary(p, sc, 1)
+}
+
+// parseBinary parses the operations binding at least as much as the given
+// precedence.
+func (c *Compiler) parseBinary(p *lineParser, sc *scope, prec int) (expr, error) {
+	x, err := c.parseOperand(p, sc)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		opPrec, ok := precedences[tok.text]
+		if tok.typ != operator || !ok || opPrec < prec {
+			return x, nil
+		}
+		p.next()
+
+		y, err := c.parseBinary(p, sc, opPrec+1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{op: tok.text, x: x, y: y, tok: tok}
+	}
+}
+
+// parseOperand parses a number, a label, a constant or a parenthesized
+// expression.
+func (c *Compiler) parseOperand(p *lineParser, sc *scope) (expr, error) {
+	tok := p.next()
+	switch tok.typ {
+	case number:
+		value, ok := math.ParseBig256(tok.text)
+		if !ok {
+			return nil, errorf(tok, "type", "invalid number %s", tok.text)
+		}
+		return &numberExpr{value: value}, nil
+
+	case label:
+		return &labelExpr{name: sc.label(tok.text), tok: tok}, nil
+
+	case element:
+		if arg, ok := sc.arg(tok.text); ok {
+			return arg, nil
+		}
+		if constant, ok := c.constants[tok.text]; ok {
+			return constant, nil
+		}
+		return nil, errorf(tok, "name", "undefined constant %q", tok.text)
+
+	case operator:
+		if tok.text == "(" {
+			x, err := c.parseExpr(p, sc)
+			if err != nil {
+				return nil, err
+			}
+			if end := p.next(); end.typ != operator || end.text != ")" {
+				return nil, compileErr(end, end.text, ")")
+			}
+			return x, nil
+		}
+	}
+	return nil, compileErr(tok, tok.text, "number, label, constant or (")
+}
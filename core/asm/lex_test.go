@@ -68,6 +68,18 @@ func TestLexer(t *testing.T) {
 			input:  "@label123",
 			tokens: []token{{typ: lineStart}, {typ: label, text: "label123"}, {typ: eof}},
 		},
+		{
+			input:  "#define SIZE 0x20",
+			tokens: []token{{typ: lineStart}, {typ: directive, text: "#define"}, {typ: element, text: "SIZE"}, {typ: number, text: "0x20"}, {typ: eof}},
+		},
+		{
+			input:  "%store(1, @a << 2)",
+			tokens: []token{{typ: lineStart}, {typ: macroCall, text: "store"}, {typ: operator, text: "("}, {typ: number, text: "1"}, {typ: operator, text: ","}, {typ: label, text: "a"}, {typ: operator, text: "<<"}, {typ: number, text: "2"}, {typ: operator, text: ")"}, {typ: eof}},
+		},
+		{
+			input:  "PUSH 1 < 2",
+			tokens: []token{{typ: lineStart}, {typ: element, text: "PUSH"}, {typ: number, text: "1"}, {typ: invalidStatement, text: "<"}, {typ: eof}},
+		},
 	}
 
 	for _, test := range tests {
@@ -36,6 +36,7 @@ type token struct {
 	typ    tokenType
 	lineno int
 	text   string
+	file   string // file the token was included from, empty for the main source
 }
 
 // tokenType are the different types the lexer
@@ -52,6 +53,9 @@ const (
 	labelDef                          // label definition is emitted when a new label is found
 	number                            // number is emitted when a number is found
 	stringValue                       // stringValue is emitted when a string has been found
+	directive                         // directive is emitted when a directive (e.g. #define) is found
+	macroCall                         // macroCall is emitted when a macro invocation is found
+	operator                          // operator is emitted when an operator or a delimiter is found
 
 	Numbers            = "1234567890"                                           // characters representing any decimal number
 	HexadecimalNumbers = Numbers + "aAbBcCdDeEfF"                               // characters representing any hexadecimal
@@ -60,7 +64,7 @@ const (
 
 // String implements stringer
 func (it tokenType) String() string {
-	if int(it) > len(stringtokenTypes) {
+	if int(it) >= len(stringtokenTypes) {
 		return "invalid"
 	}
 	return stringtokenTypes[it]
@@ -76,6 +80,9 @@ var stringtokenTypes = []string{
 	labelDef:         "label definition",
 	number:           "number",
 	stringValue:      "string",
+	directive:        "directive",
+	macroCall:        "macro invocation",
+	operator:         "operator",
 }
 
 // lexer is the basic construct for parsing
@@ -182,7 +189,7 @@ func (l *lexer) blob() string {
 
 // Emits a new token on to token channel for processing
 func (l *lexer) emit(t tokenType) {
-	token := token{t, l.lineno, l.blob()}
+	token := token{typ: t, lineno: l.lineno, text: l.blob()}
 
 	if l.debug {
 		fmt.Fprintf(os.Stderr, "%04d: (%-20v) %s\n", token.lineno, token.typ, token.text)
@@ -196,6 +203,8 @@ func (l *lexer) emit(t tokenType) {
 func lexLine(l *lexer) stateFn {
 	for {
 		switch r := l.next(); {
+		case r == 0:
+			return nil
 		case r == '\n':
 			l.emit(lineEnd)
 			l.ignore()
@@ -215,7 +224,21 @@ func lexLine(l *lexer) stateFn {
 			return lexLabel
 		case r == '"':
 			return lexInsideString
+		case r == '#':
+			return lexDirective
+		case r == '%':
+			l.ignore()
+			return lexMacroCall
+		case r == '<' || r == '>':
+			if !l.accept(string(r)) {
+				l.emit(invalidStatement)
+				return nil
+			}
+			l.emit(operator)
+		case strings.ContainsRune("+-*/&|^(),", r):
+			l.emit(operator)
 		default:
+			l.emit(invalidStatement)
 			return nil
 		}
 	}
@@ -241,6 +264,24 @@ func lexLabel(l *lexer) stateFn {
 	return lexLine
 }
 
+// lexDirective parses the current directive, including its leading '#'.
+func lexDirective(l *lexer) stateFn {
+	l.acceptRun(Alpha)
+
+	l.emit(directive)
+
+	return lexLine
+}
+
+// lexMacroCall parses the name of the macro invoked.
+func lexMacroCall(l *lexer) stateFn {
+	l.acceptRun(Alpha + "_" + Numbers)
+
+	l.emit(macroCall)
+
+	return lexLine
+}
+
 // lexInsideString lexes the inside of a string until
 // the state function finds the closing quote.
 // It returns the lex text state function.
@@ -58,6 +58,12 @@ func BenchmarkInsertChain_uncles_memdb(b *testing.B) {
 func BenchmarkInsertChain_uncles_diskdb(b *testing.B) {
 	benchInsertChain(b, true, genUncles)
 }
+func BenchmarkInsertChain_jumps_memdb(b *testing.B) {
+	benchInsertChain(b, false, genJumpCalls)
+}
+func BenchmarkInsertChain_jumps_diskdb(b *testing.B) {
+	benchInsertChain(b, true, genJumpCalls)
+}
 func BenchmarkInsertChain_ring200_memdb(b *testing.B) {
 	benchInsertChain(b, false, genTxRing(200))
 }
@@ -85,7 +91,7 @@ func genValueTx(nbytes int) func(int, *BlockGen) {
 	return func(i int, gen *BlockGen) {
 		toaddr := common.Address{}
 		data := make([]byte, nbytes)
-		gas, _ := IntrinsicGas(data, false, false, false)
+		gas, _ := IntrinsicGas(data, false, false, false, false)
 		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), toaddr, big.NewInt(1), gas, nil, data), types.HomesteadSigner{}, benchRootKey)
 		gen.AddTx(tx)
 	}
@@ -134,6 +140,28 @@ func genTxRing(naccounts int) func(int, *BlockGen) {
 	}
 }
 
+var (
+	// benchJumpAddr is a contract of a maximal size, jumping over its code.
+	benchJumpAddr = common.HexToAddress("0xc0de")
+	benchJumpCode = func() []byte {
+		code := make([]byte, params.MaxCodeSize)
+		code[0], code[1], code[2], code[3] = byte(vm.PUSH2), byte((len(code)-1)>>8), byte(len(code)-1), byte(vm.JUMP)
+		code[len(code)-1] = byte(vm.JUMPDEST)
+		return code
+	}()
+)
+
+// genJumpCalls returns a block generator that fills the blocks with calls to
+// a contract of a maximal size, whose code needs analysing to jump.
+func genJumpCalls(i int, gen *BlockGen) {
+	block := gen.PrevBlock(i - 1)
+	gas := CalcGasLimit(block, block.GasLimit(), block.GasLimit())
+	for callGas := params.TxGas + 100; gas >= callGas; gas -= callGas {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), benchJumpAddr, nil, callGas, nil, nil), types.HomesteadSigner{}, benchRootKey)
+		gen.AddTx(tx)
+	}
+}
+
 // genUncles generates blocks with two uncle headers.
 func genUncles(i int, gen *BlockGen) {
 	if i >= 6 {
@@ -168,7 +196,10 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	// generator function.
 	gspec := Genesis{
 		Config: params.TestChainConfig,
-		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+		Alloc: GenesisAlloc{
+			benchRootAddr: {Balance: benchRootFunds},
+			benchJumpAddr: {Balance: common.Big0, Code: benchJumpCode},
+		},
 	}
 	genesis := gspec.MustCommit(db)
 	chain, _ := GenerateChain(gspec.Config, genesis, mfa.NewFaker(), db, b.N, gen)
@@ -87,6 +87,7 @@ const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
+	reloadEventLimit    = 1024
 	TriesInMemory       = 128
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
@@ -121,6 +122,8 @@ type CacheConfig struct {
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
+	HistoryCutoff       uint64        // Block number below which ancient bodies and receipts are deleted (0 = keep all)
+	ParallelTxWorkers   int           // Number of workers executing block transactions in parallel (0 = sequential)
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -140,6 +143,11 @@ type CacheConfig struct {
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
+	// WARNING: The `historyTail` field is accessed atomically. On 32 bit platforms, only
+	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
+	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
+	historyTail uint64 // Oldest block whose body and receipts are retained, only headers below
+
 	chainConfig *params.ChainConfig // Chain & network configuration
 	cacheConfig *CacheConfig        // Cache configuration for pruning
 
@@ -208,6 +216,9 @@ func NewBlockChain(db mfadb.Database, cacheConfig *CacheConfig, chainConfig *par
 			SnapshotWait:   true,
 		}
 	}
+	if err := vm.ValidatePrecompiles(chainConfig); err != nil {
+		return nil, err
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	receiptsCache, _ := lru.New(receiptsCacheLimit)
@@ -217,6 +228,7 @@ func NewBlockChain(db mfadb.Database, cacheConfig *CacheConfig, chainConfig *par
 	badBlocks, _ := lru.New(badBlockLimit)
 
 	bc := &BlockChain{
+		historyTail:    rawdb.ReadHistoryTail(db),
 		chainConfig:    chainConfig,
 		cacheConfig:    cacheConfig,
 		db:             db,
@@ -236,7 +248,11 @@ func NewBlockChain(db mfadb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
-	bc.processor = NewStateProcessor(chainConfig, bc, engine)
+	if cacheConfig.ParallelTxWorkers > 0 {
+		bc.processor = NewParallelStateProcessor(chainConfig, bc, engine, cacheConfig.ParallelTxWorkers)
+	} else {
+		bc.processor = NewStateProcessor(chainConfig, bc, engine)
+	}
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
@@ -329,6 +345,9 @@ func NewBlockChain(db mfadb.Database, cacheConfig *CacheConfig, chainConfig *par
 		bc.txLookupLimit = *txLookupLimit
 		go bc.maintainTxIndex(txIndexBlock)
 	}
+	if cacheConfig.HistoryCutoff > 0 {
+		go bc.maintainHistory()
+	}
 	return bc, nil
 }
 
@@ -528,6 +547,108 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	return nil
 }
 
+// ReloadHead moves the in-memory chain markers onto the head block currently
+// recorded in the database, emitting chain events for the newly canonical blocks
+// and, on reorgs, removed log and side events for the dropped ones.
+// It is meant for chains whose database is advanced by another node, such as
+// read-only replicas, and never writes to the database itself.
+func (bc *BlockChain) ReloadHead() error {
+	// Pick up any history expired by the database owner
+	atomic.StoreUint64(&bc.historyTail, rawdb.ReadHistoryTail(bc.db))
+
+	bc.chainmu.Lock()
+	hash := rawdb.ReadHeadBlockHash(bc.db)
+	current := bc.CurrentBlock()
+	if hash == (common.Hash{}) || hash == current.Hash() {
+		bc.chainmu.Unlock()
+		return nil
+	}
+	head := bc.GetBlockByHash(hash)
+	if head == nil {
+		bc.chainmu.Unlock()
+		return fmt.Errorf("non existent head block [%x…]", hash[:4])
+	}
+	// Gather the blocks that became canonical since the previous head and the
+	// ones that were reorged out, walking both chains back to their common
+	// ancestor
+	var (
+		added    []*types.Block
+		removed  []*types.Block
+		newBlock = head
+		oldBlock = current
+	)
+	for newBlock != nil && oldBlock != nil && newBlock.Hash() != oldBlock.Hash() && len(added)+len(removed) < reloadEventLimit {
+		oldNumber, newNumber := oldBlock.NumberU64(), newBlock.NumberU64()
+		if oldNumber >= newNumber {
+			removed = append(removed, oldBlock)
+			oldBlock = bc.GetBlock(oldBlock.ParentHash(), oldNumber-1)
+		}
+		if newNumber >= oldNumber {
+			added = append(added, newBlock)
+			newBlock = bc.GetBlock(newBlock.ParentHash(), newNumber-1)
+		}
+	}
+	// Update all the in-memory chain markers
+	bc.currentBlock.Store(head)
+	headBlockGauge.Update(int64(head.NumberU64()))
+
+	header := head.Header()
+	if hash := rawdb.ReadHeadHeaderHash(bc.db); hash != (common.Hash{}) {
+		if h := bc.GetHeaderByHash(hash); h != nil {
+			header = h
+		}
+	}
+	bc.hc.SetCurrentHeader(header)
+
+	fast := head
+	if hash := rawdb.ReadHeadFastBlockHash(bc.db); hash != (common.Hash{}) {
+		if block := bc.GetBlockByHash(hash); block != nil {
+			fast = block
+		}
+	}
+	bc.currentFastBlock.Store(fast)
+	headFastBlockGauge.Update(int64(fast.NumberU64()))
+	bc.chainmu.Unlock()
+
+	// Announce the logs of the reorged out blocks as removed, the same way as
+	// reorg does
+	var deleted []*types.Log
+	for i := len(removed) - 1; i >= 0; i-- {
+		block := removed[i]
+		for _, receipt := range rawdb.ReadReceipts(bc.db, block.Hash(), block.NumberU64(), bc.chainConfig) {
+			for _, log := range receipt.Logs {
+				l := *log
+				l.Removed = true
+				deleted = append(deleted, &l)
+			}
+		}
+	}
+	if len(deleted) > 0 {
+		bc.rmLogsFeed.Send(RemovedLogsEvent{deleted})
+	}
+	// Announce the new canonical blocks in chain order, then the side blocks and
+	// the new head
+	for i := len(added) - 1; i >= 0; i-- {
+		block := added[i]
+
+		var logs []*types.Log
+		for _, receipt := range rawdb.ReadReceipts(bc.db, block.Hash(), block.NumberU64(), bc.chainConfig) {
+			logs = append(logs, receipt.Logs...)
+		}
+		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
+		if len(logs) > 0 {
+			bc.logsFeed.Send(logs)
+		}
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		bc.chainSideFeed.Send(ChainSideEvent{Block: removed[i]})
+	}
+	bc.chainHeadFeed.Send(ChainHeadEvent{Block: head})
+
+	log.Debug("Reloaded chain head", "number", head.Number(), "hash", head.Hash(), "blocks", len(added), "dropped", len(removed))
+	return nil
+}
+
 // GasLimit returns the gas limit of the current HEAD block.
 func (bc *BlockChain) GasLimit() uint64 {
 	return bc.CurrentBlock().GasLimit()
@@ -548,6 +669,27 @@ func (bc *BlockChain) Snapshot() *snapshot.Tree {
 	return bc.snaps
 }
 
+// CurrentSafeBlock retrieves the most recent canonical block that the consensus
+// engine considers safe relative to the current head, or nil if there is none.
+func (bc *BlockChain) CurrentSafeBlock() *types.Block {
+	header := bc.hc.SafeHeader(bc.CurrentBlock().Header())
+	if header == nil {
+		return nil
+	}
+	return bc.GetBlock(header.Hash(), header.Number.Uint64())
+}
+
+// CurrentFinalizedBlock retrieves the most recent canonical block that the
+// consensus engine considers finalized relative to the current head, or nil if
+// there is none.
+func (bc *BlockChain) CurrentFinalizedBlock() *types.Block {
+	header := bc.hc.FinalizedHeader(bc.CurrentBlock().Header())
+	if header == nil {
+		return nil
+	}
+	return bc.GetBlock(header.Hash(), header.Number.Uint64())
+}
+
 // CurrentFastBlock retrieves the current fast-sync head block of the canonical
 // chain. The block is retrieved from the blockchain's internal cache.
 func (bc *BlockChain) CurrentFastBlock() *types.Block {
@@ -1357,6 +1499,44 @@ func (bc *BlockChain) TxLookupLimit() uint64 {
 	return bc.txLookupLimit
 }
 
+// HistoryTail returns the number of the oldest block whose body and receipts are
+// retained. Only the headers of the blocks below it are available.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return atomic.LoadUint64(&bc.historyTail)
+}
+
+// CheckHistory returns ErrHistoryPruned if the body and receipts of the block
+// with the given number have been deleted. The genesis block is always retained.
+func (bc *BlockChain) CheckHistory(number uint64) error {
+	if number > 0 && number < bc.HistoryTail() {
+		return ErrHistoryPruned
+	}
+	return nil
+}
+
+// PruneHistory deletes the bodies and receipts of the blocks below the given
+// number, retaining their headers. Only ancient blocks can be pruned, so the
+// number is capped at the size of the ancient store.
+func (bc *BlockChain) PruneHistory(number uint64) error {
+	ancients, err := bc.db.Ancients()
+	if err != nil {
+		return err
+	}
+	if number > ancients {
+		number = ancients
+	}
+	// Mark the history unavailable before deleting it, so that it's never served
+	// partially. If a previous deletion was interrupted, resume it.
+	if tail := bc.HistoryTail(); number > tail {
+		rawdb.WriteHistoryTail(bc.db, number)
+		atomic.StoreUint64(&bc.historyTail, number)
+		log.Info("Expiring chain history", "tail", number)
+	} else {
+		number = tail
+	}
+	return bc.db.TruncateAncientTail(number)
+}
+
 var lastWrite uint64
 
 // writeBlockWithoutState writes only the block and its metadata to the database,
@@ -2209,7 +2389,13 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 		if bc.txLookupLimit != 0 && ancients > bc.txLookupLimit {
 			from = ancients - bc.txLookupLimit
 		}
-		rawdb.IndexTransactions(bc.db, from, ancients)
+		// Transactions of expired blocks can't be indexed anymore
+		if tail := bc.HistoryTail(); from < tail {
+			from = tail
+		}
+		if from < ancients {
+			rawdb.IndexTransactions(bc.db, from, ancients)
+		}
 	}
 	// indexBlocks reindexes or unindexes transactions depending on user configuration
 	indexBlocks := func(tail *uint64, head uint64, done chan struct{}) {
@@ -2227,17 +2413,25 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 			}
 			return
 		}
-		// If a previous indexing existed, make sure that we fill in any missing entries
+		// If a previous indexing existed, make sure that we fill in any missing
+		// entries, apart from the ones of expired blocks
+		history := bc.HistoryTail()
 		if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
-			if *tail > 0 {
-				rawdb.IndexTransactions(bc.db, 0, *tail)
+			if *tail > history {
+				rawdb.IndexTransactions(bc.db, history, *tail)
 			}
 			return
 		}
 		// Update the transaction index to the new chain state
 		if head-bc.txLookupLimit+1 < *tail {
 			// Reindex a part of missing indices and rewind index tail to HEAD-limit
-			rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail)
+			from := head - bc.txLookupLimit + 1
+			if from < history {
+				from = history
+			}
+			if from < *tail {
+				rawdb.IndexTransactions(bc.db, from, *tail)
+			}
 		} else {
 			// Unindex a part of stale indices and forward index tail to HEAD-limit
 			rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1)
@@ -2269,6 +2463,41 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	}
 }
 
+// maintainHistory is responsible for deleting the bodies and receipts below the
+// configured history cutoff as the blocks are moved into the ancient store.
+func (bc *BlockChain) maintainHistory() {
+	headCh := make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	var (
+		frozen uint64
+		first  = true
+	)
+	prune := func() {
+		ancients, _ := bc.db.Ancients()
+		if !first && ancients == frozen {
+			return
+		}
+		first, frozen = false, ancients
+		if err := bc.PruneHistory(bc.cacheConfig.HistoryCutoff); err != nil {
+			log.Error("Failed to expire chain history", "err", err)
+		}
+	}
+	prune()
+	for {
+		select {
+		case <-headCh:
+			prune()
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
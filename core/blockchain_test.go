@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"math/rand"
+	"net/http/httptest"
 	"os"
 	"sync"
 	"testing"
@@ -35,7 +36,9 @@ import (
 	"github.com/MFAChain/mfachain/core/vm"
 	"github.com/MFAChain/mfachain/crypto"
 	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/remotedb"
 	"github.com/MFAChain/mfachain/params"
+	"github.com/MFAChain/mfachain/rpc"
 )
 
 // So we can deterministically seed different blockchains
@@ -183,6 +186,40 @@ func testHeaderChainImport(chain []*types.Header, blockchain *BlockChain) error
 	return nil
 }
 
+// Tests that the safe and finalized blocks trail the current head by the
+// confirmation depths of the proof-of-work engine.
+func TestSafeAndFinalizedBlocks(t *testing.T) {
+	_, blockchain, err := newCanonical(mfa.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	// On a short chain both markers are pinned to the genesis
+	blocks := makeBlockChain(blockchain.CurrentBlock(), 5, mfa.NewFaker(), blockchain.db, 0)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if block := blockchain.CurrentSafeBlock(); block.NumberU64() != 0 {
+		t.Errorf("safe block mismatch: have %d, want %d", block.NumberU64(), 0)
+	}
+	if block := blockchain.CurrentFinalizedBlock(); block.NumberU64() != 0 {
+		t.Errorf("finalized block mismatch: have %d, want %d", block.NumberU64(), 0)
+	}
+	// Once the chain is long enough, the markers follow the head at their depth,
+	// 12 blocks for the safe one and 64 for the finalized one
+	blocks = makeBlockChain(blockchain.CurrentBlock(), 95, mfa.NewFaker(), blockchain.db, 0)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if block := blockchain.CurrentSafeBlock(); block.NumberU64() != 88 {
+		t.Errorf("safe block mismatch: have %d, want %d", block.NumberU64(), 88)
+	}
+	if block := blockchain.CurrentFinalizedBlock(); block.NumberU64() != 36 {
+		t.Errorf("finalized block mismatch: have %d, want %d", block.NumberU64(), 36)
+	}
+}
+
 func TestLastBlock(t *testing.T) {
 	_, blockchain, err := newCanonical(mfa.NewFaker(), 0, true)
 	if err != nil {
@@ -2194,6 +2231,90 @@ func TestTransactionIndices(t *testing.T) {
 	}
 }
 
+// Tests that the history of ancient blocks can be expired, retaining the headers
+// and reporting the missing bodies and receipts as pruned.
+func TestHistoryPruning(t *testing.T) {
+	// Configure and generate a sample block chain
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(1000000000)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{address: {Balance: funds}}}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, mfa.NewFaker(), gendb, 128, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), frdir, "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+	gspec.MustCommit(db)
+
+	// Import the first half of the chain into the ancient store
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, mfa.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers, 0); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, 64); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	ancients, _ := db.Ancients()
+
+	// Expire history beyond the ancient store, it should be capped
+	if err := chain.PruneHistory(100); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail := chain.HistoryTail(); tail != ancients {
+		t.Fatalf("history tail mismatch: have %d, want %d", tail, ancients)
+	}
+	for _, block := range blocks {
+		number := block.NumberU64()
+		if chain.GetHeaderByNumber(number) == nil {
+			t.Fatalf("block %d: header missing", number)
+		}
+		err := chain.CheckHistory(number)
+		if number < ancients && err != ErrHistoryPruned {
+			t.Fatalf("block %d: expired history not reported: %v", number, err)
+		}
+		if number >= ancients && err != nil {
+			t.Fatalf("block %d: retained history reported pruned: %v", number, err)
+		}
+	}
+	if err := chain.CheckHistory(0); err != nil {
+		t.Fatalf("genesis history reported pruned: %v", err)
+	}
+	// History can't be restored, and the tail must be persisted
+	if err := chain.PruneHistory(10); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail := rawdb.ReadHistoryTail(db); tail != ancients {
+		t.Fatalf("stored history tail mismatch: have %d, want %d", tail, ancients)
+	}
+}
+
 func TestSkipStaleTxIndicesInFastSync(t *testing.T) {
 	// Configure and generate a sample block chain
 	var (
@@ -3028,3 +3149,123 @@ func TestInitThenFailCreateContract(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a chain running on the database of another one follows its head
+// when reloaded, announcing the newly canonical blocks, including across reorgs.
+func TestReloadHead(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		engine = mfa.NewFaker()
+		db     = rawdb.NewMemoryDatabase()
+		diskdb = rawdb.NewMemoryDatabase()
+		// this code generates a log
+		code   = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(10000000000000)}}}
+		signer = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	genesis := gspec.MustCommit(db)
+	gspec.MustCommit(diskdb)
+
+	// Emit a log in every block of the original chain, to be removed on reorg
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 10, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+		tx, err := types.SignTx(types.NewContractCreation(b.TxNonce(addr), new(big.Int), 1000000, new(big.Int), code), signer, key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	fork, _ := GenerateChain(params.TestChainConfig, blocks[6], engine, db, 6, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{2})
+	})
+	// Run the primary in archive mode so the state of all heads is on disk
+	primary, err := NewBlockChain(diskdb, &CacheConfig{TrieDirtyDisabled: true}, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create primary chain: %v", err)
+	}
+	defer primary.Stop()
+
+	if n, err := primary.InsertChain(blocks[:5]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	// Create a replica chain reading the database of the primary
+	server := rpc.NewServer()
+	if err := server.RegisterName(remotedb.Namespace, remotedb.NewAPI(diskdb, "secret")); err != nil {
+		t.Fatalf("failed to register remote database API: %v", err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	remote, err := remotedb.Dial(httpsrv.URL, "secret")
+	if err != nil {
+		t.Fatalf("failed to dial primary: %v", err)
+	}
+	replica, err := NewBlockChain(remote, nil, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create replica chain: %v", err)
+	}
+	defer replica.Stop()
+
+	if head := replica.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("replica head mismatch: have #%d, want #%d", head.NumberU64(), blocks[4].NumberU64())
+	}
+	var (
+		chainCh = make(chan ChainEvent, 32)
+		sideCh  = make(chan ChainSideEvent, 32)
+		rmLogCh = make(chan RemovedLogsEvent, 32)
+		headCh  = make(chan ChainHeadEvent, 32)
+	)
+	defer replica.SubscribeChainEvent(chainCh).Unsubscribe()
+	defer replica.SubscribeChainSideEvent(sideCh).Unsubscribe()
+	defer replica.SubscribeRemovedLogsEvent(rmLogCh).Unsubscribe()
+	defer replica.SubscribeChainHeadEvent(headCh).Unsubscribe()
+
+	check := func(head *types.Block, added []*types.Block, removed []*types.Block) {
+		t.Helper()
+
+		if err := replica.ReloadHead(); err != nil {
+			t.Fatalf("failed to reload head: %v", err)
+		}
+		if have := replica.CurrentBlock(); have.Hash() != head.Hash() {
+			t.Fatalf("replica head mismatch: have #%d [%x], want #%d [%x]", have.NumberU64(), have.Hash(), head.NumberU64(), head.Hash())
+		}
+		for i, block := range added {
+			if ev := <-chainCh; ev.Hash != block.Hash() {
+				t.Fatalf("chain event %d mismatch: have #%d, want #%d", i, ev.Block.NumberU64(), block.NumberU64())
+			}
+		}
+		for i, block := range removed {
+			if ev := <-sideCh; ev.Block.Hash() != block.Hash() {
+				t.Fatalf("side event %d mismatch: have #%d, want #%d", i, ev.Block.NumberU64(), block.NumberU64())
+			}
+		}
+		if len(removed) > 0 {
+			ev := <-rmLogCh
+			if len(ev.Logs) != len(removed) {
+				t.Fatalf("removed log count mismatch: have %d, want %d", len(ev.Logs), len(removed))
+			}
+			for i, log := range ev.Logs {
+				if !log.Removed || log.BlockHash != removed[i].Hash() {
+					t.Fatalf("removed log %d mismatch: have block %x (removed %v), want %x", i, log.BlockHash, log.Removed, removed[i].Hash())
+				}
+			}
+		}
+		if ev := <-headCh; ev.Block.Hash() != head.Hash() {
+			t.Fatalf("head event mismatch: have #%d, want #%d", ev.Block.NumberU64(), head.NumberU64())
+		}
+		if len(chainCh) != 0 || len(sideCh) != 0 || len(rmLogCh) != 0 || len(headCh) != 0 {
+			t.Fatalf("unexpected events: %d chain, %d side, %d removed logs, %d head", len(chainCh), len(sideCh), len(rmLogCh), len(headCh))
+		}
+	}
+	// Extend the primary chain and reorg it, checking that the replica follows
+	if n, err := primary.InsertChain(blocks[5:]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	check(blocks[9], blocks[5:], nil)
+
+	if n, err := primary.InsertChain(fork); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	check(fork[5], fork, blocks[7:])
+}
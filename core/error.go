@@ -27,6 +27,10 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrHistoryPruned is returned when the body or receipts of a block are
+	// requested which have been deleted by history expiry.
+	ErrHistoryPruned = errors.New("history pruned")
 )
 
 // List of evm-call-message pre-checking errors. All state transtion messages will
@@ -63,4 +67,8 @@ var (
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+	// ErrMaxInitCodeSizeExceeded is returned if the initcode of a contract creation
+	// is larger than the limit of the chain.
+	ErrMaxInitCodeSizeExceeded = errors.New("max initcode size exceeded")
 )
@@ -24,6 +24,13 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// NewPrivateTxsEvent is posted when a batch of private transactions enter the
+// transaction pool. These must never be gossiped to untrusted peers.
+type NewPrivateTxsEvent struct {
+	Txs      []*types.Transaction
+	MaxBlock uint64 // Last block the transactions may be included in
+}
+
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
@@ -223,6 +223,12 @@ func gatherForks(config *params.ChainConfig) []uint64 {
 			forks = append(forks, rule.Uint64())
 		}
 	}
+	// Custom precompiles are activated by forks of their own
+	for _, pc := range config.Precompiles {
+		if pc.Block != nil {
+			forks = append(forks, pc.Block.Uint64())
+		}
+	}
 	// Sort the fork block numbers to permit chronologival XOR
 	for i := 0; i < len(forks); i++ {
 		for j := i + 1; j < len(forks); j++ {
@@ -31,6 +31,7 @@ import (
 	"github.com/MFAChain/mfachain/core/rawdb"
 	"github.com/MFAChain/mfachain/core/state"
 	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
 	"github.com/MFAChain/mfachain/crypto"
 	"github.com/MFAChain/mfachain/mfadb"
 	"github.com/MFAChain/mfachain/log"
@@ -203,6 +204,9 @@ func SetupGenesisBlock(db mfadb.Database, genesis *Genesis) (*params.ChainConfig
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
+	if err := vm.ValidatePrecompiles(newcfg); err != nil {
+		return newcfg, common.Hash{}, err
+	}
 	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
@@ -213,6 +217,9 @@ func SetupGenesisBlock(db mfadb.Database, genesis *Genesis) (*params.ChainConfig
 	// config is supplied. These chains would get AllProtocolChanges (and a compat error)
 	// if we just continued here.
 	if genesis == nil && stored != params.MainnetGenesisHash {
+		if err := vm.ValidatePrecompiles(storedcfg); err != nil {
+			return storedcfg, stored, err
+		}
 		return storedcfg, stored, nil
 	}
 
@@ -302,6 +309,9 @@ func (g *Genesis) Commit(db mfadb.Database) (*types.Block, error) {
 	if err := config.CheckConfigForkOrder(); err != nil {
 		return nil, err
 	}
+	if err := vm.ValidatePrecompiles(config); err != nil {
+		return nil, err
+	}
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), g.Difficulty)
 	rawdb.WriteBlock(db, block)
 	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
@@ -562,6 +562,25 @@ func (hc *HeaderChain) Config() *params.ChainConfig { return hc.config }
 // Engine retrieves the header chain's consensus engine.
 func (hc *HeaderChain) Engine() consensus.Engine { return hc.engine }
 
+// SafeHeader retrieves the ancestor of head that the consensus engine considers
+// safe. Engines without a notion of finality report the head itself.
+func (hc *HeaderChain) SafeHeader(head *types.Header) *types.Header {
+	if reader, ok := hc.engine.(consensus.FinalityReader); ok {
+		return reader.SafeHeader(hc, head)
+	}
+	return head
+}
+
+// FinalizedHeader retrieves the ancestor of head that the consensus engine
+// considers finalized. Engines without a notion of finality report the head
+// itself.
+func (hc *HeaderChain) FinalizedHeader(head *types.Header) *types.Header {
+	if reader, ok := hc.engine.(consensus.FinalityReader); ok {
+		return reader.FinalizedHeader(hc, head)
+	}
+	return head
+}
+
 // GetBlock implements consensus.ChainReader, and returns nil for every input as
 // a header chain does not have blocks available for retrieval.
 func (hc *HeaderChain) GetBlock(hash common.Hash, number uint64) *types.Block {
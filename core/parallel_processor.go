@@ -0,0 +1,261 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MFAChain/mfachain/consensus"
+	"github.com/MFAChain/mfachain/consensus/misc"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/metrics"
+	"github.com/MFAChain/mfachain/params"
+)
+
+var (
+	parallelTxMeter       = metrics.NewRegisteredMeter("chain/parallel/txs", nil)
+	parallelConflictMeter = metrics.NewRegisteredMeter("chain/parallel/conflicts", nil)
+	parallelFallbackMeter = metrics.NewRegisteredMeter("chain/parallel/fallbacks", nil)
+)
+
+// ParallelStateProcessor is a Processor which executes the transactions of a
+// block optimistically in parallel, each on its own copy of the pre-block state
+// recording the accessed state. The results are then committed in transaction
+// order: transactions which read state modified by an earlier one are executed
+// again on the real state, the writes of the others are merged, producing the
+// same state as sequential processing.
+//
+// If the outcome (gas used, bloom, receipt root or state root) doesn't match the
+// block header or any execution fails, the block is processed again
+// sequentially, so invalid blocks are always reported with the error of the
+// sequential processor.
+//
+// ParallelStateProcessor implements Processor.
+type ParallelStateProcessor struct {
+	config     *params.ChainConfig // Chain configuration options
+	bc         *BlockChain         // Canonical block chain
+	engine     consensus.Engine    // Consensus engine used for block rewards
+	workers    int                 // Number of transactions to execute concurrently
+	sequential *StateProcessor     // Fallback processor for unsupported or mismatching blocks
+
+	// Testing hooks
+	mergeHook func(int, *state.StateDB) // Method to call upon merging the writes of a transaction
+}
+
+// NewParallelStateProcessor initialises a new ParallelStateProcessor.
+func NewParallelStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consensus.Engine, workers int) *ParallelStateProcessor {
+	return &ParallelStateProcessor{
+		config:     config,
+		bc:         bc,
+		engine:     engine,
+		workers:    workers,
+		sequential: NewStateProcessor(config, bc, engine),
+	}
+}
+
+// speculativeResult is the outcome of a transaction executed on a copy of the
+// pre-block state.
+type speculativeResult struct {
+	statedb *state.StateDB
+	access  *state.AccessSet
+	msg     types.Message
+	result  *ExecutionResult
+	err     error
+}
+
+// Process processes the state changes according to the MFA rules, executing the
+// transactions in parallel where possible, and applies any rewards to both the
+// processor (coinbase) and any included uncles.
+//
+// Process returns the receipts and logs accumulated during the process and
+// returns the amount of gas that was used in the process. If any of the
+// transactions failed to execute due to insufficient gas it will return an error.
+func (p *ParallelStateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	// Blocks before byzantium need intermediate roots in the receipts, and
+	// tracing requires the transactions to be executed in order
+	if p.workers < 2 || len(block.Transactions()) < 2 || cfg.Debug || !p.config.IsByzantium(block.Number()) || !p.config.IsEIP158(block.Number()) {
+		return p.sequential.Process(block, statedb, cfg)
+	}
+	receipts, logs, usedGas, err := p.process(block, statedb, cfg)
+	if err == nil {
+		err = checkReceipts(block, receipts, usedGas)
+	}
+	if err == nil {
+		if root := statedb.IntermediateRoot(true); root != block.Root() {
+			err = fmt.Errorf("invalid merkle root (remote: %x local: %x)", block.Root(), root)
+		}
+	}
+	if err == nil {
+		return receipts, logs, usedGas, nil
+	}
+	// Something went wrong, process the block again from the parent state
+	parallelFallbackMeter.Mark(1)
+	log.Debug("Parallel block processing failed", "number", block.Number(), "hash", block.Hash(), "err", err)
+
+	parent := p.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, nil, 0, consensus.ErrUnknownAncestor
+	}
+	if err := statedb.Reset(parent.Root); err != nil {
+		return nil, nil, 0, err
+	}
+	return p.sequential.Process(block, statedb, cfg)
+}
+
+// process executes the transactions of a block speculatively in parallel and
+// commits their results in order.
+func (p *ParallelStateProcessor) process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	var (
+		receipts types.Receipts
+		usedGas  = new(uint64)
+		header   = block.Header()
+		allLogs  []*types.Log
+		gp       = new(GasPool).AddGas(block.GasLimit())
+		txs      = block.Transactions()
+	)
+	// Mutate the block and state according to any hard-fork specs
+	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	results := p.execute(block, statedb, cfg)
+
+	// Commit the results in order, executing again the conflicting transactions
+	var (
+		written   = state.NewWriteSet()
+		conflicts int
+	)
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+
+		res := results[i]
+		if res.err != nil || res.access.Conflicts(written) {
+			conflicts++
+
+			access := state.NewAccessSet()
+			statedb.SetAccessSet(access)
+			receipt, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+			statedb.SetAccessSet(nil)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			written.Add(access)
+
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+			continue
+		}
+		// Transaction independent of the previous ones, merge its writes
+		if err := gp.SubGas(res.msg.Gas()); err != nil {
+			return nil, nil, 0, err
+		}
+		gp.AddGas(res.msg.Gas() - res.result.UsedGas)
+
+		statedb.ApplyWrites(res.access)
+		if p.mergeHook != nil {
+			p.mergeHook(i, statedb)
+		}
+		for _, l := range res.statedb.GetLogs(tx.Hash()) {
+			statedb.AddLog(l)
+		}
+		if cfg.EnablePreimageRecording {
+			for hash, preimage := range res.statedb.Preimages() {
+				statedb.AddPreimage(hash, preimage)
+			}
+		}
+		statedb.Finalise(true)
+		written.Add(res.access)
+
+		*usedGas += res.result.UsedGas
+		receipt := newReceipt(nil, tx, res.msg, res.result, *usedGas, statedb, header)
+
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	parallelTxMeter.Mark(int64(len(txs)))
+	parallelConflictMeter.Mark(int64(conflicts))
+
+	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
+	p.engine.Finalize(p.bc, header, statedb, txs, block.Uncles())
+
+	return receipts, allLogs, *usedGas, nil
+}
+
+// execute runs all the transactions of a block concurrently, each on its own
+// copy of the given state, recording the state accessed by them.
+func (p *ParallelStateProcessor) execute(block *types.Block, statedb *state.StateDB, cfg vm.Config) []*speculativeResult {
+	var (
+		txs     = block.Transactions()
+		header  = block.Header()
+		signer  = types.MakeSigner(p.config, header.Number)
+		results = make([]*speculativeResult, len(txs))
+		tasks   = make(chan int, len(txs))
+		pend    sync.WaitGroup
+	)
+	for i := range txs {
+		results[i] = &speculativeResult{statedb: statedb.Copy(), access: state.NewAccessSet()}
+		tasks <- i
+	}
+	close(tasks)
+
+	workers := p.workers
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	for w := 0; w < workers; w++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+			for i := range tasks {
+				res := results[i]
+				res.statedb.SetAccessSet(res.access)
+				res.statedb.Prepare(txs[i].Hash(), block.Hash(), i)
+
+				if res.msg, res.err = txs[i].AsMessage(signer); res.err != nil {
+					continue
+				}
+				context := NewEVMContext(res.msg, header, p.bc, nil)
+				vmenv := vm.NewEVM(context, res.statedb, p.config, cfg)
+
+				if res.result, res.err = ApplyMessage(vmenv, res.msg, new(GasPool).AddGas(block.GasLimit())); res.err == nil {
+					res.statedb.Finalise(true)
+				}
+			}
+		}()
+	}
+	pend.Wait()
+	return results
+}
+
+// checkReceipts verifies the outcome of a block processing against the gas used,
+// bloom and receipt root of the block header.
+func checkReceipts(block *types.Block, receipts types.Receipts, usedGas uint64) error {
+	if block.GasUsed() != usedGas {
+		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
+	}
+	if types.CreateBloom(receipts) != block.Bloom() {
+		return errors.New("invalid bloom")
+	}
+	if types.DeriveSha(receipts) != block.ReceiptHash() {
+		return errors.New("invalid receipt root hash")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/consensus/mfa"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// Tests that blocks processed in parallel produce the same receipts and state
+// as the sequential processing, with transactions both independent and
+// conflicting with each other.
+func TestParallelProcessing(t *testing.T) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 24)
+		alloc    = make(GenesisAlloc)
+		counter  = common.Address{0xc0} // Emits a log and increments the slot given in the calldata
+		destruct = common.Address{0xd0} // Self destructs, sending its balance to the caller
+		shared   = common.Address{0xaa}
+		coinbase = common.Address{0xcb}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	alloc[counter] = GenesisAccount{
+		Balance: new(big.Int),
+		Code:    common.FromHex("0x60006000a060003580546001019055"),
+		Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(1))},
+	}
+	alloc[destruct] = GenesisAccount{Balance: big.NewInt(1000), Code: common.FromHex("0x33ff")}
+
+	var (
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: alloc, GasLimit: 10000000}
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, mfa.NewFaker(), gendb, 8, func(n int, block *BlockGen) {
+		block.SetCoinbase(coinbase)
+		for i := range keys {
+			var (
+				key    = keys[i]
+				from   = crypto.PubkeyToAddress(key.PublicKey)
+				to     = &shared
+				amount = big.NewInt(1000)
+				data   []byte
+			)
+			switch i % 6 {
+			case 0: // Contended storage slots
+				to, amount, data = &counter, new(big.Int), common.LeftPadBytes([]byte{byte(i % 3)}, 32)
+			case 1: // Transfers to the same account
+			case 2: // Transfers to new accounts and the coinbase
+				if i == 2 {
+					to = &coinbase
+				} else {
+					to = &common.Address{0xee, byte(n), byte(i)}
+				}
+			case 3: // Contract creations with storage
+				to, data = nil, common.FromHex("0x600160005500")
+			case 4: // Destruction and resurrection
+				to = &destruct
+			case 5: // Independent storage slots
+				to, amount, data = &counter, new(big.Int), common.LeftPadBytes([]byte{byte(100 + i)}, 32)
+			}
+			var tx *types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(block.TxNonce(from), amount, 100000, big.NewInt(1), data)
+			} else {
+				tx = types.NewTransaction(block.TxNonce(from), *to, amount, 100000, big.NewInt(1), data)
+			}
+			tx, err := types.SignTx(tx, signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	// Import the chain with parallel processing enabled
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	cacheConfig := &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, ParallelTxWorkers: 4}
+	chain, err := NewBlockChain(db, cacheConfig, gspec.Config, mfa.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	processor, ok := chain.Processor().(*ParallelStateProcessor)
+	if !ok {
+		t.Fatalf("parallel processor not enabled")
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	// Process the blocks in parallel again, without the sequential fallback
+	parent := genesis
+	for _, block := range blocks {
+		statedb, err := state.New(parent.Root(), chain.StateCache(), nil)
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		receipts, _, usedGas, err := processor.process(block, statedb, vm.Config{})
+		if err != nil {
+			t.Fatalf("block %d: failed to process: %v", block.NumberU64(), err)
+		}
+		if err := checkReceipts(block, receipts, usedGas); err != nil {
+			t.Fatalf("block %d: %v", block.NumberU64(), err)
+		}
+		if root := statedb.IntermediateRoot(true); root != block.Root() {
+			t.Fatalf("block %d: state root mismatch: have %x, want %x", block.NumberU64(), root, block.Root())
+		}
+		parent = block
+	}
+	// Blocks not matching the parallel outcome must be processed again sequentially
+	header := types.CopyHeader(blocks[0].Header())
+	header.GasUsed++
+	tampered := blocks[0].WithSeal(header)
+
+	statedb, _ := state.New(genesis.Root(), chain.StateCache(), nil)
+	if _, _, usedGas, err := processor.Process(tampered, statedb, vm.Config{}); err != nil || usedGas != blocks[0].GasUsed() {
+		t.Fatalf("fallback processing mismatch: gas %d, err %v", usedGas, err)
+	}
+	if root := statedb.IntermediateRoot(true); root != blocks[0].Root() {
+		t.Fatalf("fallback state root mismatch: have %x, want %x", root, blocks[0].Root())
+	}
+	// Blocks whose parallel outcome only diverges in the state must also be
+	// processed again sequentially
+	var merges int
+	processor.mergeHook = func(i int, statedb *state.StateDB) {
+		if merges++; merges == 1 {
+			statedb.AddBalance(common.Address{0xff}, big.NewInt(1))
+		}
+	}
+	defer func() { processor.mergeHook = nil }()
+
+	statedb, _ = state.New(genesis.Root(), chain.StateCache(), nil)
+	if _, _, usedGas, err := processor.Process(blocks[0], statedb, vm.Config{}); err != nil || usedGas != blocks[0].GasUsed() {
+		t.Fatalf("corrupted merge processing mismatch: gas %d, err %v", usedGas, err)
+	}
+	if merges == 0 {
+		t.Fatalf("no transaction writes merged")
+	}
+	if root := statedb.IntermediateRoot(true); root != blocks[0].Root() {
+		t.Fatalf("corrupted merge state root mismatch: have %x, want %x", root, blocks[0].Root())
+	}
+}
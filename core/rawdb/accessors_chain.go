@@ -192,6 +192,24 @@ func WriteTxIndexTail(db mfadb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadHistoryTail retrieves the number of the oldest block whose body and
+// receipts are retained. Zero means that no history has been pruned.
+func ReadHistoryTail(db mfadb.KeyValueReader) uint64 {
+	data, _ := db.Get(historyTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteHistoryTail stores the number of the oldest block whose body and receipts
+// are retained into database.
+func WriteHistoryTail(db mfadb.KeyValueWriter, number uint64) {
+	if err := db.Put(historyTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the history tail", "err", err)
+	}
+}
+
 // ReadFastTxLookupLimit retrieves the tx lookup limit used in fast sync.
 func ReadFastTxLookupLimit(db mfadb.KeyValueReader) *uint64 {
 	data, _ := db.Get(fastTxLookupLimitKey)
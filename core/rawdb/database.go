@@ -25,7 +25,6 @@ import (
 
 	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/mfadb"
-	"github.com/MFAChain/mfachain/mfadb/leveldb"
 	"github.com/MFAChain/mfachain/mfadb/memorydb"
 	"github.com/MFAChain/mfachain/log"
 	"github.com/olekukonko/tablewriter"
@@ -88,6 +87,11 @@ func (db *nofreezedb) TruncateAncients(items uint64) error {
 	return errNotSupported
 }
 
+// TruncateAncientTail returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) TruncateAncientTail(items uint64) error {
+	return errNotSupported
+}
+
 // Sync returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) Sync() error {
 	return errNotSupported
@@ -196,26 +200,13 @@ func NewMemoryDatabaseWithCap(size int) mfadb.Database {
 // NewLevelDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
 func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (mfadb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace)
-	if err != nil {
-		return nil, err
-	}
-	return NewDatabase(db), nil
+	return OpenDatabase(EngineLevelDB, file, cache, handles, namespace)
 }
 
 // NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
 // freezer moving immutable chain segments into cold storage.
 func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (mfadb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace)
-	if err != nil {
-		return nil, err
-	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
-	if err != nil {
-		kvdb.Close()
-		return nil, err
-	}
-	return frdb, nil
+	return OpenDatabaseWithFreezer(EngineLevelDB, file, cache, handles, freezer, namespace)
 }
 
 // InspectDatabase traverses the entire database and checks the size
@@ -0,0 +1,229 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/mfadb"
+)
+
+// StatsBuckets is the number of size buckets in the histogram of KeyStats. The
+// bucket i counts the entries smaller than 64 << (2*i) bytes, the last one all
+// the larger entries.
+const StatsBuckets = 7
+
+// StatsBucketLimit returns the exclusive upper size limit of a histogram bucket,
+// or zero for the last, unbounded one.
+func StatsBucketLimit(bucket int) common.StorageSize {
+	if bucket >= StatsBuckets-1 {
+		return 0
+	}
+	return common.StorageSize(uint64(64) << (2 * uint(bucket)))
+}
+
+// KeyStats contains the number of entries of a category of database data along
+// with the distribution of their sizes.
+type KeyStats struct {
+	Category  string
+	Count     uint64
+	Size      common.StorageSize
+	Histogram [StatsBuckets]uint64 // Number of entries by size, see StatsBucketLimit
+}
+
+// add accounts an entry of the given size.
+func (s *KeyStats) add(size int) {
+	s.Count++
+	s.Size += common.StorageSize(size)
+
+	bucket := 0
+	for bucket < StatsBuckets-1 && common.StorageSize(size) >= StatsBucketLimit(bucket) {
+		bucket++
+	}
+	s.Histogram[bucket]++
+}
+
+// keyCategory is a category of key-value store entries, identified by the key.
+type keyCategory struct {
+	name  string
+	match func(key []byte) bool
+}
+
+// keyCategories lists the categories of the key-value store, based on the schema
+// prefixes. The first matching category is applied to each key.
+var keyCategories = []keyCategory{
+	{"Difficulties", func(key []byte) bool {
+		return bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix) && len(key) == len(headerPrefix)+8+common.HashLength+len(headerTDSuffix)
+	}},
+	{"Block number->hash", func(key []byte) bool {
+		return bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix) && len(key) == len(headerPrefix)+8+len(headerHashSuffix)
+	}},
+	{"Headers", func(key []byte) bool {
+		return bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength
+	}},
+	{"Block hash->number", func(key []byte) bool {
+		return bytes.HasPrefix(key, headerNumberPrefix) && len(key) == len(headerNumberPrefix)+common.HashLength
+	}},
+	{"Bodies", func(key []byte) bool {
+		return bytes.HasPrefix(key, blockBodyPrefix) && len(key) == len(blockBodyPrefix)+8+common.HashLength
+	}},
+	{"Receipts", func(key []byte) bool {
+		return bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == len(blockReceiptsPrefix)+8+common.HashLength
+	}},
+	{"Transaction index", func(key []byte) bool {
+		return bytes.HasPrefix(key, txLookupPrefix) && len(key) == len(txLookupPrefix)+common.HashLength
+	}},
+	{"Account snapshot", func(key []byte) bool {
+		return bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == len(SnapshotAccountPrefix)+common.HashLength
+	}},
+	{"Storage snapshot", func(key []byte) bool {
+		return bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == len(SnapshotStoragePrefix)+2*common.HashLength
+	}},
+	{"Trie preimages", func(key []byte) bool {
+		return bytes.HasPrefix(key, preimagePrefix) && len(key) == len(preimagePrefix)+common.HashLength
+	}},
+	{"Chain configs", func(key []byte) bool {
+		return bytes.HasPrefix(key, configPrefix) && len(key) == len(configPrefix)+common.HashLength
+	}},
+	{"Bloombit index", func(key []byte) bool {
+		return bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == len(bloomBitsPrefix)+10+common.HashLength
+	}},
+	{"Chain indexer progress", func(key []byte) bool {
+		return bytes.HasPrefix(key, BloomBitsIndexPrefix)
+	}},
+	{"Clique snapshots", func(key []byte) bool {
+		return bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength
+	}},
+	{"CHT trie nodes", func(key []byte) bool {
+		return bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength
+	}},
+	{"Bloom trie nodes", func(key []byte) bool {
+		return bytes.HasPrefix(key, []byte("blt-")) && len(key) == 4+common.HashLength
+	}},
+	{"Trie nodes", func(key []byte) bool {
+		return len(key) == common.HashLength
+	}},
+	{"Singleton metadata", func(key []byte) bool {
+		for _, meta := range metadataKeys {
+			if bytes.Equal(key, meta) {
+				return true
+			}
+		}
+		return false
+	}},
+}
+
+// metadataKeys lists the keys of the singleton metadata entries.
+var metadataKeys = [][]byte{
+	databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey,
+	snapshotRootKey, snapshotJournalKey, txIndexTailKey, fastTxLookupLimitKey, historyTailKey,
+}
+
+// unaccountedCategory is the category of the entries not matching any other.
+const unaccountedCategory = "Unaccounted"
+
+// CollectDatabaseStats iterates over the entries of the key-value store whose key
+// starts with the given prefix, and collects statistics about them by category.
+// Only categories with entries are returned, in schema order.
+func CollectDatabaseStats(db mfadb.Iteratee, prefix []byte) []*KeyStats {
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var (
+		stats  = make([]*KeyStats, len(keyCategories)+1)
+		count  int64
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for i, category := range keyCategories {
+		stats[i] = &KeyStats{Category: category.name}
+	}
+	stats[len(keyCategories)] = &KeyStats{Category: unaccountedCategory}
+
+	for it.Next() {
+		var (
+			key   = it.Key()
+			index = len(keyCategories)
+		)
+		for i, category := range keyCategories {
+			if category.match(key) {
+				index = i
+				break
+			}
+		}
+		stats[index].add(len(key) + len(it.Value()))
+
+		count++
+		if count%1000 == 0 && time.Since(logged) > 8*time.Second {
+			log.Info("Collecting database statistics", "count", count, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	var result []*KeyStats
+	for _, s := range stats {
+		if s.Count > 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// CollectAncientStats iterates over the retained items of an ancient table and
+// collects statistics about them.
+func CollectAncientStats(db mfadb.Reader, table string) (*KeyStats, error) {
+	if _, ok := freezerNoSnappy[table]; !ok {
+		return nil, fmt.Errorf("unknown freezer table %q", table)
+	}
+	frozen, err := db.Ancients()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		stats  = &KeyStats{Category: table}
+		first  = AncientTail(db, table)
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for number := first; number < frozen; number++ {
+		blob, err := db.Ancient(table, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve ancient %s %d: %v", table, number, err)
+		}
+		stats.add(len(blob))
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Collecting ancient statistics", "table", table, "number", number, "limit", frozen, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return stats, nil
+}
+
+// AncientTail returns the number of the first item retained in an ancient table.
+// Only the tables storing chain history are pruned.
+func AncientTail(db mfadb.KeyValueReader, table string) uint64 {
+	for _, name := range freezerPrunableTables {
+		if name == table {
+			return ReadHistoryTail(db)
+		}
+	}
+	return 0
+}
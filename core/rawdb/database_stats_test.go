@@ -0,0 +1,94 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+// Tests that database entries are categorized by their keys and their sizes are
+// accounted into the right histogram buckets.
+func TestCollectDatabaseStats(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	WriteCanonicalHash(db, common.Hash{0x01}, 1)
+	WriteCanonicalHash(db, common.Hash{0x02}, 2)
+	WriteHeadBlockHash(db, common.Hash{0x02})
+	db.Put(common.Hash{0x03}.Bytes(), make([]byte, 100))
+	db.Put([]byte("unknown"), nil)
+
+	want := map[string]struct {
+		count  uint64
+		bucket int
+	}{
+		"Block number->hash": {2, 0},
+		"Singleton metadata": {1, 0},
+		"Trie nodes":         {1, 1},
+		unaccountedCategory:  {1, 0},
+	}
+	stats := CollectDatabaseStats(db, nil)
+	if len(stats) != len(want) {
+		t.Fatalf("category count mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for _, s := range stats {
+		exp, ok := want[s.Category]
+		if !ok {
+			t.Fatalf("unexpected category %q", s.Category)
+		}
+		if s.Count != exp.count || s.Histogram[exp.bucket] != exp.count {
+			t.Errorf("category %q mismatch: have %d entries, histogram %v, want %d in bucket %d", s.Category, s.Count, s.Histogram, exp.count, exp.bucket)
+		}
+	}
+	// Only the entries with the prefix should be collected
+	if stats := CollectDatabaseStats(db, headerPrefix); len(stats) != 1 || stats[0].Count != 2 {
+		t.Fatalf("prefixed statistics mismatch: have %v", stats)
+	}
+}
+
+// Tests that the statistics of ancient tables skip the expired history.
+func TestCollectAncientStats(t *testing.T) {
+	db, dir := newVerifyTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	writeVerifyTestChain(db, 10, -1)
+	WriteHistoryTail(db, 4)
+
+	for _, tt := range []struct {
+		table string
+		count uint64
+	}{
+		{freezerHeaderTable, 10},
+		{freezerHashTable, 10},
+		{freezerBodiesTable, 6},
+		{freezerReceiptTable, 6},
+	} {
+		stats, err := CollectAncientStats(db, tt.table)
+		if err != nil {
+			t.Fatalf("table %s: failed to collect statistics: %v", tt.table, err)
+		}
+		if stats.Count != tt.count {
+			t.Errorf("table %s: item count mismatch: have %d, want %d", tt.table, stats.Count, tt.count)
+		}
+	}
+	if _, err := CollectAncientStats(db, "unknown"); err == nil {
+		t.Fatalf("collected statistics of unknown table")
+	}
+}
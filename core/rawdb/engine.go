@@ -0,0 +1,138 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/leveldb"
+	"github.com/MFAChain/mfachain/mfadb/pebble"
+)
+
+const (
+	// EngineLevelDB is the name of the goleveldb backed key-value store.
+	EngineLevelDB = "leveldb"
+
+	// EnginePebble is the name of the pebble backed key-value store.
+	EnginePebble = "pebble"
+
+	// engineFile is the name of the file within the database directory recording
+	// the engine the database was created with.
+	engineFile = "ENGINE"
+)
+
+// ReadDatabaseEngine returns the engine of the persistent key-value store at
+// the given path, or an empty string if no database exists there yet. Databases
+// created before the engine was recorded are detected from their files.
+func ReadDatabaseEngine(file string) (string, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(file, engineFile))
+	switch {
+	case err == nil:
+		return strings.TrimSpace(string(blob)), nil
+	case !os.IsNotExist(err):
+		return "", err
+	}
+	if matches, _ := filepath.Glob(filepath.Join(file, "OPTIONS-*")); len(matches) > 0 {
+		return EnginePebble, nil
+	}
+	if _, err := os.Stat(filepath.Join(file, "CURRENT")); err == nil {
+		return EngineLevelDB, nil
+	}
+	return "", nil
+}
+
+// resolveDatabaseEngine checks the requested engine against the one of the
+// database at the given path, returning the engine to open it with. An empty
+// engine requests whatever the database was created with, defaulting to leveldb
+// for new ones. The engine of new databases is recorded for subsequent opens.
+func resolveDatabaseEngine(engine string, file string) (string, error) {
+	switch engine {
+	case "", EngineLevelDB, EnginePebble:
+	default:
+		return "", fmt.Errorf("unknown database engine %q", engine)
+	}
+	existing, err := ReadDatabaseEngine(file)
+	if err != nil {
+		return "", err
+	}
+	if existing != "" {
+		if engine != "" && engine != existing {
+			return "", fmt.Errorf("database %s was created with engine %q, cannot open with %q", file, existing, engine)
+		}
+		if _, err := os.Stat(filepath.Join(file, engineFile)); err == nil {
+			return existing, nil
+		}
+		engine = existing
+	}
+	if engine == "" {
+		engine = EngineLevelDB
+	}
+	if err := os.MkdirAll(file, 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(file, engineFile), []byte(engine+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return engine, nil
+}
+
+// openKeyValueStore opens the persistent key-value store at the given path with
+// the requested engine.
+func openKeyValueStore(engine string, file string, cache int, handles int, namespace string) (mfadb.KeyValueStore, error) {
+	engine, err := resolveDatabaseEngine(engine, file)
+	if err != nil {
+		return nil, err
+	}
+	if engine == EnginePebble {
+		return pebble.New(file, cache, handles, namespace)
+	}
+	return leveldb.New(file, cache, handles, namespace)
+}
+
+// OpenDatabase creates a persistent key-value database backed by the given
+// engine, without a freezer moving immutable chain segments into cold storage.
+// If the database already exists, the engine must match the one it was created
+// with, or be empty to use that.
+func OpenDatabase(engine string, file string, cache int, handles int, namespace string) (mfadb.Database, error) {
+	kvdb, err := openKeyValueStore(engine, file, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return NewDatabase(kvdb), nil
+}
+
+// OpenDatabaseWithFreezer creates a persistent key-value database backed by the
+// given engine, with a freezer moving immutable chain segments into cold storage.
+// If the database already exists, the engine must match the one it was created
+// with, or be empty to use that.
+func OpenDatabaseWithFreezer(engine string, file string, cache int, handles int, freezer string, namespace string) (mfadb.Database, error) {
+	kvdb, err := openKeyValueStore(engine, file, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return frdb, nil
+}
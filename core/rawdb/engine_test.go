@@ -0,0 +1,81 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that the engine of a database is recorded on creation, reused when
+// opening it without an explicit one and enforced on mismatching opens.
+func TestDatabaseEngine(t *testing.T) {
+	for _, engine := range []string{EngineLevelDB, EnginePebble} {
+		t.Run(engine, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			file := filepath.Join(dir, "chaindata")
+			db, err := OpenDatabase(engine, file, 0, 0, "")
+			if err != nil {
+				t.Fatalf("failed to create database: %v", err)
+			}
+			if err := db.Put([]byte("key"), []byte("value")); err != nil {
+				t.Fatalf("failed to write database: %v", err)
+			}
+			db.Close()
+
+			if have, err := ReadDatabaseEngine(file); err != nil || have != engine {
+				t.Fatalf("recorded engine mismatch: have %q (%v), want %q", have, err, engine)
+			}
+			// Reopening without an explicit engine should pick the recorded one
+			db, err = OpenDatabase("", file, 0, 0, "")
+			if err != nil {
+				t.Fatalf("failed to reopen database: %v", err)
+			}
+			if value, err := db.Get([]byte("key")); err != nil || string(value) != "value" {
+				t.Fatalf("value mismatch: have %q (%v), want %q", value, err, "value")
+			}
+			db.Close()
+
+			// Reopening with a different engine should be refused
+			other := EnginePebble
+			if engine == EnginePebble {
+				other = EngineLevelDB
+			}
+			if db, err := OpenDatabase(other, file, 0, 0, ""); err == nil {
+				db.Close()
+				t.Fatalf("opened %s database with %s engine", engine, other)
+			}
+			// Databases predating the engine file should be detected from their content
+			if err := os.Remove(filepath.Join(file, engineFile)); err != nil {
+				t.Fatalf("failed to remove engine file: %v", err)
+			}
+			if have, err := ReadDatabaseEngine(file); err != nil || have != engine {
+				t.Fatalf("detected engine mismatch: have %q (%v), want %q", have, err, engine)
+			}
+		})
+	}
+	if _, err := OpenDatabase("rocksdb", filepath.Join(os.TempDir(), "nonexistent"), 0, 0, ""); err == nil {
+		t.Fatalf("opened database with unknown engine")
+	}
+}
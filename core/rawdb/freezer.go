@@ -235,6 +235,21 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	return nil
 }
 
+// TruncateAncientTail discards the block bodies and receipts below the provided
+// threshold number. Data is deleted in whole files, so some items below the
+// threshold may be retained.
+func (f *freezer) TruncateAncientTail(items uint64) error {
+	if frozen := atomic.LoadUint64(&f.frozen); items > frozen {
+		items = frozen
+	}
+	for _, name := range freezerPrunableTables {
+		if err := f.tables[name].truncateTail(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // sync flushes all data tables to disk.
 func (f *freezer) Sync() error {
 	var errs []error
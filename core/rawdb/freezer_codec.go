@@ -0,0 +1,262 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// freezerCodec identifies the compression algorithm of the items in a freezer
+// table. The codec is recorded in the metadata file of the table.
+type freezerCodec uint8
+
+const (
+	codecNone   freezerCodec = iota // Items are stored raw
+	codecSnappy                     // Items are compressed with snappy
+	codecZstd                       // Items are compressed with zstd, optionally with a dictionary
+)
+
+// freezerTableMetaVersion is the current version of the freezer table metadata.
+const freezerTableMetaVersion = 1
+
+// String implements fmt.Stringer, returning the name of the codec.
+func (c freezerCodec) String() string {
+	switch c {
+	case codecNone:
+		return "none"
+	case codecSnappy:
+		return "snappy"
+	case codecZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// suffix returns the letter prefixing the extension of the index and data files
+// of tables with the codec. Each codec has a separate set of files, so that a
+// table can be rewritten with a new codec next to the old files.
+func (c freezerCodec) suffix() string {
+	switch c {
+	case codecNone:
+		return "r"
+	case codecSnappy:
+		return "c"
+	default:
+		return "z"
+	}
+}
+
+// parseFreezerCodec converts a codec name into a freezer codec.
+func parseFreezerCodec(name string) (freezerCodec, error) {
+	for _, codec := range []freezerCodec{codecNone, codecSnappy, codecZstd} {
+		if codec.String() == name {
+			return codec, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown freezer codec %q", name)
+}
+
+// freezerTableMeta is the metadata of a freezer table, stored RLP encoded in
+// a separate file next to the index.
+type freezerTableMeta struct {
+	Version uint16
+	Codec   freezerCodec
+	Dict    []byte // Zstd dictionary the items are compressed with, if any
+}
+
+// metaFilePath returns the path of the metadata file of a freezer table.
+func metaFilePath(path string, name string) string {
+	return filepath.Join(path, fmt.Sprintf("%s.meta", name))
+}
+
+// readTableMeta reads the metadata of a freezer table, returning nil if the
+// table has none yet.
+func readTableMeta(path string, name string) (*freezerTableMeta, error) {
+	blob, err := ioutil.ReadFile(metaFilePath(path, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := new(freezerTableMeta)
+	if err := rlp.DecodeBytes(blob, meta); err != nil {
+		return nil, fmt.Errorf("invalid freezer table metadata: %v", err)
+	}
+	if meta.Version > freezerTableMetaVersion {
+		return nil, fmt.Errorf("unsupported freezer table metadata version %d", meta.Version)
+	}
+	return meta, nil
+}
+
+// writeTableMeta atomically replaces the metadata of a freezer table, switching
+// the table over to the files of the codec recorded within.
+func writeTableMeta(path string, name string, meta *freezerTableMeta) error {
+	blob, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		return err
+	}
+	tmp := metaFilePath(path, name) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, metaFilePath(path, name)); err != nil {
+		return err
+	}
+	// Persist the rename itself, best effort as not all platforms support it
+	if dir, err := os.Open(path); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// loadTableMeta retrieves the metadata of a freezer table. Tables created before
+// metadata was recorded are detected from their files, and new tables get the
+// given default codec. The metadata is persisted in both cases.
+func loadTableMeta(path string, name string, defaultCodec freezerCodec) (*freezerTableMeta, error) {
+	meta, err := readTableMeta(path, name)
+	if err != nil || meta != nil {
+		return meta, err
+	}
+	meta = &freezerTableMeta{Version: freezerTableMetaVersion, Codec: defaultCodec}
+	for _, codec := range []freezerCodec{codecNone, codecSnappy} {
+		if common.FileExist(filepath.Join(path, fmt.Sprintf("%s.%sidx", name, codec.suffix()))) {
+			meta.Codec = codec
+			break
+		}
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeTableMeta(path, name, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// removeTableFiles deletes the index and data files of a freezer table stored
+// with the given codec.
+func removeTableFiles(path string, name string, codec freezerCodec) error {
+	files, err := filepath.Glob(filepath.Join(path, fmt.Sprintf("%s.[0-9][0-9][0-9][0-9].%sdat", name, codec.suffix())))
+	if err != nil {
+		return err
+	}
+	files = append(files, filepath.Join(path, fmt.Sprintf("%s.%sidx", name, codec.suffix())))
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// freezerCompressor compresses and decompresses the items of a freezer table.
+type freezerCompressor struct {
+	codec   freezerCodec
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// newFreezerCompressor creates a compressor for the given codec. A dictionary is
+// only supported by zstd, and must be in the zstd dictionary format.
+func newFreezerCompressor(codec freezerCodec, dict []byte) (*freezerCompressor, error) {
+	c := &freezerCompressor{codec: codec}
+	switch codec {
+	case codecNone, codecSnappy:
+		if len(dict) > 0 {
+			return nil, fmt.Errorf("freezer codec %v does not support dictionaries", codec)
+		}
+	case codecZstd:
+		var (
+			eopts = []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithEncoderCRC(false)}
+			dopts []zstd.DOption
+		)
+		if len(dict) > 0 {
+			eopts = append(eopts, zstd.WithEncoderDict(dict))
+			dopts = append(dopts, zstd.WithDecoderDicts(dict))
+		}
+		encoder, err := zstd.NewWriter(nil, eopts...)
+		if err != nil {
+			return nil, err
+		}
+		decoder, err := zstd.NewReader(nil, dopts...)
+		if err != nil {
+			encoder.Close()
+			return nil, err
+		}
+		c.encoder, c.decoder = encoder, decoder
+	default:
+		return nil, fmt.Errorf("unknown freezer codec %v", codec)
+	}
+	return c, nil
+}
+
+// encode compresses an item before it is written into a data file.
+func (c *freezerCompressor) encode(blob []byte) []byte {
+	switch c.codec {
+	case codecSnappy:
+		return snappy.Encode(nil, blob)
+	case codecZstd:
+		return c.encoder.EncodeAll(blob, nil)
+	default:
+		return blob
+	}
+}
+
+// decode decompresses an item read from a data file.
+func (c *freezerCompressor) decode(blob []byte) ([]byte, error) {
+	switch c.codec {
+	case codecSnappy:
+		return snappy.Decode(nil, blob)
+	case codecZstd:
+		return c.decoder.DecodeAll(blob, nil)
+	default:
+		return blob, nil
+	}
+}
+
+// close releases the resources held by the compressor.
+func (c *freezerCompressor) close() {
+	if c.encoder != nil {
+		c.encoder.Close()
+	}
+	if c.decoder != nil {
+		c.decoder.Close()
+	}
+}
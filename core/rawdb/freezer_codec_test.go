@@ -0,0 +1,153 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/metrics"
+)
+
+// newCodecTestTable creates a receipt table in a fresh directory, filled with
+// the given number of items.
+func newCodecTestTable(t *testing.T, items int) string {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	f, err := newTable(dir, freezerReceiptTable, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, false)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for i := 0; i < items; i++ {
+		if err := f.Append(uint64(i), getChunk(100+i, i)); err != nil {
+			t.Fatalf("failed to append item %d: %v", i, err)
+		}
+	}
+	f.Close()
+	return dir
+}
+
+// checkCodecTestTable checks that a table created by newCodecTestTable uses the
+// expected codec and still contains all the original items.
+func checkCodecTestTable(t *testing.T, dir string, items int, codec freezerCodec) {
+	t.Helper()
+
+	f, err := newTable(dir, freezerReceiptTable, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, false)
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	defer f.Close()
+
+	if f.codec != codec {
+		t.Fatalf("codec mismatch: have %v, want %v", f.codec, codec)
+	}
+	if have := int(f.items); have != items {
+		t.Fatalf("item count mismatch: have %d, want %d", have, items)
+	}
+	for i := 0; i < items; i++ {
+		blob, err := f.Retrieve(uint64(i))
+		if err != nil {
+			t.Fatalf("failed to retrieve item %d: %v", i, err)
+		}
+		if !bytes.Equal(blob, getChunk(100+i, i)) {
+			t.Fatalf("item %d mismatch", i)
+		}
+	}
+	for _, other := range []freezerCodec{codecNone, codecSnappy, codecZstd} {
+		idx := filepath.Join(dir, freezerReceiptTable+"."+other.suffix()+"idx")
+		if exist := common.FileExist(idx); exist != (other == codec) {
+			t.Fatalf("index file for codec %v existence mismatch: have %v, want %v", other, exist, other == codec)
+		}
+	}
+}
+
+// Tests that freezer tables can be recompressed between all codecs.
+func TestFreezerRecompress(t *testing.T) {
+	dict, err := ioutil.ReadFile(filepath.Join("testdata", "zstd.dict"))
+	if err != nil {
+		t.Fatalf("failed to load zstd dictionary: %v", err)
+	}
+	dir := newCodecTestTable(t, 100)
+	defer os.RemoveAll(dir)
+
+	checkCodecTestTable(t, dir, 100, codecSnappy)
+
+	tests := []struct {
+		codec string
+		dict  []byte
+		want  freezerCodec
+	}{
+		{"zstd", dict, codecZstd},
+		{"none", nil, codecNone},
+		{"zstd", nil, codecZstd},
+		{"snappy", nil, codecSnappy},
+	}
+	for i, tt := range tests {
+		if err := RecompressFreezerTable(dir, freezerReceiptTable, tt.codec, tt.dict); err != nil {
+			t.Fatalf("test %d: failed to recompress table: %v", i, err)
+		}
+		checkCodecTestTable(t, dir, 100, tt.want)
+	}
+	// Recompressing into the current codec is a noop, but not if the dictionary
+	// would change, and invalid requests are rejected
+	if err := RecompressFreezerTable(dir, freezerReceiptTable, "snappy", nil); err != nil {
+		t.Fatalf("failed to recompress into the same codec: %v", err)
+	}
+	if err := RecompressFreezerTable(dir, freezerReceiptTable, "snappy", dict); err == nil {
+		t.Fatalf("recompressed snappy table with a dictionary")
+	}
+	if err := RecompressFreezerTable(dir, freezerReceiptTable, "lz4", nil); err == nil {
+		t.Fatalf("recompressed into unknown codec")
+	}
+	if err := RecompressFreezerTable(dir, "unknown", "none", nil); err == nil {
+		t.Fatalf("recompressed unknown table")
+	}
+	checkCodecTestTable(t, dir, 100, codecSnappy)
+}
+
+// Tests that the files of an interrupted recompression are discarded, and that
+// tables without metadata are detected from their files.
+func TestFreezerRecompressInterrupted(t *testing.T) {
+	dir := newCodecTestTable(t, 10)
+	defer os.RemoveAll(dir)
+
+	// Simulate a crash midway through writing the new files
+	for _, name := range []string{"receipts.zidx", "receipts.0000.zdat"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte{0xde, 0xad}, 0644); err != nil {
+			t.Fatalf("failed to create leftover file: %v", err)
+		}
+	}
+	checkCodecTestTable(t, dir, 10, codecSnappy)
+
+	// Simulate a table created before metadata was recorded
+	if err := os.Remove(metaFilePath(dir, freezerReceiptTable)); err != nil {
+		t.Fatalf("failed to remove metadata: %v", err)
+	}
+	f, err := newTable(dir, freezerReceiptTable, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, true)
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	f.Close()
+
+	checkCodecTestTable(t, dir, 10, codecSnappy)
+}
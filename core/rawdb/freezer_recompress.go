@@ -0,0 +1,156 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/metrics"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+// FreezerTables returns the names of all the tables of the ancient store.
+func FreezerTables() []string {
+	names := make([]string, 0, len(freezerNoSnappy))
+	for name := range freezerNoSnappy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecompressFreezerTable rewrites all the items of an ancient table with a new
+// compression codec ("none", "snappy" or "zstd"), optionally using a zstd
+// dictionary. The freezer must not be in use.
+//
+// The new files are written next to the old ones and the table is only switched
+// over by atomically replacing its metadata once they are fully persisted, so an
+// interruption at any point leaves either the old or the new table intact.
+func RecompressFreezerTable(datadir string, name string, codec string, dict []byte) error {
+	target, err := parseFreezerCodec(codec)
+	if err != nil {
+		return err
+	}
+	if target != codecZstd && len(dict) > 0 {
+		return fmt.Errorf("freezer codec %v does not support dictionaries", target)
+	}
+	noCompression, ok := freezerNoSnappy[name]
+	if !ok {
+		return fmt.Errorf("unknown freezer table %q", name)
+	}
+	// Make sure the freezer is not opened concurrently
+	lock, _, err := fileutil.Flock(filepath.Join(datadir, "FLOCK"))
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	src, err := newTable(datadir, name, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, noCompression)
+	if err != nil {
+		return err
+	}
+	meta, err := readTableMeta(datadir, name)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	if meta.Codec == target {
+		src.Close()
+		if target == codecZstd && !bytes.Equal(meta.Dict, dict) {
+			return fmt.Errorf("table %s already uses %v, recompress with another codec first to change the dictionary", name, target)
+		}
+		log.Info("Freezer table already uses requested codec", "table", name, "codec", target)
+		return nil
+	}
+	// Create the new table files, dropping any leftovers of an interrupted run
+	if err := removeTableFiles(datadir, name, target); err != nil {
+		src.Close()
+		return err
+	}
+	newMeta := &freezerTableMeta{Version: freezerTableMetaVersion, Codec: target, Dict: common.CopyBytes(dict)}
+
+	dst, err := openTable(datadir, name, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, src.maxFileSize, newMeta)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	if err := recompressTable(src, dst); err != nil {
+		src.Close()
+		dst.Close()
+		return err
+	}
+	srcSize, _ := src.size()
+	dstSize, _ := dst.size()
+
+	if err := dst.Close(); err != nil {
+		src.Close()
+		return err
+	}
+	if err := src.Close(); err != nil {
+		return err
+	}
+	// Everything persisted, switch the table over and delete the old files
+	if err := writeTableMeta(datadir, name, newMeta); err != nil {
+		return err
+	}
+	if err := removeTableFiles(datadir, name, meta.Codec); err != nil {
+		return err
+	}
+	log.Info("Recompressed freezer table", "table", name, "from", meta.Codec, "to", target,
+		"size", common.StorageSize(srcSize), "newsize", common.StorageSize(dstSize))
+	return nil
+}
+
+// recompressTable copies all the items of a freezer table into an empty one and
+// flushes it to disk.
+func recompressTable(src, dst *freezerTable) error {
+	// Carry over the number of items deleted from the tail, if any
+	if src.itemOffset > 0 {
+		tail := indexEntry{filenum: dst.tailId, offset: src.itemOffset}
+		if _, err := dst.index.WriteAt(tail.marshallBinary(), 0); err != nil {
+			return err
+		}
+		dst.itemOffset = src.itemOffset
+		atomic.StoreUint64(&dst.items, uint64(src.itemOffset))
+	}
+	var (
+		items  = atomic.LoadUint64(&src.items)
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for item := uint64(src.itemOffset); item < items; item++ {
+		blob, err := src.Retrieve(item)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve item %d: %v", item, err)
+		}
+		if err := dst.Append(item, blob); err != nil {
+			return fmt.Errorf("failed to append item %d: %v", item, err)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Recompressing freezer table", "table", src.name, "item", item, "items", items, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return dst.Sync()
+}
@@ -23,13 +23,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 
 	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/log"
 	"github.com/MFAChain/mfachain/metrics"
-	"github.com/golang/snappy"
 )
 
 var (
@@ -48,6 +48,9 @@ var (
 // indexEntry contains the number/id of the file that the data resides in, aswell as the
 // offset within the file to the end of the data
 // In serialized form, the filenum is stored as uint16.
+//
+// The first entry of the index is special: it holds the number of the earliest
+// data file as filenum and the number of items deleted from the tail as offset.
 type indexEntry struct {
 	filenum uint32 // stored as uint16 ( 2 bytes)
 	offset  uint32 // stored as uint32 ( 4 bytes)
@@ -71,18 +74,20 @@ func (i *indexEntry) marshallBinary() []byte {
 }
 
 // freezerTable represents a single chained data table within the freezer (e.g. blocks).
-// It consists of a data file (snappy encoded arbitrary data blobs) and an indexEntry
-// file (uncompressed 64 bit indices into the data file).
+// It consists of a data file (compressed arbitrary data blobs), an indexEntry file
+// (uncompressed 64 bit indices into the data file) and a metadata file recording
+// the compression codec of the table.
 type freezerTable struct {
 	// WARNING: The `items` field is accessed atomically. On 32 bit platforms, only
 	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
 	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
 	items uint64 // Number of items stored in the table (including items removed from tail)
 
-	noCompression bool   // if true, disables snappy compression. Note: does not work retroactively
-	maxFileSize   uint32 // Max file size for data-files
-	name          string
-	path          string
+	codec       freezerCodec       // Compression codec of the items, recorded in the table metadata
+	compressor  *freezerCompressor // Compressor implementing the codec
+	maxFileSize uint32             // Max file size for data-files
+	name        string
+	path        string
 
 	head   *os.File            // File descriptor for the data head of the table
 	files  map[uint32]*os.File // open files
@@ -92,7 +97,7 @@ type freezerTable struct {
 
 	// In the case that old items are deleted (from the tail), we use itemOffset
 	// to count how many historic items have gone missing.
-	itemOffset uint32 // Offset (number of discarded items)
+	itemOffset uint32 // Offset (number of discarded items), accessed atomically
 
 	headBytes  uint32        // Number of bytes written to the head file
 	readMeter  metrics.Meter // Meter for measuring the effective amount of data read
@@ -148,36 +153,60 @@ func truncateFreezerFile(file *os.File, size int64) error {
 
 // newCustomTable opens a freezer table, creating the data and index files if they are
 // non existent. Both files are truncated to the shortest common length to ensure
-// they don't go out of sync.
+// they don't go out of sync. The codec recorded in the table metadata is used if
+// the table exists, otherwise snappy compression unless disabled.
 func newCustomTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool) (*freezerTable, error) {
+	codec := codecSnappy
+	if noCompression {
+		codec = codecNone
+	}
+	meta, err := loadTableMeta(path, name, codec)
+	if err != nil {
+		return nil, err
+	}
+	// Drop any files left behind by an interrupted re-compression
+	for _, codec := range []freezerCodec{codecNone, codecSnappy, codecZstd} {
+		if codec != meta.Codec {
+			if err := removeTableFiles(path, name, codec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return openTable(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, meta)
+}
+
+// openTable opens the files of a freezer table stored with the codec of the given
+// metadata, regardless of the metadata actually recorded for the table.
+func openTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, meta *freezerTableMeta) (*freezerTable, error) {
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
 	}
-	var idxName string
-	if noCompression {
-		// Raw idx
-		idxName = fmt.Sprintf("%s.ridx", name)
-	} else {
-		// Compressed idx
-		idxName = fmt.Sprintf("%s.cidx", name)
+	compressor, err := newFreezerCompressor(meta.Codec, meta.Dict)
+	if err != nil {
+		return nil, err
 	}
+	idxName := fmt.Sprintf("%s.%sidx", name, meta.Codec.suffix())
+	os.Remove(filepath.Join(path, idxName+".tmp")) // Leftover of an interrupted tail truncation
+
 	offsets, err := openFreezerFileForAppend(filepath.Join(path, idxName))
 	if err != nil {
+		compressor.close()
 		return nil, err
 	}
 	// Create the table and repair any past inconsistency
 	tab := &freezerTable{
-		index:         offsets,
-		files:         make(map[uint32]*os.File),
-		readMeter:     readMeter,
-		writeMeter:    writeMeter,
-		sizeGauge:     sizeGauge,
-		name:          name,
-		path:          path,
-		logger:        log.New("database", path, "table", name),
-		noCompression: noCompression,
-		maxFileSize:   maxFilesize,
+		index:       offsets,
+		files:       make(map[uint32]*os.File),
+		readMeter:   readMeter,
+		writeMeter:  writeMeter,
+		sizeGauge:   sizeGauge,
+		name:        name,
+		path:        path,
+		logger:      log.New("database", path, "table", name),
+		codec:       meta.Codec,
+		compressor:  compressor,
+		maxFileSize: maxFilesize,
 	}
 	if err := tab.repair(); err != nil {
 		tab.Close()
@@ -232,11 +261,16 @@ func (t *freezerTable) repair() error {
 	t.index.ReadAt(buffer, 0)
 	firstIndex.unmarshalBinary(buffer)
 
-	t.tailId = firstIndex.offset
-	t.itemOffset = firstIndex.filenum
+	t.tailId = firstIndex.filenum
+	t.itemOffset = firstIndex.offset
 
-	t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
-	lastIndex.unmarshalBinary(buffer)
+	// Delete any data files left behind by an interrupted tail truncation
+	for num := t.tailId; num > 0; num-- {
+		if err := os.Remove(t.dataFile(num - 1)); err != nil {
+			break
+		}
+	}
+	lastIndex, _ = t.readEntry(buffer, offsetsSize-indexEntrySize)
 	t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForAppend)
 	if err != nil {
 		return err
@@ -265,9 +299,7 @@ func (t *freezerTable) repair() error {
 				return err
 			}
 			offsetsSize -= indexEntrySize
-			t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
-			var newLastIndex indexEntry
-			newLastIndex.unmarshalBinary(buffer)
+			newLastIndex, _ := t.readEntry(buffer, offsetsSize-indexEntrySize)
 			// We might have slipped back into an earlier head-file here
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
@@ -306,6 +338,21 @@ func (t *freezerTable) repair() error {
 	return nil
 }
 
+// readEntry reads the index entry at the given file offset, assuming that the
+// tail fields are already initialised. The first entry holds the tail position
+// of the table, so it's substituted with the start of the earliest data file.
+func (t *freezerTable) readEntry(buffer []byte, offset int64) (indexEntry, error) {
+	if offset == 0 {
+		return indexEntry{filenum: t.tailId}, nil
+	}
+	var entry indexEntry
+	if _, err := t.index.ReadAt(buffer, offset); err != nil {
+		return entry, err
+	}
+	entry.unmarshalBinary(buffer)
+	return entry, nil
+}
+
 // preopen opens all files that the freezer will need. This method should be called from an init-context,
 // since it assumes that it doesn't have to bother with locking
 // The rationale for doing preopen is to not have to do it from within Retrieve, thus not needing to ever
@@ -340,16 +387,22 @@ func (t *freezerTable) truncate(items uint64) error {
 	}
 	// Something's out of sync, truncate the table's offset index
 	t.logger.Warn("Truncating freezer table", "items", t.items, "limit", items)
-	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
+	if items <= uint64(t.itemOffset) {
+		// All the retained items are discarded, restart the table at the limit
+		if err := t.resetTail(items); err != nil {
+			return err
+		}
+		items = uint64(t.itemOffset)
+	}
+	if err := truncateFreezerFile(t.index, int64(items-uint64(t.itemOffset)+1)*indexEntrySize); err != nil {
 		return err
 	}
 	// Calculate the new expected size of the data file and truncate it
 	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+	expected, err := t.readEntry(buffer, int64((items-uint64(t.itemOffset))*indexEntrySize))
+	if err != nil {
 		return err
 	}
-	var expected indexEntry
-	expected.unmarshalBinary(buffer)
 
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
@@ -383,6 +436,140 @@ func (t *freezerTable) truncate(items uint64) error {
 	return nil
 }
 
+// truncateTail discards the items below the provided threshold number. Items are
+// only deleted in whole data files, so the ones sharing a file with the first
+// retained item are kept too.
+func (t *freezerTable) truncateTail(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Ensure the table is still accessible and there's something to delete
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	total := atomic.LoadUint64(&t.items)
+	if items > total {
+		items = total
+	}
+	offset := uint64(t.itemOffset)
+	if items <= offset {
+		return nil
+	}
+	// Find the data file holding the first retained item, all before it can go
+	newTail := t.headId
+	if items < total {
+		_, _, filenum, err := t.getBounds(items - offset)
+		if err != nil {
+			return err
+		}
+		newTail = filenum
+	}
+	if newTail == t.tailId {
+		return nil
+	}
+	// Find the first item stored in the new tail file. Items not fitting into a
+	// data file are moved in whole to the next one, so it starts at offset zero.
+	var (
+		buffer  = make([]byte, indexEntrySize)
+		readErr error
+	)
+	first := sort.Search(int(total-offset), func(i int) bool {
+		entry, err := t.readEntry(buffer, int64(i+1)*indexEntrySize)
+		if err != nil {
+			readErr = err
+			return true
+		}
+		return entry.filenum >= newTail
+	})
+	if readErr != nil {
+		return readErr
+	}
+	oldSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	// Switch the index over to the new tail, then delete the stale data files
+	tail := indexEntry{filenum: newTail, offset: uint32(offset + uint64(first))}
+	if err := t.rewriteIndex(tail, int64(first+1)*indexEntrySize); err != nil {
+		return err
+	}
+	t.removeFilesBefore(newTail)
+	atomic.StoreUint32(&t.itemOffset, tail.offset)
+
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Dec(int64(oldSize - newSize))
+
+	t.logger.Info("Truncated freezer table tail", "items", tail.offset, "limit", items)
+	return nil
+}
+
+// resetTail discards all the items of the table, restarting it at the provided
+// item number in the current head file. The caller must hold the write lock.
+func (t *freezerTable) resetTail(items uint64) error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	tail := indexEntry{filenum: t.headId, offset: uint32(items)}
+	if err := t.rewriteIndex(tail, stat.Size()); err != nil {
+		return err
+	}
+	t.removeFilesBefore(t.headId)
+	atomic.StoreUint32(&t.itemOffset, tail.offset)
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// rewriteIndex atomically replaces the index file with one starting with the
+// given tail entry, followed by the current entries from the given file offset
+// onwards. The caller must hold the write lock.
+func (t *freezerTable) rewriteIndex(tail indexEntry, from int64) error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	name := t.index.Name()
+	index, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := index.Write(tail.marshallBinary()); err != nil {
+		index.Close()
+		return err
+	}
+	if _, err := io.Copy(index, io.NewSectionReader(t.index, from, stat.Size()-from)); err != nil {
+		index.Close()
+		return err
+	}
+	if err := index.Sync(); err != nil {
+		index.Close()
+		return err
+	}
+	if err := index.Close(); err != nil {
+		return err
+	}
+	// Swap the new index in, reopening whichever ends up in place
+	t.index.Close()
+	renameErr := os.Rename(name+".tmp", name)
+	if t.index, err = openFreezerFileForAppend(name); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// removeFilesBefore closes and deletes all data files with a lower number and
+// moves the tail of the table to the given file. The caller must hold the write
+// lock.
+func (t *freezerTable) removeFilesBefore(num uint32) {
+	for ; t.tailId < num; t.tailId++ {
+		t.releaseFile(t.tailId)
+		os.Remove(t.dataFile(t.tailId))
+	}
+}
+
 // Close closes all opened files.
 func (t *freezerTable) Close() error {
 	t.lock.Lock()
@@ -400,6 +587,7 @@ func (t *freezerTable) Close() error {
 		}
 	}
 	t.head = nil
+	t.compressor.close()
 
 	if errs != nil {
 		return fmt.Errorf("%v", errs)
@@ -411,13 +599,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(t.dataFile(num))
 		if err != nil {
 			return nil, err
 		}
@@ -426,6 +608,11 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// dataFile returns the path of the data file with the given number.
+func (t *freezerTable) dataFile(num uint32) string {
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.%sdat", t.name, num, t.codec.suffix()))
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -468,9 +655,7 @@ func (t *freezerTable) Append(item uint64, blob []byte) error {
 		return fmt.Errorf("appending unexpected item: want %d, have %d", t.items, item)
 	}
 	// Encode the blob and write it into the data file
-	if !t.noCompression {
-		blob = snappy.Encode(nil, blob)
-	}
+	blob = t.compressor.encode(blob)
 	bLen := uint32(len(blob))
 	if t.headBytes+bLen < bLen ||
 		t.headBytes+bLen > t.maxFileSize {
@@ -519,16 +704,15 @@ func (t *freezerTable) Append(item uint64, blob []byte) error {
 // getBounds returns the indexes for the item
 // returns start, end, filenumber and error
 func (t *freezerTable) getBounds(item uint64) (uint32, uint32, uint32, error) {
-	var startIdx, endIdx indexEntry
 	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(item*indexEntrySize)); err != nil {
+	startIdx, err := t.readEntry(buffer, int64(item*indexEntrySize))
+	if err != nil {
 		return 0, 0, 0, err
 	}
-	startIdx.unmarshalBinary(buffer)
-	if _, err := t.index.ReadAt(buffer, int64((item+1)*indexEntrySize)); err != nil {
+	endIdx, err := t.readEntry(buffer, int64((item+1)*indexEntrySize))
+	if err != nil {
 		return 0, 0, 0, err
 	}
-	endIdx.unmarshalBinary(buffer)
 	if startIdx.filenum != endIdx.filenum {
 		// If a piece of data 'crosses' a data-file,
 		// it's actually in one piece on the second data-file.
@@ -575,16 +759,13 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 	t.lock.RUnlock()
 	t.readMeter.Mark(int64(len(blob) + 2*indexEntrySize))
 
-	if t.noCompression {
-		return blob, nil
-	}
-	return snappy.Decode(nil, blob)
+	return t.compressor.decode(blob)
 }
 
 // has returns an indicator whether the specified number data
 // exists in the freezer table.
 func (t *freezerTable) has(number uint64) bool {
-	return atomic.LoadUint64(&t.items) > number
+	return atomic.LoadUint64(&t.items) > number && uint64(atomic.LoadUint32(&t.itemOffset)) <= number
 }
 
 // size returns the total data size in the freezer table.
@@ -247,7 +247,8 @@ func TestFreezerRepairDanglingHeadLarge(t *testing.T) {
 	}
 }
 
-// TestSnappyDetection tests that we fail to open a snappy database and vice versa
+// TestSnappyDetection tests that the codec recorded for a table is used to open
+// it, regardless of the compression requested.
 func TestSnappyDetection(t *testing.T) {
 	t.Parallel()
 	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
@@ -271,10 +272,13 @@ func TestSnappyDetection(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if _, err = f.Retrieve(0); err == nil {
-			f.Close()
-			t.Fatalf("expected empty table")
+		if f.codec != codecNone {
+			t.Fatalf("codec mismatch: have %v, want %v", f.codec, codecNone)
+		}
+		if _, err = f.Retrieve(0); err != nil {
+			t.Fatalf("expected no error, got %v", err)
 		}
+		f.Close()
 	}
 
 	// Open with snappy
@@ -403,6 +407,91 @@ func TestFreezerTruncate(t *testing.T) {
 
 }
 
+// TestFreezerTruncateTail tests that items can be deleted from the tail of the
+// table in whole data files, and that the tail survives reopening and truncating
+// the head below it.
+func TestFreezerTruncateTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("tailtruncation-%d", rand.Uint64())
+
+	{ // Fill table
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Write 15 bytes 30 times, three items per file
+		for x := 0; x < 30; x++ {
+			f.Append(uint64(x), getChunk(15, x))
+		}
+		// Item 10 is in the fourth file, together with items 9 and 11
+		if err := f.truncateTail(10); err != nil {
+			t.Fatal(err)
+		}
+		if f.itemOffset != 9 || f.tailId != 3 {
+			t.Fatalf("tail mismatch: have offset %d file %d, want offset %d file %d", f.itemOffset, f.tailId, 9, 3)
+		}
+		f.Close()
+	}
+	// Reopen, check the content and append some more
+	{
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 0; x < 30; x++ {
+			got, err := f.Retrieve(uint64(x))
+			if x < 9 {
+				if err == nil || f.has(uint64(x)) {
+					t.Fatalf("item %d: deleted item retrieved", x)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("item %d: failed to retrieve: %v", x, err)
+			}
+			if exp := getChunk(15, x); !bytes.Equal(got, exp) {
+				t.Fatalf("item %d: expected %x got %x", x, exp, got)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(os.TempDir(), fmt.Sprintf("%s.0002.rdat", fname))); !os.IsNotExist(err) {
+			t.Fatalf("deleted data file still present: %v", err)
+		}
+		if err := f.Append(30, getChunk(15, 30)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := f.Retrieve(30); err != nil || !bytes.Equal(got, getChunk(15, 30)) {
+			t.Fatalf("appended item mismatch: %x (%v)", got, err)
+		}
+		// Truncate the head below the tail, the table should restart there
+		if err := f.truncate(5); err != nil {
+			t.Fatal(err)
+		}
+		if f.items != 5 || f.itemOffset != 5 {
+			t.Fatalf("reset mismatch: have items %d offset %d, want %d", f.items, f.itemOffset, 5)
+		}
+		if err := f.Append(5, getChunk(15, 5)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	// Reopen again and check the restarted table
+	{
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if f.items != 6 || f.itemOffset != 5 {
+			t.Fatalf("reopen mismatch: have items %d offset %d, want %d, %d", f.items, f.itemOffset, 6, 5)
+		}
+		if got, err := f.Retrieve(5); err != nil || !bytes.Equal(got, getChunk(15, 5)) {
+			t.Fatalf("restarted item mismatch: %x (%v)", got, err)
+		}
+	}
+}
+
 // TestFreezerRepairFirstFile tests a head file with the very first item only half-written.
 // That will rewind the index, and _should_ truncate the head file
 func TestFreezerRepairFirstFile(t *testing.T) {
@@ -552,8 +641,8 @@ func TestOffset(t *testing.T) {
 		tailId := uint32(2)     // First file is 2
 		itemOffset := uint32(4) // We have removed four items
 		zeroIndex := indexEntry{
-			offset:  tailId,
-			filenum: itemOffset,
+			filenum: tailId,
+			offset:  itemOffset,
 		}
 		buf := zeroIndex.marshallBinary()
 		// Overwrite index zero
@@ -0,0 +1,240 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/metrics"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+// CheckAncientIndices cross checks the index of every ancient table with its
+// data files, without reading the items themselves. The freezer must not be in
+// use. It returns the number of leading items consistent in all the tables and
+// the error describing the first inconsistency, if any.
+func CheckAncientIndices(datadir string) (uint64, error) {
+	lock, _, err := fileutil.Flock(filepath.Join(datadir, "FLOCK"))
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release()
+
+	var (
+		good  = uint64(math.MaxUint64)
+		first error
+	)
+	for _, name := range FreezerTables() {
+		table, err := newTable(datadir, name, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, freezerNoSnappy[name])
+		if err != nil {
+			return 0, err
+		}
+		items, err := table.checkIndex()
+		table.Close()
+
+		if items < good {
+			good, first = items, nil
+		}
+		if err != nil && items == good {
+			first = fmt.Errorf("table %s: %v", name, err)
+		}
+	}
+	return good, first
+}
+
+// checkIndex verifies that the index entries of the table point to increasing
+// positions within the existing data files. It returns the number of leading
+// items with consistent entries and the error describing the first bad one.
+func (t *freezerTable) checkIndex() (uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var (
+		buffer = make([]byte, indexEntrySize)
+		offset = uint64(t.itemOffset)
+		items  = atomic.LoadUint64(&t.items)
+		sizes  = make(map[uint32]int64)
+	)
+	prev, _ := t.readEntry(buffer, 0)
+	for item := offset; item < items; item++ {
+		entry, err := t.readEntry(buffer, int64(item-offset+1)*indexEntrySize)
+		if err != nil {
+			return item, err
+		}
+		switch {
+		case entry.filenum < prev.filenum || entry.filenum > prev.filenum+1 || entry.filenum > t.headId:
+			return item, fmt.Errorf("item %d: data file %d out of sequence", item, entry.filenum)
+		case entry.filenum == prev.filenum && entry.offset < prev.offset:
+			return item, fmt.Errorf("item %d: data offset %d below previous %d", item, entry.offset, prev.offset)
+		}
+		size, ok := sizes[entry.filenum]
+		if !ok {
+			file, exist := t.files[entry.filenum]
+			if !exist {
+				return item, fmt.Errorf("item %d: missing data file %d", item, entry.filenum)
+			}
+			stat, err := file.Stat()
+			if err != nil {
+				return item, err
+			}
+			size, sizes[entry.filenum] = stat.Size(), stat.Size()
+		}
+		if int64(entry.offset) > size {
+			return item, fmt.Errorf("item %d: data offset %d beyond file size %d", item, entry.offset, size)
+		}
+		prev = entry
+	}
+	return items, nil
+}
+
+// VerifyAncients checks the integrity of the ancient chain segment between start
+// and limit: every header must hash to the canonical hash stored alongside and
+// link to its parent, bodies and receipts must match the roots in their headers
+// and total difficulties must add up. Bodies and receipts of expired history are
+// not checked. It returns the number of leading items found intact along with
+// the error describing the first corruption, if any.
+func VerifyAncients(db mfadb.Reader, start, limit uint64) (uint64, error) {
+	var (
+		parent common.Hash
+		td     *big.Int
+		tail   = ReadHistoryTail(db)
+		begin  = time.Now()
+		logged = time.Now()
+	)
+	if start > 0 {
+		parent = ReadCanonicalHash(db, start-1)
+		if td = ReadTd(db, parent, start-1); td == nil {
+			return start - 1, fmt.Errorf("item %d: total difficulty missing", start-1)
+		}
+	}
+	for number := start; number < limit; number++ {
+		hash, total, err := verifyAncient(db, number, parent, td, number == 0 || number >= tail)
+		if err != nil {
+			return number, fmt.Errorf("item %d: %v", number, err)
+		}
+		parent, td = hash, total
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying ancient store", "number", number, "limit", limit, "elapsed", common.PrettyDuration(time.Since(begin)))
+			logged = time.Now()
+		}
+	}
+	return limit, nil
+}
+
+// verifyAncient checks the integrity of a single ancient item, returning its hash
+// and total difficulty to check the next item against.
+func verifyAncient(db mfadb.Reader, number uint64, parent common.Hash, parentTd *big.Int, history bool) (common.Hash, *big.Int, error) {
+	kinds := []string{freezerHashTable, freezerHeaderTable, freezerDifficultyTable}
+	if history {
+		kinds = append(kinds, freezerBodiesTable, freezerReceiptTable)
+	}
+	for _, kind := range kinds {
+		if _, err := db.Ancient(kind, number); err != nil {
+			return common.Hash{}, nil, fmt.Errorf("failed to retrieve %s: %v", kind, err)
+		}
+	}
+	// Check the header against the canonical hash and its parent
+	hash := ReadCanonicalHash(db, number)
+	if blob, _ := db.Ancient(freezerHeaderTable, number); crypto.Keccak256Hash(blob) != hash {
+		return common.Hash{}, nil, fmt.Errorf("header hash mismatch: have %x, want %x", crypto.Keccak256Hash(blob), hash)
+	}
+	header := ReadHeader(db, hash, number)
+	switch {
+	case header == nil:
+		return common.Hash{}, nil, fmt.Errorf("invalid header")
+	case header.Number.Uint64() != number:
+		return common.Hash{}, nil, fmt.Errorf("header number mismatch: have %d", header.Number)
+	case number > 0 && header.ParentHash != parent:
+		return common.Hash{}, nil, fmt.Errorf("parent hash mismatch: have %x, want %x", header.ParentHash, parent)
+	}
+	// Check the total difficulty against the parent one
+	td := ReadTd(db, hash, number)
+	want := new(big.Int).Set(header.Difficulty)
+	if number > 0 {
+		want.Add(want, parentTd)
+	}
+	if td == nil || td.Cmp(want) != 0 {
+		return common.Hash{}, nil, fmt.Errorf("total difficulty mismatch: have %v, want %v", td, want)
+	}
+	if !history {
+		return hash, td, nil
+	}
+	// Check the body and receipts against the roots in the header
+	body := ReadBody(db, hash, number)
+	if body == nil {
+		return common.Hash{}, nil, fmt.Errorf("invalid body")
+	}
+	if root := types.DeriveSha(types.Transactions(body.Transactions)); root != header.TxHash {
+		return common.Hash{}, nil, fmt.Errorf("transaction root mismatch: have %x, want %x", root, header.TxHash)
+	}
+	if uncles := types.CalcUncleHash(body.Uncles); uncles != header.UncleHash {
+		return common.Hash{}, nil, fmt.Errorf("uncle hash mismatch: have %x, want %x", uncles, header.UncleHash)
+	}
+	receipts := ReadRawReceipts(db, hash, number)
+	if receipts == nil {
+		return common.Hash{}, nil, fmt.Errorf("invalid receipts")
+	}
+	if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+		return common.Hash{}, nil, fmt.Errorf("receipt root mismatch: have %x, want %x", root, header.ReceiptHash)
+	}
+	if bloom := types.CreateBloom(receipts); bloom != header.Bloom {
+		return common.Hash{}, nil, fmt.Errorf("bloom mismatch")
+	}
+	return hash, td, nil
+}
+
+// RefetchAncients replaces the ancient items from start up to limit with the ones
+// retrieved from another ancient store, e.g. the one of a remote node.
+func RefetchAncients(db mfadb.AncientWriter, source mfadb.AncientReader, start, limit uint64) error {
+	if err := db.TruncateAncients(start); err != nil {
+		return err
+	}
+	var (
+		begin  = time.Now()
+		logged = time.Now()
+		kinds  = []string{freezerHashTable, freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerDifficultyTable}
+		blobs  = make([][]byte, len(kinds))
+	)
+	for number := start; number < limit; number++ {
+		for i, kind := range kinds {
+			blob, err := source.Ancient(kind, number)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve ancient %s %d: %v", kind, number, err)
+			}
+			blobs[i] = blob
+		}
+		if err := db.AppendAncient(number, blobs[0], blobs[1], blobs[2], blobs[3], blobs[4]); err != nil {
+			return err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Refetching ancient items", "number", number, "limit", limit, "elapsed", common.PrettyDuration(time.Since(begin)))
+			logged = time.Now()
+		}
+	}
+	return db.Sync()
+}
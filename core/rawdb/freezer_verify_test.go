@@ -0,0 +1,134 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/mfadb"
+)
+
+// newVerifyTestDatabase creates a database with a freezer in a fresh directory.
+func newVerifyTestDatabase(t *testing.T) (mfadb.Database, string) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), dir, "")
+	if err != nil {
+		t.Fatalf("failed to create database with freezer: %v", err)
+	}
+	return db, dir
+}
+
+// writeVerifyTestChain writes a chain of blocks with a transaction and a receipt
+// each into the ancient store. If corrupt is not negative, the receipts stored
+// for that block don't match its header.
+func writeVerifyTestChain(db mfadb.AncientWriter, blocks int, corrupt int) {
+	var (
+		parent common.Hash
+		td     = new(big.Int)
+	)
+	for i := 0; i < blocks; i++ {
+		var (
+			header = &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Difficulty: big.NewInt(int64(i + 1)), Extra: []byte("test")}
+			tx     = types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+			logs   = []*types.Log{{Address: common.Address{byte(i)}, Topics: []common.Hash{{byte(i)}}}}
+		)
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: logs}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt})
+		if i == corrupt {
+			receipt.CumulativeGasUsed++
+		}
+		td.Add(td, block.Difficulty())
+		WriteAncientBlock(db, block, types.Receipts{receipt}, td)
+		parent = block.Hash()
+	}
+}
+
+// Tests that ancient chain segments are verified against their headers, and that
+// corrupted ones can be repaired from another ancient store.
+func TestVerifyAncients(t *testing.T) {
+	source, sourcedir := newVerifyTestDatabase(t)
+	defer os.RemoveAll(sourcedir)
+	defer source.Close()
+
+	writeVerifyTestChain(source, 10, -1)
+	if n, err := VerifyAncients(source, 0, 10); n != 10 || err != nil {
+		t.Fatalf("intact chain verification failed: have %d (%v), want %d", n, err, 10)
+	}
+	db, dir := newVerifyTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	writeVerifyTestChain(db, 10, 6)
+	if n, err := VerifyAncients(db, 0, 10); n != 6 || err == nil {
+		t.Fatalf("corrupted chain verification mismatch: have %d (%v), want %d", n, err, 6)
+	}
+	if n, err := VerifyAncients(db, 3, 6); n != 6 || err != nil {
+		t.Fatalf("partial chain verification failed: have %d (%v), want %d", n, err, 6)
+	}
+	// Expire the corrupted history, it should not be checked anymore
+	WriteHistoryTail(db, 7)
+	if n, err := VerifyAncients(db, 0, 10); n != 10 || err != nil {
+		t.Fatalf("expired chain verification failed: have %d (%v), want %d", n, err, 10)
+	}
+	WriteHistoryTail(db, 0)
+
+	// Repair the corrupted items from the intact ancient store
+	if err := RefetchAncients(db, source, 6, 10); err != nil {
+		t.Fatalf("failed to refetch ancients: %v", err)
+	}
+	if n, err := VerifyAncients(db, 0, 10); n != 10 || err != nil {
+		t.Fatalf("repaired chain verification failed: have %d (%v), want %d", n, err, 10)
+	}
+}
+
+// Tests that inconsistent ancient indices are detected.
+func TestCheckAncientIndices(t *testing.T) {
+	db, dir := newVerifyTestDatabase(t)
+	defer os.RemoveAll(dir)
+
+	writeVerifyTestChain(db, 10, -1)
+	db.Close()
+
+	if n, err := CheckAncientIndices(dir); n != 10 || err != nil {
+		t.Fatalf("intact index check failed: have %d (%v), want %d", n, err, 10)
+	}
+	// Point the end of the fourth receipt beyond the data file
+	index, err := os.OpenFile(filepath.Join(dir, freezerReceiptTable+".cidx"), os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	entry := indexEntry{filenum: 0, offset: 1 << 20}
+	if _, err := index.WriteAt(entry.marshallBinary(), 4*indexEntrySize); err != nil {
+		t.Fatalf("failed to corrupt index: %v", err)
+	}
+	index.Close()
+
+	if n, err := CheckAncientIndices(dir); n != 3 || err == nil {
+		t.Fatalf("corrupted index check mismatch: have %d (%v), want %d", n, err, 3)
+	}
+}
@@ -53,6 +53,9 @@ var (
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	fastTxLookupLimitKey = []byte("FastTransactionLookupLimit")
 
+	// historyTailKey tracks the oldest block whose body and receipts are retained.
+	historyTailKey = []byte("HistoryTail")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -104,6 +107,10 @@ var freezerNoSnappy = map[string]bool{
 	freezerDifficultyTable: true,
 }
 
+// freezerPrunableTables lists the ancient tables whose old items can be deleted
+// to expire chain history. Headers, hashes and difficulties are always retained.
+var freezerPrunableTables = []string{freezerBodiesTable, freezerReceiptTable}
+
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
 // fields.
 type LegacyTxLookupEntry struct {
@@ -0,0 +1,237 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+// AccessSet records the state read and written by a single transaction. It is
+// used to execute the transactions of a block speculatively on copies of the
+// same state, detecting the transactions which read something written by an
+// earlier one and merging the writes of the others in order.
+//
+// Account reads cover the balance, nonce, code and existence of an account,
+// whereas storage slots are tracked individually. Balance increases of accounts
+// never read by the transaction are recorded as credits, which commute with the
+// writes of other transactions (e.g. the fees paid to the coinbase).
+type AccessSet struct {
+	accounts map[common.Address]struct{}                 // Accounts whose fields were read
+	slots    map[common.Address]map[common.Hash]struct{} // Storage slots read
+	credits  map[common.Address]*big.Int                 // Balances of credited accounts before the first credit
+	writes   map[common.Address]*accountWrite            // Accounts modified, collected when finalising
+}
+
+// accountWrite is the final modification of an account by a transaction.
+type accountWrite struct {
+	credit  *big.Int // Balance increase if the account was only credited
+	fields  bool     // Whether the balance, nonce, code or existence were modified
+	created bool     // Whether the account was (re)created, dropping its storage
+	deleted bool     // Whether the account was destructed or deleted as empty
+
+	balance *big.Int
+	nonce   uint64
+	code    []byte // Contract code, if set by the transaction
+	storage Storage
+}
+
+// NewAccessSet creates an empty access set.
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+		credits:  make(map[common.Address]*big.Int),
+		writes:   make(map[common.Address]*accountWrite),
+	}
+}
+
+// readAccount records a read of the fields of an account.
+func (a *AccessSet) readAccount(addr common.Address) {
+	if a != nil {
+		a.accounts[addr] = struct{}{}
+	}
+}
+
+// readSlot records a read of a storage slot.
+func (a *AccessSet) readSlot(addr common.Address, key common.Hash) {
+	if a == nil {
+		return
+	}
+	if _, ok := a.slots[addr]; !ok {
+		a.slots[addr] = make(map[common.Hash]struct{})
+	}
+	a.slots[addr][key] = struct{}{}
+}
+
+// credit records an increase of the balance of an account, along with its
+// balance before the first credit.
+func (a *AccessSet) credit(addr common.Address, balance *big.Int) {
+	if a == nil {
+		return
+	}
+	if _, ok := a.credits[addr]; !ok {
+		a.credits[addr] = new(big.Int).Set(balance)
+	}
+}
+
+// finalise collects the final modifications of the accounts dirtied by the
+// current transaction, before the journal is cleared.
+func (a *AccessSet) finalise(s *StateDB, deleteEmptyObjects bool) {
+	if a == nil {
+		return
+	}
+	// Gather which parts of the accounts were modified from the journal, the
+	// reverted changes having been dropped already
+	fields := make(map[common.Address]struct{})
+	created := make(map[common.Address]struct{})
+	for _, entry := range s.journal.entries {
+		switch entry := entry.(type) {
+		case createObjectChange:
+			created[*entry.account] = struct{}{}
+			fields[*entry.account] = struct{}{}
+		case resetObjectChange:
+			created[entry.prev.address] = struct{}{}
+			fields[entry.prev.address] = struct{}{}
+		case balanceChange, nonceChange, codeChange, suicideChange, touchChange:
+			fields[*entry.dirtied()] = struct{}{}
+		}
+	}
+	for addr := range s.journal.dirties {
+		obj, exist := s.stateObjects[addr]
+		if !exist {
+			continue // Touched ripeMD after a revert, see Finalise
+		}
+		write := &accountWrite{
+			balance: new(big.Int).Set(obj.Balance()),
+			nonce:   obj.Nonce(),
+			deleted: obj.suicided || (deleteEmptyObjects && obj.empty()),
+			storage: obj.dirtyStorage.Copy(),
+		}
+		_, write.fields = fields[addr]
+		_, write.created = created[addr]
+		if obj.dirtyCode {
+			write.code = obj.code
+		}
+		if base, ok := a.credits[addr]; ok {
+			if _, read := a.accounts[addr]; !read {
+				write.credit = new(big.Int).Sub(obj.Balance(), base)
+			}
+		}
+		a.writes[addr] = write
+	}
+}
+
+// Conflicts reports whether the transaction read any state modified by the
+// transactions gathered in the given write set.
+func (a *AccessSet) Conflicts(w *WriteSet) bool {
+	for addr := range a.accounts {
+		if _, ok := w.accounts[addr]; ok {
+			return true
+		}
+	}
+	for addr, slots := range a.slots {
+		if _, ok := w.resets[addr]; ok {
+			return true
+		}
+		written := w.slots[addr]
+		for key := range slots {
+			if _, ok := written[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteSet is the union of the state modified by a sequence of transactions.
+type WriteSet struct {
+	accounts map[common.Address]struct{}                 // Accounts with modified fields
+	resets   map[common.Address]struct{}                 // Accounts (re)created or deleted
+	slots    map[common.Address]map[common.Hash]struct{} // Storage slots modified
+}
+
+// NewWriteSet creates an empty write set.
+func NewWriteSet() *WriteSet {
+	return &WriteSet{
+		accounts: make(map[common.Address]struct{}),
+		resets:   make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// Add merges the modifications recorded in an access set into the write set.
+func (w *WriteSet) Add(a *AccessSet) {
+	for addr, write := range a.writes {
+		if write.fields || write.deleted {
+			w.accounts[addr] = struct{}{}
+		}
+		if write.created || write.deleted {
+			w.resets[addr] = struct{}{}
+		}
+		if len(write.storage) > 0 {
+			if _, ok := w.slots[addr]; !ok {
+				w.slots[addr] = make(map[common.Hash]struct{})
+			}
+			for key := range write.storage {
+				w.slots[addr][key] = struct{}{}
+			}
+		}
+	}
+}
+
+// SetAccessSet starts recording the accesses of the following transaction into
+// the given set, which is completed when the state is finalised. A nil set stops
+// the recording.
+func (s *StateDB) SetAccessSet(set *AccessSet) {
+	s.access = set
+}
+
+// ApplyWrites applies the modifications recorded in an access set to the state,
+// as if the transaction was executed on it. The caller must ensure that the
+// transaction doesn't conflict with the ones already applied to this state. The
+// state still needs to be finalised afterwards.
+func (s *StateDB) ApplyWrites(set *AccessSet) {
+	for addr, write := range set.writes {
+		switch {
+		case write.credit != nil:
+			s.AddBalance(addr, write.credit)
+
+		case write.deleted:
+			if write.created {
+				s.CreateAccount(addr)
+			}
+			s.Suicide(addr)
+
+		default:
+			if write.created {
+				s.CreateAccount(addr)
+			}
+			if write.fields {
+				s.SetBalance(addr, write.balance)
+				s.SetNonce(addr, write.nonce)
+				if write.code != nil {
+					s.SetCode(addr, write.code)
+				}
+			}
+			for key, value := range write.storage {
+				s.SetState(addr, key, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+)
+
+// Tests that the writes of transactions executed on copies of the same state
+// can be merged in order when they don't conflict, resulting in the same state
+// as executing them sequentially.
+func TestAccessSetMerge(t *testing.T) {
+	var (
+		addrA = common.Address{0x0a}
+		addrB = common.Address{0x0b}
+		addrC = common.Address{0x0c}
+		addrD = common.Address{0x0d}
+		slot1 = common.Hash{0x01}
+		slot2 = common.Hash{0x02}
+	)
+	base, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	base.SetBalance(addrA, big.NewInt(100))
+	base.SetState(addrA, slot1, common.Hash{0x11})
+	base.SetBalance(addrB, big.NewInt(50))
+	base.Finalise(true)
+
+	txs := []func(s *StateDB){
+		// Write a new slot and credit an account, dropping a reverted credit
+		func(s *StateDB) {
+			s.SetState(addrA, slot2, common.Hash{0x22})
+			s.AddBalance(addrC, big.NewInt(10))
+			snap := s.Snapshot()
+			s.AddBalance(addrD, big.NewInt(7))
+			s.RevertToSnapshot(snap)
+		},
+		// Read an untouched slot and balance, credit the same account
+		func(s *StateDB) {
+			s.SetBalance(addrB, new(big.Int).Add(s.GetBalance(addrB), big.NewInt(int64(s.GetState(addrA, slot1)[0]))))
+			s.AddBalance(addrC, big.NewInt(3))
+		},
+		// Read the slot written by the first transaction
+		func(s *StateDB) {
+			s.SetState(addrA, slot1, s.GetState(addrA, slot2))
+		},
+		// Read the credited account
+		func(s *StateDB) {
+			s.SetNonce(addrC, s.GetBalance(addrC).Uint64())
+		},
+		// Destruct an account with storage written by earlier transactions
+		func(s *StateDB) {
+			s.Suicide(addrA)
+		},
+	}
+	// Execute the transactions sequentially and on copies of the base state
+	sequential := base.Copy()
+	for _, tx := range txs {
+		tx(sequential)
+		sequential.Finalise(true)
+	}
+	var (
+		merged  = base.Copy()
+		written = NewWriteSet()
+		want    = []bool{false, false, true, true, false}
+	)
+	for i, tx := range txs {
+		spec, access := base.Copy(), NewAccessSet()
+		spec.SetAccessSet(access)
+		tx(spec)
+		spec.Finalise(true)
+
+		conflict := access.Conflicts(written)
+		if conflict != want[i] {
+			t.Fatalf("tx %d: conflict mismatch: have %v, want %v", i, conflict, want[i])
+		}
+		if conflict {
+			access = NewAccessSet()
+			merged.SetAccessSet(access)
+			tx(merged)
+			merged.SetAccessSet(nil)
+		} else {
+			merged.ApplyWrites(access)
+		}
+		merged.Finalise(true)
+		written.Add(access)
+	}
+	if _, ok := written.accounts[addrD]; ok {
+		t.Fatalf("reverted credit recorded as write")
+	}
+	if have, want := merged.IntermediateRoot(true), sequential.IntermediateRoot(true); have != want {
+		t.Fatalf("merged state root mismatch: have %x, want %x", have, want)
+	}
+}
@@ -65,20 +65,27 @@ func VerifyState(snaptree *Tree, root common.Hash) error {
 	}
 	defer acctIt.Release()
 
+	var fail error
 	got, err := generateTrieRoot(acctIt, common.Hash{}, stdGenerate, func(account common.Hash, stat *generateStats) common.Hash {
 		storageIt, err := snaptree.StorageIterator(root, account, common.Hash{})
 		if err != nil {
+			fail = err
 			return common.Hash{}
 		}
 		defer storageIt.Release()
 
 		hash, err := generateTrieRoot(storageIt, account, stdGenerate, nil, stat, false)
 		if err != nil {
+			fail = err
 			return common.Hash{}
 		}
 		return hash
 	}, &generateStats{start: time.Now()}, true)
 
+	// Storage failures surface as a subroot mismatch, report the cause instead
+	if fail != nil {
+		return fail
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,353 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/trie"
+)
+
+// exportMagic identifies state export files.
+const exportMagic = "mfa-state"
+
+// exportVersion is the current version of the state export format.
+const exportVersion = 1
+
+// Entry kinds of the state export format. The accounts are exported in hash
+// order, each followed by its storage slots in hash order, and preceded by its
+// contract code unless already exported for another account.
+const (
+	exportKindEnd     = 0 // Trailing entry, the hash being the state root
+	exportKindAccount = 1 // Account hash and slim account RLP
+	exportKindStorage = 2 // Slot hash and value, belonging to the previous account
+	exportKindCode    = 3 // Code hash and contract code
+)
+
+// exportHeader is the first entry of a state export file.
+type exportHeader struct {
+	Magic   string
+	Version uint64
+}
+
+// exportEntry is a single record of a state export file.
+type exportEntry struct {
+	Kind uint8
+	Hash common.Hash
+	Data []byte
+}
+
+// exportAccountIterator wraps an account iterator, streaming every account
+// iterated over into the export file.
+type exportAccountIterator struct {
+	AccountIterator
+	write func() error
+	err   error
+}
+
+// Next steps the wrapped iterator and exports the new account.
+func (it *exportAccountIterator) Next() bool {
+	if it.err != nil || !it.AccountIterator.Next() {
+		return false
+	}
+	it.err = it.write()
+	return it.err == nil
+}
+
+// exportStorageIterator wraps a storage iterator, streaming every slot iterated
+// over into the export file.
+type exportStorageIterator struct {
+	StorageIterator
+	write func() error
+	err   error
+}
+
+// Next steps the wrapped iterator and exports the new slot.
+func (it *exportStorageIterator) Next() bool {
+	if it.err != nil || !it.StorageIterator.Next() {
+		return false
+	}
+	it.err = it.write()
+	return it.err == nil
+}
+
+// ExportState streams the accounts, storage slots and contract codes of the
+// snapshot with the given root into a state export file. The state root is
+// regenerated from the exported data and appended as a trailer, failing if it
+// doesn't match the requested one.
+func ExportState(snaptree *Tree, root common.Hash, w io.Writer) error {
+	if err := rlp.Encode(w, exportHeader{Magic: exportMagic, Version: exportVersion}); err != nil {
+		return err
+	}
+	acctIt, err := snaptree.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer acctIt.Release()
+
+	var (
+		codes = make(map[common.Hash]struct{})
+		it    = &exportAccountIterator{AccountIterator: acctIt}
+		fail  error
+	)
+	it.write = func() error {
+		account, err := FullAccount(acctIt.Account())
+		if err != nil {
+			return err
+		}
+		if hash := common.BytesToHash(account.CodeHash); hash != emptyCode {
+			if _, ok := codes[hash]; !ok {
+				code, err := snaptree.triedb.Node(hash)
+				if err != nil {
+					return fmt.Errorf("missing code %x of account %x: %v", hash, acctIt.Hash(), err)
+				}
+				if err := rlp.Encode(w, exportEntry{Kind: exportKindCode, Hash: hash, Data: code}); err != nil {
+					return err
+				}
+				codes[hash] = struct{}{}
+			}
+		}
+		return rlp.Encode(w, exportEntry{Kind: exportKindAccount, Hash: acctIt.Hash(), Data: acctIt.Account()})
+	}
+	got, err := generateTrieRoot(it, common.Hash{}, stdGenerate, func(account common.Hash, stat *generateStats) common.Hash {
+		storageIt, err := snaptree.StorageIterator(root, account, common.Hash{})
+		if err != nil {
+			fail = err
+			return common.Hash{}
+		}
+		defer storageIt.Release()
+
+		sit := &exportStorageIterator{StorageIterator: storageIt}
+		sit.write = func() error {
+			return rlp.Encode(w, exportEntry{Kind: exportKindStorage, Hash: storageIt.Hash(), Data: storageIt.Slot()})
+		}
+		hash, err := generateTrieRoot(sit, account, stdGenerate, nil, stat, false)
+		if err == nil {
+			err = sit.err
+		}
+		if err != nil {
+			fail = err
+			return common.Hash{}
+		}
+		return hash
+	}, &generateStats{start: time.Now()}, true)
+
+	// Storage failures surface as a subroot mismatch, report the cause instead
+	switch {
+	case fail != nil:
+		return fail
+	case it.err != nil:
+		return it.err
+	case err != nil:
+		return err
+	case got != root:
+		return fmt.Errorf("state root hash mismatch: got %x, want %x", got, root)
+	}
+	return rlp.Encode(w, exportEntry{Kind: exportKindEnd, Hash: root})
+}
+
+// importFlushAccounts is the number of imported accounts after which the
+// account trie is flushed to disk.
+const importFlushAccounts = 100000
+
+// ImportState imports a state export file into the database, rebuilding both
+// the state trie and the snapshot. Any previous snapshot is wiped. The account
+// storage roots and the state root are verified against the imported data, the
+// snapshot only being marked as complete if they all match.
+func ImportState(db mfadb.KeyValueStore, r io.Reader) (common.Hash, error) {
+	stream := rlp.NewStream(r, 0)
+
+	var header exportHeader
+	if err := stream.Decode(&header); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid state export header: %v", err)
+	}
+	if header.Magic != exportMagic {
+		return common.Hash{}, errors.New("not a state export file")
+	}
+	if header.Version != exportVersion {
+		return common.Hash{}, fmt.Errorf("unsupported state export version %d", header.Version)
+	}
+	// Drop any previous snapshot, it will be replaced by the imported one
+	<-wipeSnapshot(db, true)
+
+	var (
+		triedb   = trie.NewDatabase(db)
+		accTrie  *trie.Trie
+		stTrie   *trie.Trie
+		batch    = db.NewBatch()
+		codes    = make(map[common.Hash]struct{})
+		accounts uint64
+		slots    uint64
+		storage  common.StorageSize
+		start    = time.Now()
+		logged   = time.Now()
+
+		account     common.Hash
+		accountData []byte
+		lastSlot    common.Hash
+	)
+	accTrie, _ = trie.New(common.Hash{}, triedb)
+
+	// finish completes the storage trie of the current account and inserts the
+	// account into the account trie
+	finish := func() error {
+		if accountData == nil {
+			return nil
+		}
+		full, err := FullAccount(accountData)
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", account, err)
+		}
+		root := emptyRoot
+		if stTrie != nil {
+			if root, err = stTrie.Commit(nil); err != nil {
+				return err
+			}
+			if err := triedb.Commit(root, false); err != nil {
+				return err
+			}
+			stTrie = nil
+		}
+		if !bytes.Equal(full.Root, root.Bytes()) {
+			return fmt.Errorf("storage root mismatch of account %x: got %x, want %x", account, root, full.Root)
+		}
+		if hash := common.BytesToHash(full.CodeHash); hash != emptyCode {
+			if _, ok := codes[hash]; !ok {
+				return fmt.Errorf("missing code %x of account %x", hash, account)
+			}
+		}
+		blob, err := rlp.EncodeToBytes(full)
+		if err != nil {
+			return err
+		}
+		if err := accTrie.TryUpdate(account[:], blob); err != nil {
+			return err
+		}
+		accounts++
+		if accounts%importFlushAccounts == 0 {
+			root, err := accTrie.Commit(nil)
+			if err != nil {
+				return err
+			}
+			if err := triedb.Commit(root, false); err != nil {
+				return err
+			}
+			if accTrie, err = trie.New(root, triedb); err != nil {
+				return err
+			}
+		}
+		accountData = nil
+		return nil
+	}
+	for {
+		var entry exportEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err == io.EOF {
+				err = errors.New("missing state root trailer")
+			}
+			return common.Hash{}, err
+		}
+		switch entry.Kind {
+		case exportKindCode:
+			if crypto.Keccak256Hash(entry.Data) != entry.Hash {
+				return common.Hash{}, fmt.Errorf("code hash mismatch: have %x", entry.Hash)
+			}
+			codes[entry.Hash] = struct{}{}
+			if err := batch.Put(entry.Hash.Bytes(), entry.Data); err != nil {
+				return common.Hash{}, err
+			}
+
+		case exportKindAccount:
+			if accountData != nil && bytes.Compare(entry.Hash[:], account[:]) <= 0 {
+				return common.Hash{}, fmt.Errorf("account %x out of order", entry.Hash)
+			}
+			if err := finish(); err != nil {
+				return common.Hash{}, err
+			}
+			account, accountData, lastSlot = entry.Hash, entry.Data, common.Hash{}
+			rawdb.WriteAccountSnapshot(batch, account, accountData)
+
+		case exportKindStorage:
+			if accountData == nil {
+				return common.Hash{}, fmt.Errorf("storage slot %x without account", entry.Hash)
+			}
+			if stTrie != nil && bytes.Compare(entry.Hash[:], lastSlot[:]) <= 0 {
+				return common.Hash{}, fmt.Errorf("storage slot %x of account %x out of order", entry.Hash, account)
+			}
+			if stTrie == nil {
+				stTrie, _ = trie.New(common.Hash{}, triedb)
+			}
+			if err := stTrie.TryUpdate(entry.Hash[:], entry.Data); err != nil {
+				return common.Hash{}, err
+			}
+			rawdb.WriteStorageSnapshot(batch, account, entry.Hash, entry.Data)
+			lastSlot = entry.Hash
+			slots++
+			storage += common.StorageSize(1 + 2*common.HashLength + len(entry.Data))
+
+		case exportKindEnd:
+			if err := finish(); err != nil {
+				return common.Hash{}, err
+			}
+			root, err := accTrie.Commit(nil)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			if root != entry.Hash {
+				return common.Hash{}, fmt.Errorf("state root hash mismatch: got %x, want %x", root, entry.Hash)
+			}
+			if err := triedb.Commit(root, false); err != nil {
+				return common.Hash{}, err
+			}
+			// Everything verified, mark the snapshot as complete
+			journal, err := rlp.EncodeToBytes(journalGenerator{Done: true, Accounts: accounts, Slots: slots, Storage: uint64(storage)})
+			if err != nil {
+				return common.Hash{}, err
+			}
+			rawdb.WriteSnapshotJournal(batch, journal)
+			rawdb.WriteSnapshotRoot(batch, root)
+			if err := batch.Write(); err != nil {
+				return common.Hash{}, err
+			}
+			log.Info("Imported state", "root", root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			return root, nil
+
+		default:
+			return common.Hash{}, fmt.Errorf("unknown state export entry kind %d", entry.Kind)
+		}
+		if batch.ValueSize() > mfadb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return common.Hash{}, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Importing state", "at", account, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+}
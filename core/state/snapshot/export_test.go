@@ -0,0 +1,140 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/mfadb/memorydb"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/trie"
+)
+
+// newExportTestState creates a state with a few accounts, some of them with
+// storage and contract code, and generates its snapshot.
+func newExportTestState(t *testing.T) (*Tree, common.Hash, []byte) {
+	var (
+		db     = memorydb.New()
+		triedb = trie.NewDatabase(db)
+		code   = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	)
+	accTrie, _ := trie.New(common.Hash{}, triedb)
+	for i := 0; i < 16; i++ {
+		account := Account{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: emptyRoot[:], CodeHash: emptyCode[:]}
+		if i%3 == 0 {
+			stTrie, _ := trie.New(common.Hash{}, triedb)
+			for j := 0; j <= i; j++ {
+				stTrie.Update(crypto.Keccak256([]byte{byte(i), byte(j)}), []byte{0x80 + byte(j)})
+			}
+			root, _ := stTrie.Commit(nil)
+			account.Root = root[:]
+		}
+		if i%4 == 0 {
+			account.CodeHash = crypto.Keccak256(code)
+			triedb.InsertBlob(common.BytesToHash(account.CodeHash), code)
+		}
+		blob, _ := rlp.EncodeToBytes(account)
+		accTrie.Update(crypto.Keccak256([]byte{byte(i)}), blob)
+	}
+	root, _ := accTrie.Commit(nil)
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	return New(db, triedb, 16, root, false), root, code
+}
+
+// Tests that a state exported from a snapshot can be imported into an empty
+// database, rebuilding both the tries and the snapshot.
+func TestStateExportImport(t *testing.T) {
+	snaptree, root, code := newExportTestState(t)
+
+	buf := new(bytes.Buffer)
+	if err := ExportState(snaptree, root, buf); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	if err := ExportState(snaptree, common.Hash{0x01}, new(bytes.Buffer)); err == nil {
+		t.Fatalf("exported unknown state")
+	}
+	db := memorydb.New()
+	imported, err := ImportState(db, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	if imported != root {
+		t.Fatalf("imported root mismatch: have %x, want %x", imported, root)
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != root {
+		t.Fatalf("snapshot root mismatch: have %x, want %x", have, root)
+	}
+	if have, _ := db.Get(crypto.Keccak256(code)); !bytes.Equal(have, code) {
+		t.Fatalf("contract code mismatch: have %x, want %x", have, code)
+	}
+	// The imported snapshot must load without regeneration and match the tries
+	triedb := trie.NewDatabase(db)
+	if _, err := loadSnapshot(db, triedb, 16, root); err != nil {
+		t.Fatalf("failed to load imported snapshot: %v", err)
+	}
+	if err := VerifyState(New(db, triedb, 16, root, false), root); err != nil {
+		t.Fatalf("failed to verify imported state: %v", err)
+	}
+}
+
+// Tests that corrupted state export files are rejected, leaving no snapshot
+// behind.
+func TestStateImportCorrupted(t *testing.T) {
+	snaptree, root, _ := newExportTestState(t)
+
+	buf := new(bytes.Buffer)
+	if err := ExportState(snaptree, root, buf); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	blob := buf.Bytes()
+
+	// Truncated file, missing the trailing state root
+	db := memorydb.New()
+	if _, err := ImportState(db, bytes.NewReader(blob[:len(blob)-40])); err == nil {
+		t.Fatalf("imported truncated state")
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != (common.Hash{}) {
+		t.Fatalf("snapshot marked complete after failed import: %x", have)
+	}
+	// Tampered state root trailer
+	corrupt := common.CopyBytes(blob)
+	trailer := mustEncode(t, exportEntry{Kind: exportKindEnd, Hash: root})
+	copy(corrupt[len(corrupt)-len(trailer):], mustEncode(t, exportEntry{Kind: exportKindEnd, Hash: common.Hash{0x01}}))
+	if _, err := ImportState(memorydb.New(), bytes.NewReader(corrupt)); err == nil {
+		t.Fatalf("imported state with mismatching root")
+	}
+	// Not a state export
+	if _, err := ImportState(memorydb.New(), bytes.NewReader([]byte{0xc0})); err == nil {
+		t.Fatalf("imported invalid file")
+	}
+}
+
+// mustEncode RLP encodes a value, failing the test on error.
+func mustEncode(t *testing.T, val interface{}) []byte {
+	blob, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return blob
+}
@@ -61,43 +61,13 @@ type journalStorage struct {
 	Vals [][]byte
 }
 
-// loadSnapshot loads a pre-existing state snapshot backed by a key-value store.
+// loadSnapshot loads a pre-existing state snapshot backed by a key-value store,
+// resuming any interrupted generation.
 func loadSnapshot(diskdb mfadb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) (snapshot, error) {
-	// Retrieve the block number and hash of the snapshot, failing if no snapshot
-	// is present in the database (or crashed mid-update).
-	baseRoot := rawdb.ReadSnapshotRoot(diskdb)
-	if baseRoot == (common.Hash{}) {
-		return nil, errors.New("missing or corrupted snapshot")
-	}
-	base := &diskLayer{
-		diskdb: diskdb,
-		triedb: triedb,
-		cache:  fastcache.New(cache * 1024 * 1024),
-		root:   baseRoot,
-	}
-	// Retrieve the journal, it must exist since even for 0 layer it stores whether
-	// we've already generated the snapshot or are in progress only
-	journal := rawdb.ReadSnapshotJournal(diskdb)
-	if len(journal) == 0 {
-		return nil, errors.New("missing or corrupted snapshot journal")
-	}
-	r := rlp.NewStream(bytes.NewReader(journal), 0)
-
-	// Read the snapshot generation progress for the disk layer
-	var generator journalGenerator
-	if err := r.Decode(&generator); err != nil {
-		return nil, fmt.Errorf("failed to load snapshot progress marker: %v", err)
-	}
-	// Load all the snapshot diffs from the journal
-	snapshot, err := loadDiffLayer(base, r)
+	snapshot, base, generator, err := openSnapshot(diskdb, triedb, cache, root)
 	if err != nil {
 		return nil, err
 	}
-	// Entire snapshot journal loaded, sanity check the head and return
-	// Journal doesn't exist, don't worry if it's not supposed to
-	if head := snapshot.Root(); head != root {
-		return nil, fmt.Errorf("head doesn't match snapshot: have %#x, want %#x", head, root)
-	}
 	// Everything loaded correctly, resume any suspended operations
 	if !generator.Done {
 		// If the generator was still wiping, restart one from scratch (fine for
@@ -132,6 +102,47 @@ func loadSnapshot(diskdb mfadb.KeyValueStore, triedb *trie.Database, cache int,
 	return snapshot, nil
 }
 
+// openSnapshot loads the layers of a pre-existing state snapshot backed by a
+// key-value store, along with the generation progress of its disk layer.
+func openSnapshot(diskdb mfadb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) (snapshot, *diskLayer, *journalGenerator, error) {
+	// Retrieve the block number and hash of the snapshot, failing if no snapshot
+	// is present in the database (or crashed mid-update).
+	baseRoot := rawdb.ReadSnapshotRoot(diskdb)
+	if baseRoot == (common.Hash{}) {
+		return nil, nil, nil, errors.New("missing or corrupted snapshot")
+	}
+	base := &diskLayer{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  fastcache.New(cache * 1024 * 1024),
+		root:   baseRoot,
+	}
+	// Retrieve the journal, it must exist since even for 0 layer it stores whether
+	// we've already generated the snapshot or are in progress only
+	journal := rawdb.ReadSnapshotJournal(diskdb)
+	if len(journal) == 0 {
+		return nil, nil, nil, errors.New("missing or corrupted snapshot journal")
+	}
+	r := rlp.NewStream(bytes.NewReader(journal), 0)
+
+	// Read the snapshot generation progress for the disk layer
+	var generator journalGenerator
+	if err := r.Decode(&generator); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load snapshot progress marker: %v", err)
+	}
+	// Load all the snapshot diffs from the journal
+	snapshot, err := loadDiffLayer(base, r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Entire snapshot journal loaded, sanity check the head and return
+	// Journal doesn't exist, don't worry if it's not supposed to
+	if head := snapshot.Root(); head != root {
+		return nil, nil, nil, fmt.Errorf("head doesn't match snapshot: have %#x, want %#x", head, root)
+	}
+	return snapshot, base, &generator, nil
+}
+
 // loadDiffLayer reads the next sections of a snapshot journal, reconstructing a new
 // diff and verifying that it can be linked to the requested parent.
 func loadDiffLayer(parent snapshot, r *rlp.Stream) (snapshot, error) {
@@ -268,3 +279,24 @@ func (dl *diffLayer) Journal(buffer *bytes.Buffer) (common.Hash, error) {
 	}
 	return base, nil
 }
+
+// ParseGeneratorStatus decodes the generator progress marker heading a snapshot
+// journal into a human readable status, or returns an empty string if the
+// journal is missing or corrupted.
+func ParseGeneratorStatus(journal []byte) string {
+	if len(journal) == 0 {
+		return ""
+	}
+	var generator journalGenerator
+	if err := rlp.NewStream(bytes.NewReader(journal), 0).Decode(&generator); err != nil {
+		return ""
+	}
+	switch {
+	case generator.Done:
+		return fmt.Sprintf("Done, accounts: %d, slots: %d, storage: %v", generator.Accounts, generator.Slots, common.StorageSize(generator.Storage))
+	case generator.Wiping:
+		return "Wiping"
+	default:
+		return fmt.Sprintf("Generating at %#x, accounts: %d, slots: %d, storage: %v", generator.Marker, generator.Accounts, generator.Slots, common.StorageSize(generator.Storage))
+	}
+}
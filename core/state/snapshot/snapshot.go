@@ -193,6 +193,31 @@ func New(diskdb mfadb.KeyValueStore, triedb *trie.Database, cache int, root comm
 	return snap
 }
 
+// Open loads a pre-existing snapshot tree with the given head root, without
+// modifying the database: a snapshot failing to load is not regenerated, and a
+// snapshot not fully generated is rejected instead of resuming the generation.
+// It is meant for inspecting the snapshot of an offline node.
+func Open(diskdb mfadb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) (*Tree, error) {
+	head, _, generator, err := openSnapshot(diskdb, triedb, cache, root)
+	if err != nil {
+		return nil, err
+	}
+	if !generator.Done {
+		return nil, fmt.Errorf("snapshot generation not complete, marker %#x", generator.Marker)
+	}
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  cache,
+		layers: make(map[common.Hash]snapshot),
+	}
+	for head != nil {
+		snap.layers[head.Root()] = head
+		head = head.Parent()
+	}
+	return snap, nil
+}
+
 // waitBuild blocks until the snapshot finishes rebuilding. This method is meant
 // to  be used by tests to ensure we're testing what we believe we are.
 func (t *Tree) waitBuild() {
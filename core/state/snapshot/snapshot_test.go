@@ -17,6 +17,7 @@
 package snapshot
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -25,6 +26,7 @@ import (
 	"github.com/VictoriaMetrics/fastcache"
 	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/crypto"
 	"github.com/MFAChain/mfachain/rlp"
 )
 
@@ -369,3 +371,41 @@ func TestPostCapBasicDataAccess(t *testing.T) {
 		t.Error("expected error capping the disk layer, got none")
 	}
 }
+
+// Tests that a persisted snapshot can be opened for inspection and verified
+// against its state root, without resuming an interrupted generation.
+func TestOpenSnapshot(t *testing.T) {
+	snaptree, root, _ := newExportTestState(t)
+	if _, err := snaptree.Journal(root); err != nil {
+		t.Fatalf("failed to journal snapshot: %v", err)
+	}
+	db := snaptree.diskdb
+
+	opened, err := Open(db, snaptree.triedb, 16, root)
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	if err := VerifyState(opened, root); err != nil {
+		t.Fatalf("failed to verify snapshot: %v", err)
+	}
+	if _, err := Open(db, snaptree.triedb, 16, common.Hash{0x01}); err == nil {
+		t.Fatalf("opened snapshot with mismatching head")
+	}
+	// Corrupt a storage slot, the storage root must mismatch
+	rawdb.WriteStorageSnapshot(db, crypto.Keccak256Hash([]byte{0}), crypto.Keccak256Hash([]byte{0, 0}), []byte{0x01})
+	if opened, err = Open(db, snaptree.triedb, 16, root); err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	if err := VerifyState(opened, root); err == nil {
+		t.Fatalf("verified corrupted snapshot")
+	}
+	// Interrupted generation must be rejected and not resumed
+	journal, _ := rlp.EncodeToBytes(journalGenerator{Marker: []byte{0x01}})
+	rawdb.WriteSnapshotJournal(db, journal)
+	if _, err := Open(db, snaptree.triedb, 16, root); err == nil {
+		t.Fatalf("opened partially generated snapshot")
+	}
+	if have := rawdb.ReadSnapshotJournal(db); !bytes.Equal(have, journal) {
+		t.Fatalf("snapshot journal modified: have %x, want %x", have, journal)
+	}
+}
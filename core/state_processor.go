@@ -109,14 +109,20 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	}
 	*usedGas += result.UsedGas
 
+	return newReceipt(root, tx, msg, result, *usedGas, statedb, header), err
+}
+
+// newReceipt creates the receipt of a transaction applied to the state, storing
+// the intermediate root (pre-byzantium) and the gas used.
+func newReceipt(root []byte, tx *types.Transaction, msg types.Message, result *ExecutionResult, cumulativeGasUsed uint64, statedb *state.StateDB, header *types.Header) *types.Receipt {
 	// Create a new receipt for the transaction, storing the intermediate root and gas used by the tx
 	// based on the eip phase, we're passing whether the root touch-delete accounts.
-	receipt := types.NewReceipt(root, result.Failed(), *usedGas)
+	receipt := types.NewReceipt(root, result.Failed(), cumulativeGasUsed)
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = result.UsedGas
 	// if the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
-		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
@@ -125,5 +131,5 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	receipt.BlockNumber = header.Number
 	receipt.TransactionIndex = uint(statedb.TxIndex())
 
-	return receipt, err
+	return receipt
 }
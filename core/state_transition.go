@@ -104,7 +104,8 @@ func (result *ExecutionResult) Revert() []byte {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 bool) (uint64, error) {
+// The initcode of contract creations is metered if the chain limits it.
+func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 bool, isInitCodeLimited bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation && isHomestead {
@@ -136,6 +137,14 @@ func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 boo
 			return 0, ErrGasUintOverflow
 		}
 		gas += z * params.TxDataZeroGas
+
+		if contractCreation && isInitCodeLimited {
+			words := (uint64(len(data)) + 31) / 32
+			if (math.MaxUint64-gas)/params.InitCodeWordGas < words {
+				return 0, ErrGasUintOverflow
+			}
+			gas += words * params.InitCodeWordGas
+		}
 	}
 	return gas, nil
 }
@@ -223,6 +232,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	// 4. the purchased gas is enough to cover intrinsic usage
 	// 5. there is no overflow when calculating intrinsic gas
 	// 6. caller has enough balance to cover asset transfer for **topmost** call
+	// 7. the initcode of a contract creation doesn't exceed the chain's limit
 
 	// Check clauses 1-3, buy gas if everything is correct
 	if err := st.preCheck(); err != nil {
@@ -232,10 +242,15 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	sender := vm.AccountRef(msg.From())
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
 	istanbul := st.evm.ChainConfig().IsIstanbul(st.evm.BlockNumber)
+	maxInitCodeSize := st.evm.ChainConfig().InitCodeSizeLimit(st.evm.BlockNumber)
 	contractCreation := msg.To() == nil
 
+	// Check clause 7, as the intrinsic gas meters the initcode
+	if contractCreation && maxInitCodeSize != 0 && uint64(len(st.data)) > maxInitCodeSize {
+		return nil, ErrMaxInitCodeSizeExceeded
+	}
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
-	gas, err := IntrinsicGas(st.data, contractCreation, homestead, istanbul)
+	gas, err := IntrinsicGas(st.data, contractCreation, homestead, istanbul, maxInitCodeSize != 0)
 	if err != nil {
 		return nil, err
 	}
@@ -249,16 +249,18 @@ func (l *txList) Overlaps(tx *types.Transaction) bool {
 // If the new transaction is accepted into the list, the lists' cost and gas
 // thresholds are also potentially updated.
 func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
+	return l.Insert(tx, func(old, tx *types.Transaction) bool {
+		return priceBumped(old, tx, priceBump)
+	})
+}
+
+// Insert is like Add, but leaves the decision whether an already contained
+// transaction with the same nonce may be replaced to the given function.
+func (l *txList) Insert(tx *types.Transaction, replace func(old, tx *types.Transaction) bool) (bool, *types.Transaction) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
-	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		// Have to ensure that the new gas price is higher than the old gas
-		// price as well as checking the percentage threshold to ensure that
-		// this is accurate for low (Wei-level) gas price replacements
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
-			return false, nil
-		}
+	if old != nil && !replace(old, tx) {
+		return false, nil
 	}
 	// Otherwise overwrite the old transaction with the current one
 	l.txs.Put(tx)
@@ -271,6 +273,16 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	return true, old
 }
 
+// priceBumped reports whether tx pays at least priceBump percent more gas price
+// than old.
+func priceBumped(old, tx *types.Transaction, priceBump uint64) bool {
+	threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+	// Have to ensure that the new gas price is higher than the old gas
+	// price as well as checking the percentage threshold to ensure that
+	// this is accurate for low (Wei-level) gas price replacements
+	return old.GasPrice().Cmp(tx.GasPrice()) < 0 && threshold.Cmp(tx.GasPrice()) <= 0
+}
+
 // Forward removes all transactions from the list with a nonce lower than the
 // provided threshold. Every removed transaction is returned for any post-removal
 // maintenance.
@@ -437,7 +449,7 @@ func (l *txPricedList) Removed(count int) {
 
 // Cap finds all the transactions below the given price threshold, drops them
 // from the priced list and returns them for further removal from the entire pool.
-func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transactions {
+func (l *txPricedList) Cap(threshold *big.Int, exempt func(*types.Transaction) bool) types.Transactions {
 	drop := make(types.Transactions, 0, 128) // Remote underpriced transactions to drop
 	save := make(types.Transactions, 0, 64)  // Local underpriced transactions to keep
 
@@ -453,8 +465,8 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			save = append(save, tx)
 			break
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or exempt
+		if exempt(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -468,9 +480,9 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
 // lowest priced transaction currently being tracked.
-func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
-	// Local transactions cannot be underpriced
-	if local.containsTx(tx) {
+func (l *txPricedList) Underpriced(tx *types.Transaction, exempt func(*types.Transaction) bool) bool {
+	// Local and otherwise exempt transactions cannot be underpriced
+	if exempt(tx) {
 		return false
 	}
 	// Discard stale price points if found at the heap start
@@ -494,7 +506,7 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 
 // Discard finds a number of most underpriced transactions, removes them from the
 // priced list and returns them for further removal from the entire pool.
-func (l *txPricedList) Discard(slots int, local *accountSet) types.Transactions {
+func (l *txPricedList) Discard(slots int, exempt func(*types.Transaction) bool) types.Transactions {
 	drop := make(types.Transactions, 0, slots) // Remote underpriced transactions to drop
 	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
 
@@ -505,8 +517,8 @@ func (l *txPricedList) Discard(slots int, local *accountSet) types.Transactions
 			l.stales--
 			continue
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or exempt
+		if exempt(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -0,0 +1,196 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+)
+
+var (
+	// ErrSenderQuotaExceeded is returned if a transaction's sender already has
+	// the maximum number of transactions permitted by the pool policy.
+	ErrSenderQuotaExceeded = errors.New("sender quota exceeded")
+
+	// ErrPriorityUnderpriced is returned if a transaction's gas price is below
+	// the minimum configured for its priority class.
+	ErrPriorityUnderpriced = errors.New("transaction underpriced for priority class")
+)
+
+// TxPoolPolicy customises the admission and eviction rules of the transaction
+// pool. The pool consults its policy with the pool lock held, so implementations
+// must be fast and must not call back into the pool.
+type TxPoolPolicy interface {
+	// ValidateTx runs additional admission checks on a transaction that already
+	// passed the consensus and pricing rules of the pool. The pooled argument is
+	// the number of transactions the sender already has in the pool.
+	ValidateTx(tx *types.Transaction, from common.Address, pooled int, local bool) error
+
+	// Replace reports whether tx may replace old, an already pooled transaction
+	// with the same sender and nonce.
+	Replace(old, tx *types.Transaction) bool
+
+	// AccountSlots returns the number of executable transaction slots
+	// guaranteed to the given account.
+	AccountSlots(addr common.Address) uint64
+
+	// AccountQueue returns the maximum number of non-executable transaction
+	// slots permitted to the given account.
+	AccountQueue(addr common.Address) uint64
+
+	// Exempt reports whether the transactions of the given account are protected
+	// from price and lifetime based eviction, the same way local ones are.
+	Exempt(addr common.Address) bool
+}
+
+// DefaultTxPoolPolicy is the standard policy of the transaction pool, enforcing
+// the price bump and per account limits of the pool configuration.
+type DefaultTxPoolPolicy struct {
+	priceBump    uint64
+	accountSlots uint64
+	accountQueue uint64
+}
+
+// NewDefaultTxPoolPolicy creates the standard policy from the limits of the
+// given pool configuration.
+func NewDefaultTxPoolPolicy(config TxPoolConfig) *DefaultTxPoolPolicy {
+	return &DefaultTxPoolPolicy{
+		priceBump:    config.PriceBump,
+		accountSlots: config.AccountSlots,
+		accountQueue: config.AccountQueue,
+	}
+}
+
+// ValidateTx implements TxPoolPolicy, accepting every transaction.
+func (p *DefaultTxPoolPolicy) ValidateTx(tx *types.Transaction, from common.Address, pooled int, local bool) error {
+	return nil
+}
+
+// Replace implements TxPoolPolicy, requiring the replacement to pay at least
+// the configured price bump over the old transaction.
+func (p *DefaultTxPoolPolicy) Replace(old, tx *types.Transaction) bool {
+	return priceBumped(old, tx, p.priceBump)
+}
+
+// AccountSlots implements TxPoolPolicy, returning the same allowance for every
+// account.
+func (p *DefaultTxPoolPolicy) AccountSlots(addr common.Address) uint64 {
+	return p.accountSlots
+}
+
+// AccountQueue implements TxPoolPolicy, returning the same allowance for every
+// account.
+func (p *DefaultTxPoolPolicy) AccountQueue(addr common.Address) uint64 {
+	return p.accountQueue
+}
+
+// Exempt implements TxPoolPolicy, leaving eviction exemptions to local accounts.
+func (p *DefaultTxPoolPolicy) Exempt(addr common.Address) bool {
+	return false
+}
+
+// ReservedTxPoolPolicy extends another policy with a set of whitelisted senders
+// that are guaranteed extra slots and are never evicted for pricing reasons.
+type ReservedTxPoolPolicy struct {
+	TxPoolPolicy
+
+	senders map[common.Address]struct{}
+	slots   uint64
+}
+
+// NewReservedTxPoolPolicy wraps the given policy, reserving the given number of
+// executable and non-executable slots for each of the whitelisted senders.
+func NewReservedTxPoolPolicy(base TxPoolPolicy, senders []common.Address, slots uint64) *ReservedTxPoolPolicy {
+	policy := &ReservedTxPoolPolicy{
+		TxPoolPolicy: base,
+		senders:      make(map[common.Address]struct{}),
+		slots:        slots,
+	}
+	for _, sender := range senders {
+		policy.senders[sender] = struct{}{}
+	}
+	return policy
+}
+
+// AccountSlots implements TxPoolPolicy, granting whitelisted senders their
+// reserved slots on top of the base allowance.
+func (p *ReservedTxPoolPolicy) AccountSlots(addr common.Address) uint64 {
+	if _, ok := p.senders[addr]; ok {
+		return p.TxPoolPolicy.AccountSlots(addr) + p.slots
+	}
+	return p.TxPoolPolicy.AccountSlots(addr)
+}
+
+// AccountQueue implements TxPoolPolicy, granting whitelisted senders their
+// reserved slots on top of the base allowance.
+func (p *ReservedTxPoolPolicy) AccountQueue(addr common.Address) uint64 {
+	if _, ok := p.senders[addr]; ok {
+		return p.TxPoolPolicy.AccountQueue(addr) + p.slots
+	}
+	return p.TxPoolPolicy.AccountQueue(addr)
+}
+
+// Exempt implements TxPoolPolicy, protecting whitelisted senders from eviction.
+func (p *ReservedTxPoolPolicy) Exempt(addr common.Address) bool {
+	if _, ok := p.senders[addr]; ok {
+		return true
+	}
+	return p.TxPoolPolicy.Exempt(addr)
+}
+
+// QuotaTxPoolPolicy extends another policy with a hard cap on the number of
+// transactions a single remote sender may keep in the pool, and with minimum
+// gas prices for classes of transactions.
+type QuotaTxPoolPolicy struct {
+	TxPoolPolicy
+
+	quota     int                                                  // Maximum pooled transactions per remote sender, 0 for unlimited
+	classify  func(tx *types.Transaction, from common.Address) int // Maps a transaction to its priority class
+	minPrices []*big.Int                                           // Minimum gas price of each priority class
+}
+
+// NewQuotaTxPoolPolicy wraps the given policy, limiting every remote sender to
+// quota pooled transactions. If classify is non-nil, each transaction must pay
+// at least the minimum price of the class it is mapped to; classes outside of
+// the minPrices range are not constrained.
+func NewQuotaTxPoolPolicy(base TxPoolPolicy, quota int, classify func(tx *types.Transaction, from common.Address) int, minPrices []*big.Int) *QuotaTxPoolPolicy {
+	return &QuotaTxPoolPolicy{
+		TxPoolPolicy: base,
+		quota:        quota,
+		classify:     classify,
+		minPrices:    minPrices,
+	}
+}
+
+// ValidateTx implements TxPoolPolicy, enforcing the sender quota and the class
+// price floors before deferring to the base policy.
+func (p *QuotaTxPoolPolicy) ValidateTx(tx *types.Transaction, from common.Address, pooled int, local bool) error {
+	if !local && p.quota > 0 && pooled >= p.quota {
+		return ErrSenderQuotaExceeded
+	}
+	if p.classify != nil {
+		if class := p.classify(tx, from); class >= 0 && class < len(p.minPrices) {
+			if tx.GasPrice().Cmp(p.minPrices[class]) < 0 {
+				return ErrPriorityUnderpriced
+			}
+		}
+	}
+	return p.TxPoolPolicy.ValidateTx(tx, from, pooled, local)
+}
@@ -138,6 +138,10 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	RemoteJournal      string        // Journal of remote transactions to survive node restarts, empty to disable
+	RemoteRejournal    time.Duration // Time interval to regenerate the remote transaction journal
+	RemoteJournalSlots uint64        // Maximum number of remote transactions to persist in the journal
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -147,6 +151,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Policy TxPoolPolicy `toml:"-"` // Admission and eviction policy, nil for the default one
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -155,6 +161,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteRejournal:    10 * time.Minute,
+	RemoteJournalSlots: 5120,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -174,6 +183,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.RemoteRejournal < time.Second {
+		log.Warn("Sanitizing invalid txpool remote journal time", "provided", conf.RemoteRejournal, "updated", time.Second)
+		conf.RemoteRejournal = time.Second
+	}
+	if conf.RemoteJournal != "" && conf.RemoteJournalSlots < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal slots", "provided", conf.RemoteJournalSlots, "updated", DefaultTxPoolConfig.RemoteJournalSlots)
+		conf.RemoteJournalSlots = DefaultTxPoolConfig.RemoteJournalSlots
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -202,6 +219,9 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.Policy == nil {
+		conf.Policy = NewDefaultTxPoolPolicy(conf)
+	}
 	return conf
 }
 
@@ -214,6 +234,7 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 // two states over time as they are received and processed.
 type TxPool struct {
 	config      TxPoolConfig
+	policy      TxPoolPolicy
 	chainconfig *params.ChainConfig
 	chain       blockChain
 	gasPrice    *big.Int
@@ -222,14 +243,17 @@ type TxPool struct {
 	signer      types.Signer
 	mu          sync.RWMutex
 
-	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	istanbul        bool   // Fork indicator whether we are in the istanbul stage.
+	maxInitCodeSize uint64 // Initcode size limit of the next block, zero if unlimited
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals  *accountSet   // Set of local transaction to exempt from eviction rules
+	journal *txJournal    // Journal of local transaction to back up to disk
+	remotes *txJournal    // Journal of remote transactions to back up to disk
+	private *txPrivateSet // Transactions withheld from the network until mined
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -260,6 +284,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	// Create the transaction pool with its initial settings
 	pool := &TxPool{
 		config:          config,
+		policy:          config.Policy,
 		chainconfig:     chainconfig,
 		chain:           chain,
 		signer:          types.NewEIP155Signer(chainconfig.ChainID),
@@ -267,6 +292,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
 		all:             newTxLookup(),
+		private:         newTxPrivateSet(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
 		reqPromoteCh:    make(chan *accountSet),
@@ -298,6 +324,16 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, load from disk, revalidating all the
+	// transactions against the current head
+	if config.RemoteJournal != "" {
+		pool.remotes = newTxJournal(config.RemoteJournal)
+
+		if err := pool.remotes.load(pool.AddRemotesSync); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+		pool.rotateRemotes()
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -319,12 +355,14 @@ func (pool *TxPool) loop() {
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
 		journal = time.NewTicker(pool.config.Rejournal)
+		remotes = time.NewTicker(pool.config.RemoteRejournal)
 		// Track the previous head headers for transaction reorgs
 		head = pool.chain.CurrentBlock()
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
+	defer remotes.Stop()
 
 	for {
 		select {
@@ -356,8 +394,8 @@ func (pool *TxPool) loop() {
 		case <-evict.C:
 			pool.mu.Lock()
 			for addr := range pool.queue {
-				// Skip local transactions from the eviction mechanism
-				if pool.locals.contains(addr) {
+				// Skip local and exempt transactions from the eviction mechanism
+				if pool.exempt(addr) {
 					continue
 				}
 				// Any non-locals old enough should be removed
@@ -378,6 +416,12 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle remote transaction journal rotation
+		case <-remotes.C:
+			if pool.remotes != nil {
+				pool.rotateRemotes()
+			}
 		}
 	}
 }
@@ -394,6 +438,10 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.remotes != nil {
+		pool.rotateRemotes()
+		pool.remotes.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -418,7 +466,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
-	for _, tx := range pool.priced.Cap(price, pool.locals) {
+	for _, tx := range pool.priced.Cap(price, pool.exemptTx) {
 		pool.removeTx(tx.Hash(), false)
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
@@ -511,13 +559,59 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves the non-local transactions of the pool to persist in the
+// remote journal, up to the configured limit. Executable transactions take
+// precedence over the queued ones.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	var (
+		txs   = make(map[common.Address]types.Transactions)
+		slots = pool.config.RemoteJournalSlots
+	)
+	for _, set := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr, list := range set {
+			if pool.locals.contains(addr) {
+				continue
+			}
+			flat := list.Flatten()
+			if uint64(len(flat)) > slots {
+				flat = flat[:slots]
+			}
+			txs[addr] = append(txs[addr], flat...)
+
+			if slots -= uint64(len(flat)); slots == 0 {
+				return txs
+			}
+		}
+	}
+	return txs
+}
+
+// rotateRemotes regenerates the remote transaction journal from the current
+// contents of the pool.
+func (pool *TxPool) rotateRemotes() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if err := pool.remotes.rotate(pool.remote()); err != nil {
+		log.Warn("Failed to rotate remote tx journal", "err", err)
+	}
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
-	// Reject transactions over defined size to prevent DOS attacks
-	if uint64(tx.Size()) > txMaxSize {
+	// Reject transactions over defined size to prevent DOS attacks. Contract
+	// creations may be as large as the initcode limit of the chain allows.
+	maxSize := uint64(txMaxSize)
+	if tx.To() == nil && pool.maxInitCodeSize+txSlotSize > maxSize {
+		maxSize = pool.maxInitCodeSize + txSlotSize
+	}
+	if uint64(tx.Size()) > maxSize {
 		return ErrOversizedData
 	}
+	if tx.To() == nil && pool.maxInitCodeSize != 0 && uint64(len(tx.Data())) > pool.maxInitCodeSize {
+		return ErrMaxInitCodeSizeExceeded
+	}
 	// Transactions can't be negative. This may never happen using RLP decoded
 	// transactions but may occur if you create a transaction using the RPC.
 	if tx.Value().Sign() < 0 {
@@ -547,14 +641,49 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul, pool.maxInitCodeSize != 0)
 	if err != nil {
 		return err
 	}
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
-	return nil
+	// Finally let the pool policy have its say
+	return pool.policy.ValidateTx(tx, from, pool.pooled(from, tx.Nonce()), local)
+}
+
+// pooled returns the number of transactions the given account has in the pool,
+// not counting the one with the given nonce, which would be replaced.
+func (pool *TxPool) pooled(addr common.Address, nonce uint64) int {
+	var count int
+	if list := pool.pending[addr]; list != nil {
+		count += list.Len()
+		if list.txs.Get(nonce) != nil {
+			count--
+		}
+	}
+	if list := pool.queue[addr]; list != nil {
+		count += list.Len()
+		if list.txs.Get(nonce) != nil {
+			count--
+		}
+	}
+	return count
+}
+
+// exempt reports whether the transactions of the given account are protected
+// from eviction, either by being local or by the pool policy.
+func (pool *TxPool) exempt(addr common.Address) bool {
+	return pool.locals.contains(addr) || pool.policy.Exempt(addr)
+}
+
+// exemptTx reports whether the sender of the given transaction is exempt from
+// eviction. If the sender cannot be derived, this method returns false.
+func (pool *TxPool) exemptTx(tx *types.Transaction) bool {
+	if addr, err := types.Sender(pool.signer, tx); err == nil {
+		return pool.exempt(addr)
+	}
+	return false
 }
 
 // add validates a transaction and inserts it into the non-executable queue for later
@@ -579,15 +708,15 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		return false, err
 	}
 	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+	if uint64(pool.all.Count()+len(pool.private.txs)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
-		if !local && pool.priced.Underpriced(tx, pool.locals) {
+		if !local && pool.priced.Underpriced(tx, pool.exemptTx) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxMeter.Mark(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Slots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), pool.locals)
+		drop := pool.priced.Discard(pool.all.Slots()+pool.private.slots-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), pool.exemptTx)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxMeter.Mark(1)
@@ -598,7 +727,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
+		inserted, old := list.Insert(tx, pool.policy.Replace)
 		if !inserted {
 			pendingDiscardMeter.Mark(1)
 			return false, ErrReplaceUnderpriced
@@ -646,7 +775,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
+	inserted, old := pool.queue[from].Insert(tx, pool.policy.Replace)
 	if !inserted {
 		// An older transaction was better, discard this
 		queuedDiscardMeter.Mark(1)
@@ -691,7 +820,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
+	inserted, old := list.Insert(tx, pool.policy.Replace)
 	if !inserted {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
@@ -747,7 +876,8 @@ func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, false, false)
 }
 
-// This is like AddRemotes, but waits for pool reorganization. Tests use this method.
+// This is like AddRemotes, but waits for pool reorganization. Tests and the remote
+// transaction journal use this method.
 func (pool *TxPool) AddRemotesSync(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, false, true)
 }
@@ -1157,9 +1287,13 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	senderCacher.recover(pool.signer, reinject)
 	pool.addTxsLocked(reinject, false)
 
+	// Drop any private transactions that got mined or expired
+	pool.prunePrivate(newHead)
+
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.maxInitCodeSize = pool.chainconfig.InitCodeSizeLimit(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1205,7 +1339,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		// Drop all transactions over the allowed limit
 		var caps types.Transactions
 		if !pool.locals.contains(addr) {
-			caps = list.Cap(int(pool.config.AccountQueue))
+			caps = list.Cap(int(pool.policy.AccountQueue(addr)))
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
@@ -1244,7 +1378,7 @@ func (pool *TxPool) truncatePending() {
 	spammers := prque.New(nil)
 	for addr, list := range pool.pending {
 		// Only evict transactions from high rollers
-		if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
+		if !pool.locals.contains(addr) && uint64(list.Len()) > pool.policy.AccountSlots(addr) {
 			spammers.Push(addr, int64(list.Len()))
 		}
 	}
@@ -1260,60 +1394,59 @@ func (pool *TxPool) truncatePending() {
 			// Calculate the equalization threshold for all current offenders
 			threshold := pool.pending[offender.(common.Address)].Len()
 
-			// Iteratively reduce all offenders until below limit or threshold reached
+			// Iteratively reduce all offenders until below limit or threshold reached,
+			// never dropping an offender below its own allowance
 			for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
-				for i := 0; i < len(offenders)-1; i++ {
-					list := pool.pending[offenders[i]]
-
-					caps := list.Cap(list.Len() - 1)
-					for _, tx := range caps {
-						// Drop the transaction from the global pools too
-						hash := tx.Hash()
-						pool.all.Remove(hash)
-
-						// Update the account nonce to the dropped transaction
-						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
-						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
-					}
-					pool.priced.Removed(len(caps))
-					pendingGauge.Dec(int64(len(caps)))
-					if pool.locals.contains(offenders[i]) {
-						localGauge.Dec(int64(len(caps)))
-					}
-					pending--
+				dropped := pool.dropFairnessExceeding(offenders[:len(offenders)-1], threshold)
+				if dropped == 0 {
+					break
 				}
+				pending -= uint64(dropped)
 			}
 		}
 	}
 
 	// If still above threshold, reduce to limit or min allowance
-	if pending > pool.config.GlobalSlots && len(offenders) > 0 {
-		for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
-			for _, addr := range offenders {
-				list := pool.pending[addr]
-
-				caps := list.Cap(list.Len() - 1)
-				for _, tx := range caps {
-					// Drop the transaction from the global pools too
-					hash := tx.Hash()
-					pool.all.Remove(hash)
-
-					// Update the account nonce to the dropped transaction
-					pool.pendingNonces.setIfLower(addr, tx.Nonce())
-					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
-				}
-				pool.priced.Removed(len(caps))
-				pendingGauge.Dec(int64(len(caps)))
-				if pool.locals.contains(addr) {
-					localGauge.Dec(int64(len(caps)))
-				}
-				pending--
-			}
+	for pending > pool.config.GlobalSlots && len(offenders) > 0 {
+		dropped := pool.dropFairnessExceeding(offenders, 0)
+		if dropped == 0 {
+			break
 		}
+		pending -= uint64(dropped)
 	}
 	pendingRateLimitMeter.Mark(int64(pendingBeforeCap - pending))
 }
 
+// dropFairnessExceeding drops the last pending transaction of each of the given
+// accounts holding more than both the threshold and their own allowance, and
+// returns the number of transactions dropped.
+func (pool *TxPool) dropFairnessExceeding(addrs []common.Address, threshold int) int {
+	var dropped int
+	for _, addr := range addrs {
+		list := pool.pending[addr]
+		if list.Len() <= threshold || uint64(list.Len()) <= pool.policy.AccountSlots(addr) {
+			continue
+		}
+		caps := list.Cap(list.Len() - 1)
+		for _, tx := range caps {
+			// Drop the transaction from the global pools too
+			hash := tx.Hash()
+			pool.all.Remove(hash)
+
+			// Update the account nonce to the dropped transaction
+			pool.pendingNonces.setIfLower(addr, tx.Nonce())
+			log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+		}
+		pool.priced.Removed(len(caps))
+		pendingGauge.Dec(int64(len(caps)))
+		if pool.locals.contains(addr) {
+			localGauge.Dec(int64(len(caps)))
+		}
+		dropped += len(caps)
+	}
+	return dropped
+}
+
 // truncateQueue drops the oldes transactions in the queue if the pool is above the global queue limit.
 func (pool *TxPool) truncateQueue() {
 	queued := uint64(0)
@@ -1327,7 +1460,7 @@ func (pool *TxPool) truncateQueue() {
 	// Sort all accounts with queued transactions by heartbeat
 	addresses := make(addressesByHeartbeat, 0, len(pool.queue))
 	for addr := range pool.queue {
-		if !pool.locals.contains(addr) { // don't drop locals
+		if !pool.exempt(addr) { // don't drop locals or exempt accounts
 			addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 		}
 	}
@@ -1070,6 +1070,48 @@ func TestTransactionAllowedTxSize(t *testing.T) {
 	}
 }
 
+// Tests that contract creations are validated against the initcode limit of the
+// chain, and that their initcode is metered.
+func TestTransactionInitCodeLimit(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.InitCodeLimitBlock = big.NewInt(0)
+	config.MaxInitCodeSize = 1024
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	create := func(nonce uint64, gas uint64, data []byte) *types.Transaction {
+		tx, _ := types.SignTx(types.NewContractCreation(nonce, big.NewInt(0), gas, big.NewInt(1), data), types.HomesteadSigner{}, key)
+		return tx
+	}
+	data := make([]byte, 1025)
+	rand.Read(data)
+
+	if err := pool.addRemoteSync(create(0, 100000, data)); err != ErrMaxInitCodeSizeExceeded {
+		t.Errorf("oversized initcode error mismatch: have %v, want %v", err, ErrMaxInitCodeSizeExceeded)
+	}
+	unmetered, _ := IntrinsicGas(data[:1024], true, true, true, false)
+	if err := pool.addRemoteSync(create(0, unmetered, data[:1024])); err != ErrIntrinsicGas {
+		t.Errorf("unmetered initcode error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	if err := pool.addRemoteSync(create(0, unmetered+32*params.InitCodeWordGas, data[:1024])); err != nil {
+		t.Errorf("failed to add creation at the initcode limit: %v", err)
+	}
+	// Calls aren't subject to the initcode limit
+	tx, _ := types.SignTx(types.NewTransaction(1, common.Address{}, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.addRemoteSync(tx); err != nil {
+		t.Errorf("failed to add call with large data: %v", err)
+	}
+}
+
 // Tests that if transactions start being capped, transactions are also removed from 'all'
 func TestTransactionCapClearsFromAll(t *testing.T) {
 	t.Parallel()
@@ -1651,6 +1693,163 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that the senders whitelisted by a reserved slot policy get extra queue
+// and pending allowance on top of the configured limits, but are still capped
+// once they exceed their reserved slots.
+func TestTransactionPolicyReservedSlots(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	queued, _ := crypto.GenerateKey()
+	pending, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	config := testTxPoolConfig
+	config.GlobalSlots = config.AccountSlots
+	config.Policy = NewReservedTxPoolPolicy(NewDefaultTxPoolPolicy(config), []common.Address{
+		crypto.PubkeyToAddress(queued.PublicKey),
+		crypto.PubkeyToAddress(pending.PublicKey),
+	}, 8)
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	for _, key := range []*ecdsa.PrivateKey{queued, pending, remote} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	// Queue up more transactions than allowed even with the reserved slots
+	var txs types.Transactions
+	for i := uint64(1); i <= config.AccountQueue+16; i++ {
+		txs = append(txs, transaction(i, 100000, queued))
+		txs = append(txs, transaction(i, 100000, remote))
+	}
+	// Push more executable transactions than reserved into an overflowing pool
+	for i := uint64(0); i < config.AccountSlots+16; i++ {
+		txs = append(txs, transaction(i, 100000, pending))
+	}
+	pool.AddRemotesSync(txs)
+
+	if have, want := pool.queue[crypto.PubkeyToAddress(queued.PublicKey)].Len(), int(config.AccountQueue+8); have != want {
+		t.Errorf("reserved queue size mismatch: have %d, want %d", have, want)
+	}
+	if have, want := pool.queue[crypto.PubkeyToAddress(remote.PublicKey)].Len(), int(config.AccountQueue); have != want {
+		t.Errorf("remote queue size mismatch: have %d, want %d", have, want)
+	}
+	if have, want := pool.pending[crypto.PubkeyToAddress(pending.PublicKey)].Len(), int(config.AccountSlots+8); have != want {
+		t.Errorf("reserved pending size mismatch: have %d, want %d", have, want)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pending truncation of an overflowing pool never cuts a reserved
+// sender below its allowance, even when listed among ordinary spammers.
+func TestTransactionPolicyReservedTruncation(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	reserved, _ := crypto.GenerateKey()
+	spammers := []*ecdsa.PrivateKey{}
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		spammers = append(spammers, key)
+	}
+	config := testTxPoolConfig
+	config.AccountSlots = 4
+	config.GlobalSlots = 8
+	config.Policy = NewReservedTxPoolPolicy(NewDefaultTxPoolPolicy(config), []common.Address{crypto.PubkeyToAddress(reserved.PublicKey)}, 8)
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	for _, key := range append(spammers, reserved) {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	// Overflow the pool with the reserved sender exceeding its allowance too
+	var txs types.Transactions
+	for i := uint64(0); i < 16; i++ {
+		txs = append(txs, transaction(i, 100000, reserved))
+	}
+	for _, key := range spammers {
+		for i := uint64(0); i < 10; i++ {
+			txs = append(txs, transaction(i, 100000, key))
+		}
+	}
+	pool.AddRemotesSync(txs)
+
+	if have, want := pool.pending[crypto.PubkeyToAddress(reserved.PublicKey)].Len(), int(config.AccountSlots+8); have != want {
+		t.Errorf("reserved pending size mismatch: have %d, want %d", have, want)
+	}
+	for i, key := range spammers {
+		if have, want := pool.pending[crypto.PubkeyToAddress(key.PublicKey)].Len(), int(config.AccountSlots); have != want {
+			t.Errorf("spammer %d pending size mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a quota policy limits the number of transactions pooled from a
+// single remote sender, still permits replacements and enforces the minimum
+// prices of the priority classes.
+func TestTransactionPolicyQuota(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	// Contract creations are classified as class 0, requiring a higher price
+	classify := func(tx *types.Transaction, from common.Address) int {
+		if tx.To() == nil {
+			return 0
+		}
+		return 1
+	}
+	config := testTxPoolConfig
+	config.Policy = NewQuotaTxPoolPolicy(NewDefaultTxPoolPolicy(config), 2, classify, []*big.Int{big.NewInt(10)})
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add first transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(1, 100000, key)); err != nil {
+		t.Fatalf("failed to add second transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(2, 100000, key)); err != ErrSenderQuotaExceeded {
+		t.Fatalf("quota exceeding transaction error mismatch: have %v, want %v", err, ErrSenderQuotaExceeded)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to replace transaction within quota: %v", err)
+	}
+	// Drop a slot and check the class price floor
+	pool.mu.Lock()
+	pool.removeTx(pool.pending[crypto.PubkeyToAddress(key.PublicKey)].txs.Get(1).Hash(), true)
+	pool.mu.Unlock()
+
+	create, _ := types.SignTx(types.NewContractCreation(1, big.NewInt(0), 100000, big.NewInt(9), nil), types.HomesteadSigner{}, key)
+	if err := pool.addRemoteSync(create); err != ErrPriorityUnderpriced {
+		t.Fatalf("underpriced class transaction error mismatch: have %v, want %v", err, ErrPriorityUnderpriced)
+	}
+	create, _ = types.SignTx(types.NewContractCreation(1, big.NewInt(0), 100000, big.NewInt(10), nil), types.HomesteadSigner{}, key)
+	if err := pool.addRemoteSync(create); err != nil {
+		t.Fatalf("failed to add properly priced class transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -1914,3 +2113,180 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that private transactions are held apart from the pending and queued
+// sets, and that they are dropped once mined.
+func TestTransactionPrivate(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	if err := pool.AddPrivate(transaction(0, 100000, key), 0); err != ErrPrivateTxExpired {
+		t.Fatalf("expired private transaction error mismatch: have %v, want %v", err, ErrPrivateTxExpired)
+	}
+	tx := transaction(0, 100000, key)
+	if err := pool.AddPrivate(tx, 10); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddPrivate(tx, 10); err != ErrAlreadyKnown {
+		t.Fatalf("duplicate private transaction error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if pool.Get(tx.Hash()) != nil {
+		t.Fatalf("private transaction retrievable from the public pool")
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("private transaction pooled publicly: pending %d, queued %d", pending, queued)
+	}
+	if private := pool.Private(); len(private[from]) != 1 || private[from][0].Hash() != tx.Hash() {
+		t.Fatalf("private transaction mismatch: have %v", private)
+	}
+	// Mine the transaction and ensure it's dropped
+	pool.mu.Lock()
+	pool.currentState.SetNonce(from, 1)
+	pool.mu.Unlock()
+
+	if private := pool.Private(); len(private) != 0 {
+		t.Fatalf("mined private transaction still executable: %v", private)
+	}
+	<-pool.requestReset(nil, nil)
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if len(pool.private.txs) != 0 {
+		t.Fatalf("mined private transaction not pruned: %d left", len(pool.private.txs))
+	}
+}
+
+// Tests that remote transactions are persisted in the remote journal if it is
+// enabled, that they are revalidated on load and that the journal is bounded.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.RemoteJournal = journal
+	config.RemoteJournalSlots = 4
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	first, _ := crypto.GenerateKey()
+	second, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(first.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(second.PublicKey), big.NewInt(1000000000))
+
+	// Add three pending transactions from each account and a queued one
+	for _, key := range []*ecdsa.PrivateKey{first, second} {
+		for nonce := uint64(0); nonce < 3; nonce++ {
+			if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(1), key)); err != nil {
+				t.Fatalf("failed to add remote transaction: %v", err)
+			}
+		}
+	}
+	if err := pool.addRemoteSync(pricedTransaction(5, 100000, big.NewInt(1), first)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 6 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 6, 1)
+	}
+	// Terminate the pool, persisting only four of the executable transactions
+	pool.Stop()
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	if pending, queued := pool.Stats(); pending+queued != 4 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 4, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Mine the transactions of the first account and ensure they are not reloaded
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(first.PublicKey), 3)
+	statedb.SetNonce(crypto.PubkeyToAddress(second.PublicKey), 3)
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 0, 0)
+	}
+}
+
+// Tests that private transactions are capped per sender by the executable slot
+// allowance and globally by the slot limits of the pool.
+func TestTransactionPrivateLimits(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.GlobalSlots = 3
+	config.GlobalQueue = 1
+	config.Policy = NewDefaultTxPoolPolicy(config)
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Private transactions are validated as remote ones
+	if err := pool.AddPrivate(pricedTransaction(0, 100000, big.NewInt(0), keys[0]), 10); err != ErrUnderpriced {
+		t.Fatalf("underpriced private transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Fill up the private allowance of a single sender
+	for i := uint64(0); i < config.AccountSlots; i++ {
+		if err := pool.AddPrivate(transaction(i, 100000, keys[0]), 10); err != nil {
+			t.Fatalf("failed to add private transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.AddPrivate(transaction(config.AccountSlots, 100000, keys[0]), 10); err != ErrPrivateTxAccountLimit {
+		t.Fatalf("account limit error mismatch: have %v, want %v", err, ErrPrivateTxAccountLimit)
+	}
+	// Fill up the global private allowance with another sender
+	if err := pool.AddPrivate(transaction(0, 100000, keys[1]), 10); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddPrivate(transaction(1, 100000, keys[1]), 10); err != ErrPrivateTxPoolFull {
+		t.Fatalf("global limit error mismatch: have %v, want %v", err, ErrPrivateTxPoolFull)
+	}
+	// Private transactions take up pool slots, crowding out public ones
+	if err := pool.addRemoteSync(transaction(0, 100000, keys[2])); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(1, 100000, keys[2])); err != ErrUnderpriced {
+		t.Fatalf("overflowing remote transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Pruning the set releases the slots again
+	pool.mu.Lock()
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(keys[0].PublicKey), config.AccountSlots)
+	pool.prunePrivate(blockchain.CurrentBlock().Header())
+	pool.mu.Unlock()
+
+	if err := pool.AddPrivate(transaction(1, 100000, keys[1]), 10); err != nil {
+		t.Fatalf("failed to add private transaction after pruning: %v", err)
+	}
+}
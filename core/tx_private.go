@@ -0,0 +1,170 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/event"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/metrics"
+)
+
+var (
+	// ErrPrivateTxExpired is returned if a private transaction is submitted with a
+	// maximum block number that the chain has already passed.
+	ErrPrivateTxExpired = errors.New("private transaction expired")
+
+	// ErrPrivateTxAccountLimit is returned if the sender of a private transaction
+	// already has as many private transactions pooled as executable slots.
+	ErrPrivateTxAccountLimit = errors.New("private transaction account limit reached")
+
+	// ErrPrivateTxPoolFull is returned if a private transaction would overflow
+	// either the private set or the pool as a whole.
+	ErrPrivateTxPoolFull = errors.New("private transaction pool full")
+)
+
+var privateGauge = metrics.NewRegisteredGauge("txpool/private", nil)
+
+// privateTx is a transaction withheld from the network, along with the last
+// block it may be included in.
+type privateTx struct {
+	tx       *types.Transaction
+	from     common.Address
+	maxBlock uint64
+}
+
+// txPrivateSet tracks the private transactions of the pool. They are kept apart
+// from the pending and queued sets, so that none of the gossip paths (broadcasts,
+// announcements and pooled transaction retrievals) can ever leak them.
+type txPrivateSet struct {
+	txs     map[common.Hash]*privateTx
+	senders map[common.Address]int // Number of private transactions per sender
+	slots   int                    // Number of pool slots taken up by the set
+	feed    event.Feed
+}
+
+// newTxPrivateSet creates an empty private transaction set.
+func newTxPrivateSet() *txPrivateSet {
+	return &txPrivateSet{
+		txs:     make(map[common.Hash]*privateTx),
+		senders: make(map[common.Address]int),
+	}
+}
+
+// add inserts a private transaction into the set.
+func (s *txPrivateSet) add(hash common.Hash, ptx *privateTx) {
+	s.txs[hash] = ptx
+	s.senders[ptx.from]++
+	s.slots += numSlots(ptx.tx)
+}
+
+// remove deletes a private transaction from the set.
+func (s *txPrivateSet) remove(hash common.Hash) {
+	ptx := s.txs[hash]
+	if ptx == nil {
+		return
+	}
+	delete(s.txs, hash)
+	if s.senders[ptx.from]--; s.senders[ptx.from] == 0 {
+		delete(s.senders, ptx.from)
+	}
+	s.slots -= numSlots(ptx.tx)
+}
+
+// AddPrivate validates a transaction and holds it in the pool without ever
+// propagating it to the network, until it is mined or the chain passes the given
+// maximum block number. Private transactions are validated as remote ones and
+// count against the limits of the pool, with each sender allowed at most as many
+// of them as executable slots and the whole set capped at the global slot count.
+func (pool *TxPool) AddPrivate(tx *types.Transaction, maxBlock uint64) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	hash := tx.Hash()
+	if pool.all.Get(hash) != nil || pool.private.txs[hash] != nil {
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	if head := pool.chain.CurrentBlock().NumberU64(); maxBlock <= head {
+		return ErrPrivateTxExpired
+	}
+	if err := pool.validateTx(tx, false); err != nil {
+		invalidTxMeter.Mark(1)
+		return err
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if uint64(pool.private.senders[from]) >= pool.policy.AccountSlots(from) {
+		return ErrPrivateTxAccountLimit
+	}
+	slots := pool.private.slots + numSlots(tx)
+	if uint64(slots) > pool.config.GlobalSlots || uint64(pool.all.Slots()+slots) > pool.config.GlobalSlots+pool.config.GlobalQueue {
+		return ErrPrivateTxPoolFull
+	}
+	pool.private.add(hash, &privateTx{tx: tx, from: from, maxBlock: maxBlock})
+	privateGauge.Inc(1)
+
+	log.Trace("Pooled new private transaction", "hash", hash, "from", from, "to", tx.To(), "maxblock", maxBlock)
+	go pool.private.feed.Send(NewPrivateTxsEvent{Txs: []*types.Transaction{tx}, MaxBlock: maxBlock})
+	return nil
+}
+
+// Private retrieves all private transactions that are still executable against
+// the current head state, grouped by origin account and sorted by nonce.
+func (pool *TxPool) Private() map[common.Address]types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	txs := make(map[common.Address]types.Transactions)
+	for _, ptx := range pool.private.txs {
+		if ptx.tx.Nonce() >= pool.currentState.GetNonce(ptx.from) {
+			txs[ptx.from] = append(txs[ptx.from], ptx.tx)
+		}
+	}
+	for _, list := range txs {
+		sort.Sort(types.TxByNonce(list))
+	}
+	return txs
+}
+
+// SubscribePrivateTxsEvent registers a subscription of NewPrivateTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribePrivateTxsEvent(ch chan<- NewPrivateTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.private.feed.Subscribe(ch))
+}
+
+// prunePrivate drops all private transactions that were included in the chain
+// (or got invalidated by a conflicting nonce) and the ones past their deadline.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) prunePrivate(head *types.Header) {
+	for hash, ptx := range pool.private.txs {
+		switch {
+		case ptx.tx.Nonce() < pool.currentState.GetNonce(ptx.from):
+			log.Trace("Removed mined private transaction", "hash", hash)
+		case ptx.maxBlock <= head.Number.Uint64():
+			log.Trace("Removed expired private transaction", "hash", hash, "maxblock", ptx.maxBlock)
+		default:
+			continue
+		}
+		pool.private.remove(hash)
+		privateGauge.Dec(1)
+	}
+}
@@ -16,6 +16,24 @@
 
 package vm
 
+import (
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// analysisCacheSize is the number of code analyses shared by all executions.
+// An analysis takes an eighth of the size of its code, so the cache holds up
+// to about 12MB with contracts of the maximal size.
+const analysisCacheSize = 4096
+
+var (
+	analysisCache, _ = lru.New(analysisCacheSize)
+
+	analysisCacheHitMeter  = metrics.NewRegisteredMeter("vm/analysis/cache/hit", nil)
+	analysisCacheMissMeter = metrics.NewRegisteredMeter("vm/analysis/cache/miss", nil)
+)
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
@@ -60,3 +78,18 @@ func codeBitmap(code []byte) bitvec {
 	}
 	return bits
 }
+
+// codeAnalysis returns the analysis of the code with the given hash, using the
+// cache shared across transactions and blocks. The returned bitmap must not be
+// modified.
+func codeAnalysis(hash common.Hash, code []byte) bitvec {
+	if cached, ok := analysisCache.Get(hash); ok {
+		analysisCacheHitMeter.Mark(1)
+		return cached.(bitvec)
+	}
+	analysisCacheMissMeter.Mark(1)
+
+	analysis := codeBitmap(code)
+	analysisCache.Add(hash, analysis)
+	return analysis
+}
@@ -17,8 +17,10 @@
 package vm
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/crypto"
 )
 
@@ -73,3 +75,48 @@ func BenchmarkJumpdestHashing_1200k(bench *testing.B) {
 	}
 	bench.StopTimer()
 }
+
+// Tests that the analyses are shared across contracts through the cache.
+func TestAnalysisCache(t *testing.T) {
+	code := []byte{byte(PUSH1), byte(JUMPDEST), byte(JUMPDEST)}
+	hash := crypto.Keccak256Hash(code)
+	analysisCache.Remove(hash)
+
+	for i := 0; i < 2; i++ {
+		contract := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{}), new(big.Int), 0)
+		contract.SetCallCode(&common.Address{}, hash, code)
+
+		if contract.validJumpdest(big.NewInt(1)) {
+			t.Errorf("run %d: push data accepted as jump destination", i)
+		}
+		if !contract.validJumpdest(big.NewInt(2)) {
+			t.Errorf("run %d: jump destination rejected", i)
+		}
+		if _, ok := analysisCache.Peek(hash); !ok {
+			t.Fatalf("run %d: analysis missing from the cache", i)
+		}
+	}
+}
+
+// benchmarkJumpdestCalls measures the validation of a jump in contracts of a
+// maximal size, each call starting with a fresh contract as transactions do.
+func benchmarkJumpdestCalls(bench *testing.B, cached bool) {
+	code := make([]byte, 24576)
+	code[len(code)-1] = byte(JUMPDEST)
+	hash := crypto.Keccak256Hash(code)
+	dest := big.NewInt(int64(len(code) - 1))
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		if !cached {
+			analysisCache.Remove(hash)
+		}
+		contract := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{}), new(big.Int), 0)
+		contract.SetCallCode(&common.Address{}, hash, code)
+		contract.validJumpdest(dest)
+	}
+	bench.StopTimer()
+}
+
+func BenchmarkJumpdestCalls_cached_24k(bench *testing.B)   { benchmarkJumpdestCalls(bench, true) }
+func BenchmarkJumpdestCalls_uncached_24k(bench *testing.B) { benchmarkJumpdestCalls(bench, false) }
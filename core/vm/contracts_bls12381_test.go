@@ -0,0 +1,997 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+// bls12381G1AddTests are the test and benchmark data for the BLS12-381 G1 point addition precompiled contract.
+var bls12381G1AddTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2",
+		expected: "0000000000000000000000000000000019906dbdfd6f71fab654efb243146d63b26dee34bd04928d388ed81402e12db1ebd520c658d088359ddea51e812f38b8" +
+			"0000000000000000000000000000000005ddc87dbc656167a76bee40e28432da6ec11d76e86eba34a64c926b87f25df2a9d23cabef114fb4008812c02d8ce645",
+		name: "g1_add_g1_p",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"0000000000000000000000000000000004c463fc267100d5a44a67f1a9e80f61bb80455566526359b674026c9013c4b8188edb746e4b4afbdcfeadc853d86e26" +
+			"000000000000000000000000000000000b843bb4e933a82041f2567e7426a48e37f1f67d626eed560965d5cd7f5ff0b918354a5b1f3778d9a64027f22645fe34",
+		expected: "000000000000000000000000000000000afa416f0bef00c9c8d418dacd00113968394e5afe55a32547d4649035e5a349ad0ca113fc89fcd27bb44cf899b184cf" +
+			"0000000000000000000000000000000010a19928b62010da9dc045fffa1d4a53c4388c636bf0ddb3b1e50701681587e8782f9ca43d31758aea09161538e03729",
+		name: "g1_add_p_q",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2",
+		expected: "000000000000000000000000000000000b26b44e2019718ab536ce4230f8a7891539049ade0ae0edff834655a1f85db9ca4f47ca06ed534b98966361a275452c" +
+			"0000000000000000000000000000000002d28a862121590ad66de77692806d368d76f45f0836eebf4c6a070e0342ce93ef2cbc9ffce37c1f69308a201a3fab07",
+		name: "g1_add_p_p",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2",
+		name: "g1_add_p_infinity",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"00000000000000000000000000000000194580e2f3cd015c91a7e6566c6f6be556b767ae6b3eb2ee45b7f45941a6a5f894e24e43493f5ef205c7b6bc0c8368c9",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g1_add_p_neg_p",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g1_add_infinity_infinity",
+	},
+}
+
+// bls12381G1MulTests are the test and benchmark data for the BLS12-381 G1 point
+// scalar multiplication precompiled contract.
+var bls12381G1MulTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e" +
+			"00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+		name: "g1_mul_g1_2",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"34f1ec5d6216cb5921ce6c540e940dea231f98904418b0455513068914d15c59",
+		expected: "000000000000000000000000000000000e55c50bb9e20ee18e98df081fd019bb0bc8bf6e4853891b628186b9c2ce337b13cbe616b76964407aa8493d91661193" +
+			"0000000000000000000000000000000004e9b3279e182fdbf87b9b6c41e114132f9ef08dff54e7bdab27a7ea2e5167957ce31c27a15de28435a7ca87b975f173",
+		name: "g1_mul_p_random",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g1_mul_p_0",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"0000000000000000000000000000000000000000000000000000000000000001",
+		expected: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2",
+		name: "g1_mul_p_1",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g1_mul_p_order",
+	},
+	{
+		input: "0000000000000000000000000000000003af3dad64d1dbffc4322c0547d4695a829f41fb2996ec37bdaeabd855fb5a466c0585c5b886be927f775f4c045deb09" +
+			"0000000000000000000000000000000000bb910745b2e53db973c15fd6dc40f20dbfe3d688465fd12178de47b50a502b89c9b1bb6814a10db4374943f37c41e2" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		expected: "00000000000000000000000000000000090ad31f4e1fb3fa78d2f8dcd4476aee8d278d0d970ef706880b3b3b076bf7ce5b3f2ac0d6b61ff918fe95fb8465dd77" +
+			"0000000000000000000000000000000004cabae2cf5b556bf2dec4013c1cb143d27f3d8c883500dff40b288574d0b4ae27be7929139aabb4c8d62373ebedbc6a",
+		name: "g1_mul_p_max",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"34f1ec5d6216cb5921ce6c540e940dea231f98904418b0455513068914d15c59",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g1_mul_infinity",
+	},
+}
+
+// bls12381G1MultiExpTests are the test and benchmark data for the BLS12-381 G1 multi
+// exponentiation precompiled contract.
+var bls12381G1MultiExpTests = []precompiledTest{
+	{
+		input: "000000000000000000000000000000000c2ede540cc758843889278a2dcaf8385d6cf3566951b2e17ff3468f3256caa1bda7e332dbe92d4da34e3b5170a8595e" +
+			"000000000000000000000000000000000fde15894377740c0948959fce3214ebf3295afe4c81efeb337f0418d4b1ab3ba8f1d95683896fcf34bfeb65ea78a246" +
+			"9f2cf505d5415b22461d9d9d9233c19032657b121d87b4ca1633ba18c32e85bc",
+		expected: "0000000000000000000000000000000014414785f38a6b33d1de6e78686c564b396eac4387c97ea7513cc17c7b5b123f0b18c820b5f00a5135cf660f83da5542" +
+			"0000000000000000000000000000000019c19d442ff8231cd0c8b2a061c85099f40bfabedea2ef5009a0aa2a2ebfa7817569c8e68da5c31bd4ba9e75c25a9c52",
+		name: "g1_multiexp_1",
+	},
+	{
+		input: "00000000000000000000000000000000007d1369a7e371f1a1489df8bd9ee869a3be0976ef1b8fb4fa3979597f15ec9e4c3aeca06cc58c75c6211cfd9754bcac" +
+			"000000000000000000000000000000000f9cf56f19269436e2532b3cb23bdff75a8cb2eaca1386db94e5e1b3c7573744f3453fec3e9968d8b6c0536a70645956" +
+			"f5a22737532e4ebac13353c6b06b0cb6c92aa28b53435ff6a87e69270ab6a433" +
+			"0000000000000000000000000000000010c0b6d0eddbd54bb63150817c0c7f1213848d40280958f23448980fae306c24150f637cce07cc50d188cbbcfc746ade" +
+			"0000000000000000000000000000000010af8f2cc4dc016502f2ed23d4176f3fd04ec16607cfb9d48b867bfafc3a76dd6e87bd3dfeda02034a18b8069ae49161" +
+			"255cc5b696c5513b7a02aa82c538b8719a20b8fe7030232b134f6c08754b879c",
+		expected: "000000000000000000000000000000000297538d33eb8839f131367b44f40f726cc3741f26d2e0c0a989fd51dbee2951450651417eb876b60109bc256c87551a" +
+			"0000000000000000000000000000000011d2b2db3f5395dac0b04e5ede9d7567de68d062eeea6a71836aa039a63f79cc1dacdc78abc0c771d1fe87a875dc855b",
+		name: "g1_multiexp_2",
+	},
+	{
+		input: "0000000000000000000000000000000016e07eaf3e4e293abdd0c6d8394a0a52124e05b7a228038e8e91534319e16847a4dbf12e2a498d5dad2ecf099beaf642" +
+			"000000000000000000000000000000000aa7d343d7bd00de5be295873c946d466e7162d2d1ea6e076137162e24d14809579fbd6b72b655a20d583ff0e49c52e1" +
+			"26f8757a4f05082435c6de28b60aa53e1f12798e578e79f7829ea9e56632b9d9" +
+			"000000000000000000000000000000000c13ab4930f83667d7966a39a845c7a18d0dda415bca07c743b542a994362bea627f4c618166510b0459ad6cf6a0860c" +
+			"00000000000000000000000000000000099af3d3ba37c0467af0c518682c30916b8dce81724099623a9934344851d3bd9147e236ccc23f48ab7fcfcadcaecb72" +
+			"8fd4e34e3bcff9d7b7ccac6083dccf10c86d7795e26b34e5d3fd63fd555ee1c2" +
+			"00000000000000000000000000000000113c47834d6494e025043a76327d2a8d48dfaa862757140c39d47ebe2ffe3d11d7dec057fde79910c043898e55941ad8" +
+			"0000000000000000000000000000000005b579b8414009781b0cd91a836461483b1ccd259fdb5b3635c654ad5a652b0ad7cf0f14dbe7a7e829abc34acdda111c" +
+			"ce1ad16d74cd980b6699e1761a8fc7497d7df0c057b6201670ef02276b2d9fe6" +
+			"000000000000000000000000000000000cf114d8556d41dbd0ff2c8a0ed3693b39b8bcfca69bfab3fabac08a810c0400d99a8cad7e4bd47971863828544c4ba9" +
+			"0000000000000000000000000000000019b17c60487795f2832b42f2ad5ec33ffbb0215c2eb425738c317969b441ea8982c9ea5e5e099bb259a25981059ee4f2" +
+			"690824a2ed9dfcd0e86d097b0aa92aa0c2be5b6d9f986fff1a83b5d934bc3953" +
+			"00000000000000000000000000000000032ce569c25addf555f4620f86b8e66ff717ebdfa2d2de339d1a83e66efc37e41be58b3ee5161b3f586dcc4e3f27a179" +
+			"0000000000000000000000000000000004be0a03896fcbd0f90d0a288ff53b7c334859c67ecadc6fdad524e0b24c7ce0f6421d9ef29de295cf192e918b713c80" +
+			"3c6c4fa8b2fa7c42dd4a20b1871c1abd9edc1b24750c23dc39bb8c3e146f3f8e",
+		expected: "0000000000000000000000000000000014edf6e9c6d6c162252382d1b1e35c1589f90f63aa26aeb85aeccc29cdaa9910328bad740e9fa0066dc0e3133ed876d8" +
+			"0000000000000000000000000000000007cd7d3ed8471fd5e28ef00e48f1a28a6ddf6072c7ebdefb7447152a368b2b2afc0a60661a4f0a8194874923d633f280",
+		name: "g1_multiexp_5",
+	},
+	{
+		input: "000000000000000000000000000000000af67e4d214d78abbd8d1cdc8206f9255b104987f745511e8473735123eea8dca1ce05f091206b18b5abecbf5eb1e355" +
+			"00000000000000000000000000000000076dc7b18b4b80ca17c9e89dd95467e1b8e852fcfca674c75f11e41c1660e564ea802f7192246d943d38fb3f54d6562b" +
+			"c313240f46fb1772b90477627c1dff8c96f883a791f039e14014e5bdb1bd5c3c" +
+			"00000000000000000000000000000000159a3341d7a77e7e82ba1fde78249b47003cecfe6d21eb9a5d89322b5c328722e1063afa0577bb717ed2f3d4c34fbd09" +
+			"0000000000000000000000000000000009122b0561bcf49956385c969c78aad9b4b35a1a84eb358bb9637dca4dddf668bcde39a23fbaa5c976d4c07406ab2a6b" +
+			"a555d2a53cfeaef8ac858601d2223ed3ca85bbfedd8e10d60bf8941f7be54664" +
+			"0000000000000000000000000000000016eb7c2d44110ec94c4851a453e533a2b66213554aee3209591515bf01f2d6f2c8b20c57858d76d380a99c5111eb1df3" +
+			"0000000000000000000000000000000005890a9248df5abc894921be61e849bf6e65257d62cec27388d46502e311c1005c22290a942423f499428fb43a50eeca" +
+			"0306f1a1ed2f64402c17dac461405b2cd588238b922af7f8c324625f5038342b" +
+			"000000000000000000000000000000000ae65e3a1d5ef17866c54aa485843cb46ee2132b1253547dc54c2358fc7b80f69817d799cf0489b8a8bc1ec1fbd837ec" +
+			"000000000000000000000000000000000d7cffca4a1e170c546d2d804d5cbdfa957937c93550b5d9516aefc2d5557971eaa79e5d73a34afc33d80462094b2163" +
+			"c20c276cbd5c6d851a8ac694219feb428c83e2ebc5cb529c935ee30465eb0fcc" +
+			"000000000000000000000000000000000d78fe6c4ac6e56794b17e22d229ef25fd0c7acfcbe6ce7c2d0fcbae23ec3798f833c4b524f4494e0403f81459c8837b" +
+			"0000000000000000000000000000000017c21f75aa49303c30497ee796ec08e1b5ed4758bf640e1c44e75d9ce6f3996e8c0f5b7cfb6dd3cbc673f0e68b943ab4" +
+			"c715fdf12f24924f74c068d977b4913a4e8cfdb4ec37cf690c9ce89815418b11" +
+			"000000000000000000000000000000000940d2195a2706697d4b1666e2cbb6a231910054f0f7e2803196ca74569d9973f7ff3c2fea3745e8c6b82b5e87ce59c7" +
+			"000000000000000000000000000000000a140ff35765f8b692092ee9d51575c71f80b734dbb839e421d71d5a373005968e6f92f598d6605abb18a6d77e842978" +
+			"fd626bb65d714872b908e604b717196f7234cb22097979db1d6f87bf7a8e0618" +
+			"00000000000000000000000000000000178207dc0994eb071c0df26b3594c57fb8bdab5657cb7d57cddb38e97b9c2d9b697d373eafa9f2c9edb3c0d2ea023886" +
+			"000000000000000000000000000000000fb7d906d5a1cf5df60c166a427e8e1bbeb3cf929c256e1aeab30369b3468eaef6cf4df33ced5b30413904321de97329" +
+			"d84162fd56c880da35b2cdbe37d182390c32082b1483460ac9ad75a96b3e5b0a" +
+			"000000000000000000000000000000000dcdb440f8862bff272705aa1740af9d5c0e47d3530f0739a9a16ad6bd64d804014a2e193fb3328ac4b2948c83ad5211" +
+			"0000000000000000000000000000000001c0e8d0db51bea2fa127a7203ef016a354fe8ddef6604ad0ef4f96e2aa976b40e78a79c8d737003822fc8a72bc4fb1e" +
+			"b020368e28a85a4b44434a443bfbb39f8ecfcf76ddbfc5748f08cdbf1538e2e8" +
+			"000000000000000000000000000000000a998855e9dca89ee61407ef295e7e44d90854b799fe68d209dbb7a21f0263dc297b27493da8d13c67f92153628f4e7a" +
+			"00000000000000000000000000000000082bcef436418e15d778846334bc5341741ecefcbf6f482ea1117b8e79267fe936db1d78588799ab9f8b5a80aa129113" +
+			"e22d02d8f88a97cfaa41e64db65b7c7319739492f6b04bd92c04b9d92f1414e2" +
+			"0000000000000000000000000000000014d914620bafc3fe2f542405448ed8fe4b4f0adf79abee4e4d59880698fe65e277d42591cedde708494cc86515106b13" +
+			"0000000000000000000000000000000011dc84e27221e159de80f9074a1ef91acd1d7f916e2ef9e09b3df96b8548928dc5bd7548e4b53d84813f3d76cdabb0b7" +
+			"300e7b7a43b250b7ee62de694486e08b1d7ce762976dabe8014fdb8ff0d12d8d" +
+			"0000000000000000000000000000000016b496a4287b0b22b5e80e5368a61ffc1cc142a5671e98d400e5833c5612581ec2286e49546bb4fc677d1f02f094eb35" +
+			"000000000000000000000000000000000d1a8d802357276ba98ccbf02da6cd8516ff9808c021687a08bc59ef09e449b99ab9f42cc156c3d7d217fd40a044a65e" +
+			"691808f371a8c00c02248766f5c190a86254486e03ca8b2e67218c0d27f0ea45" +
+			"0000000000000000000000000000000019d701c6d3c13c799ffb595f0a32cb178cedc7012bcf7763566a989dbc9c05df0ee1fa03686c1a08ae9985f77d7d0902" +
+			"00000000000000000000000000000000018526363f0d54d8d19b0a931ef11ce0950a8d221b6b219f27a725dfd60089736896805be03fd583b3af1a6f21d24aa5" +
+			"89d81e39a9579336a0f511f7c33a1d5b890f846b130cd85be936823c1bccbf83" +
+			"0000000000000000000000000000000014fee07aa0013f1a5bc8a2428343ae901dbc6d703550a36c0869b0035e1e37ae02a448b62409579bc6e09f1d57ac1d47" +
+			"00000000000000000000000000000000022bd6fa7e544bf0dab8b37c327cf4d283c541402b24f2a26e5852ed6f19150fb2e3b39742f4cabd21d1e437f596d699" +
+			"9c15189209db5933dad6a8008fc0e133ae4c54f20adab11d987e4850aa981bd2" +
+			"000000000000000000000000000000001895264b7ab9cbd284eb9bd1f9e2fa64dc1ff78d91b25627af391adb757b22333c1cd954005cb672398c3f4f3e35ef19" +
+			"000000000000000000000000000000000f363e764cbc26b90c93b5ea54602b1a8e91d9cfff9712877eb3caa1d76c557d692db1170c82951be344c0a403cb0236" +
+			"2f0af0a08a62aae0aa70449400f826090240fbfd84af8226e7628a3601917cf6" +
+			"000000000000000000000000000000000e9d8839e76bf89a5add105ff6242538b6a82d37f65a273e983f672a82a2247f951021a0959e558fbbfe839612a56e7b" +
+			"0000000000000000000000000000000000c797b7d34f4b9d049b0f544ba83aaced694f9a68725776b040ed94ba5cee3cef5832fd0d8ff5aab7ea1613c3f127e8" +
+			"0136ef0792ed764c7e342c4561924a36fa437d5e7abae63d852b878456be6592" +
+			"0000000000000000000000000000000018f6ea51fe6c10fed35ba2e9d793e3b100d564ae36387c5bb31121aed785e27912dacb5f6775a04e8194d2ef5883130b" +
+			"0000000000000000000000000000000005a2cab650be9b03bef49eed96e54eac99da3497ae2de90e063d4b11674af51241f8f90e65490efe109dd4a7996ad8b5" +
+			"88a250d258ec35366f0fceb0fc06af5e6c0a469b9183e68e09e09b36872d266d" +
+			"0000000000000000000000000000000012df6465b515c9ea83031cfb5f5f0ff150709f699a2792e4f233ac600b3bba84c2e1697329f129447dae4bea8e665bdb" +
+			"000000000000000000000000000000000908f504940914dc851909ceb66837fe7299fb3f6b241b5f1459a41ad5970be1fd3a04c9829be3671ff70b0e02818383" +
+			"1142bd96d8d98fae7c2238369fb0bee9022aadb56e166c7d20a4231898b1080b" +
+			"000000000000000000000000000000001404c2de9c7d9a62bd204f6d7a0168fb3acbead31188ffff13ec14e5a288709f9968daa4705cee6947f76328d9473de7" +
+			"0000000000000000000000000000000008710a1385b615dace8fdbbab86a21311379fe4d40dcdf067759031265764fdeeae6afaedbfa66417c7990be81410363" +
+			"28f4336a09ef4136592e8978ec04cdf04924129f84e37a514a2566064d1d4861" +
+			"00000000000000000000000000000000081478811d92377eb866774a7973612671b4b2bf92afb696610650e5e870a9a3f862595496b54a8515a72ed796b63b07" +
+			"0000000000000000000000000000000011a736b7a7e013042a5a5c4eaff21a8cb45dadc9608a09d43b470481a49019273104f27c98c7d970c7a6dd369b17ee2c" +
+			"055e50b57f8cf5837b95fc444e4b2bdffaab15d91634db7d74bf1c27993e8e8c" +
+			"00000000000000000000000000000000081b9d43c9f134408deac21d389d263214f90ce49ae5b47c2f456c70cdbe79ff80b3f519e0832ffbc0805153deaf91cb" +
+			"00000000000000000000000000000000109951c7b48429aff6f561858ad914d5400de2ed238c076a92949a539216d72f07ddfe4febdca92d2d077436be592413" +
+			"1706f3174c070dd3aa1f5a8c4c967209b56b4aea142ae99aaf6652848e874480" +
+			"0000000000000000000000000000000009c91794fbaf356d7bf90af9a01edfbbd96eed8a9f56742ab99ddcdbca3de8bcecb547e4d0684686e20a2b68b8a68253" +
+			"000000000000000000000000000000000116090d2a6544dd51fbedfef952de2a8b9839dba6c7b0d517dec55928041f8e10c352ccb586a0e6867e9c6801452dd2" +
+			"4534ccb8f323cd2851e29634b3658748a3ce6f7839c7c415f8fd7cb92620bfc9" +
+			"00000000000000000000000000000000107e3971e9723a7d63ed2fa76b2b58880ac883cc4d035c08544ab500432372833a60bfda95845c4e3a1499fafe01717f" +
+			"000000000000000000000000000000000fe3ec074c2e269772a1a956bca4414d27a8892e828ec5dc1ba3e3ebfd9125aad2571129724c0b23707e9810dd473897" +
+			"bd8d8828a2c6de9e372c3835e3766b153edad6921074765fe3732805487f5d3d" +
+			"000000000000000000000000000000000d49756acb3b5e766b8ec85e3e3742414854651b4a019482a9c37a1512fda9492bbd987c83c774107b2b85ad844812e8" +
+			"0000000000000000000000000000000012416debe58907bf7448138d0a0a2b01b23d405f7b206d6c458c29645dc28ade6b2357c76a01165b31b1e55a3948fdf7" +
+			"864c77d9be5d791328f0908ddafdd4984fb7aa46b0ae0582b6b0802d1104cdb2" +
+			"0000000000000000000000000000000019ed647dcac75eb6447c523eafe27495f63102520e7c17f6a9e68f6feb8b4b6fab2944416102ed7aace16c7c5c8c39bc" +
+			"000000000000000000000000000000000715b7e1702b7f5c8b589204240694eccbaaefbc8629ff95997d3d595ce1ce626d8deb168fcd23904c58dd799fd82a0e" +
+			"217865c7d64d8b1d4108e040bf2680bf16d792cc69b1df91e559cbc360709bf2" +
+			"0000000000000000000000000000000017fd1bcf47345aa17c924288d8ac9a294dda98f0293d23ca089566f54ff79c528c8eb60114c2e45baea805ef8bc224cd" +
+			"000000000000000000000000000000001872d0542c03c9a2e604dbaa477df71af50538f4612ed53ce90336f9ee6bf825ac38e336376669ea76a5aa6035227249" +
+			"e40c3ec9d98b34548155acc04d03091f33022eb9945494c5238edc6d16f99f78" +
+			"00000000000000000000000000000000015444d48b71879da71d6d0bb688a577dd63c59228601f8f51621cc21baf374b9cef44d6344f77218a22d123c6178fc9" +
+			"000000000000000000000000000000000c714e4bdee0aa92b2d507d68219a46af316fbc10d864d065d1685c10bf09680c1f25524b4ade3bdc89d061592d62880" +
+			"ef83825541777227d785fab9038682276d552646a518958d587178b16c7e2d5c" +
+			"0000000000000000000000000000000013507ec1d8eb76dae41cf71cac47a9b4d3bff47ad90577728e0909f89c35819dc2713d2ed62fa21ac02ab4cbba6bb2e5" +
+			"0000000000000000000000000000000002c263cf7cd5f9229b166799fd8c2a402eef488e62d0e67ce88807f94aac6e26e90e9c4523ba84495c3bd19ea277b1e9" +
+			"3be679c5cd9c4cab7609a0b027c6c6d0f590b55fd2897c449d57e37e2a8a5ebe" +
+			"00000000000000000000000000000000174d7bf6a92c65f86053568cb8f117efd07300925298dcb1d8fc6e7fdc1243f359c3005bf3f92074d1e96e9b85d94125" +
+			"000000000000000000000000000000000947067259d46f47ab5c68c69fe8a5aca6ba27b6dd6a9e7b526eb43d434d5eb490f381b77ca7ee1fd8e2df9f45ceb2e6" +
+			"befce1edf1bd34d2f8046f93cffb9372d6c1b3fb72a88b315be9a5d62ef96092" +
+			"00000000000000000000000000000000138988690e31ebe7d5ea43957a10021d8034655192628e390c31aed06c41b1d0c432ed9b9d624caa5ab9f1cf24465398" +
+			"000000000000000000000000000000000d0bf908bb60d82d8d1dd69e5526a59bac5e3a80e7a07ea2691f9b6e76916cee49fddbcb03f322b21feaf2177d6ee7ed" +
+			"e0a94d77d030f1cf0f9b9e878a6095bf7ec55ecbb835a0624afd5ff79e38bff9" +
+			"000000000000000000000000000000001466ccc9a4b990880abca296852a9aceca593f564891c1d8db9eb5f9ff57c1df34b141e0604b6c99090ae381c7e99f27" +
+			"0000000000000000000000000000000018202567335da453016c49b4fb6af71237808c4238f7b0f1d6d9969381481f2e55e2dbe082839d974638d09615f427ae" +
+			"b946fb9f95b203a03745f5d70b1099c07ff80ddbef458aebdcd274390be63b61" +
+			"000000000000000000000000000000000ad4047affc1b735b444b0f4996956aab4bb389b9345f81b2fbcf30e0b4b9c70dbe73d8df351b3d66ea8082a837d31e6" +
+			"000000000000000000000000000000000bfddf6185c5e353b6263e0b50470ac25f42dadd0616b065442eb3010a18caf89656c62d459f1091f89d7b69ec8895f3" +
+			"ef852261bb0195b36835ec81e56e1831dc85898399369e76ab22916fad520e88" +
+			"000000000000000000000000000000000667c1da10b6229fb070a96ffaf7cad1da7608817f0f7ddc829802d5fe62fa79517f8fb87be0fba281ce09dd6ca0aebf" +
+			"0000000000000000000000000000000014b455cdc319d607cb515164d54f3f85060bed65ada0236ff840983ba9999f844a0f7f8e3ad9f0501d029eaf23da2b76" +
+			"0ebe443fdea3bd61b8c370f1f6f768fffa1b6fc887592284f2b2ad17bd8662e7" +
+			"00000000000000000000000000000000025df4e0c51caa8fe8e99a722f9a37efcf866c295e63dda3e63f21e98e747ca47ad14220775d2b813a7bbca8f825d415" +
+			"00000000000000000000000000000000103f28c41c3bdaae27eb0a071c89035626af9d32a93e8bfc4283e14b197c6237599b1ef1880b1f33cb7d34eea325c5c0" +
+			"290d246f87733a9e8c219be206369bcf30edb10a6ff0bbb8bca27ca909a5965c" +
+			"00000000000000000000000000000000113e351ad0b8e2839348b12b7dc3769a51d22ae75f4657b9fd17b8b43da3659bbc3c16140d6bf8675b9fc5e3589bdd20" +
+			"000000000000000000000000000000000ef84cbb75b12d376604b87c3d7f9c44c76e30a497578fb802898c1ba98443b328117e8e953557acb9baacd6a67aa405" +
+			"daf58b7824e034ef0a02188b8426b76c5213f91fe90d0946a79b9f942e3e2c91" +
+			"0000000000000000000000000000000009c697949850285222e5318ef9e10e9c30386201727aeb289fe432482372cf90fe42eb89d7ad4bcf031f8f343f3474ec" +
+			"0000000000000000000000000000000002dc4a140feae8ac1afa8ad6adfb40c83112ba4960c8b3f25c7e7e4cd3204babfe0d9377d3629878853958e9d916379d" +
+			"9ad9a16b3da53ff168720971ae0cc52a423d9952bc4357c493f284313ed9d686" +
+			"000000000000000000000000000000000d518affd0e20e9a73f732a18532857133a9f21d40a5bb270a1ab3b2bb8f2d1049ca0d8f4b93c6bc28861b07cd7bd94f" +
+			"0000000000000000000000000000000006a353c0e087ddc8c19304d3fd70092d581e533972f1896c2f6b395f339579e78b69e5434eed0e7ab31c287628b7dae0" +
+			"a5c28f5270164298bb570ef4b3aa42a9ce0e36987db31d5b0b97926645df5c46" +
+			"000000000000000000000000000000000dff8ba54d8b0924fd732ab8ce436072273e91eb2a28d6bbfea5ce1dcf8534a0dca8e3b7b95873b98fd8811caa8b81db" +
+			"00000000000000000000000000000000040cf484050d2195436fed087ecd0c383acd543fae331cf59f39a415e2ba6234c6a218d3ad47af3e3d548aa295faa9f4" +
+			"444d2d5eb1c3367be115d4f5d0a1a40dfdfdab26f3f44e54703441b27a876268" +
+			"0000000000000000000000000000000006abfcc53925a3836fee2fa23af6fbfc9c1a6f95c263459dcc83d1296726ff1638997e96d3f808b53462a8ccac5373a3" +
+			"000000000000000000000000000000000ea5c3778dac9d0985038e9a7f1a870633d3098ce1b48f4006eb82b4cf8e0c4ee39f1972189273657c343aa5f7f4fcbe" +
+			"a9b0f247afb3a6bf5eae920ac4d2572cd0eb905856935a7c17dd7344d4464408" +
+			"000000000000000000000000000000000b137ac3e2c1f047aa8550e78c371711432e9a3c08cb69a9a699b805d7cae4d7b34dc0a0ea5a28594ce03af0da6f659f" +
+			"000000000000000000000000000000001029781dae3c722390af0c9c910a196e0f95901f4fd1a06f40720961e7041f69898837dc0d40326c6bcc00912623f9f7" +
+			"b209a5c522ba51e59ec46e0e91532df5fa4fe3de2d6aa6ac2cd4f3621ccacdb3" +
+			"0000000000000000000000000000000004c19a7f00243f29e8d4664c9c91ddef87b940eb31f4ab08194396564d574317f508c619f02d99f99f40e7472f1ce22d" +
+			"0000000000000000000000000000000010d3b66940231e0d6aec3cc7297e80d143a02a3ba400704c1e473e89f61b86704c2b3f2c0323113bd3a226e815ef6fcb" +
+			"bc8287042503b1f6f152f1b223f5ceb5ab5d5dadda793c5e3b39f51328ae463f",
+		expected: "000000000000000000000000000000000108ba472ff35316d11cf66b955fcf7a25982c7758cd1a7dc016db3ae4955c76654e96b9700a9d86244231859a01983c" +
+			"0000000000000000000000000000000005f19f59c32d31942d5255dfbb2708d9f2169a455f6420f300a131c8e05797062e2194fa66365d00d2d92eb08382fd81",
+		name: "g1_multiexp_40",
+	},
+}
+
+// bls12381G2AddTests are the test and benchmark data for the BLS12-381 G2 point addition precompiled contract.
+var bls12381G2AddTests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218",
+		expected: "00000000000000000000000000000000099426f7179b7e819c207ff72379b8fbf59d6e8c70a3b69f0111c82376617e545340496a0be4fef98529ba0b90bb9110" +
+			"00000000000000000000000000000000130f5c956ff6777462048347587518edb144f184237ad297524060133073d16a0e25e9a1a8f2f0f97a825d395871c3e5" +
+			"000000000000000000000000000000000b550d20f9bacb3bea4eb6fb5fdbdf678c4261b64e744ba1b793c93faea63364b211f78124bf152df602999b568876b8" +
+			"00000000000000000000000000000000116473b0564f087631b85666af035bd3502eb94d5554102aabc8246666ae0a88d8d385241c78112c47e8a8b8ab6818ce",
+		name: "g2_add_g2_p",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"000000000000000000000000000000000998acf8045bac41f81cff99b7d316188bb9a89b7d4fbed03744369aab290322ae67d26c060277b7073dc87ca2697c9a" +
+			"000000000000000000000000000000000ddc28e31f7bcdff85578c5069fef0e56ed8fc3af7e8e969de9240bbf33cc4c6d4c606f607ad75dab4c0ffa23f56f062" +
+			"0000000000000000000000000000000015a8716732eb96193c506f0d5bcf80155464b306f3a4932b5cf5bb524c123c6ba3aaf31646f592e4d574027b5b92039e" +
+			"000000000000000000000000000000000114a99b1245472c4650c1f87facba8208b17882ba29fdb855956485e148675809b27f46387b5a971f91cf7ca4b615ca",
+		expected: "000000000000000000000000000000000eb1f0fa967995a5b9a65480292f2e7c023d711fc16109f92bf493c50552b018f7760d8076e6f0c2358abd19f0752baf" +
+			"000000000000000000000000000000000a0326966a1eecf01fbca7c20580e8cc236806ca593eeae919925a3300c7e14cea39ea0cd870cdf20d2d24e3c95e71d1" +
+			"0000000000000000000000000000000009904103b2cb03a8c02263a75d38d11175467623b8fc436076acf14e8ca4ad2810cdd26db6d2c03c5bb521bc3c4ce77f" +
+			"000000000000000000000000000000000994c00ddafd2e00ebcfbfedc78051fd91cd35fc5b21644c96a7f582b96d8c392a584eaf0bf7e3330d51e89de2a636a6",
+		name: "g2_add_p_q",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218",
+		expected: "00000000000000000000000000000000063ea2483d4350729241ceb933f37f5d88211b39479b8b3bbd10afe0dd0975a69ea47031c1aff24a16e586f3bd9544ff" +
+			"000000000000000000000000000000000620872847b1719e20f01dde9aa5070be5f06746eea5a1a91c6d57fdbf661d7ee5b6702a42e0947e0402e320ecfa5116" +
+			"000000000000000000000000000000000ce9ce6961cb730fa004d04f4e0661bea723c7675b2e501dd324ab72cfd0c83216312913026fb3b6033f62ebc3860aaa" +
+			"000000000000000000000000000000000680cbaa93419dc578982c810f60f21ed5e537bbc76d2d52c7a859f3ed94f72873f44b5c70b51c54c3109bcc0c2c3a7c",
+		name: "g2_add_p_p",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218",
+		name: "g2_add_p_infinity",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000019244410e71da81474716097eb8631d5737b08f9572ca89e9a04646fa86ff72dfde2be211fbfaff06d86a5420bb73a5f" +
+			"0000000000000000000000000000000000ba68183fdc15d5ef5fd5b0173251daac47d935aa35ea6230c75e1cc2c320ce2338859eadb48d6dc42760540a9f2893",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g2_add_p_neg_p",
+	},
+}
+
+// bls12381G2MulTests are the test and benchmark data for the BLS12-381 G2 point
+// scalar multiplication precompiled contract.
+var bls12381G2MulTests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053" +
+			"000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899" +
+			"000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		name: "g2_mul_g2_2",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"34f1ec5d6216cb5921ce6c540e940dea231f98904418b0455513068914d15c59",
+		expected: "0000000000000000000000000000000000eb8315d1947cf6062e622680f4775c531bf1280155819ee1c1b66e19e8141f5176d710c247770170057f03b4c7f080" +
+			"0000000000000000000000000000000002037effe0f73f62ce13636c58d329d1f57c67921e131f65203499f2de4626708fdf0c119d86da0164fbc7735dde14ad" +
+			"0000000000000000000000000000000018d641a861745272cd5e12a5e19367eae856ba5fe8b96b2e85929e25fe9f9e50a8f382ecb88ffeacd8d58e30cfbe6c5f" +
+			"000000000000000000000000000000000c65acecaf6f083b86d4785af5e94269148efb9e397617d89c1c82d4574fd0d9f208875ec98f49759db90cdaaf9dd7de",
+		name: "g2_mul_p_random",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g2_mul_p_0",
+	},
+	{
+		input: "0000000000000000000000000000000012bf4056d98efa373b0f9b2473b01f39a41267be59e5fb6538f421e652bde64e327ca1670d390f0408ff39764dc734b5" +
+			"0000000000000000000000000000000019ec4c8352cc79a728c65790302dee45f4b70b7785fcdfe296c0bdd4c4512bef152cab3af30a57a777c945a58fb484a7" +
+			"0000000000000000000000000000000000dccdd952623e85d6aa471e57c57b01f0fc428b9c586a20cd2c6e314e40fef620c941dd9194500f4c785abdf448704c" +
+			"000000000000000000000000000000001946a9d1f9a3d0c45bbbd2062c195afcb82f724f494f285d3669748433edd555fb737a60039f7291f5d79fabf5608218" +
+			"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g2_mul_p_order",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"34f1ec5d6216cb5921ce6c540e940dea231f98904418b0455513068914d15c59",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "g2_mul_infinity",
+	},
+}
+
+// bls12381G2MultiExpTests are the test and benchmark data for the BLS12-381 G2 multi
+// exponentiation precompiled contract.
+var bls12381G2MultiExpTests = []precompiledTest{
+	{
+		input: "000000000000000000000000000000000f311844df12422f6b828b68feaae213d87c83ac712a0e89ff1a16738cdc461dbb895b6e8ef488040ce979b18454bcd6" +
+			"00000000000000000000000000000000085423eb3d6c2e6b1279fbd0832d5d2709646e74a23ae57a09edf8dd4934963b2868b33c791c857dc650613dd0ccc3d4" +
+			"000000000000000000000000000000000f683ef8196c76c74a5027db05261efdfa69a43007ee3b5ef0e8afb42aafdd440df278119d4bc85366f59324c7315e72" +
+			"000000000000000000000000000000000716f2a5f2939664ce69736914e3ccb223eff905d220fe364056dc422f3ae7ce41e25bb99d1ef61e9ca0a769e88936b2" +
+			"a661a3acdc214a721d04ee4b1cf298b75906a357fc8fb7f72dca45be665f512b",
+		expected: "000000000000000000000000000000000db6eb28f30919ebb3f58d47ec553d5313c13ab365cab8bfb092cfa1c12790b0985130ffa8d4356defa1da46e80f0544" +
+			"000000000000000000000000000000000b0374f1a5bca42cde04ca5b0d620eacf57b3f54124b8c732cc41cbe5bdb5a6b8e98a1d16c3f9e428cf21e79b5f6b664" +
+			"0000000000000000000000000000000009c85564d1d0a918990f49d9a07ae769029ddc08bb7b5403f987f8f5c1a75bcfe15b36dedbd188417146b25f54ad6c32" +
+			"00000000000000000000000000000000116e2981b3ec3e54ed16f0683aaea161517e7956d571d7a60672e2476c9ee75ebaa3b41c327e42b11220eb9cc7d22406",
+		name: "g2_multiexp_1",
+	},
+	{
+		input: "0000000000000000000000000000000012e4ec0458b27a910b9388a86cc9d2b8cee9ab9fce466fbc4121f6cc39a3f06e3e71a2b3995d21dd4c9dfc902da763ad" +
+			"0000000000000000000000000000000005342edff0a4f44f468bca0c4e28d16c948fda0822853eac30249a951f9d44095e65b1e7e772c1e4d1854f95ba488d13" +
+			"00000000000000000000000000000000047c09f1ca0e5cbe63f09ce9ef583ef0d9dc1add180ccdf9528b8115c8ca50ad92f6f009e5da922770fab375b6f59c2c" +
+			"0000000000000000000000000000000012d1877d29f722925eeafcc673934b31e1ffabd2357e040ed285aad6bf139f4f9482c90ee7007260ca31f91d0032b230" +
+			"8ac9d9826bc431f040070e55b696ef96977f17cbae1ff15c90a860d1230dcde1" +
+			"00000000000000000000000000000000004746691fe8ae006d8ea886e95e4e95fd8f78497952d3c1229d0bddb24267768719463f3f91e3c2776e48d136273a8f" +
+			"00000000000000000000000000000000125f99b69fd6bf9a62dfdf1c59e008dbbe466cda9eb49558f564e095502cabc608fa6f9dbbd1b03934db1d1ccdd39f7d" +
+			"0000000000000000000000000000000013233a1e9cb083791927db46907976af46a2a2f3b3cd191c72072cbbce6659671ce17e860b7f0ecd539416e3f1be5b03" +
+			"0000000000000000000000000000000018e058ef5482699ca9330f935168f29f4ad7cc58b0b77745ec77fe2afb4c37c746a3a633bff1cd756a5f85d773b661a6" +
+			"d636024ec14a61dd5a794df0f39fcd7a36efeca2811f8efa35d5fe9482cdef84",
+		expected: "0000000000000000000000000000000005d35d134218abd276ad0a325e31b2a365a7ad81c26a4060c45005910377f74212f88d915530dad3e6b77a848926746a" +
+			"000000000000000000000000000000000aae031b7ba2b904ae479778cc7904156309173357cdd105b2fa5198a27385241e7ed3a2834cbd4b47b6b65c40a16201" +
+			"00000000000000000000000000000000114779a8f78b451b45cc6f6673db9c33a0507bcc43a2896ae2be051efec72468e07e6ca789d431ce14f1457982895947" +
+			"0000000000000000000000000000000012520ea21af0ff78a340b7061ed7bc75b09a8e9e5f0ebf307dd959b63856c518347ec5f3df19c10838c31788510bb95c",
+		name: "g2_multiexp_2",
+	},
+	{
+		input: "0000000000000000000000000000000012c82cd88f2a559cd1368c0c9346939b50c96916c241a7eb9063e856a0543254ca2987f3cf74cdbe4e5171e7c7b696bc" +
+			"000000000000000000000000000000000f5c59f67ec5b73551951d5a8a0ab6b81ecf7850a5b85aef1a32b2664121f0440e73cb50554f5265960f2c5104f0a65a" +
+			"000000000000000000000000000000000b72cc307e2a11953cc8485f702556be263cda4d46ffc57561c925a369b7a510901f4546ab3d178d9be1348dddb166cd" +
+			"000000000000000000000000000000000c0ef67857ad559289d36756724ac5b1ec1857d873111c2cc19b6e116b75f40e9f7d0e04feb1bd5985bddddd71a995dc" +
+			"4d8836c908112ffb08a27a3fc68e137af7c5e627dd275486e3ef02b36a75e993" +
+			"000000000000000000000000000000000389d5a191db33d609b50ab0d75bc7a22b25eba86994887438527469f654d83dae74b16997d07093a8509334ea0b480d" +
+			"0000000000000000000000000000000018d0f925cb6cb26021185dabb034b682e9d9c94b0213d0b8f2be002f5ab390d6db26fe3a687c4b9ca0d7c0472df7cf68" +
+			"00000000000000000000000000000000030d93e45233e1cdaa4d10ab8a7ef97f13ec6305a6b5c8c6ca31b13bfce8f480f5295b54c96112d9318f07f219e74bbc" +
+			"0000000000000000000000000000000006b374b0311f5b8ac3c7e605351a06dee9eacb3c92cbaf0a60e849174fee8a010631143edb0611495cf66712a6fb7ba3" +
+			"12cf1c639ddb98c4cdc45a095a8673132ab7a0b1c61101d85256791ef25d27c2" +
+			"00000000000000000000000000000000005585ddf887fb37be4390b9ec7f6d32adadea2f85476bece5d1902bf84f25f0c24ef29cef355a335477225f7ebae0ba" +
+			"000000000000000000000000000000000ae451cc291851166664e033e355b71c6f3469e5c3dbf355e5b18d0d276b9230700d0552ef6f208ec7d07511c3170b16" +
+			"0000000000000000000000000000000013f158d5e48597a6aecf93c001430cfef82a52e6ccffadd6a247d31d0ce156687eeb648960f76518f55c72c3bd76bb28" +
+			"0000000000000000000000000000000007224df27606f495e003ec634c95ba8186f19efb368603c084b225a1ef39b8a4616d6af0cdf24bb4b602d2997eb00893" +
+			"29e1ff45fe3245d05f36d4be314f05a15d8bef1a8670b3748be31c1384280d48" +
+			"000000000000000000000000000000000b6c1132c625c5b4f8277da606357bd0e9a81baefb3fb4228e74b2053099ef03e90bb61345e9b0864c0922bc39905c22" +
+			"00000000000000000000000000000000131eff3b33ceefcb32b9491093302ca5e25f56550dbcd79739ad89ede73c924f7585c12ae49f49f3247101e41e26022d" +
+			"0000000000000000000000000000000008933e6a79022e3ae90871c893aa8cc682f846346f199b4414d207458bc9e5a524d4695044d3babdb330b7bc972a78e6" +
+			"000000000000000000000000000000001702fcf1a128a1aedb60ba34cfa93c2d9948206a6005dd51a693a5971c1a405f5d5cb4f1c9db5c340b3fcce8e4a515e9" +
+			"dc97663bee568aa71c272299efe8bdcd8d2d42fa90d1394f66092991c618d168" +
+			"000000000000000000000000000000000ed93267cb0b263a85a2437cd2755c254378f07ab6f88b8bec9ed29945bc633e5fc8cf495952ae27145b773477176360" +
+			"00000000000000000000000000000000136406e648608d4b58d63a6a29d14a0216acc99555ebe6df439da2051f30d2a101803f5591e43f6462ae766735778a36" +
+			"00000000000000000000000000000000080f51810c0f6c2ab2b835771b9430d4e16beaacf01267390e7b1b47a3e87131c564547d2ca2857dfa2f537c62c8322c" +
+			"00000000000000000000000000000000021c43530be007433989c3731dde0b7eff0017d5d2231c437569f7bc772376692fe48b532d113b1b8fef90a66ce3c582" +
+			"c51bb25ba7fc11ebb2ed8ddf054bbd78819b5e5cf7f90a9ef4fd4b88561bc364",
+		expected: "0000000000000000000000000000000007711dfc3c1c5ce42ecda9f48eda53d671d992deba183bd5e0744a21d9ecb87009e48ee0ab2c5978f4ae24d91b4a81ba" +
+			"0000000000000000000000000000000019d394ccc4596d709fd06f6591a591b1bbbb92a34ffebe14b5e3c62e642a51c5c9d4e400f8892c2790131318a272d8e8" +
+			"0000000000000000000000000000000018c08a01c39aa17e06f67b4f3117b4f06207b130fbbccfd0fd772ec1e6d7b0e1aff07fd7a193c692458cd423f84d8e72" +
+			"00000000000000000000000000000000150d6ad04af736b6dba3db66ceffc23893c501ad4803e84997a222f9ded3dbe88a51f6f2791fa424c0b1e6a47058eff5",
+		name: "g2_multiexp_5",
+	},
+}
+
+// bls12381PairingTests are the test and benchmark data for the BLS12-381 pairing check precompiled contract.
+var bls12381PairingTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"00000000000000000000000000000000114d1d6855d545a8aa7d76c8cf2e21f267816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "pairing_g1_g2_neg_g1_g2",
+	},
+	{
+		input: "00000000000000000000000000000000118bcd50db8911ed7b83b2b5ac08e6319cb274a7cd498a3bca346a2e22a696ba373553d11721ad13fc021861189d0ff4" +
+			"0000000000000000000000000000000000d4cbed57532a26e68086aa7b7c95074712626720d54e959f85d4914aa00486670d98aefb10633afd012d3053a3c0a4" +
+			"000000000000000000000000000000000363ae7d55e14bf52b090e2fbb18311f694b7b32540101d7bb32e8eee017c15da724b1ea03f246934f201f5678016f2e" +
+			"00000000000000000000000000000000036dbaf54c59d10b455e98996e143cf9f47e58b4d7c55bf6d40b644e99fd2085cdf69f8e9cc89caef331cb3d6f6cf7f3" +
+			"00000000000000000000000000000000116a5217d8b7f5f7b129d5799995849f800892cb80ac915835e7c80db75cda5636b2028505e98bbb21b6a9fa2aaf895d" +
+			"000000000000000000000000000000000e764eadd049edf9b6a255587a05fb6c9a60f5b65a8752bc9e84db7ab3dd629cc29653273dee87233436e356cdcd1ea9" +
+			"000000000000000000000000000000000734523ae85442e1fd6d85ed5bb17103b68447a4e22a768dfae8823c6f8d23b603c86233be29bbed7b3f8eca6ab35892" +
+			"00000000000000000000000000000000038d1ed6e83af09d1307aa465ea094d825866e567243953d29929d6043c3112497bf97be79008292b0f8ac82b4115d1c" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "pairing_bilinear",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "pairing_g1_g2",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "pairing_infinity_g1",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "pairing_infinity_g2",
+	},
+	{
+		input: "00000000000000000000000000000000118bcd50db8911ed7b83b2b5ac08e6319cb274a7cd498a3bca346a2e22a696ba373553d11721ad13fc021861189d0ff4" +
+			"0000000000000000000000000000000000d4cbed57532a26e68086aa7b7c95074712626720d54e959f85d4914aa00486670d98aefb10633afd012d3053a3c0a4" +
+			"000000000000000000000000000000000363ae7d55e14bf52b090e2fbb18311f694b7b32540101d7bb32e8eee017c15da724b1ea03f246934f201f5678016f2e" +
+			"00000000000000000000000000000000036dbaf54c59d10b455e98996e143cf9f47e58b4d7c55bf6d40b644e99fd2085cdf69f8e9cc89caef331cb3d6f6cf7f3" +
+			"00000000000000000000000000000000116a5217d8b7f5f7b129d5799995849f800892cb80ac915835e7c80db75cda5636b2028505e98bbb21b6a9fa2aaf895d" +
+			"000000000000000000000000000000000e764eadd049edf9b6a255587a05fb6c9a60f5b65a8752bc9e84db7ab3dd629cc29653273dee87233436e356cdcd1ea9" +
+			"000000000000000000000000000000000734523ae85442e1fd6d85ed5bb17103b68447a4e22a768dfae8823c6f8d23b603c86233be29bbed7b3f8eca6ab35892" +
+			"00000000000000000000000000000000038d1ed6e83af09d1307aa465ea094d825866e567243953d29929d6043c3112497bf97be79008292b0f8ac82b4115d1c" +
+			"000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053" +
+			"000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899" +
+			"000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "pairing_not_bilinear",
+	},
+}
+
+// bls12381MapG1Tests are the test and benchmark data for the BLS12-381 field element to G1
+// mapping precompiled contract.
+var bls12381MapG1Tests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000011a9a0372b8f332d5c30de9ad14e50372a73fa4c45d5f2fa5097f2d6fb93bcac592f2e1711ac43db0519870c7d0ea415" +
+			"00000000000000000000000000000000092c0f994164a0719f51c24ba3788de240ff926b55f58c445116e8bc6a47cd63392fd4e8e22bdf9feaa96ee773222133",
+		name: "map_g1_zero",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+		expected: "000000000000000000000000000000001073311196f8ef19477219ccee3a48035ff432295aa9419eed45d186027d88b90832e14c4f0e2aa4d15f54d1c3ed0f93" +
+			"00000000000000000000000000000000034d6e3755a2073039d609db4cf3aef548283b5cc92f1021cbdb276414bcd8072b112d80a2b0a7dbf22bdaf17e006d45",
+		name: "map_g1_one",
+	},
+	{
+		input: "000000000000000000000000000000001a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaaa",
+		expected: "000000000000000000000000000000001073311196f8ef19477219ccee3a48035ff432295aa9419eed45d186027d88b90832e14c4f0e2aa4d15f54d1c3ed0f93" +
+			"0000000000000000000000000000000016b3a3b2e3dddf6a11459ddaf657fde21c4f10282a56029d9b55ab3ce1f41e1cf39ad27e0ea35823c7d3250e81ff3d66",
+		name: "map_g1_minus_one",
+	},
+	{
+		input: "0000000000000000000000000000000014f07daa39391b742d933694c92cc02203b1f683ba7a27ec7b16ba84669d0bfdffab3fae7964e8642500a66ec0993617",
+		expected: "00000000000000000000000000000000193729a98621ce477942b880fc6514ee2f989c99d86bf2f3c0db8b62b58568d53086f9c12f74b572cf0222260e20f6cc" +
+			"000000000000000000000000000000000ff4fe61babaaf349be449bd3fcc4de4f3beeec5a9dec6085da28f79b4b440acdffde597944a25ec995061f8690fa1fb",
+		name: "map_g1_random_1",
+	},
+	{
+		input: "0000000000000000000000000000000012520d4c161b7a2e95bf4db10eda642b334341b111222d8e8d9b1f3c0c0d4c8731355da5977f62a19451c51ff098ba26",
+		expected: "0000000000000000000000000000000010810698a94db16f57e8104eb8e1cf233cdbc267b9a9bfb86370fd6b388fe58bfaa0fb9a6e444c77ec094284f1b451c0" +
+			"000000000000000000000000000000000275222137b46e1e1977a70777903b48217e689152f4ae2a28217b8613998da53d4f270b54e7c40161fb36eb99d9ef0f",
+		name: "map_g1_random_2",
+	},
+}
+
+// bls12381MapG2Tests are the test and benchmark data for the BLS12-381 field element to G2
+// mapping precompiled contract.
+var bls12381MapG2Tests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000018320896ec9eef9d5e619848dc29ce266f413d02dd31d9b9d44ec0c79cd61f18b075ddba6d7bd20b7ff27a4b324bfce" +
+			"000000000000000000000000000000000a67d12118b5a35bb02d2e86b3ebfa7e23410db93de39fb06d7025fa95e96ffa428a7a27c3ae4dd4b40bd251ac658892" +
+			"000000000000000000000000000000000260e03644d1a2c321256b3246bad2b895cad13890cbe6f85df55106a0d334604fb143c7a042d878006271865bc35941" +
+			"0000000000000000000000000000000004c69777a43f0bda07679d5805e63f18cf4e0e7c6112ac7f70266d199b4f76ae27c6269a3ceebdae30806e9a76aadf5c",
+		name: "map_g2_zero",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "000000000000000000000000000000001770d4f641225e1a1c0f7d05857299763e98e47ec6355b81dd6cdaf6db6825052f71d35ede3af8b70f046474c48d712e" +
+			"0000000000000000000000000000000000e12b55d801607d9760f8637ac80a4fececd3eb74045b342ee3c7dddd2037e72dedccc27e9a89491d4e57bde555fead" +
+			"0000000000000000000000000000000005695a740eaae8452a882e7647f22bc17782b00afa7b6be2d974824a2a7cba7eece26c60671d41145266582912235323" +
+			"00000000000000000000000000000000143ef77ba72f284b5b4f5c5ea227d269d98a8cf74a5c048a07852874d50632806cf66bc25db089319df2ee3f0212fc1c",
+		name: "map_g2_one",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+		expected: "000000000000000000000000000000000f5ab9ab512bac0e5aa9d4be326afefbfa5db2dba6c88000f1cfeaa0cd62b2b2604935e2794933d76f9887bae7ed2851" +
+			"0000000000000000000000000000000005d991fb690fdad1923ac1834188ed45d160a15ee5547a4476b836a158a9884236846408b8abd5d99217876d12f8f5d6" +
+			"000000000000000000000000000000001055354681ba663d288d9a5256844c48ec43e27e9f2b87ce06850d4a5661095c189f8bab578093d2161db0b32550f3a0" +
+			"00000000000000000000000000000000184ee89023a361021f9d288e65deb12b2045b1e3d2560590fc3139354c51b756018cf3c54a13f60cb7b970567c39c08f",
+		name: "map_g2_i",
+	},
+	{
+		input: "000000000000000000000000000000000ee6559b4eb097d739353a97678a78fbf081fc2a3f4537f9e14cebc21728e99f7d7685b97c77e953f80a75a230cbb1c4" +
+			"0000000000000000000000000000000006f81c85200b8a867b65910e2e861f9973583d21b2b93311c0306f49937c75bb8328f37a0af38269219faf1224056bb0",
+		expected: "00000000000000000000000000000000064df50061b0f04d60579dbd89a21f0d40d6ed8707f3ffa3619d5689ced724531229cc4feecb413f9ca6a8e3f9d88de1" +
+			"000000000000000000000000000000000f67b491607fa926715ad1d4513efeccffbf4755518fceb05df02bdc62ebee90a063f5f883118c1a0e69cc769a1971e7" +
+			"000000000000000000000000000000000dccab89f57c2cd94c47dd82842189adbffde958f2ff389b7c0bd79239c011e60885324ad0d2d9cad501aca80e2d550b" +
+			"0000000000000000000000000000000007e0a68058b362c2e7068eeb43bc6be2a91da71f02a01c6bf817573c461e8a613eb64008c456053df632f4f15c8832b7",
+		name: "map_g2_random_1",
+	},
+	{
+		input: "0000000000000000000000000000000007bb3840956c75808363957a17a54a80e162efa3025dcb35a4923e96e6e6386cc743652ceb35de93f83ebc07e398b905" +
+			"0000000000000000000000000000000014ca0e96a6404fcaa08dd953d69ca23e4ce9ccf7b95c64d72c6e91d9490057881a0681848fc36d89bcb9244e3aec310b",
+		expected: "0000000000000000000000000000000010adb7b06bee7e97c3abd2bf8d5176b1eab5e6577880a8f11e28750e4341ab91d1e263a8925cf80deb4a61e32f6730ae" +
+			"000000000000000000000000000000001682c967b88e0ef7235a84756b78ee740c9bd543b427215b1dc1e39b3934313aa0e5316898a7ef5e5fd6cdfb4e9b858c" +
+			"0000000000000000000000000000000010eecc9e0c17f013f954a65e431e911c32409adf4672ffc9ce7ddd7736fce0d95394b1b030da2fc7440671aa6fe191dd" +
+			"000000000000000000000000000000000e69825b3ac0bd9d60d89daffe7d07d45d46b4d20193ee0f3c608cb31c3162d77cc858f64fccfe2b50cf9e92213025ee",
+		name: "map_g2_random_2",
+	},
+}
+
+var bls12381G1AddFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g1add_empty_input",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g1add_short_input",
+	},
+	{
+		input: "0100000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+		expectedError: errBLS12381InvalidFieldElementTopBytes,
+		name:          "bls_g1add_violate_top_bytes",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e0" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+		expectedError: errors.New("point is not on curve"),
+		name:          "bls_g1add_point_not_on_curve",
+	},
+}
+
+var bls12381G1MulFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g1mul_empty_input",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e0" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errors.New("point is not on curve"),
+		name:          "bls_g1mul_point_not_on_curve",
+	},
+}
+
+var bls12381G1MultiExpFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g1multiexp_empty_input",
+	},
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+			"0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g1multiexp_short_input",
+	},
+}
+
+var bls12381G2AddFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g2add_empty_input",
+	},
+	{
+		input: "01000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expectedError: errBLS12381InvalidFieldElementTopBytes,
+		name:          "bls_g2add_violate_top_bytes",
+	},
+}
+
+var bls12381G2MulFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g2mul_empty_input",
+	},
+}
+
+var bls12381G2MultiExpFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_g2multiexp_empty_input",
+	},
+}
+
+var bls12381PairingFailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_pairing_empty_input",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8" +
+			"0000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801" +
+			"000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expectedError: errBLS12381G1PointSubgroup,
+		name:          "bls_pairing_g1_not_in_subgroup",
+	},
+}
+
+var bls12381MapG1FailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_mapg1_empty_input",
+	},
+	{
+		input:         "01000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errBLS12381InvalidFieldElementTopBytes,
+		name:          "bls_mapg1_violate_top_bytes",
+	},
+	{
+		input:         "000000000000000000000000000000001a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab",
+		expectedError: errors.New("field element not less than modulus"),
+		name:          "bls_mapg1_modulus",
+	},
+}
+
+var bls12381MapG2FailureTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBLS12381InvalidInputLength,
+		name:          "bls_mapg2_empty_input",
+	},
+	{
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"01000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errBLS12381InvalidFieldElementTopBytes,
+		name:          "bls_mapg2_violate_top_bytes",
+	},
+}
+
+func TestPrecompiledBLS12381G1Add(t *testing.T) {
+	for _, test := range bls12381G1AddTests {
+		testPrecompiled("0a", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G1AddFail(t *testing.T) {
+	for _, test := range bls12381G1AddFailureTests {
+		testPrecompiledFailure("0a", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G1Add(bench *testing.B) {
+	for _, test := range bls12381G1AddTests {
+		benchmarkPrecompiled("0a", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381G1Mul(t *testing.T) {
+	for _, test := range bls12381G1MulTests {
+		testPrecompiled("0b", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G1MulFail(t *testing.T) {
+	for _, test := range bls12381G1MulFailureTests {
+		testPrecompiledFailure("0b", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G1Mul(bench *testing.B) {
+	for _, test := range bls12381G1MulTests {
+		benchmarkPrecompiled("0b", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381G1MultiExp(t *testing.T) {
+	for _, test := range bls12381G1MultiExpTests {
+		testPrecompiled("0c", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G1MultiExpFail(t *testing.T) {
+	for _, test := range bls12381G1MultiExpFailureTests {
+		testPrecompiledFailure("0c", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G1MultiExp(bench *testing.B) {
+	for _, test := range bls12381G1MultiExpTests {
+		benchmarkPrecompiled("0c", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381G2Add(t *testing.T) {
+	for _, test := range bls12381G2AddTests {
+		testPrecompiled("0d", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G2AddFail(t *testing.T) {
+	for _, test := range bls12381G2AddFailureTests {
+		testPrecompiledFailure("0d", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G2Add(bench *testing.B) {
+	for _, test := range bls12381G2AddTests {
+		benchmarkPrecompiled("0d", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381G2Mul(t *testing.T) {
+	for _, test := range bls12381G2MulTests {
+		testPrecompiled("0e", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G2MulFail(t *testing.T) {
+	for _, test := range bls12381G2MulFailureTests {
+		testPrecompiledFailure("0e", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G2Mul(bench *testing.B) {
+	for _, test := range bls12381G2MulTests {
+		benchmarkPrecompiled("0e", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381G2MultiExp(t *testing.T) {
+	for _, test := range bls12381G2MultiExpTests {
+		testPrecompiled("0f", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381G2MultiExpFail(t *testing.T) {
+	for _, test := range bls12381G2MultiExpFailureTests {
+		testPrecompiledFailure("0f", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381G2MultiExp(bench *testing.B) {
+	for _, test := range bls12381G2MultiExpTests {
+		benchmarkPrecompiled("0f", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381Pairing(t *testing.T) {
+	for _, test := range bls12381PairingTests {
+		testPrecompiled("10", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381PairingFail(t *testing.T) {
+	for _, test := range bls12381PairingFailureTests {
+		testPrecompiledFailure("10", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381Pairing(bench *testing.B) {
+	for _, test := range bls12381PairingTests {
+		benchmarkPrecompiled("10", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381MapG1(t *testing.T) {
+	for _, test := range bls12381MapG1Tests {
+		testPrecompiled("11", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381MapG1Fail(t *testing.T) {
+	for _, test := range bls12381MapG1FailureTests {
+		testPrecompiledFailure("11", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381MapG1(bench *testing.B) {
+	for _, test := range bls12381MapG1Tests {
+		benchmarkPrecompiled("11", test, bench)
+	}
+}
+
+func TestPrecompiledBLS12381MapG2(t *testing.T) {
+	for _, test := range bls12381MapG2Tests {
+		testPrecompiled("12", test, t)
+	}
+}
+
+func TestPrecompiledBLS12381MapG2Fail(t *testing.T) {
+	for _, test := range bls12381MapG2FailureTests {
+		testPrecompiledFailure("12", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBLS12381MapG2(bench *testing.B) {
+	for _, test := range bls12381MapG2Tests {
+		benchmarkPrecompiled("12", test, bench)
+	}
+}
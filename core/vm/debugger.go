@@ -0,0 +1,344 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+)
+
+var (
+	errDebuggerFinished = errors.New("execution finished")
+	errDebuggerClosed   = errors.New("debugger closed")
+)
+
+// Breakpoint is a condition pausing the execution under a Debugger, matching
+// the steps about to be executed for which all its set fields match. A
+// breakpoint having only an address pauses when a call into it starts.
+type Breakpoint struct {
+	ID      int             `json:"id"`
+	Address *common.Address `json:"address,omitempty"` // Address of the executing contract
+	Pc      *uint64         `json:"pc,omitempty"`      // Program counter of the instruction
+	Op      string          `json:"op,omitempty"`      // Opcode of the instruction
+	Storage bool            `json:"storage,omitempty"` // Whether the instruction writes to the storage
+	Slot    *common.Hash    `json:"slot,omitempty"`    // Storage slot written by the instruction
+}
+
+// matches returns whether the breakpoint pauses the given step.
+func (bp *Breakpoint) matches(pc uint64, op OpCode, stack *Stack, contract *Contract, entering bool) bool {
+	if bp.Address != nil && *bp.Address != contract.Address() {
+		return false
+	}
+	if bp.Pc == nil && bp.Op == "" && !bp.Storage && bp.Slot == nil {
+		return bp.Address != nil && entering
+	}
+	if bp.Pc != nil && *bp.Pc != pc {
+		return false
+	}
+	if bp.Op != "" && bp.Op != op.String() {
+		return false
+	}
+	if bp.Storage || bp.Slot != nil {
+		if op != SSTORE {
+			return false
+		}
+		if bp.Slot != nil && common.BigToHash(stack.Back(0)) != *bp.Slot {
+			return false
+		}
+	}
+	return true
+}
+
+// DebugState is the state of an execution paused by a Debugger before running
+// an instruction, or the outcome of the execution once done.
+type DebugState struct {
+	Pc         uint64         `json:"pc"`
+	Op         string         `json:"op"`
+	Gas        uint64         `json:"gas"`
+	GasCost    uint64         `json:"gasCost"`
+	Depth      int            `json:"depth"`
+	Address    common.Address `json:"address"`
+	Stack      []*hexutil.Big `json:"stack"`
+	Memory     hexutil.Bytes  `json:"memory"`
+	Breakpoint *int           `json:"breakpoint,omitempty"` // Breakpoint which paused the execution, if any
+
+	Done   bool          `json:"done"`
+	Output hexutil.Bytes `json:"output,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// debugMode is how a Debugger lets the execution proceed.
+type debugMode int
+
+const (
+	debugStepping debugMode = iota // Pause before every instruction
+	debugRunning                   // Pause at the breakpoints
+)
+
+// debugCommand is a request to the paused execution, either inspecting it or
+// resuming it.
+type debugCommand struct {
+	inspect func(env *EVM, contract *Contract)
+	done    chan struct{}
+}
+
+// Debugger pauses the interpreter at breakpoints, letting a client inspect the
+// execution and step through it. The execution starts paused before its first
+// instruction.
+//
+// The execution is driven by the goroutine running the EVM, which must call
+// Finish once done. The other methods are meant for the client, and block until
+// the execution pauses or finishes.
+type Debugger struct {
+	mode        debugMode
+	breakpoints []Breakpoint
+	lastID      int
+	depth       int // Call depth of the previous step
+	lock        sync.Mutex
+
+	paused chan *DebugState   // States the execution paused in, then its outcome
+	cmds   chan *debugCommand // Commands to the paused execution
+	quit   chan struct{}      // Closed when the client detaches
+	closer sync.Once
+
+	state   *DebugState // Latest state received by the client
+	running bool        // Whether the execution was resumed since
+	client  sync.Mutex  // Serialises the client calls
+}
+
+// NewDebugger creates a debugger pausing before the first instruction.
+func NewDebugger() *Debugger {
+	return &Debugger{
+		mode:   debugStepping,
+		paused: make(chan *DebugState),
+		cmds:   make(chan *debugCommand),
+		quit:   make(chan struct{}),
+	}
+}
+
+// pause is called by the interpreter before running an instruction, blocking as
+// long as the client keeps the execution paused.
+func (d *Debugger) pause(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int) {
+	select {
+	case <-d.quit:
+		return
+	default:
+	}
+	d.lock.Lock()
+	entering := depth > d.depth
+	d.depth = depth
+
+	var hit *int
+	for i := range d.breakpoints {
+		if d.breakpoints[i].matches(pc, op, stack, contract, entering) {
+			id := d.breakpoints[i].ID
+			hit = &id
+			break
+		}
+	}
+	stop := d.mode == debugStepping || hit != nil
+	d.lock.Unlock()
+
+	if !stop {
+		return
+	}
+	state := &DebugState{
+		Pc:         pc,
+		Op:         op.String(),
+		Gas:        gas,
+		GasCost:    cost,
+		Depth:      depth,
+		Address:    contract.Address(),
+		Stack:      make([]*hexutil.Big, len(stack.Data())),
+		Memory:     common.CopyBytes(memory.Data()),
+		Breakpoint: hit,
+	}
+	for i, item := range stack.Data() {
+		state.Stack[i] = (*hexutil.Big)(new(big.Int).Set(item))
+	}
+	select {
+	case d.paused <- state:
+	case <-d.quit:
+		return
+	}
+	for {
+		select {
+		case cmd := <-d.cmds:
+			if cmd.inspect == nil {
+				close(cmd.done)
+				return
+			}
+			cmd.inspect(env, contract)
+			close(cmd.done)
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// Finish reports the outcome of the execution to the client. It must be called
+// by the goroutine running the EVM once done.
+func (d *Debugger) Finish(output []byte, err error) {
+	state := &DebugState{Done: true, Output: common.CopyBytes(output)}
+	if err != nil {
+		state.Error = err.Error()
+	}
+	select {
+	case d.paused <- state:
+	case <-d.quit:
+	}
+}
+
+// wait blocks until the execution pauses or finishes, returning its state.
+func (d *Debugger) wait() (*DebugState, error) {
+	if d.running {
+		select {
+		case d.state = <-d.paused:
+			d.running = false
+		case <-d.quit:
+			return nil, errDebuggerClosed
+		}
+	}
+	if d.state == nil {
+		select {
+		case d.state = <-d.paused:
+		case <-d.quit:
+			return nil, errDebuggerClosed
+		}
+	}
+	return d.state, nil
+}
+
+// send delivers a command to the paused execution and waits for it to run.
+func (d *Debugger) send(cmd *debugCommand) error {
+	state, err := d.wait()
+	if err != nil {
+		return err
+	}
+	if state.Done {
+		return errDebuggerFinished
+	}
+	cmd.done = make(chan struct{})
+	select {
+	case d.cmds <- cmd:
+	case <-d.quit:
+		return errDebuggerClosed
+	}
+	<-cmd.done
+	return nil
+}
+
+// resume lets the paused execution proceed in the given mode, and waits until
+// it pauses again or finishes.
+func (d *Debugger) resume(mode debugMode) (*DebugState, error) {
+	d.client.Lock()
+	defer d.client.Unlock()
+
+	d.lock.Lock()
+	d.mode = mode
+	d.lock.Unlock()
+
+	if err := d.send(new(debugCommand)); err != nil {
+		return nil, err
+	}
+	d.running = true
+	return d.wait()
+}
+
+// State waits until the execution pauses or finishes, and returns its state.
+func (d *Debugger) State() (*DebugState, error) {
+	d.client.Lock()
+	defer d.client.Unlock()
+
+	return d.wait()
+}
+
+// Step runs the next instruction, pausing before the following one.
+func (d *Debugger) Step() (*DebugState, error) {
+	return d.resume(debugStepping)
+}
+
+// Continue runs until the next breakpoint, or until the execution finishes.
+func (d *Debugger) Continue() (*DebugState, error) {
+	return d.resume(debugRunning)
+}
+
+// Storage returns the value of a storage slot of an account, or of the
+// executing contract if no address is given.
+func (d *Debugger) Storage(addr *common.Address, slot common.Hash) (common.Hash, error) {
+	d.client.Lock()
+	defer d.client.Unlock()
+
+	var value common.Hash
+	err := d.send(&debugCommand{inspect: func(env *EVM, contract *Contract) {
+		account := contract.Address()
+		if addr != nil {
+			account = *addr
+		}
+		value = env.StateDB.GetState(account, slot)
+	}})
+	return value, err
+}
+
+// AddBreakpoint adds a breakpoint, returning its id.
+func (d *Debugger) AddBreakpoint(bp Breakpoint) (int, error) {
+	if bp.Op != "" {
+		if _, ok := stringToOp[bp.Op]; !ok {
+			return 0, fmt.Errorf("unknown opcode %q", bp.Op)
+		}
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.lastID++
+	bp.ID = d.lastID
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp.ID, nil
+}
+
+// RemoveBreakpoint removes a breakpoint, returning whether it existed.
+func (d *Debugger) RemoveBreakpoint(id int) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Breakpoints returns the breakpoints set.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return append([]Breakpoint{}, d.breakpoints...)
+}
+
+// Close detaches the client, letting the execution run to its end without
+// pausing anymore.
+func (d *Debugger) Close() {
+	d.closer.Do(func() { close(d.quit) })
+}
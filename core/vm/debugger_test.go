@@ -0,0 +1,96 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// Tests stepping through an execution, pausing at breakpoints and inspecting
+// the paused state.
+func TestDebugger(t *testing.T) {
+	var (
+		caller   = common.HexToAddress("0x1337")
+		contract = common.HexToAddress("0xaa")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	// Store 1 at slot 0, then 2 at slot 1, and return
+	statedb.SetCode(contract, common.FromHex("600160005560026001550000"))
+
+	debugger := NewDebugger()
+	vmctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	evm := NewEVM(vmctx, statedb, params.AllEthashProtocolChanges, Config{Debugger: debugger})
+	go func() {
+		_, _, err := evm.Call(AccountRef(caller), contract, nil, 100000, new(big.Int))
+		debugger.Finish(nil, err)
+	}()
+	defer debugger.Close()
+
+	// The execution starts paused before the first instruction
+	state, err := debugger.State()
+	if err != nil {
+		t.Fatalf("failed to get the state: %v", err)
+	}
+	if state.Pc != 0 || state.Op != "PUSH1" || state.Depth != 1 || state.Address != contract || state.Breakpoint != nil {
+		t.Fatalf("initial state mismatch: have %+v", state)
+	}
+	if state, err = debugger.Step(); err != nil || state.Pc != 2 || len(state.Stack) != 1 || state.Stack[0].ToInt().Uint64() != 1 {
+		t.Fatalf("stepped state mismatch: have %+v (%v)", state, err)
+	}
+	// Break on the write to slot 1, and check the first write is visible
+	slot := common.BigToHash(big.NewInt(1))
+	if _, err := debugger.AddBreakpoint(Breakpoint{Op: "INVALIDOP"}); err == nil {
+		t.Errorf("expected error adding a breakpoint on an unknown opcode")
+	}
+	id, err := debugger.AddBreakpoint(Breakpoint{Slot: &slot})
+	if err != nil {
+		t.Fatalf("failed to add breakpoint: %v", err)
+	}
+	if state, err = debugger.Continue(); err != nil || state.Pc != 9 || state.Op != "SSTORE" || state.Breakpoint == nil || *state.Breakpoint != id {
+		t.Fatalf("breakpoint state mismatch: have %+v (%v)", state, err)
+	}
+	if value, err := debugger.Storage(nil, common.Hash{}); err != nil || value != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("slot 0 mismatch: have %x (%v), want 1", value, err)
+	}
+	if value, err := debugger.Storage(&contract, slot); err != nil || value != (common.Hash{}) {
+		t.Errorf("slot 1 mismatch: have %x (%v), want 0", value, err)
+	}
+	// Remove the breakpoint and run to the end
+	if !debugger.RemoveBreakpoint(id) || len(debugger.Breakpoints()) != 0 {
+		t.Fatalf("failed to remove breakpoint")
+	}
+	if state, err = debugger.Continue(); err != nil || !state.Done || state.Error != "" {
+		t.Fatalf("final state mismatch: have %+v (%v)", state, err)
+	}
+	if _, err := debugger.Step(); err != errDebuggerFinished {
+		t.Errorf("stepping error mismatch: have %v, want %v", err, errDebuggerFinished)
+	}
+	if value := statedb.GetState(contract, slot); value != common.BigToHash(big.NewInt(2)) {
+		t.Errorf("slot 1 mismatch after execution: have %x, want 2", value)
+	}
+}
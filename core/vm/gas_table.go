@@ -18,6 +18,7 @@ package vm
 
 import (
 	"errors"
+	"math/big"
 
 	"github.com/MFAChain/mfachain/common"
 	"github.com/MFAChain/mfachain/common/math"
@@ -278,9 +279,16 @@ var (
 	gasMLoad   = pureMemoryGascost
 	gasMStore8 = pureMemoryGascost
 	gasMStore  = pureMemoryGascost
-	gasCreate  = pureMemoryGascost
 )
 
+func gasCreate(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	gas, err := memoryGasCost(mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	return gasInitCode(evm, gas, stack.Back(2))
+}
+
 func gasCreate2(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	gas, err := memoryGasCost(mem, memorySize)
 	if err != nil {
@@ -296,6 +304,26 @@ func gasCreate2(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memoryS
 	if gas, overflow = math.SafeAdd(gas, wordGas); overflow {
 		return 0, ErrGasUintOverflow
 	}
+	return gasInitCode(evm, gas, stack.Back(2))
+}
+
+// gasInitCode adds the metering of the initcode of a contract creation to gas,
+// if the chain limits the initcode. Oversized initcode aborts the execution.
+func gasInitCode(evm *EVM, gas uint64, size *big.Int) (uint64, error) {
+	limit := evm.chainRules.MaxInitCodeSize
+	if limit == 0 {
+		return gas, nil
+	}
+	if !size.IsUint64() || size.Uint64() > limit {
+		return 0, ErrMaxInitCodeSizeExceeded
+	}
+	wordGas, overflow := math.SafeMul(toWordSize(size.Uint64()), params.InitCodeWordGas)
+	if overflow {
+		return 0, ErrGasUintOverflow
+	}
+	if gas, overflow = math.SafeAdd(gas, wordGas); overflow {
+		return 0, ErrGasUintOverflow
+	}
 	return gas, nil
 }
 
@@ -27,10 +27,11 @@ import (
 
 // Config are the configuration options for the Interpreter
 type Config struct {
-	Debug                   bool   // Enables debugging
-	Tracer                  Tracer // Opcode logger
-	NoRecursion             bool   // Disables call, callcode, delegate call and create
-	EnablePreimageRecording bool   // Enables recording of SHA3/keccak preimages
+	Debug                   bool      // Enables debugging
+	Tracer                  Tracer    // Opcode logger
+	Debugger                *Debugger // Interactive debugger pausing the execution
+	NoRecursion             bool      // Disables call, callcode, delegate call and create
+	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
 
 	JumpTable [256]operation // EVM instruction table, automatically populated if unset
 
@@ -282,6 +283,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			in.cfg.Tracer.CaptureState(in.evm, pc, op, gasCopy, cost, mem, stack, contract, in.evm.depth, err)
 			logged = true
 		}
+		if in.cfg.Debugger != nil {
+			in.cfg.Debugger.pause(in.evm, pc, op, contract.Gas+cost, cost, mem, stack, contract, in.evm.depth)
+		}
 
 		// execute the operation
 		res, err = operation.execute(&pc, in, callContext)
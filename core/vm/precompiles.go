@@ -0,0 +1,200 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// StatefulPrecompiledContract is a precompiled contract which has access to the
+// EVM running it, allowing it to read and modify the state. The gas returned by
+// RequiredGas is charged before running the contract, which may charge more
+// through the UseGas method of the contract.
+type StatefulPrecompiledContract interface {
+	PrecompiledContract
+
+	// RunStateful runs the contract within the given EVM. The contract must not
+	// modify the state if readOnly is set, returning ErrWriteProtection instead.
+	RunStateful(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error)
+}
+
+// PrecompileFactory creates a custom precompiled contract from the contract
+// specific configuration given in the chain config. The returned contracts are
+// shared between EVMs, hence must be safe for concurrent use.
+type PrecompileFactory func(config json.RawMessage) (PrecompiledContract, error)
+
+var (
+	precompileFactories    = make(map[string]PrecompileFactory)
+	precompileFactoriesMux sync.RWMutex
+
+	// customPrecompiles caches the contracts created for the configurations
+	// seen so far.
+	customPrecompiles sync.Map // customPrecompileKey -> PrecompiledContract
+
+	// activeSets caches the precompiled contract sets assembled for the native
+	// and active custom contract combinations seen so far.
+	activeSets sync.Map // string -> map[common.Address]PrecompiledContract
+)
+
+// customPrecompileKey identifies a custom precompiled contract configuration.
+type customPrecompileKey struct {
+	name   string
+	config string
+}
+
+// RegisterPrecompile registers a precompiled contract factory under the name
+// the chain config refers to it with. It panics if the name is already taken.
+func RegisterPrecompile(name string, factory PrecompileFactory) {
+	precompileFactoriesMux.Lock()
+	defer precompileFactoriesMux.Unlock()
+
+	if _, ok := precompileFactories[name]; ok {
+		panic(fmt.Sprintf("precompile %q registered twice", name))
+	}
+	precompileFactories[name] = factory
+}
+
+// newCustomPrecompile returns the contract for a custom precompiled contract
+// configuration, creating it on first use.
+func newCustomPrecompile(config *params.PrecompileConfig) (PrecompiledContract, error) {
+	key := customPrecompileKey{name: config.Name, config: string(config.Config)}
+	if p, ok := customPrecompiles.Load(key); ok {
+		return p.(PrecompiledContract), nil
+	}
+	precompileFactoriesMux.RLock()
+	factory := precompileFactories[config.Name]
+	precompileFactoriesMux.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("unknown precompile %q", config.Name)
+	}
+	p, err := factory(config.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid precompile %q config: %v", config.Name, err)
+	}
+	actual, _ := customPrecompiles.LoadOrStore(key, p)
+	return actual.(PrecompiledContract), nil
+}
+
+// ValidatePrecompiles checks that the custom precompiled contracts of a chain
+// config refer to registered factories accepting their configuration, and that
+// their addresses don't collide with each other or the native contracts.
+func ValidatePrecompiles(config *params.ChainConfig) error {
+	seen := make(map[common.Address]bool)
+	for _, pc := range config.Precompiles {
+		if _, ok := PrecompiledContractsBLS[pc.Address]; ok {
+			return fmt.Errorf("precompile %q address %s taken by a native contract", pc.Name, pc.Address.Hex())
+		}
+		if seen[pc.Address] {
+			return fmt.Errorf("precompile %q address %s taken by another precompile", pc.Name, pc.Address.Hex())
+		}
+		seen[pc.Address] = true
+
+		if _, err := newCustomPrecompile(pc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activePrecompiles returns the precompiled contracts available at the given
+// block, the native ones of the active fork and the active custom ones. The sets
+// are shared between EVMs and must not be modified.
+func activePrecompiles(config *params.ChainConfig, rules params.Rules, num *big.Int) map[common.Address]PrecompiledContract {
+	var (
+		precompiles = PrecompiledContractsHomestead
+		fork        = byte(0)
+	)
+	if rules.IsByzantium {
+		precompiles, fork = PrecompiledContractsByzantium, 1
+	}
+	if rules.IsIstanbul {
+		precompiles, fork = PrecompiledContractsIstanbul, 2
+	}
+	if rules.IsBLS {
+		precompiles, fork = PrecompiledContractsBLS, 3
+	}
+	// Identify the combination of native and active custom contracts
+	var (
+		active []*params.PrecompileConfig
+		key    = []byte{fork}
+	)
+	for _, pc := range config.Precompiles {
+		if pc.IsActive(num) {
+			active = append(active, pc)
+
+			key = append(key, pc.Address[:]...)
+			key = append(append(key, pc.Name...), 0)
+			key = append(append(key, pc.Config...), 0)
+		}
+	}
+	if len(active) == 0 {
+		return precompiles
+	}
+	if set, ok := activeSets.Load(string(key)); ok {
+		return set.(map[common.Address]PrecompiledContract)
+	}
+	set := make(map[common.Address]PrecompiledContract, len(precompiles)+len(active))
+	for addr, p := range precompiles {
+		set[addr] = p
+	}
+	for _, pc := range active {
+		// The config is validated when the chain config is loaded, so this can
+		// only happen with configs crafted by hand.
+		p, err := newCustomPrecompile(pc)
+		if err != nil {
+			log.Error("Custom precompile unavailable", "address", pc.Address, "err", err)
+			continue
+		}
+		set[pc.Address] = p
+	}
+	actual, _ := activeSets.LoadOrStore(string(key), set)
+	return actual.(map[common.Address]PrecompiledContract)
+}
+
+// runPrecompiledContract runs a precompiled contract within the given EVM,
+// giving stateful contracts access to it.
+func runPrecompiledContract(evm *EVM, p PrecompiledContract, input []byte, contract *Contract, readOnly bool) ([]byte, error) {
+	sp, ok := p.(StatefulPrecompiledContract)
+	if !ok {
+		return RunPrecompiledContract(p, input, contract)
+	}
+	if !contract.UseGas(p.RequiredGas(input)) {
+		return nil, ErrOutOfGas
+	}
+	// Any call made from within a static call is read-only too (EIP-214)
+	if in, ok := evm.interpreter.(*EVMInterpreter); ok && in.readOnly {
+		readOnly = true
+	}
+	ret, err := sp.RunStateful(evm, contract, input, readOnly)
+	if err == nil && !readOnly && contract.Address() == *contract.CodeAddr {
+		// Keep the account of a directly called contract alive, lest its storage
+		// be wiped as an empty account at the end of the transaction. Delegated
+		// calls run on the account of the caller, which is left untouched.
+		if addr := *contract.CodeAddr; evm.StateDB.GetNonce(addr) == 0 {
+			evm.StateDB.SetNonce(addr, 1)
+		}
+	}
+	return ret, err
+}
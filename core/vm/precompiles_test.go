@@ -0,0 +1,216 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// testCounter is a stateful precompiled contract adding a configured step to a
+// counter kept in its storage, returning the new value.
+type testCounter struct {
+	Step uint64 `json:"step"`
+}
+
+func (c *testCounter) RequiredGas(input []byte) uint64 {
+	return 100
+}
+
+func (c *testCounter) Run(input []byte) ([]byte, error) {
+	return nil, errors.New("stateful contract run statelessly")
+}
+
+func (c *testCounter) RunStateful(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	if readOnly {
+		return nil, ErrWriteProtection
+	}
+	if !contract.UseGas(params.SstoreSetGas) {
+		return nil, ErrOutOfGas
+	}
+	value := evm.StateDB.GetState(contract.Address(), common.Hash{}).Big()
+	value.Add(value, new(big.Int).SetUint64(c.Step))
+
+	evm.StateDB.SetState(contract.Address(), common.Hash{}, common.BigToHash(value))
+	return common.BigToHash(value).Bytes(), nil
+}
+
+func init() {
+	RegisterPrecompile("test-counter", func(config json.RawMessage) (PrecompiledContract, error) {
+		c := new(testCounter)
+		if err := json.Unmarshal(config, c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	RegisterPrecompile("test-identity", func(config json.RawMessage) (PrecompiledContract, error) {
+		return &dataCopy{}, nil
+	})
+}
+
+// Tests that custom precompiles are available from their activation block on,
+// and that stateful ones can access the state.
+func TestCustomPrecompiles(t *testing.T) {
+	var (
+		counter  = common.HexToAddress("0x0100")
+		identity = common.HexToAddress("0x0101")
+		caller   = common.HexToAddress("0x1337")
+	)
+	config := *params.AllEthashProtocolChanges
+	config.Precompiles = []*params.PrecompileConfig{
+		{Name: "test-counter", Address: counter, Block: big.NewInt(10), Config: json.RawMessage(`{"step": 3}`)},
+		{Name: "test-identity", Address: identity, Block: big.NewInt(20)},
+	}
+	if err := ValidatePrecompiles(&config); err != nil {
+		t.Fatalf("failed to validate precompiles: %v", err)
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	newEVM := func(number int64) *EVM {
+		vmctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(number),
+		}
+		return NewEVM(vmctx, statedb, &config, Config{})
+	}
+	// Check the activation of the contracts
+	for _, tt := range []struct {
+		number            int64
+		counter, identity bool
+		native            bool
+	}{
+		{0, false, false, true},
+		{10, true, false, true},
+		{20, true, true, true},
+	} {
+		evm := newEVM(tt.number)
+		if have := evm.IsPrecompile(counter); have != tt.counter {
+			t.Errorf("block %d: counter availability mismatch: have %v, want %v", tt.number, have, tt.counter)
+		}
+		if have := evm.IsPrecompile(identity); have != tt.identity {
+			t.Errorf("block %d: identity availability mismatch: have %v, want %v", tt.number, have, tt.identity)
+		}
+		if have := evm.IsPrecompile(common.BytesToAddress([]byte{1})); have != tt.native {
+			t.Errorf("block %d: ecrecover availability mismatch: have %v, want %v", tt.number, have, tt.native)
+		}
+	}
+	// Run the stateful contract and check its effects
+	evm := newEVM(20)
+	for i := uint64(1); i <= 2; i++ {
+		ret, gas, err := evm.Call(AccountRef(caller), counter, nil, 50000, new(big.Int))
+		if err != nil {
+			t.Fatalf("call %d: failed to run counter: %v", i, err)
+		}
+		if have, want := new(big.Int).SetBytes(ret).Uint64(), 3*i; have != want {
+			t.Errorf("call %d: counter mismatch: have %d, want %d", i, have, want)
+		}
+		if used, want := 50000-gas, 100+params.SstoreSetGas; used != want {
+			t.Errorf("call %d: gas used mismatch: have %d, want %d", i, used, want)
+		}
+	}
+	if nonce := statedb.GetNonce(counter); nonce != 1 {
+		t.Errorf("counter account nonce mismatch: have %d, want 1", nonce)
+	}
+	if _, _, err := evm.StaticCall(AccountRef(caller), counter, nil, 50000); err != ErrWriteProtection {
+		t.Errorf("static call error mismatch: have %v, want %v", err, ErrWriteProtection)
+	}
+	if _, _, err := evm.Call(AccountRef(caller), counter, nil, 1000, new(big.Int)); err != ErrOutOfGas {
+		t.Errorf("underpriced call error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+	// Run the stateless contract
+	ret, _, err := evm.Call(AccountRef(caller), identity, []byte{1, 2, 3}, 50000, new(big.Int))
+	if err != nil || string(ret) != string([]byte{1, 2, 3}) {
+		t.Errorf("identity mismatch: have %x, err %v", ret, err)
+	}
+	// Calls made from within a static call must not modify the state either
+	relay := common.HexToAddress("0x1338")
+	statedb.SetCode(relay, common.FromHex("6000600060006000600061010061fffff160005260206000f3"))
+
+	ret, _, err = evm.StaticCall(AccountRef(caller), relay, nil, 200000)
+	if err != nil {
+		t.Fatalf("failed to run relay: %v", err)
+	}
+	if new(big.Int).SetBytes(ret).Sign() != 0 {
+		t.Errorf("nested call in static frame succeeded")
+	}
+	if value := statedb.GetState(counter, common.Hash{}).Big().Uint64(); value != 6 {
+		t.Errorf("counter modified in static frame: have %d, want 6", value)
+	}
+	// Delegated calls run on the account of the caller, leaving its nonce alone
+	delegator := common.HexToAddress("0x1339")
+	statedb.SetCode(delegator, common.FromHex("600060006000600061010061fffff460005260206000f3"))
+
+	if ret, _, err = evm.Call(AccountRef(caller), delegator, nil, 200000, new(big.Int)); err != nil || new(big.Int).SetBytes(ret).Sign() == 0 {
+		t.Fatalf("failed to delegate to counter: %x, %v", ret, err)
+	}
+	if value := statedb.GetState(delegator, common.Hash{}).Big().Uint64(); value != 3 {
+		t.Errorf("delegated counter mismatch: have %d, want 3", value)
+	}
+	if nonce := statedb.GetNonce(delegator); nonce != 0 {
+		t.Errorf("delegator nonce modified: have %d, want 0", nonce)
+	}
+	// The contract sets must be shared between EVMs of the same block
+	if a, b := newEVM(20).precompiles, newEVM(20).precompiles; fmt.Sprintf("%p", a) != fmt.Sprintf("%p", b) {
+		t.Errorf("precompile set rebuilt for every EVM")
+	}
+}
+
+// Tests that invalid custom precompile configurations are rejected.
+func TestValidatePrecompiles(t *testing.T) {
+	tests := []struct {
+		precompiles []*params.PrecompileConfig
+		valid       bool
+	}{
+		{
+			[]*params.PrecompileConfig{{Name: "test-identity", Address: common.HexToAddress("0x0100")}},
+			true,
+		},
+		{
+			[]*params.PrecompileConfig{{Name: "test-unknown", Address: common.HexToAddress("0x0100")}},
+			false,
+		},
+		{
+			[]*params.PrecompileConfig{{Name: "test-counter", Address: common.HexToAddress("0x0100"), Config: json.RawMessage(`{"step": "x"}`)}},
+			false,
+		},
+		{
+			[]*params.PrecompileConfig{{Name: "test-identity", Address: common.BytesToAddress([]byte{9})}},
+			false,
+		},
+		{
+			[]*params.PrecompileConfig{
+				{Name: "test-identity", Address: common.HexToAddress("0x0100")},
+				{Name: "test-counter", Address: common.HexToAddress("0x0100"), Config: json.RawMessage(`{"step": 1}`)},
+			},
+			false,
+		},
+	}
+	for i, tt := range tests {
+		err := ValidatePrecompiles(&params.ChainConfig{Precompiles: tt.precompiles})
+		if (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, err, tt.valid)
+		}
+	}
+}
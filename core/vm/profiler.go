@@ -0,0 +1,305 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+// ProfileLocation identifies an instruction of a contract: the address of the
+// code, the program counter and the opcode found there.
+type ProfileLocation struct {
+	Address common.Address
+	Pc      uint64
+	Op      OpCode
+}
+
+// ProfileEntry is the execution count, gas and wall-clock time spent on an
+// instruction, excluding the calls it makes.
+type ProfileEntry struct {
+	Address common.Address `json:"address"`
+	Pc      uint64         `json:"pc"`
+	Op      string         `json:"op"`
+	Count   uint64         `json:"count"`
+	Gas     uint64         `json:"gas"`
+	Time    time.Duration  `json:"time"`
+}
+
+// FlameGraphNode is a node of a flame graph of the gas usage, in the format of
+// d3-flame-graph. The value of a node is the gas used by the instruction and
+// the calls it made, while the time is the wall-clock time spent in both.
+type FlameGraphNode struct {
+	Name     string            `json:"name"`
+	Value    uint64            `json:"value"`
+	Count    uint64            `json:"count"`
+	Time     time.Duration     `json:"time"`
+	Children []*FlameGraphNode `json:"children,omitempty"`
+}
+
+// profileNode is an instruction executed with a given call stack, with its
+// aggregated usage excluding the calls it made.
+type profileNode struct {
+	loc    ProfileLocation
+	parent *profileNode
+
+	count uint64
+	gas   uint64
+	time  time.Duration
+
+	children map[ProfileLocation]*profileNode
+}
+
+// child returns the node of an instruction executed by a call made from n,
+// creating it if needed.
+func (n *profileNode) child(loc ProfileLocation) *profileNode {
+	if c, ok := n.children[loc]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[ProfileLocation]*profileNode)
+	}
+	c := &profileNode{loc: loc, parent: n}
+	n.children[loc] = c
+	return c
+}
+
+// profileFrame is the state of a call being profiled. The usage of a step is
+// only known once the next step of the same call is reached, so it's kept
+// pending until then.
+type profileFrame struct {
+	caller *profileNode // Node of the instruction which made the call
+	start  time.Time    // Time the call started at
+	used   uint64       // Gas used by the call so far
+
+	pending *profileNode  // Node of the step in progress
+	gas     uint64        // Gas available before the step in progress
+	cost    uint64        // Gas charged upfront for the step in progress
+	burn    bool          // Whether the step in progress consumes all the gas
+	began   time.Time     // Time the step in progress started at
+	subGas  uint64        // Gas used by the calls of the step in progress
+	subTime time.Duration // Time spent in the calls of the step in progress
+}
+
+// Profiler is a Tracer aggregating the gas, the execution count and the
+// wall-clock time spent by contract, program counter and opcode. The profile
+// spans all the executions traced, so a single profiler can cover a whole
+// block. It is not safe for concurrent use.
+//
+// The gas of an instruction excludes the gas used by the calls it makes, which
+// is accounted to the instructions of the callee. Calls to precompiled contracts
+// and accounts without code are accounted to the calling instruction.
+type Profiler struct {
+	root   profileNode
+	frames []*profileFrame
+}
+
+// NewProfiler creates an empty profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// CaptureStart implements the Tracer interface to start a profiled execution.
+func (p *Profiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	p.frames = p.frames[:0]
+	return nil
+}
+
+// CaptureState implements the Tracer interface, accounting the previous step of
+// the call and starting the new one.
+func (p *Profiler) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	now := time.Now()
+
+	// Return from the calls which ended, and account the previous step
+	p.unwind(depth, now)
+	if len(p.frames) == depth {
+		p.frames[depth-1].finish(&gas, now)
+	}
+	// Enter the new call if the step starts one
+	for len(p.frames) < depth {
+		caller := &p.root
+		if n := len(p.frames); n > 0 && p.frames[n-1].pending != nil {
+			caller = p.frames[n-1].pending
+		}
+		p.frames = append(p.frames, &profileFrame{caller: caller, start: now})
+	}
+	frame := p.frames[depth-1]
+
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	node := frame.caller.child(ProfileLocation{Address: addr, Pc: pc, Op: op})
+	node.count++
+
+	frame.pending = node
+	frame.gas, frame.cost = gas, cost
+	frame.burn = err != nil // Failed before running, consuming all the gas
+	frame.began = now
+	frame.subGas, frame.subTime = 0, 0
+	return nil
+}
+
+// CaptureFault implements the Tracer interface, marking the step in progress
+// as consuming all the gas of the call unless it reverted.
+func (p *Profiler) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	p.unwind(depth, time.Now())
+	if len(p.frames) == depth && err != ErrExecutionReverted {
+		p.frames[depth-1].burn = true
+	}
+	return nil
+}
+
+// CaptureEnd implements the Tracer interface, accounting the last steps of the
+// execution.
+func (p *Profiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	p.unwind(0, time.Now())
+	return nil
+}
+
+// unwind returns from the calls deeper than the given depth, accounting their
+// last step and their usage to the step which made them.
+func (p *Profiler) unwind(depth int, now time.Time) {
+	for len(p.frames) > depth {
+		n := len(p.frames)
+		frame := p.frames[n-1]
+		frame.finish(nil, now)
+
+		p.frames = p.frames[:n-1]
+		if n > 1 {
+			parent := p.frames[n-2]
+			parent.subGas += frame.used
+			parent.subTime += now.Sub(frame.start)
+		}
+	}
+}
+
+// finish accounts the step in progress of the call, given the gas available
+// after it if known. When unknown, the gas charged upfront is used instead.
+func (f *profileFrame) finish(gasAfter *uint64, now time.Time) {
+	if f.pending == nil {
+		return
+	}
+	var used uint64
+	switch {
+	case f.burn:
+		used = f.gas
+	case gasAfter != nil:
+		// A call returning its unused stipend may leave more gas than before
+		if *gasAfter < f.gas {
+			used = f.gas - *gasAfter
+		}
+	default:
+		used = f.cost
+	}
+	// The calls may use more than paid for thanks to the stipend
+	if used < f.subGas {
+		used = f.subGas
+	}
+	f.pending.gas += used - f.subGas
+	f.used += used
+
+	if elapsed := now.Sub(f.began); elapsed > f.subTime {
+		f.pending.time += elapsed - f.subTime
+	}
+	f.pending = nil
+}
+
+// Entries returns the usage aggregated by instruction, sorted by decreasing
+// gas usage.
+func (p *Profiler) Entries() []ProfileEntry {
+	entries := make(map[ProfileLocation]*ProfileEntry)
+	p.walk(&p.root, func(n *profileNode) {
+		entry, ok := entries[n.loc]
+		if !ok {
+			entry = &ProfileEntry{Address: n.loc.Address, Pc: n.loc.Pc, Op: n.loc.Op.String()}
+			entries[n.loc] = entry
+		}
+		entry.Count += n.count
+		entry.Gas += n.gas
+		entry.Time += n.time
+	})
+	list := make([]ProfileEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Gas != list[j].Gas {
+			return list[i].Gas > list[j].Gas
+		}
+		if c := bytes.Compare(list[i].Address[:], list[j].Address[:]); c != 0 {
+			return c < 0
+		}
+		return list[i].Pc < list[j].Pc
+	})
+	return list
+}
+
+// FlameGraph returns the usage of the instructions by call stack, as a flame
+// graph rooted at the executions traced.
+func (p *Profiler) FlameGraph() *FlameGraphNode {
+	root := p.flameGraph(&p.root)
+	root.Name = "all"
+	return root
+}
+
+// flameGraph converts the subtree of a node into a flame graph.
+func (p *Profiler) flameGraph(n *profileNode) *FlameGraphNode {
+	node := &FlameGraphNode{
+		Name:  fmt.Sprintf("%s:%d %s", n.loc.Address.Hex(), n.loc.Pc, n.loc.Op),
+		Value: n.gas,
+		Count: n.count,
+		Time:  n.time,
+	}
+	for _, c := range sortedChildren(n) {
+		child := p.flameGraph(c)
+		node.Value += child.Value
+		node.Time += child.Time
+		node.Children = append(node.Children, child)
+	}
+	return node
+}
+
+// walk calls fn on all the nodes below n, parents first.
+func (p *Profiler) walk(n *profileNode, fn func(*profileNode)) {
+	for _, c := range sortedChildren(n) {
+		fn(c)
+		p.walk(c, fn)
+	}
+}
+
+// sortedChildren returns the children of a node ordered by location, so the
+// outputs are deterministic.
+func sortedChildren(n *profileNode) []*profileNode {
+	children := make([]*profileNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		a, b := children[i].loc, children[j].loc
+		if c := bytes.Compare(a.Address[:], b.Address[:]); c != 0 {
+			return c < 0
+		}
+		return a.Pc < b.Pc
+	})
+	return children
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// WritePprof writes the profile in the gzipped protobuf format of pprof. Each
+// instruction is reported as a line of a function named after its opcode, in
+// a file named after its contract, so pprof can aggregate the usage by opcode,
+// by contract or by instruction. The samples hold the execution count, the gas
+// and the wall-clock time, the gas being the default.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	enc := newPprofEncoder()
+
+	// Declare the sample types, and the samples of the call stacks
+	for _, typ := range [][2]string{{"count", "count"}, {"gas", "gas"}, {"time", "nanoseconds"}} {
+		var vt protobuf
+		vt.varint(1, uint64(enc.str(typ[0])))
+		vt.varint(2, uint64(enc.str(typ[1])))
+		enc.profile.bytes(1, vt)
+	}
+	p.walk(&p.root, func(n *profileNode) {
+		if n.count == 0 {
+			return
+		}
+		var stack []uint64
+		for c := n; c != &p.root; c = c.parent {
+			stack = append(stack, enc.location(c.loc))
+		}
+		var sample protobuf
+		sample.packed(1, stack)
+		sample.packed(2, []uint64{n.count, n.gas, uint64(n.time)})
+		enc.profile.bytes(2, sample)
+	})
+	enc.profile = append(enc.profile, enc.locations...)
+	enc.profile = append(enc.profile, enc.functions...)
+	for _, s := range enc.strings {
+		enc.profile.bytes(6, []byte(s))
+	}
+	enc.profile.varint(14, uint64(enc.str("gas")))
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(enc.profile); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// pprofEncoder assembles a profile message, interning its strings, locations
+// and functions.
+type pprofEncoder struct {
+	profile   protobuf
+	locations protobuf
+	functions protobuf
+
+	strings     []string
+	stringIDs   map[string]int
+	locationIDs map[ProfileLocation]uint64
+	functionIDs map[ProfileLocation]uint64
+}
+
+func newPprofEncoder() *pprofEncoder {
+	return &pprofEncoder{
+		strings:     []string{""},
+		stringIDs:   map[string]int{"": 0},
+		locationIDs: make(map[ProfileLocation]uint64),
+		functionIDs: make(map[ProfileLocation]uint64),
+	}
+}
+
+// str returns the index of a string in the string table.
+func (e *pprofEncoder) str(s string) int {
+	if id, ok := e.stringIDs[s]; ok {
+		return id
+	}
+	e.strings = append(e.strings, s)
+	e.stringIDs[s] = len(e.strings) - 1
+	return len(e.strings) - 1
+}
+
+// location returns the id of the location of an instruction, its address being
+// the program counter.
+func (e *pprofEncoder) location(loc ProfileLocation) uint64 {
+	if id, ok := e.locationIDs[loc]; ok {
+		return id
+	}
+	id := uint64(len(e.locationIDs) + 1)
+	e.locationIDs[loc] = id
+
+	var line protobuf
+	line.varint(1, e.function(loc))
+	line.varint(2, loc.Pc)
+
+	var msg protobuf
+	msg.varint(1, id)
+	msg.varint(3, loc.Pc)
+	msg.bytes(4, line)
+	e.locations.bytes(4, msg)
+	return id
+}
+
+// function returns the id of the function of an opcode within a contract.
+func (e *pprofEncoder) function(loc ProfileLocation) uint64 {
+	key := ProfileLocation{Address: loc.Address, Op: loc.Op}
+	if id, ok := e.functionIDs[key]; ok {
+		return id
+	}
+	id := uint64(len(e.functionIDs) + 1)
+	e.functionIDs[key] = id
+
+	name := uint64(e.str(loc.Op.String()))
+	var msg protobuf
+	msg.varint(1, id)
+	msg.varint(2, name)
+	msg.varint(3, name)
+	msg.varint(4, uint64(e.str(loc.Address.Hex())))
+	e.functions.bytes(5, msg)
+	return id
+}
+
+// protobuf is an encoded protocol buffer message.
+type protobuf []byte
+
+func (b *protobuf) uvarint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+// varint appends a varint field.
+func (b *protobuf) varint(field int, v uint64) {
+	b.uvarint(uint64(field) << 3)
+	b.uvarint(v)
+}
+
+// bytes appends a length-delimited field.
+func (b *protobuf) bytes(field int, data []byte) {
+	b.uvarint(uint64(field)<<3 | 2)
+	b.uvarint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+// packed appends a packed repeated varint field.
+func (b *protobuf) packed(field int, vs []uint64) {
+	var data protobuf
+	for _, v := range vs {
+		data.uvarint(v)
+	}
+	b.bytes(field, data)
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// Tests that the profiler accounts the gas of the instructions excluding their
+// calls, so the whole profile adds up to the gas used.
+func TestProfiler(t *testing.T) {
+	var (
+		caller = common.HexToAddress("0x1337")
+		outer  = common.HexToAddress("0xaa")
+		store  = common.HexToAddress("0xbb")
+		fail   = common.HexToAddress("0xcc")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	// Call the storing contract, then the failing one with 0x1000 gas
+	statedb.SetCode(outer, common.FromHex("6000600060006000600060bb61fffff1506000600060006000600060cc611000f15000"))
+	statedb.SetCode(store, common.FromHex("600160005500"))
+	statedb.SetCode(fail, common.FromHex("fe"))
+
+	profiler := NewProfiler()
+	vmctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	evm := NewEVM(vmctx, statedb, params.AllEthashProtocolChanges, Config{Debug: true, Tracer: profiler})
+
+	gas := uint64(1000000)
+	_, left, err := evm.Call(AccountRef(caller), outer, nil, gas, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	used := gas - left
+
+	var total uint64
+	entries := make(map[ProfileLocation]ProfileEntry)
+	for _, entry := range profiler.Entries() {
+		total += entry.Gas
+		entries[ProfileLocation{Address: entry.Address, Pc: entry.Pc}] = entry
+	}
+	if total != used {
+		t.Errorf("profile gas mismatch: have %d, want %d", total, used)
+	}
+	for _, tt := range []struct {
+		loc   ProfileLocation
+		count uint64
+		gas   uint64
+	}{
+		{ProfileLocation{outer, 15, CALL}, 1, params.CallGasEIP150},
+		{ProfileLocation{outer, 32, CALL}, 1, params.CallGasEIP150},
+		{ProfileLocation{store, 4, SSTORE}, 1, params.SstoreInitGasEIP2200},
+		{ProfileLocation{fail, 0, OpCode(0xfe)}, 1, 0x1000},
+	} {
+		entry, ok := entries[ProfileLocation{Address: tt.loc.Address, Pc: tt.loc.Pc}]
+		if !ok || entry.Op != tt.loc.Op.String() {
+			t.Errorf("%x:%d %v: missing from the profile", tt.loc.Address, tt.loc.Pc, tt.loc.Op)
+			continue
+		}
+		if entry.Count != tt.count || entry.Gas != tt.gas {
+			t.Errorf("%x:%d %v: usage mismatch: have %d times %d gas, want %d times %d gas", tt.loc.Address, tt.loc.Pc, tt.loc.Op, entry.Count, entry.Gas, tt.count, tt.gas)
+		}
+	}
+	// Check the flame graph covers the whole execution, through the calls
+	graph := profiler.FlameGraph()
+	if graph.Value != used {
+		t.Errorf("flame graph gas mismatch: have %d, want %d", graph.Value, used)
+	}
+	if len(graph.Children) == 0 || len(graph.Children[0].Children) != 0 {
+		t.Fatalf("flame graph misses the steps of the outer contract")
+	}
+	var calls int
+	for _, child := range graph.Children {
+		if len(child.Children) > 0 {
+			calls++
+		}
+	}
+	if calls != 2 {
+		t.Errorf("flame graph calls mismatch: have %d, want 2", calls)
+	}
+	// Check the pprof output is a gzipped profile message
+	var buf bytes.Buffer
+	if err := profiler.WritePprof(&buf); err != nil {
+		t.Fatalf("failed to write pprof profile: %v", err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("pprof profile not gzipped: %v", err)
+	}
+	msg, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress pprof profile: %v", err)
+	}
+	if len(msg) == 0 || msg[0] != 1<<3|2 {
+		t.Errorf("pprof profile doesn't start with a sample type: %x", msg)
+	}
+}
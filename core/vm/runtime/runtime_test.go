@@ -242,6 +242,49 @@ func (d *dummyChain) GetHeader(h common.Hash, n uint64) *types.Header {
 	return fakeHeader(n, parentHash)
 }
 
+// Tests that contract creations respect the code and initcode size limits of
+// the chain config.
+func TestContractSizeLimits(t *testing.T) {
+	// deploy returns initcode deploying size zero bytes
+	deploy := func(size int) []byte {
+		return []byte{byte(vm.PUSH2), byte(size >> 8), byte(size), byte(vm.PUSH1), 0, byte(vm.RETURN)}
+	}
+	// create returns initcode creating a contract from size bytes of memory
+	create := func(size byte) []byte {
+		return []byte{byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CREATE)}
+	}
+	limited := &params.ChainConfig{
+		ChainID:            big.NewInt(1),
+		HomesteadBlock:     new(big.Int),
+		EIP150Block:        new(big.Int),
+		EIP155Block:        new(big.Int),
+		EIP158Block:        new(big.Int),
+		CodeSizeLimitBlock: new(big.Int),
+		MaxCodeSize:        0x8000,
+		InitCodeLimitBlock: new(big.Int),
+		MaxInitCodeSize:    64,
+	}
+	tests := []struct {
+		config *params.ChainConfig
+		code   []byte
+		err    error
+	}{
+		{code: deploy(params.MaxCodeSize), err: nil},
+		{code: deploy(params.MaxCodeSize + 1), err: vm.ErrMaxCodeSizeExceeded},
+		{code: append(deploy(1), make([]byte, 64)...), err: nil},
+		{config: limited, code: deploy(0x8000), err: nil},
+		{config: limited, code: deploy(0x8001), err: vm.ErrMaxCodeSizeExceeded},
+		{config: limited, code: append(deploy(1), make([]byte, 64)...), err: vm.ErrMaxInitCodeSizeExceeded},
+		{config: limited, code: create(64), err: nil},
+		{config: limited, code: create(65), err: vm.ErrOutOfGas},
+	}
+	for i, test := range tests {
+		if _, _, _, err := Create(test.code, &Config{ChainConfig: test.config}); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
 // TestBlockhash tests the blockhash operation. It's a bit special, since it internally
 // requires access to a chain reader.
 func TestBlockhash(t *testing.T) {
@@ -0,0 +1,336 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Arithmetic of the base field, on elements in Montgomery form. The results
+// may alias any of the operands.
+
+// toMont converts a canonical value to the Montgomery form.
+func toMont(c, a *fe) {
+	mul(c, a, &r2)
+}
+
+// fromMont converts an element in Montgomery form to its canonical value.
+func fromMont(c, a *fe) {
+	mul(c, a, &fe{1})
+}
+
+// reduce subtracts the modulus from a value less than twice the modulus, if
+// it's not less than the modulus.
+func reduce(c *fe) {
+	var (
+		t      fe
+		borrow uint64
+	)
+	for i := 0; i < 6; i++ {
+		t[i], borrow = bits.Sub64(c[i], modulus[i], borrow)
+	}
+	if borrow == 0 {
+		*c = t
+	}
+}
+
+func add(c, a, b *fe) {
+	var carry uint64
+	for i := 0; i < 6; i++ {
+		c[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	reduce(c) // Both operands are below 2^381, the sum can't overflow
+}
+
+func double(c, a *fe) {
+	add(c, a, a)
+}
+
+func sub(c, a, b *fe) {
+	var borrow uint64
+	for i := 0; i < 6; i++ {
+		c[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	if borrow != 0 {
+		var carry uint64
+		for i := 0; i < 6; i++ {
+			c[i], carry = bits.Add64(c[i], modulus[i], carry)
+		}
+	}
+}
+
+// half computes a/2, adding the modulus to odd values before the shift. The
+// sum fits into 384 bits as both are below 2^381.
+func half(c, a *fe) {
+	if a[0]&1 == 1 {
+		ladd(c, a, &modulus)
+	} else {
+		c.set(a)
+	}
+	rsh1(c)
+}
+
+func neg(c, a *fe) {
+	if a.isZero() {
+		c.zero()
+		return
+	}
+	sub(c, &modulus, a)
+}
+
+// madd computes a*b + c + d, which can't overflow 128 bits, returning the high
+// and low limbs.
+func madd(a, b, c, d uint64) (hi, lo uint64) {
+	var carry uint64
+	hi, lo = bits.Mul64(a, b)
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, d, 0)
+	hi += carry
+	return hi, lo
+}
+
+// mul computes the Montgomery product a*b/R mod p, interleaving the schoolbook
+// multiplication and the reduction one limb at a time. The rounds are unrolled
+// to keep the accumulator in registers. As the top limb of the modulus is below
+// 2^62, the accumulator never needs a seventh limb.
+func mul(c, a, b *fe) {
+	var t0, t1, t2, t3, t4, t5, A, C, m uint64
+
+	// Accumulate a*b[0], then add a multiple of the modulus clearing the
+	// lowest limb and shift down
+	A, t0 = bits.Mul64(a[0], b[0])
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[0], A, 0)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[0], A, 0)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[0], A, 0)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[0], A, 0)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[0], A, 0)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+
+	A, t0 = madd(a[0], b[1], t0, 0)
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[1], t1, A)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[1], t2, A)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[1], t3, A)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[1], t4, A)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[1], t5, A)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+
+	A, t0 = madd(a[0], b[2], t0, 0)
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[2], t1, A)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[2], t2, A)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[2], t3, A)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[2], t4, A)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[2], t5, A)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+
+	A, t0 = madd(a[0], b[3], t0, 0)
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[3], t1, A)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[3], t2, A)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[3], t3, A)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[3], t4, A)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[3], t5, A)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+
+	A, t0 = madd(a[0], b[4], t0, 0)
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[4], t1, A)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[4], t2, A)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[4], t3, A)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[4], t4, A)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[4], t5, A)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+
+	A, t0 = madd(a[0], b[5], t0, 0)
+	m = t0 * inp
+	C, _ = madd(m, modulus[0], t0, 0)
+	A, t1 = madd(a[1], b[5], t1, A)
+	C, t0 = madd(m, modulus[1], t1, C)
+	A, t2 = madd(a[2], b[5], t2, A)
+	C, t1 = madd(m, modulus[2], t2, C)
+	A, t3 = madd(a[3], b[5], t3, A)
+	C, t2 = madd(m, modulus[3], t3, C)
+	A, t4 = madd(a[4], b[5], t4, A)
+	C, t3 = madd(m, modulus[4], t4, C)
+	A, t5 = madd(a[5], b[5], t5, A)
+	C, t4 = madd(m, modulus[5], t5, C)
+	t5 = C + A
+	// The result is below twice the modulus, which fits into 384 bits
+	c[0], c[1], c[2], c[3], c[4], c[5] = t0, t1, t2, t3, t4, t5
+	reduce(c)
+}
+
+func square(c, a *fe) {
+	mul(c, a, a)
+}
+
+// exp computes a^e for a non-negative exponent, multiplying by a precomputed
+// power of a for every 4 bit window of the exponent.
+func exp(c, a *fe, e *big.Int) {
+	var (
+		z     fe
+		table [15]fe
+	)
+	table[0] = *a
+	for i := 1; i < len(table); i++ {
+		mul(&table[i], &table[i-1], a)
+	}
+	z.one()
+	for start := (e.BitLen() - 1) / 4 * 4; start >= 0; start -= 4 {
+		for i := 0; i < 4; i++ {
+			square(&z, &z)
+		}
+		if idx := scalarWindow(e, start, 4); idx > 0 {
+			mul(&z, &z, &table[idx-1])
+		}
+	}
+	c.set(&z)
+}
+
+// inverse computes a^-1 with the binary extended Euclidean algorithm, mapping
+// zero to zero. On the Montgomery form aR, the first phase yields (aR)^-1 2^k
+// for some k between 381 and 768, which the doublings of the second phase turn
+// into (aR)^-1 2^768 = a^-1 R. See "The Montgomery Modular Inverse - Revisited"
+// by Savaş and Koç.
+func inverse(c, a *fe) {
+	if a.isZero() {
+		c.zero()
+		return
+	}
+	var (
+		u, v = modulus, *a
+		r, s = fe{}, fe{1}
+		k    int
+	)
+	// The loop keeps p = us + vr, hence r and s up to p until the last doubling
+	for !v.isZero() {
+		switch {
+		case u[0]&1 == 0:
+			rsh1(&u)
+			lsh1(&s)
+		case v[0]&1 == 0:
+			rsh1(&v)
+			lsh1(&r)
+		case u.cmp(&v) > 0:
+			lsub(&u, &u, &v)
+			rsh1(&u)
+			ladd(&r, &r, &s)
+			lsh1(&s)
+		default:
+			lsub(&v, &v, &u)
+			rsh1(&v)
+			ladd(&s, &s, &r)
+			lsh1(&r)
+		}
+		k++
+	}
+	if r.cmp(&modulus) >= 0 {
+		lsub(&r, &r, &modulus)
+	}
+	lsub(c, &modulus, &r)
+	for ; k < 768; k++ {
+		double(c, c)
+	}
+}
+
+// ladd, lsub, lsh1 and rsh1 operate on the limbs as plain 384 bit numbers,
+// ignoring any carry or borrow out of the top limb.
+func ladd(c, a, b *fe) {
+	var carry uint64
+	for i := 0; i < 6; i++ {
+		c[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+}
+
+func lsub(c, a, b *fe) {
+	var borrow uint64
+	for i := 0; i < 6; i++ {
+		c[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+}
+
+func lsh1(a *fe) {
+	for i := 5; i > 0; i-- {
+		a[i] = a[i]<<1 | a[i-1]>>63
+	}
+	a[0] <<= 1
+}
+
+func rsh1(a *fe) {
+	for i := 0; i < 5; i++ {
+		a[i] = a[i]>>1 | a[i+1]<<63
+	}
+	a[5] >>= 1
+}
+
+// sqrt computes a square root of a, reporting whether a is a quadratic residue.
+// As p = 3 mod 4, the root is a^((p+1)/4).
+func sqrt(c, a *fe) bool {
+	var r, t fe
+	exp(&r, a, pPlus1Over4)
+	square(&t, &r)
+	if !t.equal(a) {
+		return false
+	}
+	c.set(&r)
+	return true
+}
+
+// isQuadraticResidue reports whether a is a square in the base field, zero
+// included.
+func isQuadraticResidue(a *fe) bool {
+	if a.isZero() {
+		return true
+	}
+	var t fe
+	exp(&t, a, pMinus1Over2)
+	return t.isOne()
+}
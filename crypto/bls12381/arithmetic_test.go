@@ -0,0 +1,168 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+const fuzz = 100
+
+func randomBig(t testing.TB, max *big.Int) *big.Int {
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func randomFe(t testing.TB) *fe {
+	return fromBig(randomBig(t, modulusBig))
+}
+
+func randomFe2(t testing.TB) *fe2 {
+	return &fe2{*randomFe(t), *randomFe(t)}
+}
+
+func randomFe12(t testing.TB) *fe12 {
+	var e fe12
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			e[i][j].set(randomFe2(t))
+		}
+	}
+	return &e
+}
+
+// Tests the base field arithmetic against math/big.
+func TestFieldArithmetic(t *testing.T) {
+	edges := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(modulusBig, big.NewInt(1))}
+	for i := 0; i < fuzz+len(edges); i++ {
+		var a, b *big.Int
+		if i < len(edges) {
+			a, b = edges[i], edges[len(edges)-1-i]
+		} else {
+			a, b = randomBig(t, modulusBig), randomBig(t, modulusBig)
+		}
+		fa, fb := fromBig(a), fromBig(b)
+
+		var c fe
+		check := func(op string, want *big.Int) {
+			t.Helper()
+			if have := toBig(&c); have.Cmp(want.Mod(want, modulusBig)) != 0 {
+				t.Fatalf("%s(%x, %x): have %x, want %x", op, a, b, have, want)
+			}
+		}
+		add(&c, fa, fb)
+		check("add", new(big.Int).Add(a, b))
+		sub(&c, fa, fb)
+		check("sub", new(big.Int).Sub(a, b))
+		neg(&c, fa)
+		check("neg", new(big.Int).Neg(a))
+		mul(&c, fa, fb)
+		check("mul", new(big.Int).Mul(a, b))
+		square(&c, fa)
+		check("square", new(big.Int).Mul(a, a))
+		inverse(&c, fa)
+		if a.Sign() == 0 {
+			check("inverse", new(big.Int))
+		} else {
+			check("inverse", new(big.Int).ModInverse(a, modulusBig))
+		}
+		want := big.Jacobi(a, modulusBig) >= 0
+		if isQuadraticResidue(fa) != want {
+			t.Fatalf("quadratic residuosity mismatch for %x", a)
+		}
+		if sqrt(&c, fa) != want {
+			t.Fatalf("square root existence mismatch for %x", a)
+		}
+		if want {
+			square(&c, &c)
+			check("sqrt^2", new(big.Int).Set(a))
+		}
+	}
+}
+
+// Tests the encoding of field elements.
+func TestFieldEncoding(t *testing.T) {
+	for i := 0; i < fuzz; i++ {
+		a := randomFe(t)
+		b, err := fromBytes(toBytes(a))
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if !a.equal(b) {
+			t.Fatalf("encoding roundtrip mismatch")
+		}
+	}
+	if _, err := fromBytes(modulusBig.Bytes()); err != errFieldElementRange {
+		t.Fatalf("modulus decoded, err %v", err)
+	}
+	if _, err := fromBytes(make([]byte, 47)); err != errFieldElementLength {
+		t.Fatalf("short element decoded, err %v", err)
+	}
+}
+
+// Tests the arithmetic of the extension fields.
+func TestExtensionFieldArithmetic(t *testing.T) {
+	for i := 0; i < fuzz; i++ {
+		// Fp2 square roots and inverses
+		var a, b, c fe2
+		a.set(randomFe2(t))
+		square2(&b, &a)
+		if !sqrt2(&c, &b) || !isQuadraticResidue2(&b) {
+			t.Fatalf("no square root of a square")
+		}
+		square2(&c, &c)
+		if !c.equal(&b) {
+			t.Fatalf("square root mismatch")
+		}
+		inverse2(&b, &a)
+		mul2(&b, &b, &a)
+		if !b.isOne() {
+			t.Fatalf("fp2 inverse mismatch")
+		}
+		// Fp12 multiplication and inverses
+		x, y, z := randomFe12(t), randomFe12(t), randomFe12(t)
+		var l, r fe12
+		mul12(&l, x, y)
+		mul12(&l, &l, z)
+		mul12(&r, y, z)
+		mul12(&r, x, &r)
+		if !l.equal(&r) {
+			t.Fatalf("fp12 multiplication not associative")
+		}
+		inverse12(&l, x)
+		mul12(&l, &l, x)
+		if !l.isOne() {
+			t.Fatalf("fp12 inverse mismatch")
+		}
+		// Frobenius maps against exponentiation
+		frobenius12(&l, x)
+		exp12(&r, x, modulusBig)
+		if !l.equal(&r) {
+			t.Fatalf("frobenius map mismatch")
+		}
+	}
+	var nonResidue, c fe2
+	nonResidue.set(&swuG2Z)
+	if sqrt2(&c, &nonResidue) || isQuadraticResidue2(&nonResidue) {
+		t.Fatalf("square root of a non-residue")
+	}
+}
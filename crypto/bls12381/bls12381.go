@@ -0,0 +1,109 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bls12381 implements the group operations and the optimal ate pairing
+// of the BLS12-381 curve, along with the simplified SWU maps of field elements
+// to the curve groups, as required by the EIP-2537 precompiled contracts.
+//
+// Points are encoded uncompressed, as the big-endian encoding of their affine
+// coordinates. The point at infinity is encoded as all zeroes.
+//
+// The implementation is not constant time, it must not be used on secret data.
+package bls12381
+
+import "math/big"
+
+var (
+	// modulusBig is the characteristic p of the base field.
+	modulusBig = bigFromHex("0x1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+	// q is the order r of the G1, G2 and GT groups.
+	q = bigFromHex("0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+	// curveX is the absolute value of the curve parameter, which is negative.
+	curveX = bigFromHex("0xd201000000010000")
+)
+
+var (
+	// modulus is p in 64 bit limbs, least significant first.
+	modulus fe
+
+	// inp is -p^-1 mod 2^64, used by the Montgomery reduction.
+	inp uint64
+
+	// r1 and r2 are R and R^2 modulo p, with R = 2^384 the Montgomery radix.
+	r1, r2 fe
+
+	// pPlus1Over4 and pMinus1Over2 are exponents used for square roots and
+	// quadratic residuosity in the base field.
+	pPlus1Over4, pMinus1Over2 *big.Int
+)
+
+// Generators of G1 and G2 as affine coordinates.
+var (
+	g1GeneratorX = "0x17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+	g1GeneratorY = "0x08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"
+
+	g2GeneratorX = [2]string{
+		"0x024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+		"0x13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e",
+	}
+	g2GeneratorY = [2]string{
+		"0x0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801",
+		"0x0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+	}
+)
+
+// Effective cofactors clearing the output of the maps to the curves, as defined
+// by the hash-to-curve specification.
+var (
+	cofactorEFFG1 = bigFromHex("0xd201000000010001")
+	cofactorEFFG2 = bigFromHex("0x0bc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551")
+)
+
+func init() {
+	// Derive the Montgomery arithmetic constants from the modulus
+	modulus.setBig(modulusBig)
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - modulus[0]*inv // Newton iteration, doubling the correct bits
+	}
+	inp = -inv
+
+	radix := new(big.Int).Lsh(big.NewInt(1), 384)
+	r1.setBig(new(big.Int).Mod(radix, modulusBig))
+	r2.setBig(new(big.Int).Mod(new(big.Int).Mul(radix, radix), modulusBig))
+
+	pPlus1Over4 = new(big.Int).Rsh(new(big.Int).Add(modulusBig, big.NewInt(1)), 2)
+	pMinus1Over2 = new(big.Int).Rsh(new(big.Int).Sub(modulusBig, big.NewInt(1)), 1)
+
+	initTower()
+	initSWU()
+	initIsogenies()
+	initGroups()
+}
+
+// bigFromHex parses a hex constant, panicking if it is malformed.
+func bigFromHex(hex string) *big.Int {
+	if len(hex) > 1 && hex[:2] == "0x" {
+		hex = hex[2:]
+	}
+	n, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		panic("invalid hex constant " + hex)
+	}
+	return n
+}
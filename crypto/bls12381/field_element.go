@@ -0,0 +1,253 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+)
+
+// fe is an element of the base field, stored in Montgomery form as six 64 bit
+// limbs, least significant first.
+type fe [6]uint64
+
+// fe2 is an element of the quadratic extension Fp[i]/(i^2+1), as c0 + c1*i.
+type fe2 [2]fe
+
+// fe6 is an element of the cubic extension Fp2[v]/(v^3-(1+i)), as
+// c0 + c1*v + c2*v^2.
+type fe6 [3]fe2
+
+// fe12 is an element of the quadratic extension Fp6[w]/(w^2-v), as c0 + c1*w.
+type fe12 [2]fe6
+
+var (
+	errFieldElementLength = errors.New("invalid field element length")
+	errFieldElementRange  = errors.New("field element not less than modulus")
+)
+
+// setBig sets the limbs of e to the given number, without any conversion to
+// the Montgomery form. The number must fit into 384 bits.
+func (e *fe) setBig(n *big.Int) *fe {
+	return e.setBytes(n.Bytes())
+}
+
+// setBytes sets the limbs of e to the given big-endian number, without any
+// conversion to the Montgomery form. The number must fit into 384 bits.
+func (e *fe) setBytes(in []byte) *fe {
+	var buf [48]byte
+	copy(buf[48-len(in):], in)
+	for i := 0; i < 6; i++ {
+		off := 48 - 8*(i+1)
+		e[i] = uint64(buf[off])<<56 | uint64(buf[off+1])<<48 | uint64(buf[off+2])<<40 | uint64(buf[off+3])<<32 |
+			uint64(buf[off+4])<<24 | uint64(buf[off+5])<<16 | uint64(buf[off+6])<<8 | uint64(buf[off+7])
+	}
+	return e
+}
+
+// bytes returns the big-endian encoding of the limbs of e, without any
+// conversion from the Montgomery form.
+func (e *fe) bytes() []byte {
+	out := make([]byte, 48)
+	for i := 0; i < 6; i++ {
+		off := 48 - 8*(i+1)
+		for j := 0; j < 8; j++ {
+			out[off+j] = byte(e[i] >> (56 - 8*j))
+		}
+	}
+	return out
+}
+
+// cmp compares the limbs of two elements as numbers.
+func (e *fe) cmp(o *fe) int {
+	for i := 5; i >= 0; i-- {
+		if e[i] > o[i] {
+			return 1
+		}
+		if e[i] < o[i] {
+			return -1
+		}
+	}
+	return 0
+}
+
+func (e *fe) set(o *fe) *fe {
+	*e = *o
+	return e
+}
+
+func (e *fe) zero() *fe {
+	*e = fe{}
+	return e
+}
+
+func (e *fe) one() *fe {
+	*e = r1
+	return e
+}
+
+func (e *fe) isZero() bool {
+	return *e == fe{}
+}
+
+func (e *fe) isOne() bool {
+	return *e == r1
+}
+
+func (e *fe) equal(o *fe) bool {
+	return *e == *o
+}
+
+// isOdd reports whether the canonical value of e is odd.
+func (e *fe) isOdd() bool {
+	var c fe
+	fromMont(&c, e)
+	return c[0]&1 == 1
+}
+
+// fromBytes decodes a 48 byte big-endian field element.
+func fromBytes(in []byte) (*fe, error) {
+	if len(in) != 48 {
+		return nil, errFieldElementLength
+	}
+	e := new(fe).setBytes(in)
+	if e.cmp(&modulus) >= 0 {
+		return nil, errFieldElementRange
+	}
+	toMont(e, e)
+	return e, nil
+}
+
+// toBytes encodes a field element as 48 big-endian bytes.
+func toBytes(e *fe) []byte {
+	var c fe
+	fromMont(&c, e)
+	return c.bytes()
+}
+
+// fromBytes2 decodes a 96 byte element of Fp2, as the big-endian encodings of
+// the coefficients c0 and c1.
+func fromBytes2(in []byte) (*fe2, error) {
+	if len(in) != 96 {
+		return nil, errFieldElementLength
+	}
+	c0, err := fromBytes(in[:48])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := fromBytes(in[48:])
+	if err != nil {
+		return nil, err
+	}
+	return &fe2{*c0, *c1}, nil
+}
+
+// toBytes2 encodes an element of Fp2 as 96 bytes.
+func toBytes2(e *fe2) []byte {
+	return append(toBytes(&e[0]), toBytes(&e[1])...)
+}
+
+// fromBig converts a number to a field element, reducing it modulo p.
+func fromBig(n *big.Int) *fe {
+	e := new(fe).setBig(new(big.Int).Mod(n, modulusBig))
+	toMont(e, e)
+	return e
+}
+
+// toBig returns the canonical value of a field element.
+func toBig(e *fe) *big.Int {
+	return new(big.Int).SetBytes(toBytes(e))
+}
+
+// fromHex converts a hex constant to a field element.
+func fromHex(hex string) *fe {
+	return fromBig(bigFromHex(hex))
+}
+
+// fe2FromHex converts the hex constants of the two coefficients to an element
+// of the quadratic extension.
+func fe2FromHex(c0, c1 string) *fe2 {
+	return &fe2{*fromHex(c0), *fromHex(c1)}
+}
+
+func (e *fe2) set(o *fe2) *fe2 {
+	*e = *o
+	return e
+}
+
+func (e *fe2) zero() *fe2 {
+	*e = fe2{}
+	return e
+}
+
+func (e *fe2) one() *fe2 {
+	e[0].one()
+	e[1].zero()
+	return e
+}
+
+func (e *fe2) isZero() bool {
+	return e[0].isZero() && e[1].isZero()
+}
+
+func (e *fe2) isOne() bool {
+	return e[0].isOne() && e[1].isZero()
+}
+
+func (e *fe2) equal(o *fe2) bool {
+	return *e == *o
+}
+
+func (e *fe6) set(o *fe6) *fe6 {
+	*e = *o
+	return e
+}
+
+func (e *fe6) zero() *fe6 {
+	*e = fe6{}
+	return e
+}
+
+func (e *fe6) one() *fe6 {
+	e[0].one()
+	e[1].zero()
+	e[2].zero()
+	return e
+}
+
+func (e *fe6) isZero() bool {
+	return e[0].isZero() && e[1].isZero() && e[2].isZero()
+}
+
+func (e *fe12) set(o *fe12) *fe12 {
+	*e = *o
+	return e
+}
+
+func (e *fe12) one() *fe12 {
+	e[0].one()
+	e[1].zero()
+	return e
+}
+
+func (e *fe12) isOne() bool {
+	return e[0][0].isOne() && e[0][1].isZero() && e[0][2].isZero() && e[1].isZero()
+}
+
+func (e *fe12) equal(o *fe12) bool {
+	return *e == *o
+}
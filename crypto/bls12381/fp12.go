@@ -0,0 +1,157 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// Arithmetic of the quadratic extension Fp12 = Fp6[w]/(w^2-v).
+
+func mul12(c, a, b *fe12) {
+	var t0, t1, s0, s1 fe6
+	mul6(&t0, &a[0], &b[0])
+	mul6(&t1, &a[1], &b[1])
+
+	// c1 = (a0+a1)(b0+b1) - t0 - t1
+	add6(&s0, &a[0], &a[1])
+	add6(&s1, &b[0], &b[1])
+	mul6(&s0, &s0, &s1)
+	sub6(&s0, &s0, &t0)
+	sub6(&c[1], &s0, &t1)
+
+	// c0 = t0 + v*t1
+	mulByNonResidue6(&t1, &t1)
+	add6(&c[0], &t0, &t1)
+}
+
+// mulBy014 multiplies an element by the sparse element (b0 + b1*v) + b4*v*w,
+// the shape of the lines of the Miller loop.
+func mulBy014(c, a *fe12, b0, b1, b4 *fe2) {
+	var t0, t1, s fe6
+	mulBy01(&t0, &a[0], b0, b1)
+	mulBy1(&t1, &a[1], b4)
+
+	// c1 = (a0+a1)(b0 + (b1+b4)v) - t0 - t1
+	var b fe2
+	add2(&b, b1, b4)
+	add6(&s, &a[0], &a[1])
+	mulBy01(&s, &s, b0, &b)
+	sub6(&s, &s, &t0)
+	sub6(&c[1], &s, &t1)
+
+	// c0 = t0 + v*t1
+	mulByNonResidue6(&t1, &t1)
+	add6(&c[0], &t0, &t1)
+}
+
+func square12(c, a *fe12) {
+	mul12(c, a, a)
+}
+
+// cyclotomicSquare12 squares an element of the cyclotomic subgroup, which is
+// the case for all the values after the easy part of the final exponentiation,
+// using the formulas of "Faster squaring in the cyclotomic subgroup of sixth
+// degree extensions" by Granger and Scott. The element is seen as three
+// elements of Fp4 = Fp2[s]/(s^2-ξ).
+func cyclotomicSquare12(c, a *fe12) {
+	var t0, t1, t2, t3, t4, t5, s fe2
+
+	fp4Square(&t0, &t1, &a[0][0], &a[1][1])
+	fp4Square(&t2, &t3, &a[1][0], &a[0][2])
+	fp4Square(&t4, &t5, &a[0][1], &a[1][2])
+
+	var r fe12
+
+	// r00 = 3t0 - 2a00, r11 = 3t1 + 2a11
+	sub2(&s, &t0, &a[0][0])
+	double2(&s, &s)
+	add2(&r[0][0], &s, &t0)
+	add2(&s, &t1, &a[1][1])
+	double2(&s, &s)
+	add2(&r[1][1], &s, &t1)
+
+	// r01 = 3t2 - 2a01, r12 = 3t3 + 2a12
+	sub2(&s, &t2, &a[0][1])
+	double2(&s, &s)
+	add2(&r[0][1], &s, &t2)
+	add2(&s, &t3, &a[1][2])
+	double2(&s, &s)
+	add2(&r[1][2], &s, &t3)
+
+	// r10 = 3ξt5 + 2a10, r02 = 3t4 - 2a02
+	mulByNonResidue2(&t5, &t5)
+	add2(&s, &t5, &a[1][0])
+	double2(&s, &s)
+	add2(&r[1][0], &s, &t5)
+	sub2(&s, &t4, &a[0][2])
+	double2(&s, &s)
+	add2(&r[0][2], &s, &t4)
+
+	c.set(&r)
+}
+
+// fp4Square computes (c0 + c1*s) = (a0 + a1*s)^2 in Fp4 = Fp2[s]/(s^2-ξ).
+func fp4Square(c0, c1, a0, a1 *fe2) {
+	var t0, t1, t2 fe2
+	square2(&t0, a0)
+	square2(&t1, a1)
+	add2(&t2, a0, a1)
+	square2(&t2, &t2)
+	sub2(&t2, &t2, &t0)
+	sub2(c1, &t2, &t1)
+	mulByNonResidue2(&t1, &t1)
+	add2(c0, &t1, &t0)
+}
+
+// conjugate12 computes c0 - c1*w, which is also the Frobenius map a^(p^6).
+func conjugate12(c, a *fe12) {
+	c[0].set(&a[0])
+	neg6(&c[1], &a[1])
+}
+
+// inverse12 computes a^-1 as conj(a)/(a0^2 - v*a1^2).
+func inverse12(c, a *fe12) {
+	var t0, t1 fe6
+	square6(&t0, &a[0])
+	square6(&t1, &a[1])
+	mulByNonResidue6(&t1, &t1)
+	sub6(&t0, &t0, &t1)
+	inverse6(&t0, &t0)
+
+	mul6(&c[0], &a[0], &t0)
+	mul6(&t1, &a[1], &t0)
+	neg6(&c[1], &t1)
+}
+
+// exp12 computes a^e for a non-negative exponent.
+func exp12(c, a *fe12, e *big.Int) {
+	var z fe12
+	z.one()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		square12(&z, &z)
+		if e.Bit(i) == 1 {
+			mul12(&z, &z, a)
+		}
+	}
+	c.set(&z)
+}
+
+// frobenius12 computes a^p.
+func frobenius12(c, a *fe12) {
+	frobenius6(&c[0], &a[0])
+	frobenius6(&c[1], &a[1])
+	mulByFp2(&c[1], &c[1], &frobeniusW)
+}
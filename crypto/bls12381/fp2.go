@@ -0,0 +1,158 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// Arithmetic of the quadratic extension Fp2 = Fp[i]/(i^2+1).
+
+// pMinus3Over4 is an exponent of the square root algorithm.
+var pMinus3Over4 = new(big.Int).Rsh(new(big.Int).Sub(modulusBig, big.NewInt(3)), 2)
+
+func add2(c, a, b *fe2) {
+	add(&c[0], &a[0], &b[0])
+	add(&c[1], &a[1], &b[1])
+}
+
+func double2(c, a *fe2) {
+	double(&c[0], &a[0])
+	double(&c[1], &a[1])
+}
+
+func half2(c, a *fe2) {
+	half(&c[0], &a[0])
+	half(&c[1], &a[1])
+}
+
+func sub2(c, a, b *fe2) {
+	sub(&c[0], &a[0], &b[0])
+	sub(&c[1], &a[1], &b[1])
+}
+
+func neg2(c, a *fe2) {
+	neg(&c[0], &a[0])
+	neg(&c[1], &a[1])
+}
+
+// conjugate2 computes c0 - c1*i, which is also the Frobenius map a^p.
+func conjugate2(c, a *fe2) {
+	c[0].set(&a[0])
+	neg(&c[1], &a[1])
+}
+
+func mul2(c, a, b *fe2) {
+	var t0, t1, t2, t3 fe
+	mul(&t0, &a[0], &b[0])
+	mul(&t1, &a[1], &b[1])
+	add(&t2, &a[0], &a[1])
+	add(&t3, &b[0], &b[1])
+	mul(&t2, &t2, &t3)
+	sub(&t2, &t2, &t0)
+	sub(&c[1], &t2, &t1)
+	sub(&c[0], &t0, &t1)
+}
+
+func square2(c, a *fe2) {
+	var t0, t1, t2 fe
+	add(&t0, &a[0], &a[1])
+	sub(&t1, &a[0], &a[1])
+	mul(&t2, &a[0], &a[1])
+	mul(&c[0], &t0, &t1)
+	double(&c[1], &t2)
+}
+
+// mulByFp multiplies an element by a base field element.
+func mulByFp(c *fe2, a *fe2, b *fe) {
+	mul(&c[0], &a[0], b)
+	mul(&c[1], &a[1], b)
+}
+
+// mulByNonResidue2 multiplies an element by the non-residue 1+i defining the
+// cubic extension.
+func mulByNonResidue2(c, a *fe2) {
+	var t fe
+	sub(&t, &a[0], &a[1])
+	add(&c[1], &a[0], &a[1])
+	c[0].set(&t)
+}
+
+// inverse2 computes a^-1 as conj(a)/(c0^2 + c1^2), mapping zero to zero.
+func inverse2(c, a *fe2) {
+	var t0, t1 fe
+	square(&t0, &a[0])
+	square(&t1, &a[1])
+	add(&t0, &t0, &t1)
+	inverse(&t0, &t0)
+	mul(&c[0], &a[0], &t0)
+	mul(&t0, &a[1], &t0)
+	neg(&c[1], &t0)
+}
+
+// exp2 computes a^e for a non-negative exponent.
+func exp2(c, a *fe2, e *big.Int) {
+	var z fe2
+	z.one()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		square2(&z, &z)
+		if e.Bit(i) == 1 {
+			mul2(&z, &z, a)
+		}
+	}
+	c.set(&z)
+}
+
+// sqrt2 computes a square root of a, reporting whether a is a quadratic residue,
+// using the algorithm for p = 3 mod 4 of "Square root computation over even
+// extension fields" by Adj and Rodríguez-Henríquez.
+func sqrt2(c, a *fe2) bool {
+	var a1, alpha, x0, t fe2
+	exp2(&a1, a, pMinus3Over4)
+	square2(&alpha, &a1)
+	mul2(&alpha, &alpha, a)
+	mul2(&x0, &a1, a)
+
+	var minusOne fe2
+	neg2(&minusOne, minusOne.one())
+	if alpha.equal(&minusOne) {
+		// The root is i*x0
+		t[0].set(&x0[1])
+		neg(&t[0], &t[0])
+		t[1].set(&x0[0])
+	} else {
+		var b fe2
+		add2(&b, b.one(), &alpha)
+		exp2(&b, &b, pMinus1Over2)
+		mul2(&t, &b, &x0)
+	}
+	var check fe2
+	square2(&check, &t)
+	if !check.equal(a) {
+		return false
+	}
+	c.set(&t)
+	return true
+}
+
+// isQuadraticResidue2 reports whether a is a square in Fp2, which is the case
+// if its norm is a square in Fp.
+func isQuadraticResidue2(a *fe2) bool {
+	var t0, t1 fe
+	square(&t0, &a[0])
+	square(&t1, &a[1])
+	add(&t0, &t0, &t1)
+	return isQuadraticResidue(&t0)
+}
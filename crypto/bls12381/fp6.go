@@ -0,0 +1,200 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// Arithmetic of the cubic extension Fp6 = Fp2[v]/(v^3-ξ) with ξ = 1+i.
+
+var (
+	// frobeniusV1 and frobeniusV2 are ξ^((p-1)/3) and ξ^(2(p-1)/3), such that
+	// (v^k)^p = v^k * frobeniusVk.
+	frobeniusV1, frobeniusV2 fe2
+
+	// frobeniusW is ξ^((p-1)/6), such that w^p = w * frobeniusW.
+	frobeniusW fe2
+)
+
+// initTower computes the constants of the Frobenius maps.
+func initTower() {
+	var xi fe2
+	xi[0].one()
+	xi[1].one()
+
+	e := new(big.Int).Sub(modulusBig, big.NewInt(1))
+	exp2(&frobeniusV1, &xi, new(big.Int).Div(e, big.NewInt(3)))
+	square2(&frobeniusV2, &frobeniusV1)
+	exp2(&frobeniusW, &xi, new(big.Int).Div(e, big.NewInt(6)))
+}
+
+func add6(c, a, b *fe6) {
+	add2(&c[0], &a[0], &b[0])
+	add2(&c[1], &a[1], &b[1])
+	add2(&c[2], &a[2], &b[2])
+}
+
+func sub6(c, a, b *fe6) {
+	sub2(&c[0], &a[0], &b[0])
+	sub2(&c[1], &a[1], &b[1])
+	sub2(&c[2], &a[2], &b[2])
+}
+
+func neg6(c, a *fe6) {
+	neg2(&c[0], &a[0])
+	neg2(&c[1], &a[1])
+	neg2(&c[2], &a[2])
+}
+
+// mulByNonResidue6 multiplies an element by v, the non-residue defining the
+// quadratic extension Fp12.
+func mulByNonResidue6(c, a *fe6) {
+	var t fe2
+	mulByNonResidue2(&t, &a[2])
+	c[2].set(&a[1])
+	c[1].set(&a[0])
+	c[0].set(&t)
+}
+
+// mul6 multiplies two elements using Karatsuba on the coefficients.
+func mul6(c, a, b *fe6) {
+	var t0, t1, t2, s0, s1, r0, r1, r2 fe2
+	mul2(&t0, &a[0], &b[0])
+	mul2(&t1, &a[1], &b[1])
+	mul2(&t2, &a[2], &b[2])
+
+	// r0 = t0 + ξ((a1+a2)(b1+b2) - t1 - t2)
+	add2(&s0, &a[1], &a[2])
+	add2(&s1, &b[1], &b[2])
+	mul2(&r0, &s0, &s1)
+	sub2(&r0, &r0, &t1)
+	sub2(&r0, &r0, &t2)
+	mulByNonResidue2(&r0, &r0)
+	add2(&r0, &r0, &t0)
+
+	// r1 = (a0+a1)(b0+b1) - t0 - t1 + ξt2
+	add2(&s0, &a[0], &a[1])
+	add2(&s1, &b[0], &b[1])
+	mul2(&r1, &s0, &s1)
+	sub2(&r1, &r1, &t0)
+	sub2(&r1, &r1, &t1)
+	mulByNonResidue2(&s0, &t2)
+	add2(&r1, &r1, &s0)
+
+	// r2 = (a0+a2)(b0+b2) - t0 - t2 + t1
+	add2(&s0, &a[0], &a[2])
+	add2(&s1, &b[0], &b[2])
+	mul2(&r2, &s0, &s1)
+	sub2(&r2, &r2, &t0)
+	sub2(&r2, &r2, &t2)
+	add2(&r2, &r2, &t1)
+
+	c[0], c[1], c[2] = r0, r1, r2
+}
+
+// mulBy01 multiplies an element by the sparse element b0 + b1*v.
+func mulBy01(c, a *fe6, b0, b1 *fe2) {
+	var t0, t1, r0, r1, r2, s fe2
+	mul2(&t0, &a[0], b0)
+	mul2(&t1, &a[1], b1)
+
+	// r0 = t0 + ξ((a1+a2)b1 - t1)
+	add2(&s, &a[1], &a[2])
+	mul2(&r0, &s, b1)
+	sub2(&r0, &r0, &t1)
+	mulByNonResidue2(&r0, &r0)
+	add2(&r0, &r0, &t0)
+
+	// r1 = (a0+a1)(b0+b1) - t0 - t1
+	add2(&s, &a[0], &a[1])
+	add2(&r1, b0, b1)
+	mul2(&r1, &r1, &s)
+	sub2(&r1, &r1, &t0)
+	sub2(&r1, &r1, &t1)
+
+	// r2 = (a0+a2)b0 - t0 + t1
+	add2(&s, &a[0], &a[2])
+	mul2(&r2, &s, b0)
+	sub2(&r2, &r2, &t0)
+	add2(&r2, &r2, &t1)
+
+	c[0], c[1], c[2] = r0, r1, r2
+}
+
+// mulBy1 multiplies an element by the sparse element b1*v.
+func mulBy1(c, a *fe6, b1 *fe2) {
+	var t fe2
+	mul2(&t, &a[2], b1)
+	mulByNonResidue2(&t, &t)
+	mul2(&c[2], &a[1], b1)
+	mul2(&c[1], &a[0], b1)
+	c[0].set(&t)
+}
+
+func square6(c, a *fe6) {
+	mul6(c, a, a)
+}
+
+// inverse6 computes a^-1 from the adjugate of the multiplication matrix.
+func inverse6(c, a *fe6) {
+	var c0, c1, c2, t0, t1 fe2
+
+	// c0 = a0^2 - ξa1a2
+	square2(&c0, &a[0])
+	mul2(&t0, &a[1], &a[2])
+	mulByNonResidue2(&t0, &t0)
+	sub2(&c0, &c0, &t0)
+
+	// c1 = ξa2^2 - a0a1
+	square2(&c1, &a[2])
+	mulByNonResidue2(&c1, &c1)
+	mul2(&t0, &a[0], &a[1])
+	sub2(&c1, &c1, &t0)
+
+	// c2 = a1^2 - a0a2
+	square2(&c2, &a[1])
+	mul2(&t0, &a[0], &a[2])
+	sub2(&c2, &c2, &t0)
+
+	// t = a0c0 + ξ(a2c1 + a1c2)
+	mul2(&t0, &a[2], &c1)
+	mul2(&t1, &a[1], &c2)
+	add2(&t0, &t0, &t1)
+	mulByNonResidue2(&t0, &t0)
+	mul2(&t1, &a[0], &c0)
+	add2(&t0, &t0, &t1)
+	inverse2(&t0, &t0)
+
+	mul2(&c[0], &c0, &t0)
+	mul2(&c[1], &c1, &t0)
+	mul2(&c[2], &c2, &t0)
+}
+
+// mulByFp2 multiplies all the coefficients of an element by an Fp2 element.
+func mulByFp2(c, a *fe6, b *fe2) {
+	mul2(&c[0], &a[0], b)
+	mul2(&c[1], &a[1], b)
+	mul2(&c[2], &a[2], b)
+}
+
+// frobenius6 computes a^p.
+func frobenius6(c, a *fe6) {
+	conjugate2(&c[0], &a[0])
+	conjugate2(&c[1], &a[1])
+	conjugate2(&c[2], &a[2])
+	mul2(&c[1], &c[1], &frobeniusV1)
+	mul2(&c[2], &c[2], &frobeniusV2)
+}
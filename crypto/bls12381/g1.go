@@ -0,0 +1,463 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+)
+
+// PointG1 is a point of the curve y^2 = x^3 + 4 over the base field, in
+// Jacobian coordinates (X, Y, Z) standing for the affine (X/Z^2, Y/Z^3). The
+// point at infinity has Z = 0.
+type PointG1 [3]fe
+
+var (
+	errPointNotOnCurve   = errors.New("point is not on curve")
+	errPointLength       = errors.New("invalid point length")
+	errMultiExpArguments = errors.New("point and scalar counts mismatch")
+)
+
+var (
+	// b1 is the coefficient b of the curve equation.
+	b1 fe
+
+	// g1One is the generator of G1.
+	g1One PointG1
+
+	// g1Beta is the cube root of unity defining the endomorphism σ of G1.
+	g1Beta fe
+)
+
+// G1 implements the operations of the G1 group, the points of order r of the
+// curve over the base field. Points are only validated to be on the curve when
+// decoded, the subgroup membership needs to be checked separately.
+type G1 struct{}
+
+// NewG1 returns the operations of the G1 group.
+func NewG1() *G1 {
+	return &G1{}
+}
+
+// FromBytes decodes a point from its 96 byte uncompressed encoding, checking
+// that it's on the curve.
+func (g *G1) FromBytes(in []byte) (*PointG1, error) {
+	if len(in) != 96 {
+		return nil, errPointLength
+	}
+	px, err := fromBytes(in[:48])
+	if err != nil {
+		return nil, err
+	}
+	py, err := fromBytes(in[48:])
+	if err != nil {
+		return nil, err
+	}
+	p := new(PointG1)
+	if px.isZero() && py.isZero() {
+		return p, nil
+	}
+	p[0].set(px)
+	p[1].set(py)
+	p[2].one()
+	if !g.IsOnCurve(p) {
+		return nil, errPointNotOnCurve
+	}
+	return p, nil
+}
+
+// ToBytes encodes a point as its 96 byte uncompressed encoding.
+func (g *G1) ToBytes(p *PointG1) []byte {
+	out := make([]byte, 96)
+	if g.IsZero(p) {
+		return out
+	}
+	a := g.Affine(p)
+	copy(out[:48], toBytes(&a[0]))
+	copy(out[48:], toBytes(&a[1]))
+	return out
+}
+
+// New returns a new point at infinity.
+func (g *G1) New() *PointG1 {
+	return new(PointG1)
+}
+
+// One returns a new copy of the generator of the group.
+func (g *G1) One() *PointG1 {
+	p := g1One
+	return &p
+}
+
+// IsZero reports whether a point is the point at infinity.
+func (g *G1) IsZero(p *PointG1) bool {
+	return p[2].isZero()
+}
+
+// Equal reports whether two points are the same.
+func (g *G1) Equal(p, q *PointG1) bool {
+	if g.IsZero(p) || g.IsZero(q) {
+		return g.IsZero(p) && g.IsZero(q)
+	}
+	// Compare X1*Z2^2 with X2*Z1^2 and Y1*Z2^3 with Y2*Z1^3
+	var z1z1, z2z2, t0, t1 fe
+	square(&z1z1, &p[2])
+	square(&z2z2, &q[2])
+	mul(&t0, &p[0], &z2z2)
+	mul(&t1, &q[0], &z1z1)
+	if !t0.equal(&t1) {
+		return false
+	}
+	mul(&t0, &p[1], &z2z2)
+	mul(&t0, &t0, &q[2])
+	mul(&t1, &q[1], &z1z1)
+	mul(&t1, &t1, &p[2])
+	return t0.equal(&t1)
+}
+
+// IsOnCurve reports whether a point satisfies the curve equation, which in
+// Jacobian coordinates is Y^2 = X^3 + 4Z^6.
+func (g *G1) IsOnCurve(p *PointG1) bool {
+	if g.IsZero(p) {
+		return true
+	}
+	var t0, t1, t2 fe
+	square(&t0, &p[1])
+	square(&t1, &p[0])
+	mul(&t1, &t1, &p[0])
+	square(&t2, &p[2])
+	square(&t2, &t2)
+	mul(&t2, &t2, &p[2])
+	mul(&t2, &t2, &p[2])
+	mul(&t2, &t2, &b1)
+	add(&t1, &t1, &t2)
+	return t0.equal(&t1)
+}
+
+// Affine returns the point with Z = 1, or the point at infinity.
+func (g *G1) Affine(p *PointG1) *PointG1 {
+	r := new(PointG1)
+	if g.IsZero(p) {
+		return r
+	}
+	var zinv, zinv2 fe
+	inverse(&zinv, &p[2])
+	square(&zinv2, &zinv)
+	mul(&r[0], &p[0], &zinv2)
+	mul(&zinv2, &zinv2, &zinv)
+	mul(&r[1], &p[1], &zinv2)
+	r[2].one()
+	return r
+}
+
+// Neg sets r to -p and returns r.
+func (g *G1) Neg(r, p *PointG1) *PointG1 {
+	r[0].set(&p[0])
+	neg(&r[1], &p[1])
+	r[2].set(&p[2])
+	return r
+}
+
+// Double sets r to 2p and returns r.
+func (g *G1) Double(r, p *PointG1) *PointG1 {
+	if g.IsZero(p) {
+		return r.set(p)
+	}
+	// dbl-2009-l formulas for a = 0
+	var a, b, c, d, e, f, t fe
+	square(&a, &p[0])
+	square(&b, &p[1])
+	square(&c, &b)
+	add(&d, &p[0], &b)
+	square(&d, &d)
+	sub(&d, &d, &a)
+	sub(&d, &d, &c)
+	double(&d, &d)
+	double(&e, &a)
+	add(&e, &e, &a)
+	square(&f, &e)
+
+	mul(&r[2], &p[1], &p[2])
+	double(&r[2], &r[2])
+
+	double(&t, &d)
+	sub(&r[0], &f, &t)
+
+	sub(&t, &d, &r[0])
+	mul(&t, &t, &e)
+	double(&c, &c)
+	double(&c, &c)
+	double(&c, &c)
+	sub(&r[1], &t, &c)
+	return r
+}
+
+// Add sets r to p + q and returns r.
+func (g *G1) Add(r, p, q *PointG1) *PointG1 {
+	if g.IsZero(p) {
+		return r.set(q)
+	}
+	if g.IsZero(q) {
+		return r.set(p)
+	}
+	// add-2007-bl formulas, or madd-2007-bl ones if q is affine as decoded
+	// points are
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, rr, v, t fe
+	mixed := q[2].isOne()
+	square(&z1z1, &p[2])
+	if mixed {
+		u1.set(&p[0])
+		s1.set(&p[1])
+	} else {
+		square(&z2z2, &q[2])
+		mul(&u1, &p[0], &z2z2)
+		mul(&s1, &p[1], &q[2])
+		mul(&s1, &s1, &z2z2)
+	}
+	mul(&u2, &q[0], &z1z1)
+	mul(&s2, &q[1], &p[2])
+	mul(&s2, &s2, &z1z1)
+	sub(&h, &u2, &u1)
+	sub(&rr, &s2, &s1)
+	if h.isZero() {
+		if rr.isZero() {
+			return g.Double(r, p)
+		}
+		return r.set(new(PointG1))
+	}
+	double(&i, &h)
+	square(&i, &i)
+	mul(&j, &h, &i)
+	double(&rr, &rr)
+	mul(&v, &u1, &i)
+
+	// Z3 = ((Z1+Z2)^2 - Z1Z1 - Z2Z2) * H, which is 2 Z1 H if Z2 = 1
+	if mixed {
+		double(&t, &p[2])
+	} else {
+		add(&t, &p[2], &q[2])
+		square(&t, &t)
+		sub(&t, &t, &z1z1)
+		sub(&t, &t, &z2z2)
+	}
+	mul(&r[2], &t, &h)
+
+	// X3 = r^2 - J - 2V
+	square(&t, &rr)
+	sub(&t, &t, &j)
+	sub(&t, &t, &v)
+	sub(&r[0], &t, &v)
+
+	// Y3 = r(V - X3) - 2 S1 J
+	sub(&t, &v, &r[0])
+	mul(&t, &t, &rr)
+	mul(&s1, &s1, &j)
+	double(&s1, &s1)
+	sub(&r[1], &t, &s1)
+	return r
+}
+
+// Sub sets r to p - q and returns r.
+func (g *G1) Sub(r, p, q *PointG1) *PointG1 {
+	var n PointG1
+	g.Neg(&n, q)
+	return g.Add(r, p, &n)
+}
+
+// MulScalar sets r to e*p and returns r, adding a precomputed multiple of p
+// for every 4 bit window of the scalar.
+func (g *G1) MulScalar(r, p *PointG1, e *big.Int) *PointG1 {
+	var (
+		q     PointG1
+		table [15]PointG1
+	)
+	table[0] = *p
+	if e.Sign() < 0 {
+		g.Neg(&table[0], &table[0])
+		e = new(big.Int).Neg(e)
+	}
+	for i := 1; i < len(table); i++ {
+		g.Add(&table[i], &table[i-1], &table[0])
+	}
+	for start := (e.BitLen() - 1) / 4 * 4; start >= 0; start -= 4 {
+		for i := 0; i < 4; i++ {
+			g.Double(&q, &q)
+		}
+		if idx := scalarWindow(e, start, 4); idx > 0 {
+			g.Add(&q, &q, &table[idx-1])
+		}
+	}
+	return r.set(&q)
+}
+
+// mulByX sets r to |u|*p with u the curve parameter and returns r. Its few set
+// bits make plain double and add cheaper than the windowed multiplication.
+func (g *G1) mulByX(r, p *PointG1) *PointG1 {
+	var q PointG1
+	q.set(p)
+	for i := curveX.BitLen() - 2; i >= 0; i-- {
+		g.Double(&q, &q)
+		if curveX.Bit(i) == 1 {
+			g.Add(&q, &q, p)
+		}
+	}
+	return r.set(&q)
+}
+
+// MultiExp sets r to the sum of the given points multiplied by their scalars
+// and returns r. The scalars must not be negative. The doublings are shared,
+// each point adding a precomputed affine multiple of itself for every 4 bit
+// window of its scalar.
+func (g *G1) MultiExp(r *PointG1, points []*PointG1, scalars []*big.Int) (*PointG1, error) {
+	if len(points) != len(scalars) {
+		return nil, errMultiExpArguments
+	}
+	table := make([]PointG1, 15*len(points))
+	for i, p := range points {
+		multiples := table[15*i : 15*(i+1)]
+		multiples[0] = *p
+		for j := 1; j < len(multiples); j++ {
+			g.Add(&multiples[j], &multiples[j-1], p)
+		}
+	}
+	g.affineBatch(table)
+
+	var acc PointG1
+	for start := (maxBitLen(scalars) - 1) / 4 * 4; start >= 0; start -= 4 {
+		for i := 0; i < 4; i++ {
+			g.Double(&acc, &acc)
+		}
+		for i, s := range scalars {
+			if idx := scalarWindow(s, start, 4); idx > 0 {
+				g.Add(&acc, &acc, &table[15*i+idx-1])
+			}
+		}
+	}
+	return r.set(&acc), nil
+}
+
+// affineBatch converts the points to affine coordinates sharing a single
+// inversion, leaving any point at infinity as is.
+func (g *G1) affineBatch(points []PointG1) {
+	var (
+		prods = make([]fe, len(points))
+		acc   fe
+	)
+	acc.one()
+	for i := range points {
+		if !g.IsZero(&points[i]) {
+			prods[i] = acc
+			mul(&acc, &acc, &points[i][2])
+		}
+	}
+	inverse(&acc, &acc)
+	for i := len(points) - 1; i >= 0; i-- {
+		if g.IsZero(&points[i]) {
+			continue
+		}
+		// acc is the inverse of the product of the first i+1 Z coordinates
+		var zinv, zinv2 fe
+		mul(&zinv, &acc, &prods[i])
+		mul(&acc, &acc, &points[i][2])
+
+		square(&zinv2, &zinv)
+		mul(&points[i][0], &points[i][0], &zinv2)
+		mul(&zinv2, &zinv2, &zinv)
+		mul(&points[i][1], &points[i][1], &zinv2)
+		points[i][2].one()
+	}
+}
+
+// ClearCofactor sets p to its multiple by the effective cofactor of G1, which
+// is 1 - u.
+func (g *G1) ClearCofactor(p *PointG1) {
+	var t PointG1
+	g.mulByX(&t, p)
+	g.Add(p, p, &t)
+}
+
+// InCorrectSubgroup reports whether a point on the curve is in G1, i.e. its
+// order is r. Rather than multiplying by r, it checks that the endomorphism
+// σ(x, y) = (βx, y) acts as the multiplication by -u^2, which only holds in G1
+// as shown in "Co-factor clearing and subgroup membership testing on pairing
+// friendly curves" by El Housni, Guillevic and Piellard.
+func (g *G1) InCorrectSubgroup(p *PointG1) bool {
+	var s, t PointG1
+	g.mulByX(&t, p)
+	g.mulByX(&t, &t)
+	g.Neg(&t, &t)
+	s.set(p)
+	mul(&s[0], &s[0], &g1Beta)
+	return g.Equal(&s, &t)
+}
+
+// MapToCurve maps a 48 byte big-endian field element to a point of G1, using
+// the simplified SWU map for BLS12-381 G1 and clearing the cofactor.
+func (g *G1) MapToCurve(in []byte) (*PointG1, error) {
+	u, err := fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	p := isogenyMapG1(swuMapG1(u))
+	g.ClearCofactor(p)
+	return p, nil
+}
+
+func (p *PointG1) set(q *PointG1) *PointG1 {
+	*p = *q
+	return p
+}
+
+// maxBitLen returns the bit length of the largest scalar.
+func maxBitLen(scalars []*big.Int) int {
+	var n int
+	for _, s := range scalars {
+		if s.BitLen() > n {
+			n = s.BitLen()
+		}
+	}
+	return n
+}
+
+// scalarWindow returns the bits [start, start+window) of a scalar.
+func scalarWindow(s *big.Int, start, window int) int {
+	var idx int
+	for i := window - 1; i >= 0; i-- {
+		idx = idx<<1 | int(s.Bit(start+i))
+	}
+	return idx
+}
+
+// initGroups decodes the curve constants and generators.
+func initGroups() {
+	b1.set(fromHex("0x04"))
+	g1One[0].set(fromHex(g1GeneratorX))
+	g1One[1].set(fromHex(g1GeneratorY))
+	g1One[2].one()
+	g1Beta.set(fromHex("0x5f19672fdf76ce51ba69c6076a0f77eaddb3a93be6f89688de17d813620a00022e01fffffffefffe"))
+
+	b2[0].set(fromHex("0x04"))
+	b2[1].set(fromHex("0x04"))
+	g2One[0].set(fe2FromHex(g2GeneratorX[0], g2GeneratorX[1]))
+	g2One[1].set(fe2FromHex(g2GeneratorY[0], g2GeneratorY[1]))
+	g2One[2].one()
+
+	inverse2(&psiX, &frobeniusV1)
+	square2(&psiY, &frobeniusW)
+	mul2(&psiY, &psiY, &frobeniusW)
+	inverse2(&psiY, &psiY)
+}
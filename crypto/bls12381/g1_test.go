@@ -0,0 +1,208 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+// mapTest is a field element and its image by a map to a curve.
+type mapTest struct {
+	u, point string
+}
+
+var g1MapTests = []mapTest{
+	{
+		u:     "000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		point: "11a9a0372b8f332d5c30de9ad14e50372a73fa4c45d5f2fa5097f2d6fb93bcac592f2e1711ac43db0519870c7d0ea415092c0f994164a0719f51c24ba3788de240ff926b55f58c445116e8bc6a47cd63392fd4e8e22bdf9feaa96ee773222133",
+	},
+	{
+		u:     "000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+		point: "1073311196f8ef19477219ccee3a48035ff432295aa9419eed45d186027d88b90832e14c4f0e2aa4d15f54d1c3ed0f93034d6e3755a2073039d609db4cf3aef548283b5cc92f1021cbdb276414bcd8072b112d80a2b0a7dbf22bdaf17e006d45",
+	},
+	{
+		u:     "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaaa",
+		point: "1073311196f8ef19477219ccee3a48035ff432295aa9419eed45d186027d88b90832e14c4f0e2aa4d15f54d1c3ed0f9316b3a3b2e3dddf6a11459ddaf657fde21c4f10282a56029d9b55ab3ce1f41e1cf39ad27e0ea35823c7d3250e81ff3d66",
+	},
+	{
+		u:     "14f07daa39391b742d933694c92cc02203b1f683ba7a27ec7b16ba84669d0bfdffab3fae7964e8642500a66ec0993617",
+		point: "193729a98621ce477942b880fc6514ee2f989c99d86bf2f3c0db8b62b58568d53086f9c12f74b572cf0222260e20f6cc0ff4fe61babaaf349be449bd3fcc4de4f3beeec5a9dec6085da28f79b4b440acdffde597944a25ec995061f8690fa1fb",
+	},
+	{
+		u:     "12520d4c161b7a2e95bf4db10eda642b334341b111222d8e8d9b1f3c0c0d4c8731355da5977f62a19451c51ff098ba26",
+		point: "10810698a94db16f57e8104eb8e1cf233cdbc267b9a9bfb86370fd6b388fe58bfaa0fb9a6e444c77ec094284f1b451c00275222137b46e1e1977a70777903b48217e689152f4ae2a28217b8613998da53d4f270b54e7c40161fb36eb99d9ef0f",
+	},
+}
+
+func randomG1(t testing.TB) *PointG1 {
+	g := NewG1()
+	return g.MulScalar(g.New(), g.One(), randomBig(t, q))
+}
+
+// Tests that the generator is a valid point of G1.
+func TestG1Generator(t *testing.T) {
+	g := NewG1()
+	if !g.IsOnCurve(g.One()) || !g.InCorrectSubgroup(g.One()) {
+		t.Fatalf("generator not in G1")
+	}
+	p, err := g.FromBytes(g.ToBytes(g.One()))
+	if err != nil {
+		t.Fatalf("failed to decode generator: %v", err)
+	}
+	if !g.Equal(p, g.One()) {
+		t.Fatalf("generator encoding roundtrip mismatch")
+	}
+	if p, err := g.FromBytes(make([]byte, 96)); err != nil || !g.IsZero(p) {
+		t.Fatalf("infinity decoding mismatch: %v", err)
+	}
+}
+
+// Tests the consistency of the group operations.
+func TestG1Arithmetic(t *testing.T) {
+	g := NewG1()
+	for i := 0; i < fuzz; i++ {
+		a, b := randomBig(t, q), randomBig(t, q)
+		pa := g.MulScalar(g.New(), g.One(), a)
+		pb := g.MulScalar(g.New(), g.One(), b)
+
+		// aG + bG = (a+b)G
+		sum := g.MulScalar(g.New(), g.One(), new(big.Int).Add(a, b))
+		if !g.Equal(g.Add(g.New(), pa, pb), sum) {
+			t.Fatalf("addition mismatch")
+		}
+		// aG + aG = 2aG
+		if !g.Equal(g.Add(g.New(), pa, pa), g.Double(g.New(), pa)) {
+			t.Fatalf("doubling mismatch")
+		}
+		// aG - aG = 0 and (-a)G = -(aG)
+		if !g.IsZero(g.Sub(g.New(), pa, pa)) {
+			t.Fatalf("subtraction mismatch")
+		}
+		if !g.Equal(g.MulScalar(g.New(), g.One(), new(big.Int).Neg(a)), g.Neg(g.New(), pa)) {
+			t.Fatalf("negative scalar mismatch")
+		}
+		// Encoding roundtrip
+		dec, err := g.FromBytes(g.ToBytes(pa))
+		if err != nil || !g.Equal(dec, pa) {
+			t.Fatalf("encoding roundtrip mismatch: %v", err)
+		}
+	}
+}
+
+// Tests the multi exponentiation against the sum of scalar multiplications.
+func TestG1MultiExp(t *testing.T) {
+	g := NewG1()
+	for _, n := range []int{1, 2, 5, 40, 130} {
+		var (
+			points  = make([]*PointG1, n)
+			scalars = make([]*big.Int, n)
+			want    = g.New()
+		)
+		for i := 0; i < n; i++ {
+			points[i] = randomG1(t)
+			scalars[i] = randomBig(t, new(big.Int).Lsh(big.NewInt(1), 256))
+			g.Add(want, want, g.MulScalar(g.New(), points[i], scalars[i]))
+		}
+		have, err := g.MultiExp(g.New(), points, scalars)
+		if err != nil {
+			t.Fatalf("multi exponentiation failed: %v", err)
+		}
+		if !g.Equal(have, want) {
+			t.Fatalf("multi exponentiation of %d points mismatch", n)
+		}
+	}
+	// Points at infinity are skipped
+	points := []*PointG1{g.New(), g.One()}
+	scalars := []*big.Int{big.NewInt(5), big.NewInt(3)}
+	have, err := g.MultiExp(g.New(), points, scalars)
+	if err != nil {
+		t.Fatalf("multi exponentiation failed: %v", err)
+	}
+	if want := g.MulScalar(g.New(), g.One(), big.NewInt(3)); !g.Equal(have, want) {
+		t.Fatalf("multi exponentiation with infinity mismatch")
+	}
+}
+
+// Tests that points outside the curve and G1 are detected.
+func TestG1Invalid(t *testing.T) {
+	g := NewG1()
+	enc := g.ToBytes(g.One())
+	enc[95] ^= 1
+	if _, err := g.FromBytes(enc); err != errPointNotOnCurve {
+		t.Fatalf("invalid point decoded, err %v", err)
+	}
+	// A point of the curve outside the subgroup, with x = 0
+	var y fe
+	sqrt(&y, &b1)
+	p := &PointG1{fe{}, y, *new(fe).one()}
+	if !g.IsOnCurve(p) || g.InCorrectSubgroup(p) {
+		t.Fatalf("subgroup check mismatch")
+	}
+}
+
+// Tests the endomorphism based subgroup check and cofactor clearing against
+// plain scalar multiplications, on random points of the curve.
+func TestG1Endomorphisms(t *testing.T) {
+	g := NewG1()
+	for i := 0; i < fuzz/10; i++ {
+		var x, y, rhs fe
+		for {
+			x.set(randomFe(t))
+			square(&rhs, &x)
+			mul(&rhs, &rhs, &x)
+			add(&rhs, &rhs, &b1)
+			if sqrt(&y, &rhs) {
+				break
+			}
+		}
+		p := &PointG1{x, y, *new(fe).one()}
+		if g.InCorrectSubgroup(p) != g.IsZero(g.MulScalar(g.New(), p, q)) {
+			t.Fatalf("subgroup check mismatch")
+		}
+		want := g.MulScalar(g.New(), p, cofactorEFFG1)
+		if g.ClearCofactor(p); !g.Equal(p, want) {
+			t.Fatalf("cofactor clearing mismatch")
+		}
+		if !g.InCorrectSubgroup(p) {
+			t.Fatalf("cleared point not in G1")
+		}
+	}
+}
+
+// Tests the map of field elements to G1.
+func TestG1MapToCurve(t *testing.T) {
+	g := NewG1()
+	for i, test := range g1MapTests {
+		p, err := g.MapToCurve(common.FromHex(test.u))
+		if err != nil {
+			t.Fatalf("test %d: failed to map: %v", i, err)
+		}
+		if !g.InCorrectSubgroup(p) {
+			t.Fatalf("test %d: image not in G1", i)
+		}
+		if have := g.ToBytes(p); !bytes.Equal(have, common.FromHex(test.point)) {
+			t.Fatalf("test %d: image mismatch: have %x, want %s", i, have, test.point)
+		}
+	}
+	if _, err := g.MapToCurve(modulusBig.Bytes()); err == nil {
+		t.Fatalf("modulus mapped")
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// PointG2 is a point of the twisted curve y^2 = x^3 + 4(1+i) over Fp2, in
+// Jacobian coordinates (X, Y, Z) standing for the affine (X/Z^2, Y/Z^3). The
+// point at infinity has Z = 0.
+type PointG2 [3]fe2
+
+var (
+	// b2 is the coefficient b of the twisted curve equation.
+	b2 fe2
+
+	// g2One is the generator of G2.
+	g2One PointG2
+
+	// psiX and psiY are ξ^((1-p)/3) and ξ^((1-p)/2), the coefficients of the
+	// endomorphism ψ(x, y) = (psiX x^p, psiY y^p) of the twisted curve.
+	psiX, psiY fe2
+)
+
+// G2 implements the operations of the G2 group, the points of order r of the
+// twisted curve over Fp2. Points are only validated to be on the curve when
+// decoded, the subgroup membership needs to be checked separately.
+type G2 struct{}
+
+// NewG2 returns the operations of the G2 group.
+func NewG2() *G2 {
+	return &G2{}
+}
+
+// FromBytes decodes a point from its 192 byte uncompressed encoding, checking
+// that it's on the curve.
+func (g *G2) FromBytes(in []byte) (*PointG2, error) {
+	if len(in) != 192 {
+		return nil, errPointLength
+	}
+	px, err := fromBytes2(in[:96])
+	if err != nil {
+		return nil, err
+	}
+	py, err := fromBytes2(in[96:])
+	if err != nil {
+		return nil, err
+	}
+	p := new(PointG2)
+	if px.isZero() && py.isZero() {
+		return p, nil
+	}
+	p[0].set(px)
+	p[1].set(py)
+	p[2].one()
+	if !g.IsOnCurve(p) {
+		return nil, errPointNotOnCurve
+	}
+	return p, nil
+}
+
+// ToBytes encodes a point as its 192 byte uncompressed encoding.
+func (g *G2) ToBytes(p *PointG2) []byte {
+	out := make([]byte, 192)
+	if g.IsZero(p) {
+		return out
+	}
+	a := g.Affine(p)
+	copy(out[:96], toBytes2(&a[0]))
+	copy(out[96:], toBytes2(&a[1]))
+	return out
+}
+
+// New returns a new point at infinity.
+func (g *G2) New() *PointG2 {
+	return new(PointG2)
+}
+
+// One returns a new copy of the generator of the group.
+func (g *G2) One() *PointG2 {
+	p := g2One
+	return &p
+}
+
+// IsZero reports whether a point is the point at infinity.
+func (g *G2) IsZero(p *PointG2) bool {
+	return p[2].isZero()
+}
+
+// Equal reports whether two points are the same.
+func (g *G2) Equal(p, q *PointG2) bool {
+	if g.IsZero(p) || g.IsZero(q) {
+		return g.IsZero(p) && g.IsZero(q)
+	}
+	// Compare X1*Z2^2 with X2*Z1^2 and Y1*Z2^3 with Y2*Z1^3
+	var z1z1, z2z2, t0, t1 fe2
+	square2(&z1z1, &p[2])
+	square2(&z2z2, &q[2])
+	mul2(&t0, &p[0], &z2z2)
+	mul2(&t1, &q[0], &z1z1)
+	if !t0.equal(&t1) {
+		return false
+	}
+	mul2(&t0, &p[1], &z2z2)
+	mul2(&t0, &t0, &q[2])
+	mul2(&t1, &q[1], &z1z1)
+	mul2(&t1, &t1, &p[2])
+	return t0.equal(&t1)
+}
+
+// IsOnCurve reports whether a point satisfies the curve equation, which in
+// Jacobian coordinates is Y^2 = X^3 + 4(1+i)Z^6.
+func (g *G2) IsOnCurve(p *PointG2) bool {
+	if g.IsZero(p) {
+		return true
+	}
+	var t0, t1, t2 fe2
+	square2(&t0, &p[1])
+	square2(&t1, &p[0])
+	mul2(&t1, &t1, &p[0])
+	square2(&t2, &p[2])
+	square2(&t2, &t2)
+	mul2(&t2, &t2, &p[2])
+	mul2(&t2, &t2, &p[2])
+	mul2(&t2, &t2, &b2)
+	add2(&t1, &t1, &t2)
+	return t0.equal(&t1)
+}
+
+// Affine returns the point with Z = 1, or the point at infinity.
+func (g *G2) Affine(p *PointG2) *PointG2 {
+	r := new(PointG2)
+	if g.IsZero(p) {
+		return r
+	}
+	var zinv, zinv2 fe2
+	inverse2(&zinv, &p[2])
+	square2(&zinv2, &zinv)
+	mul2(&r[0], &p[0], &zinv2)
+	mul2(&zinv2, &zinv2, &zinv)
+	mul2(&r[1], &p[1], &zinv2)
+	r[2].one()
+	return r
+}
+
+// Neg sets r to -p and returns r.
+func (g *G2) Neg(r, p *PointG2) *PointG2 {
+	r[0].set(&p[0])
+	neg2(&r[1], &p[1])
+	r[2].set(&p[2])
+	return r
+}
+
+// Double sets r to 2p and returns r.
+func (g *G2) Double(r, p *PointG2) *PointG2 {
+	if g.IsZero(p) {
+		return r.set(p)
+	}
+	// dbl-2009-l formulas for a = 0
+	var a, b, c, d, e, f, t fe2
+	square2(&a, &p[0])
+	square2(&b, &p[1])
+	square2(&c, &b)
+	add2(&d, &p[0], &b)
+	square2(&d, &d)
+	sub2(&d, &d, &a)
+	sub2(&d, &d, &c)
+	double2(&d, &d)
+	double2(&e, &a)
+	add2(&e, &e, &a)
+	square2(&f, &e)
+
+	mul2(&r[2], &p[1], &p[2])
+	double2(&r[2], &r[2])
+
+	double2(&t, &d)
+	sub2(&r[0], &f, &t)
+
+	sub2(&t, &d, &r[0])
+	mul2(&t, &t, &e)
+	double2(&c, &c)
+	double2(&c, &c)
+	double2(&c, &c)
+	sub2(&r[1], &t, &c)
+	return r
+}
+
+// Add sets r to p + q and returns r.
+func (g *G2) Add(r, p, q *PointG2) *PointG2 {
+	if g.IsZero(p) {
+		return r.set(q)
+	}
+	if g.IsZero(q) {
+		return r.set(p)
+	}
+	// add-2007-bl formulas, or madd-2007-bl ones if q is affine as decoded
+	// points are
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, rr, v, t fe2
+	mixed := q[2].isOne()
+	square2(&z1z1, &p[2])
+	if mixed {
+		u1.set(&p[0])
+		s1.set(&p[1])
+	} else {
+		square2(&z2z2, &q[2])
+		mul2(&u1, &p[0], &z2z2)
+		mul2(&s1, &p[1], &q[2])
+		mul2(&s1, &s1, &z2z2)
+	}
+	mul2(&u2, &q[0], &z1z1)
+	mul2(&s2, &q[1], &p[2])
+	mul2(&s2, &s2, &z1z1)
+	sub2(&h, &u2, &u1)
+	sub2(&rr, &s2, &s1)
+	if h.isZero() {
+		if rr.isZero() {
+			return g.Double(r, p)
+		}
+		return r.set(new(PointG2))
+	}
+	double2(&i, &h)
+	square2(&i, &i)
+	mul2(&j, &h, &i)
+	double2(&rr, &rr)
+	mul2(&v, &u1, &i)
+
+	// Z3 = ((Z1+Z2)^2 - Z1Z1 - Z2Z2) * H, which is 2 Z1 H if Z2 = 1
+	if mixed {
+		double2(&t, &p[2])
+	} else {
+		add2(&t, &p[2], &q[2])
+		square2(&t, &t)
+		sub2(&t, &t, &z1z1)
+		sub2(&t, &t, &z2z2)
+	}
+	mul2(&r[2], &t, &h)
+
+	// X3 = r^2 - J - 2V
+	square2(&t, &rr)
+	sub2(&t, &t, &j)
+	sub2(&t, &t, &v)
+	sub2(&r[0], &t, &v)
+
+	// Y3 = r(V - X3) - 2 S1 J
+	sub2(&t, &v, &r[0])
+	mul2(&t, &t, &rr)
+	mul2(&s1, &s1, &j)
+	double2(&s1, &s1)
+	sub2(&r[1], &t, &s1)
+	return r
+}
+
+// Sub sets r to p - q and returns r.
+func (g *G2) Sub(r, p, q *PointG2) *PointG2 {
+	var n PointG2
+	g.Neg(&n, q)
+	return g.Add(r, p, &n)
+}
+
+// MulScalar sets r to e*p and returns r, adding a precomputed multiple of p
+// for every 4 bit window of the scalar.
+func (g *G2) MulScalar(r, p *PointG2, e *big.Int) *PointG2 {
+	var (
+		q     PointG2
+		table [15]PointG2
+	)
+	table[0] = *p
+	if e.Sign() < 0 {
+		g.Neg(&table[0], &table[0])
+		e = new(big.Int).Neg(e)
+	}
+	for i := 1; i < len(table); i++ {
+		g.Add(&table[i], &table[i-1], &table[0])
+	}
+	for start := (e.BitLen() - 1) / 4 * 4; start >= 0; start -= 4 {
+		for i := 0; i < 4; i++ {
+			g.Double(&q, &q)
+		}
+		if idx := scalarWindow(e, start, 4); idx > 0 {
+			g.Add(&q, &q, &table[idx-1])
+		}
+	}
+	return r.set(&q)
+}
+
+// mulByX sets r to |u|*p with u the curve parameter and returns r. Its few set
+// bits make plain double and add cheaper than the windowed multiplication.
+func (g *G2) mulByX(r, p *PointG2) *PointG2 {
+	var q PointG2
+	q.set(p)
+	for i := curveX.BitLen() - 2; i >= 0; i-- {
+		g.Double(&q, &q)
+		if curveX.Bit(i) == 1 {
+			g.Add(&q, &q, p)
+		}
+	}
+	return r.set(&q)
+}
+
+// MultiExp sets r to the sum of the given points multiplied by their scalars
+// and returns r. The scalars must not be negative. The doublings are shared,
+// each point adding a precomputed affine multiple of itself for every 4 bit
+// window of its scalar.
+func (g *G2) MultiExp(r *PointG2, points []*PointG2, scalars []*big.Int) (*PointG2, error) {
+	if len(points) != len(scalars) {
+		return nil, errMultiExpArguments
+	}
+	table := make([]PointG2, 15*len(points))
+	for i, p := range points {
+		multiples := table[15*i : 15*(i+1)]
+		multiples[0] = *p
+		for j := 1; j < len(multiples); j++ {
+			g.Add(&multiples[j], &multiples[j-1], p)
+		}
+	}
+	g.affineBatch(table)
+
+	var acc PointG2
+	for start := (maxBitLen(scalars) - 1) / 4 * 4; start >= 0; start -= 4 {
+		for i := 0; i < 4; i++ {
+			g.Double(&acc, &acc)
+		}
+		for i, s := range scalars {
+			if idx := scalarWindow(s, start, 4); idx > 0 {
+				g.Add(&acc, &acc, &table[15*i+idx-1])
+			}
+		}
+	}
+	return r.set(&acc), nil
+}
+
+// affineBatch converts the points to affine coordinates sharing a single
+// inversion, leaving any point at infinity as is.
+func (g *G2) affineBatch(points []PointG2) {
+	var (
+		prods = make([]fe2, len(points))
+		acc   fe2
+	)
+	acc.one()
+	for i := range points {
+		if !g.IsZero(&points[i]) {
+			prods[i] = acc
+			mul2(&acc, &acc, &points[i][2])
+		}
+	}
+	inverse2(&acc, &acc)
+	for i := len(points) - 1; i >= 0; i-- {
+		if g.IsZero(&points[i]) {
+			continue
+		}
+		// acc is the inverse of the product of the first i+1 Z coordinates
+		var zinv, zinv2 fe2
+		mul2(&zinv, &acc, &prods[i])
+		mul2(&acc, &acc, &points[i][2])
+
+		square2(&zinv2, &zinv)
+		mul2(&points[i][0], &points[i][0], &zinv2)
+		mul2(&zinv2, &zinv2, &zinv)
+		mul2(&points[i][1], &points[i][1], &zinv2)
+		points[i][2].one()
+	}
+}
+
+// ClearCofactor sets p to its multiple by the effective cofactor of G2, using
+// the endomorphism ψ to compute [u^2-u-1]p + [u-1]ψ(p) + ψ^2(2p) as specified
+// by the hash-to-curve specification.
+func (g *G2) ClearCofactor(p *PointG2) {
+	var t1, t2, t3 PointG2
+	g.mulByX(&t1, p)
+	g.Neg(&t1, &t1)
+	g.psi(&t2, p)
+	g.Double(&t3, p)
+	g.psi(&t3, &t3)
+	g.psi(&t3, &t3)
+	g.Sub(&t3, &t3, &t2)
+	g.Add(&t2, &t1, &t2)
+	g.mulByX(&t2, &t2)
+	g.Neg(&t2, &t2)
+	g.Add(&t3, &t3, &t2)
+	g.Sub(&t3, &t3, &t1)
+	g.Sub(p, &t3, p)
+}
+
+// InCorrectSubgroup reports whether a point on the curve is in G2, i.e. its
+// order is r. Rather than multiplying by r, it checks that the endomorphism ψ
+// acts as the multiplication by u, which only holds in G2 as shown in "A note
+// on group membership tests for G1, G2 and GT on BLS pairing-friendly curves"
+// by Scott.
+func (g *G2) InCorrectSubgroup(p *PointG2) bool {
+	var s, t PointG2
+	g.mulByX(&t, p)
+	g.Neg(&t, &t)
+	g.psi(&s, p)
+	return g.Equal(&s, &t)
+}
+
+// psi sets r to ψ(p), untwisting p, applying the Frobenius map and twisting the
+// result back, and returns r.
+func (g *G2) psi(r, p *PointG2) *PointG2 {
+	conjugate2(&r[0], &p[0])
+	conjugate2(&r[1], &p[1])
+	conjugate2(&r[2], &p[2])
+	mul2(&r[0], &r[0], &psiX)
+	mul2(&r[1], &r[1], &psiY)
+	return r
+}
+
+// MapToCurve maps a 96 byte element of Fp2 to a point of G2, using the
+// simplified SWU map for BLS12-381 G2 and clearing the cofactor.
+func (g *G2) MapToCurve(in []byte) (*PointG2, error) {
+	u, err := fromBytes2(in)
+	if err != nil {
+		return nil, err
+	}
+	p := isogenyMapG2(swuMapG2(u))
+	g.ClearCofactor(p)
+	return p, nil
+}
+
+func (p *PointG2) set(q *PointG2) *PointG2 {
+	*p = *q
+	return p
+}
@@ -0,0 +1,210 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+var g2MapTests = []mapTest{
+	{
+		u:     "000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		point: "018320896ec9eef9d5e619848dc29ce266f413d02dd31d9b9d44ec0c79cd61f18b075ddba6d7bd20b7ff27a4b324bfce0a67d12118b5a35bb02d2e86b3ebfa7e23410db93de39fb06d7025fa95e96ffa428a7a27c3ae4dd4b40bd251ac6588920260e03644d1a2c321256b3246bad2b895cad13890cbe6f85df55106a0d334604fb143c7a042d878006271865bc3594104c69777a43f0bda07679d5805e63f18cf4e0e7c6112ac7f70266d199b4f76ae27c6269a3ceebdae30806e9a76aadf5c",
+	},
+	{
+		u:     "000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		point: "1770d4f641225e1a1c0f7d05857299763e98e47ec6355b81dd6cdaf6db6825052f71d35ede3af8b70f046474c48d712e00e12b55d801607d9760f8637ac80a4fececd3eb74045b342ee3c7dddd2037e72dedccc27e9a89491d4e57bde555fead05695a740eaae8452a882e7647f22bc17782b00afa7b6be2d974824a2a7cba7eece26c60671d41145266582912235323143ef77ba72f284b5b4f5c5ea227d269d98a8cf74a5c048a07852874d50632806cf66bc25db089319df2ee3f0212fc1c",
+	},
+	{
+		u:     "000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+		point: "0f5ab9ab512bac0e5aa9d4be326afefbfa5db2dba6c88000f1cfeaa0cd62b2b2604935e2794933d76f9887bae7ed285105d991fb690fdad1923ac1834188ed45d160a15ee5547a4476b836a158a9884236846408b8abd5d99217876d12f8f5d61055354681ba663d288d9a5256844c48ec43e27e9f2b87ce06850d4a5661095c189f8bab578093d2161db0b32550f3a0184ee89023a361021f9d288e65deb12b2045b1e3d2560590fc3139354c51b756018cf3c54a13f60cb7b970567c39c08f",
+	},
+	{
+		u:     "0ee6559b4eb097d739353a97678a78fbf081fc2a3f4537f9e14cebc21728e99f7d7685b97c77e953f80a75a230cbb1c406f81c85200b8a867b65910e2e861f9973583d21b2b93311c0306f49937c75bb8328f37a0af38269219faf1224056bb0",
+		point: "064df50061b0f04d60579dbd89a21f0d40d6ed8707f3ffa3619d5689ced724531229cc4feecb413f9ca6a8e3f9d88de10f67b491607fa926715ad1d4513efeccffbf4755518fceb05df02bdc62ebee90a063f5f883118c1a0e69cc769a1971e70dccab89f57c2cd94c47dd82842189adbffde958f2ff389b7c0bd79239c011e60885324ad0d2d9cad501aca80e2d550b07e0a68058b362c2e7068eeb43bc6be2a91da71f02a01c6bf817573c461e8a613eb64008c456053df632f4f15c8832b7",
+	},
+	{
+		u:     "07bb3840956c75808363957a17a54a80e162efa3025dcb35a4923e96e6e6386cc743652ceb35de93f83ebc07e398b90514ca0e96a6404fcaa08dd953d69ca23e4ce9ccf7b95c64d72c6e91d9490057881a0681848fc36d89bcb9244e3aec310b",
+		point: "10adb7b06bee7e97c3abd2bf8d5176b1eab5e6577880a8f11e28750e4341ab91d1e263a8925cf80deb4a61e32f6730ae1682c967b88e0ef7235a84756b78ee740c9bd543b427215b1dc1e39b3934313aa0e5316898a7ef5e5fd6cdfb4e9b858c10eecc9e0c17f013f954a65e431e911c32409adf4672ffc9ce7ddd7736fce0d95394b1b030da2fc7440671aa6fe191dd0e69825b3ac0bd9d60d89daffe7d07d45d46b4d20193ee0f3c608cb31c3162d77cc858f64fccfe2b50cf9e92213025ee",
+	},
+}
+
+func randomG2(t testing.TB) *PointG2 {
+	g := NewG2()
+	return g.MulScalar(g.New(), g.One(), randomBig(t, q))
+}
+
+// Tests that the generator is a valid point of G2.
+func TestG2Generator(t *testing.T) {
+	g := NewG2()
+	if !g.IsOnCurve(g.One()) || !g.InCorrectSubgroup(g.One()) {
+		t.Fatalf("generator not in G2")
+	}
+	p, err := g.FromBytes(g.ToBytes(g.One()))
+	if err != nil {
+		t.Fatalf("failed to decode generator: %v", err)
+	}
+	if !g.Equal(p, g.One()) {
+		t.Fatalf("generator encoding roundtrip mismatch")
+	}
+	if p, err := g.FromBytes(make([]byte, 192)); err != nil || !g.IsZero(p) {
+		t.Fatalf("infinity decoding mismatch: %v", err)
+	}
+}
+
+// Tests the consistency of the group operations.
+func TestG2Arithmetic(t *testing.T) {
+	g := NewG2()
+	for i := 0; i < fuzz; i++ {
+		a, b := randomBig(t, q), randomBig(t, q)
+		pa := g.MulScalar(g.New(), g.One(), a)
+		pb := g.MulScalar(g.New(), g.One(), b)
+
+		// aG + bG = (a+b)G
+		sum := g.MulScalar(g.New(), g.One(), new(big.Int).Add(a, b))
+		if !g.Equal(g.Add(g.New(), pa, pb), sum) {
+			t.Fatalf("addition mismatch")
+		}
+		// aG + aG = 2aG
+		if !g.Equal(g.Add(g.New(), pa, pa), g.Double(g.New(), pa)) {
+			t.Fatalf("doubling mismatch")
+		}
+		// aG - aG = 0 and (-a)G = -(aG)
+		if !g.IsZero(g.Sub(g.New(), pa, pa)) {
+			t.Fatalf("subtraction mismatch")
+		}
+		if !g.Equal(g.MulScalar(g.New(), g.One(), new(big.Int).Neg(a)), g.Neg(g.New(), pa)) {
+			t.Fatalf("negative scalar mismatch")
+		}
+		// Encoding roundtrip
+		dec, err := g.FromBytes(g.ToBytes(pa))
+		if err != nil || !g.Equal(dec, pa) {
+			t.Fatalf("encoding roundtrip mismatch: %v", err)
+		}
+	}
+}
+
+// Tests the multi exponentiation against the sum of scalar multiplications.
+func TestG2MultiExp(t *testing.T) {
+	g := NewG2()
+	for _, n := range []int{1, 2, 5, 40} {
+		var (
+			points  = make([]*PointG2, n)
+			scalars = make([]*big.Int, n)
+			want    = g.New()
+		)
+		for i := 0; i < n; i++ {
+			points[i] = randomG2(t)
+			scalars[i] = randomBig(t, new(big.Int).Lsh(big.NewInt(1), 256))
+			g.Add(want, want, g.MulScalar(g.New(), points[i], scalars[i]))
+		}
+		have, err := g.MultiExp(g.New(), points, scalars)
+		if err != nil {
+			t.Fatalf("multi exponentiation failed: %v", err)
+		}
+		if !g.Equal(have, want) {
+			t.Fatalf("multi exponentiation of %d points mismatch", n)
+		}
+	}
+	// Points at infinity are skipped
+	points := []*PointG2{g.New(), g.One()}
+	scalars := []*big.Int{big.NewInt(5), big.NewInt(3)}
+	have, err := g.MultiExp(g.New(), points, scalars)
+	if err != nil {
+		t.Fatalf("multi exponentiation failed: %v", err)
+	}
+	if want := g.MulScalar(g.New(), g.One(), big.NewInt(3)); !g.Equal(have, want) {
+		t.Fatalf("multi exponentiation with infinity mismatch")
+	}
+}
+
+// Tests that points outside the curve and G2 are detected.
+func TestG2Invalid(t *testing.T) {
+	g := NewG2()
+	enc := g.ToBytes(g.One())
+	enc[191] ^= 1
+	if _, err := g.FromBytes(enc); err != errPointNotOnCurve {
+		t.Fatalf("invalid point decoded, err %v", err)
+	}
+	// A point of the curve outside the subgroup, with the smallest x possible
+	var x, y, rhs fe2
+	for x.zero(); ; add2(&x, &x, new(fe2).one()) {
+		square2(&rhs, &x)
+		mul2(&rhs, &rhs, &x)
+		add2(&rhs, &rhs, &b2)
+		if sqrt2(&y, &rhs) {
+			break
+		}
+	}
+	p := &PointG2{x, y, *new(fe2).one()}
+	if !g.IsOnCurve(p) || g.InCorrectSubgroup(p) {
+		t.Fatalf("subgroup check mismatch")
+	}
+}
+
+// Tests the endomorphism based subgroup check and cofactor clearing against
+// plain scalar multiplications, on random points of the curve.
+func TestG2Endomorphisms(t *testing.T) {
+	g := NewG2()
+	for i := 0; i < fuzz/10; i++ {
+		var x, y, rhs fe2
+		for {
+			x.set(randomFe2(t))
+			square2(&rhs, &x)
+			mul2(&rhs, &rhs, &x)
+			add2(&rhs, &rhs, &b2)
+			if sqrt2(&y, &rhs) {
+				break
+			}
+		}
+		p := &PointG2{x, y, *new(fe2).one()}
+		if g.InCorrectSubgroup(p) != g.IsZero(g.MulScalar(g.New(), p, q)) {
+			t.Fatalf("subgroup check mismatch")
+		}
+		want := g.MulScalar(g.New(), p, cofactorEFFG2)
+		if g.ClearCofactor(p); !g.Equal(p, want) {
+			t.Fatalf("cofactor clearing mismatch")
+		}
+		if !g.InCorrectSubgroup(p) {
+			t.Fatalf("cleared point not in G2")
+		}
+	}
+}
+
+// Tests the map of field elements to G2.
+func TestG2MapToCurve(t *testing.T) {
+	g := NewG2()
+	for i, test := range g2MapTests {
+		p, err := g.MapToCurve(common.FromHex(test.u))
+		if err != nil {
+			t.Fatalf("test %d: failed to map: %v", i, err)
+		}
+		if !g.InCorrectSubgroup(p) {
+			t.Fatalf("test %d: image not in G2", i)
+		}
+		if have := g.ToBytes(p); !bytes.Equal(have, common.FromHex(test.point)) {
+			t.Fatalf("test %d: image mismatch: have %x, want %s", i, have, test.point)
+		}
+	}
+	if _, err := g.MapToCurve(append(make([]byte, 48), modulusBig.Bytes()...)); err == nil {
+		t.Fatalf("modulus mapped")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+// pair is a G1 and a G2 point in affine coordinates.
+type pair struct {
+	g1 PointG1
+	g2 PointG2
+}
+
+// Engine computes the product of the optimal ate pairings of a set of point
+// pairs, sharing the squarings of the Miller loop and the final exponentiation.
+type Engine struct {
+	pairs []pair
+}
+
+// NewPairingEngine creates an engine without any pairs.
+func NewPairingEngine() *Engine {
+	return &Engine{}
+}
+
+// AddPair adds a pair of points to the product. Pairs containing the point at
+// infinity don't contribute to the product and are skipped.
+func (e *Engine) AddPair(g1 *PointG1, g2 *PointG2) *Engine {
+	var (
+		G1 = NewG1()
+		G2 = NewG2()
+	)
+	if G1.IsZero(g1) || G2.IsZero(g2) {
+		return e
+	}
+	e.pairs = append(e.pairs, pair{*G1.Affine(g1), *G2.Affine(g2)})
+	return e
+}
+
+// Reset removes all the pairs added so far.
+func (e *Engine) Reset() *Engine {
+	e.pairs = e.pairs[:0]
+	return e
+}
+
+// Check reports whether the product of the pairings of the added pairs is the
+// identity of GT.
+func (e *Engine) Check() bool {
+	return e.result().isOne()
+}
+
+// result computes the product of the pairings of the added pairs.
+func (e *Engine) result() *fe12 {
+	f := new(fe12).one()
+	if len(e.pairs) == 0 {
+		return f
+	}
+	millerLoop(f, e.pairs)
+	finalExponentiation(f, f)
+	return f
+}
+
+// millerLoop computes the product of the Miller loops of the optimal ate pairing
+// for the given pairs. The G2 points are untwisted as (x w^-2, y w^-3), making
+// the lines through them, scaled by w^3, evaluated at (xP, yP):
+//
+//	(λ xT - yT) - λ xP w^2 + yP w^3
+//
+// with λ the slope of the line on the twisted curve. The vertical lines and the
+// w^3 scaling lie in proper subfields of Fp12, hence vanish in the final
+// exponentiation, as does any further scaling of the lines by an element of
+// Fp2. This allows keeping the running points in homogeneous projective
+// coordinates (X, Y, Z), standing for the affine (X/Z, Y/Z), without any
+// inversion, using the formulas of "Faster Explicit Formulas for Computing
+// Pairings over Ordinary Curves" by Aranha et al.
+func millerLoop(f *fe12, pairs []pair) {
+	t := make([][3]fe2, len(pairs))
+	for i := range pairs {
+		t[i][0].set(&pairs[i].g2[0])
+		t[i][1].set(&pairs[i].g2[1])
+		t[i][2].one()
+	}
+	var line [3]fe2
+	for i := curveX.BitLen() - 2; i >= 0; i-- {
+		square12(f, f)
+		for j := range pairs {
+			doublingStep(&line, &t[j])
+			mulByLine(f, &line, &pairs[j].g1)
+		}
+		if curveX.Bit(i) == 1 {
+			for j := range pairs {
+				additionStep(&line, &t[j], &pairs[j].g2)
+				mulByLine(f, &line, &pairs[j].g1)
+			}
+		}
+	}
+	// The curve parameter is negative
+	conjugate12(f, f)
+}
+
+// doublingStep sets line to the coefficients of the tangent at t, and doubles
+// t. With b' the coefficient of the twisted curve, the line scaled by 2YZ is
+//
+//	(Y^2 - 3b'Z^2) - 3X^2 xP w^2 + 2YZ yP w^3
+func doublingStep(line *[3]fe2, t *[3]fe2) {
+	var a, b, c, e, f, g, h fe2
+
+	// a = XY/2, b = Y^2, c = Z^2, e = 3b'c, f = 3e, g = (b+f)/2
+	mul2(&a, &t[0], &t[1])
+	half2(&a, &a)
+	square2(&b, &t[1])
+	square2(&c, &t[2])
+	mulByB2(&e, &c)
+	double2(&f, &e)
+	add2(&e, &e, &f)
+	double2(&f, &e)
+	add2(&f, &f, &e)
+	add2(&g, &b, &f)
+	half2(&g, &g)
+
+	// h = (Y+Z)^2 - b - c = 2YZ
+	add2(&h, &t[1], &t[2])
+	square2(&h, &h)
+	sub2(&h, &h, &b)
+	sub2(&h, &h, &c)
+
+	sub2(&line[0], &b, &e)
+	square2(&line[1], &t[0])
+	double2(&c, &line[1])
+	add2(&line[1], &line[1], &c)
+	neg2(&line[1], &line[1])
+	line[2].set(&h)
+
+	// X3 = a(b-f), Y3 = g^2 - 3e^2, Z3 = bh
+	sub2(&f, &b, &f)
+	mul2(&t[0], &a, &f)
+	square2(&e, &e)
+	double2(&c, &e)
+	add2(&e, &e, &c)
+	square2(&g, &g)
+	sub2(&t[1], &g, &e)
+	mul2(&t[2], &b, &h)
+}
+
+// additionStep sets line to the coefficients of the line through t and the
+// affine point q, and adds q to t. With θ = Y - yQ Z and λ = X - xQ Z, the
+// line scaled by λ is
+//
+//	(θ xQ - λ yQ) - θ xP w^2 + λ yP w^3
+func additionStep(line *[3]fe2, t *[3]fe2, q *PointG2) {
+	var theta, lambda, c, d, e, f, g, h fe2
+
+	mul2(&theta, &q[1], &t[2])
+	sub2(&theta, &t[1], &theta)
+	mul2(&lambda, &q[0], &t[2])
+	sub2(&lambda, &t[0], &lambda)
+
+	mul2(&line[0], &theta, &q[0])
+	mul2(&c, &lambda, &q[1])
+	sub2(&line[0], &line[0], &c)
+	neg2(&line[1], &theta)
+	line[2].set(&lambda)
+
+	// c = θ^2, d = λ^2, e = λ^3, f = Zc, g = Xd, h = e + f - 2g
+	square2(&c, &theta)
+	square2(&d, &lambda)
+	mul2(&e, &d, &lambda)
+	mul2(&f, &t[2], &c)
+	mul2(&g, &t[0], &d)
+	add2(&h, &e, &f)
+	sub2(&h, &h, &g)
+	sub2(&h, &h, &g)
+
+	// X3 = λh, Y3 = θ(g-h) - Ye, Z3 = Ze
+	mul2(&t[0], &lambda, &h)
+	sub2(&g, &g, &h)
+	mul2(&g, &g, &theta)
+	mul2(&h, &t[1], &e)
+	sub2(&t[1], &g, &h)
+	mul2(&t[2], &t[2], &e)
+}
+
+// mulByLine multiplies f by the line with the given coefficients evaluated at
+// the affine point p.
+func mulByLine(f *fe12, line *[3]fe2, p *PointG1) {
+	var l1, l2 fe2
+	mulByFp(&l1, &line[1], &p[0])
+	mulByFp(&l2, &line[2], &p[1])
+	mulBy014(f, f, &line[0], &l1, &l2)
+}
+
+// mulByB2 multiplies an element by the coefficient 4(1+i) of the twisted curve.
+func mulByB2(c, a *fe2) {
+	mulByNonResidue2(c, a)
+	double2(c, c)
+	double2(c, c)
+}
+
+// finalExponentiation computes f^((p^12-1)/r), or rather its cube, as the hard
+// part is computed using 3(p^4-p^2+1)/r = (u-1)^2 (u+p) (u^2+p^2-1) + 3 with u
+// the curve parameter. Cubing doesn't affect the comparison with the identity,
+// r being prime to 3.
+func finalExponentiation(c, f *fe12) {
+	var t, a, b, s fe12
+
+	// Easy part: t = f^((p^6-1)(p^2+1)), which is in the cyclotomic subgroup
+	// where inverses are conjugates
+	inverse12(&t, f)
+	conjugate12(&a, f)
+	mul12(&t, &a, &t)
+	frobenius12(&a, &t)
+	frobenius12(&a, &a)
+	mul12(&t, &a, &t)
+
+	// a = t^((u-1)^2)
+	expByU(&a, &t)
+	conjugate12(&s, &t)
+	mul12(&a, &a, &s)
+	expByU(&b, &a)
+	conjugate12(&s, &a)
+	mul12(&a, &b, &s)
+
+	// b = a^(u+p)
+	expByU(&b, &a)
+	frobenius12(&s, &a)
+	mul12(&b, &b, &s)
+
+	// a = b^(u^2+p^2-1)
+	expByU(&a, &b)
+	expByU(&a, &a)
+	frobenius12(&s, &b)
+	frobenius12(&s, &s)
+	mul12(&a, &a, &s)
+	conjugate12(&s, &b)
+	mul12(&a, &a, &s)
+
+	// c = a t^3
+	square12(&s, &t)
+	mul12(&s, &s, &t)
+	mul12(c, &a, &s)
+}
+
+// expByU computes a^u for an element of the cyclotomic subgroup, with u the
+// negative curve parameter.
+func expByU(c, a *fe12) {
+	var z fe12
+	z.set(a)
+	for i := curveX.BitLen() - 2; i >= 0; i-- {
+		cyclotomicSquare12(&z, &z)
+		if curveX.Bit(i) == 1 {
+			mul12(&z, &z, a)
+		}
+	}
+	conjugate12(c, &z)
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"math/big"
+	"testing"
+)
+
+// Tests that the final exponentiation matches the plain exponentiation by
+// (p^12-1)/r, cubed.
+func TestFinalExponentiation(t *testing.T) {
+	p12 := new(big.Int).Exp(modulusBig, big.NewInt(12), nil)
+	e := new(big.Int).Sub(p12, big.NewInt(1))
+	e.Div(e, q)
+	e.Mul(e, big.NewInt(3))
+
+	f := randomFe12(t)
+	var have, want fe12
+	finalExponentiation(&have, f)
+	exp12(&want, f, e)
+	if !have.equal(&want) {
+		t.Fatalf("final exponentiation mismatch")
+	}
+}
+
+// Tests that the pairing is bilinear and non-degenerate.
+func TestPairingBilinearity(t *testing.T) {
+	var (
+		g1 = NewG1()
+		g2 = NewG2()
+	)
+	base := NewPairingEngine().AddPair(g1.One(), g2.One()).result()
+	if base.isOne() {
+		t.Fatalf("pairing of the generators is degenerate")
+	}
+	for i := 0; i < 4; i++ {
+		a, b := randomBig(t, q), randomBig(t, q)
+		pa := g1.MulScalar(g1.New(), g1.One(), a)
+		qb := g2.MulScalar(g2.New(), g2.One(), b)
+
+		// e(aP, bQ) = e(P, Q)^ab
+		var want fe12
+		exp12(&want, base, new(big.Int).Mul(a, b))
+		if have := NewPairingEngine().AddPair(pa, qb).result(); !have.equal(&want) {
+			t.Fatalf("pairing not bilinear")
+		}
+		// e(aP, bQ) e(-abP, Q) = 1
+		pab := g1.MulScalar(g1.New(), g1.One(), new(big.Int).Mul(a, b))
+		engine := NewPairingEngine().AddPair(pa, qb).AddPair(g1.Neg(pab, pab), g2.One())
+		if !engine.Check() {
+			t.Fatalf("pairing product check failed")
+		}
+		// Pairs with the point at infinity are neutral
+		if !engine.AddPair(g1.New(), g2.One()).AddPair(g1.One(), g2.New()).Check() {
+			t.Fatalf("pairing with infinity not neutral")
+		}
+		if engine.Reset().AddPair(pa, g2.One()).Check() {
+			t.Fatalf("pairing check of a single pair succeeded")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+// Simplified Shallue-van de Woestijne-Ulas maps of field elements to curves
+// isogenous to the BLS12-381 curves, as specified in the hash-to-curve draft.
+// The images are then mapped to the BLS12-381 curves by the isogeny maps.
+
+var (
+	// Coefficients A and B of the curve y^2 = x^3 + Ax + B isogenous to the
+	// G1 curve, the non-square Z of the map and a square root of -Z
+	swuG1A, swuG1B, swuG1Z, swuG1SqrtMinusZ fe
+
+	// Coefficients A and B of the curve y^2 = x^3 + Ax + B isogenous to the
+	// G2 curve, and the non-square Z of the map
+	swuG2A, swuG2B, swuG2Z fe2
+)
+
+// initSWU decodes the parameters of the maps.
+func initSWU() {
+	swuG1A.set(fromHex("0x144698a3b8e9433d693a02c96d4982b0ea985383ee66a8d8e8981aefd881ac98936f8da0e0f97f5cf428082d584c1d"))
+	swuG1B.set(fromHex("0x12e2908d11688030018b12e8753eee3b2016c1f0f24f4070a0b9c14fcef35ef55a23215a316ceaa5d1cc48e98e172be0"))
+	swuG1Z.set(fromHex("0x0b"))
+	neg(&swuG1SqrtMinusZ, &swuG1Z)
+	sqrt(&swuG1SqrtMinusZ, &swuG1SqrtMinusZ)
+
+	swuG2A.set(fe2FromHex("0x00", "0xf0"))
+	swuG2B.set(fe2FromHex("0x03f4", "0x03f4"))
+	neg2(&swuG2Z, fe2FromHex("0x02", "0x01"))
+}
+
+// swuMapG1 maps a field element to a point of the curve isogenous to the G1
+// curve.
+func swuMapG1(u *fe) (*fe, *fe) {
+	var zu2, tv1, x1, gx1, y, t fe
+
+	// tv1 = 1 / (Z^2 u^4 + Z u^2), zero if the denominator is zero
+	square(&zu2, u)
+	mul(&zu2, &zu2, &swuG1Z)
+	square(&tv1, &zu2)
+	add(&tv1, &tv1, &zu2)
+	inverse(&tv1, &tv1)
+
+	// x1 = (-B / A) (1 + tv1), or B / (Z A) in the exceptional case
+	if tv1.isZero() {
+		mul(&t, &swuG1Z, &swuG1A)
+		inverse(&t, &t)
+		mul(&x1, &swuG1B, &t)
+	} else {
+		inverse(&t, &swuG1A)
+		mul(&t, &t, &swuG1B)
+		neg(&t, &t)
+		x1.one()
+		add(&x1, &x1, &tv1)
+		mul(&x1, &x1, &t)
+	}
+	// Use x1 if g(x1) is square, x2 = Z u^2 x1 otherwise, g(x2) = Z^3 u^6 g(x1)
+	// being square. The candidate root y of g(x1) is then a root of -g(x1), so
+	// that u^3 Z sqrt(-Z) y is a root of g(x2), sparing a second exponentiation.
+	x := new(fe).set(&x1)
+	curveEquationG1(&gx1, x)
+	exp(&y, &gx1, pPlus1Over4)
+	if square(&t, &y); !t.equal(&gx1) {
+		mul(x, &zu2, &x1)
+		mul(&y, &y, &zu2)
+		mul(&y, &y, u)
+		mul(&y, &y, &swuG1SqrtMinusZ)
+	}
+	if u.isOdd() != y.isOdd() {
+		neg(&y, &y)
+	}
+	return x, &y
+}
+
+// swuMapG2 maps an element of Fp2 to a point of the curve isogenous to the G2
+// curve.
+func swuMapG2(u *fe2) (*fe2, *fe2) {
+	var zu2, tv1, x1, gx1, y, t fe2
+
+	square2(&zu2, u)
+	mul2(&zu2, &zu2, &swuG2Z)
+	square2(&tv1, &zu2)
+	add2(&tv1, &tv1, &zu2)
+	inverse2(&tv1, &tv1)
+
+	if tv1.isZero() {
+		mul2(&t, &swuG2Z, &swuG2A)
+		inverse2(&t, &t)
+		mul2(&x1, &swuG2B, &t)
+	} else {
+		inverse2(&t, &swuG2A)
+		mul2(&t, &t, &swuG2B)
+		neg2(&t, &t)
+		x1.one()
+		add2(&x1, &x1, &tv1)
+		mul2(&x1, &x1, &t)
+	}
+	x := new(fe2).set(&x1)
+	curveEquationG2(&gx1, x)
+	if !sqrt2(&y, &gx1) {
+		mul2(x, &zu2, &x1)
+		curveEquationG2(&gx1, x)
+		sqrt2(&y, &gx1)
+	}
+	if sgn0(u) != sgn0(&y) {
+		neg2(&y, &y)
+	}
+	return x, &y
+}
+
+// curveEquationG1 computes x^3 + Ax + B for the curve of the G1 map.
+func curveEquationG1(c, x *fe) {
+	var t fe
+	square(&t, x)
+	add(&t, &t, &swuG1A)
+	mul(&t, &t, x)
+	add(c, &t, &swuG1B)
+}
+
+// curveEquationG2 computes x^3 + Ax + B for the curve of the G2 map.
+func curveEquationG2(c, x *fe2) {
+	var t fe2
+	square2(&t, x)
+	add2(&t, &t, &swuG2A)
+	mul2(&t, &t, x)
+	add2(c, &t, &swuG2B)
+}
+
+// sgn0 returns the sign of an element of Fp2, the parity of its first non-zero
+// coefficient.
+func sgn0(e *fe2) bool {
+	if !e[0].isZero() {
+		return e[0].isOdd()
+	}
+	return e[1].isOdd()
+}
@@ -962,6 +962,33 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	return ret, nil
 }
 
+func (r *Resolver) SafeBlock(ctx context.Context) (*Block, error) {
+	return r.settledBlock(ctx, rpc.SafeBlockNumber)
+}
+
+func (r *Resolver) FinalizedBlock(ctx context.Context) (*Block, error) {
+	return r.settledBlock(ctx, rpc.FinalizedBlockNumber)
+}
+
+// settledBlock resolves the safe or finalized block tag and pins the result to
+// its hash, so the fields of the returned block stay consistent even if the
+// tag moves on while they are being fetched.
+func (r *Resolver) settledBlock(ctx context.Context, number rpc.BlockNumber) (*Block, error) {
+	header, err := r.backend.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	} else if header == nil {
+		return nil, nil
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithHash(header.Hash(), false)
+	return &Block{
+		backend:      r.backend,
+		numberOrHash: &numberOrHash,
+		hash:         header.Hash(),
+		header:       header,
+	}, nil
+}
+
 func (r *Resolver) Pending(ctx context.Context) *Pending {
 	return &Pending{r.backend}
 }
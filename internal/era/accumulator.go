@@ -0,0 +1,81 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/MFAChain/mfachain/common"
+)
+
+// accumulatorDepth is the depth of the merkle tree of the accumulator, fitting
+// MaxEra1Size header records.
+const accumulatorDepth = 13
+
+// ComputeAccumulator calculates the accumulator root of an archive: the SSZ hash
+// tree root of the list of header records, each being a block hash along with
+// the total difficulty up to and including the block.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("header record count mismatch: %d hashes, %d total difficulties", len(hashes), len(tds))
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many header records: %d > %d", len(hashes), MaxEra1Size)
+	}
+	layer := make([]common.Hash, len(hashes))
+	for i := range hashes {
+		if tds[i].Sign() < 0 || tds[i].BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("invalid total difficulty %v", tds[i])
+		}
+		layer[i] = hashPair(hashes[i], common.Hash(littleEndian32(tds[i])))
+	}
+	// Merkleize the records, padding every layer with the root of empty subtrees
+	zero := common.Hash{}
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zero)
+		}
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer, zero = next, hashPair(zero, zero)
+	}
+	root := zero
+	if len(layer) > 0 {
+		root = layer[0]
+	}
+	// Mix in the number of records
+	return hashPair(root, common.Hash(littleEndian32(new(big.Int).SetInt64(int64(len(hashes)))))), nil
+}
+
+// hashPair returns the sha256 hash of the concatenation of two hashes.
+func hashPair(a, b common.Hash) common.Hash {
+	return sha256.Sum256(append(a.Bytes(), b.Bytes()...))
+}
+
+// littleEndian32 encodes a number as 32 little endian bytes.
+func littleEndian32(n *big.Int) [32]byte {
+	var buf [32]byte
+	blob := n.Bytes()
+	for i, b := range blob {
+		buf[len(blob)-1-i] = b
+	}
+	return buf
+}
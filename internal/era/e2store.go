@@ -0,0 +1,135 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// headerSize is the size of the header prefixing every e2store entry: a 2 byte
+// type, a 4 byte length and 2 reserved bytes, all little endian.
+const headerSize = 8
+
+// Entry is a type-length-value record of an e2store file.
+type Entry struct {
+	Type  uint16
+	Value []byte
+}
+
+// Writer appends entries to an e2store stream.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter creates an entry writer on top of the given stream.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write appends an entry with the given type and value, returning the number of
+// bytes written including the header.
+func (w *Writer) Write(typ uint16, value []byte) (int, error) {
+	if uint64(len(value)) > uint64(^uint32(0)) {
+		return 0, fmt.Errorf("e2store entry too large: %d bytes", len(value))
+	}
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint16(header[:2], typ)
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(value)))
+
+	n, err := w.w.Write(header[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.w.Write(value)
+	return n + m, err
+}
+
+// Reader reads entries from an e2store file, either sequentially or at given
+// offsets.
+type Reader struct {
+	r      io.ReaderAt
+	offset int64
+}
+
+// NewReader creates an entry reader on top of the given file.
+func NewReader(r io.ReaderAt) *Reader {
+	return &Reader{r: r}
+}
+
+// Read reads the next entry of the file, returning io.EOF at the end of it.
+func (r *Reader) Read() (*Entry, error) {
+	entry, n, err := r.ReadAt(r.offset)
+	if err != nil {
+		return nil, err
+	}
+	r.offset += int64(n)
+	return entry, nil
+}
+
+// ReadAt reads the entry starting at the given offset, returning it along with
+// its total length including the header.
+func (r *Reader) ReadAt(off int64) (*Entry, int, error) {
+	typ, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := &Entry{Type: typ, Value: make([]byte, length)}
+	if length > 0 {
+		if _, err := r.r.ReadAt(entry.Value, off+headerSize); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, err
+		}
+	}
+	return entry, headerSize + int(length), nil
+}
+
+// ReadMetadataAt reads the type and value length of the entry starting at the
+// given offset.
+func (r *Reader) ReadMetadataAt(off int64) (uint16, uint32, error) {
+	var header [headerSize]byte
+	if n, err := r.r.ReadAt(header[:], off); err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, err
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return 0, 0, errors.New("reserved bytes of e2store entry header are non-zero")
+	}
+	return binary.LittleEndian.Uint16(header[:2]), binary.LittleEndian.Uint32(header[2:6]), nil
+}
+
+// Find scans the file from the beginning for the first entry of the given type.
+func (r *Reader) Find(typ uint16) (*Entry, error) {
+	var off int64
+	for {
+		have, length, err := r.ReadMetadataAt(off)
+		if err != nil {
+			return nil, err
+		}
+		if have == typ {
+			entry, _, err := r.ReadAt(off)
+			return entry, err
+		}
+		off += headerSize + int64(length)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package era implements the era archive format, storing fixed size segments of
+// chain history in self-describing, indexed and seekable files.
+//
+// An archive is an e2store file, a sequence of type-length-value entries. It
+// starts with a version entry, followed by the snappy compressed header, body
+// and receipts and the total difficulty of every block. It ends with the root of
+// the accumulator over the block hashes and total difficulties, and with the
+// block index containing the starting block number, the offsets of the entries
+// of every block and the block count:
+//
+//   Version | block-tuple* | Accumulator | BlockIndex
+//   block-tuple = CompressedHeader | CompressedBody | CompressedReceipts | TotalDifficulty
+//   BlockIndex  = start-number | offset* | count
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/golang/snappy"
+)
+
+// Entry types of the era archive format.
+const (
+	TypeVersion            uint16 = 0x3265
+	TypeCompressedHeader   uint16 = 0x03
+	TypeCompressedBody     uint16 = 0x04
+	TypeCompressedReceipts uint16 = 0x05
+	TypeTotalDifficulty    uint16 = 0x06
+	TypeAccumulator        uint16 = 0x07
+	TypeBlockIndex         uint16 = 0x3266
+)
+
+// MaxEra1Size is the maximum number of blocks in an archive.
+const MaxEra1Size = 8192
+
+// Filename returns the name of the archive of the given network and epoch, the
+// epoch being the number of the first block divided by MaxEra1Size.
+func Filename(network string, epoch int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s.era1", network, epoch, root.Hex()[2:10])
+}
+
+// Builder writes the blocks of a chain segment into an archive.
+type Builder struct {
+	w       *Writer
+	start   *uint64
+	written uint64
+
+	offsets []uint64
+	hashes  []common.Hash
+	tds     []*big.Int
+}
+
+// NewBuilder creates an archive builder writing into the given stream.
+func NewBuilder(w io.Writer) *Builder {
+	return &Builder{w: NewWriter(w)}
+}
+
+// Add appends a block along with its receipts and the total difficulty up to and
+// including it to the archive.
+func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
+	header, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		return err
+	}
+	body, err := rlp.EncodeToBytes(block.Body())
+	if err != nil {
+		return err
+	}
+	storage := make([]*types.ReceiptForStorage, len(receipts))
+	for i, receipt := range receipts {
+		storage[i] = (*types.ReceiptForStorage)(receipt)
+	}
+	blob, err := rlp.EncodeToBytes(storage)
+	if err != nil {
+		return err
+	}
+	return b.AddRLP(header, body, blob, block.NumberU64(), block.Hash(), td)
+}
+
+// AddRLP appends an RLP encoded block along with its receipts in storage form and
+// the total difficulty up to and including it to the archive.
+func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td *big.Int) error {
+	if len(b.offsets) >= MaxEra1Size {
+		return fmt.Errorf("archive full: %d blocks", MaxEra1Size)
+	}
+	if b.start == nil {
+		if err := b.write(TypeVersion, nil); err != nil {
+			return err
+		}
+		b.start = &number
+	} else if want := *b.start + uint64(len(b.offsets)); number != want {
+		return fmt.Errorf("non contiguous block: have %d, want %d", number, want)
+	}
+	b.offsets = append(b.offsets, b.written)
+	b.hashes = append(b.hashes, hash)
+	b.tds = append(b.tds, new(big.Int).Set(td))
+
+	total := littleEndian32(td)
+	for _, entry := range []Entry{
+		{TypeCompressedHeader, snappy.Encode(nil, header)},
+		{TypeCompressedBody, snappy.Encode(nil, body)},
+		{TypeCompressedReceipts, snappy.Encode(nil, receipts)},
+		{TypeTotalDifficulty, total[:]},
+	} {
+		if err := b.write(entry.Type, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finalize writes the accumulator and the block index, completing the archive.
+// It returns the accumulator root.
+func (b *Builder) Finalize() (common.Hash, error) {
+	if b.start == nil {
+		return common.Hash{}, errors.New("empty archive")
+	}
+	root, err := ComputeAccumulator(b.hashes, b.tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := b.write(TypeAccumulator, root.Bytes()); err != nil {
+		return common.Hash{}, err
+	}
+	index := make([]byte, 16+8*len(b.offsets))
+	binary.LittleEndian.PutUint64(index, *b.start)
+	for i, offset := range b.offsets {
+		binary.LittleEndian.PutUint64(index[8+8*i:], offset)
+	}
+	binary.LittleEndian.PutUint64(index[8+8*len(b.offsets):], uint64(len(b.offsets)))
+	if err := b.write(TypeBlockIndex, index); err != nil {
+		return common.Hash{}, err
+	}
+	return root, nil
+}
+
+// write appends an entry to the archive, tracking the offset of the next one.
+func (b *Builder) write(typ uint16, value []byte) error {
+	n, err := b.w.Write(typ, value)
+	b.written += uint64(n)
+	return err
+}
+
+// ReadAtSeekCloser is the file interface an archive is read from.
+type ReadAtSeekCloser interface {
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+// Era is an opened archive, providing random access to its blocks.
+type Era struct {
+	f       ReadAtSeekCloser
+	r       *Reader
+	start   uint64
+	offsets []uint64
+}
+
+// Open opens the archive at the given path.
+func Open(path string) (*Era, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := From(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// From opens an archive from the given file, loading its block index.
+func From(f ReadAtSeekCloser) (*Era, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(f)
+	if typ, _, err := r.ReadMetadataAt(0); err != nil || typ != TypeVersion {
+		return nil, errors.New("missing archive version")
+	}
+	// Locate the block index from the block count at the end of the file
+	if size < headerSize+16 {
+		return nil, errors.New("archive too short")
+	}
+	var buf [8]byte
+	if _, err := f.ReadAt(buf[:], size-8); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint64(buf[:])
+	if count == 0 || count > MaxEra1Size {
+		return nil, fmt.Errorf("invalid block count %d", count)
+	}
+	length := 16 + 8*count
+	index, _, err := r.ReadAt(size - headerSize - int64(length))
+	if err != nil {
+		return nil, err
+	}
+	if index.Type != TypeBlockIndex || uint64(len(index.Value)) != length {
+		return nil, errors.New("invalid block index")
+	}
+	e := &Era{f: f, r: r, start: binary.LittleEndian.Uint64(index.Value), offsets: make([]uint64, count)}
+	for i := range e.offsets {
+		e.offsets[i] = binary.LittleEndian.Uint64(index.Value[8+8*i:])
+	}
+	return e, nil
+}
+
+// Close closes the archive file.
+func (e *Era) Close() error {
+	return e.f.Close()
+}
+
+// Start returns the number of the first block of the archive.
+func (e *Era) Start() uint64 {
+	return e.start
+}
+
+// Count returns the number of blocks in the archive.
+func (e *Era) Count() uint64 {
+	return uint64(len(e.offsets))
+}
+
+// Accumulator returns the accumulator root stored in the archive.
+func (e *Era) Accumulator() (common.Hash, error) {
+	entry, err := e.r.Find(TypeAccumulator)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(entry.Value) != common.HashLength {
+		return common.Hash{}, errors.New("invalid accumulator")
+	}
+	return common.BytesToHash(entry.Value), nil
+}
+
+// readEntries reads the entries of the given block, decompressing them.
+func (e *Era) readEntries(number uint64) ([]*Entry, error) {
+	if number < e.start || number-e.start >= uint64(len(e.offsets)) {
+		return nil, fmt.Errorf("block %d out of archive range [%d, %d)", number, e.start, e.start+uint64(len(e.offsets)))
+	}
+	var (
+		off     = int64(e.offsets[number-e.start])
+		kinds   = []uint16{TypeCompressedHeader, TypeCompressedBody, TypeCompressedReceipts, TypeTotalDifficulty}
+		entries = make([]*Entry, len(kinds))
+	)
+	for i, kind := range kinds {
+		entry, n, err := e.r.ReadAt(off)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Type != kind {
+			return nil, fmt.Errorf("block %d: unexpected entry type %#x, want %#x", number, entry.Type, kind)
+		}
+		if kind != TypeTotalDifficulty {
+			if entry.Value, err = snappy.Decode(nil, entry.Value); err != nil {
+				return nil, fmt.Errorf("block %d: failed to decompress entry %#x: %v", number, kind, err)
+			}
+		}
+		entries[i] = entry
+		off += int64(n)
+	}
+	return entries, nil
+}
+
+// GetRawBlockByNumber returns the RLP encoded header, body and receipts in storage
+// form of the given block along with the total difficulty up to and including it.
+func (e *Era) GetRawBlockByNumber(number uint64) (header, body, receipts []byte, td *big.Int, err error) {
+	entries, err := e.readEntries(number)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	total := entries[3].Value
+	if len(total) != 32 {
+		return nil, nil, nil, nil, fmt.Errorf("block %d: invalid total difficulty", number)
+	}
+	td = new(big.Int)
+	for i := len(total) - 1; i >= 0; i-- {
+		td.Lsh(td, 8)
+		td.Or(td, big.NewInt(int64(total[i])))
+	}
+	return entries[0].Value, entries[1].Value, entries[2].Value, td, nil
+}
+
+// GetBlockByNumber returns the given block along with its receipts and the total
+// difficulty up to and including it.
+func (e *Era) GetBlockByNumber(number uint64) (*types.Block, types.Receipts, *big.Int, error) {
+	rawHeader, rawBody, rawReceipts, td, err := e.GetRawBlockByNumber(number)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(rawHeader, header); err != nil {
+		return nil, nil, nil, fmt.Errorf("block %d: invalid header: %v", number, err)
+	}
+	body := new(types.Body)
+	if err := rlp.DecodeBytes(rawBody, body); err != nil {
+		return nil, nil, nil, fmt.Errorf("block %d: invalid body: %v", number, err)
+	}
+	var storage []*types.ReceiptForStorage
+	if err := rlp.DecodeBytes(rawReceipts, &storage); err != nil {
+		return nil, nil, nil, fmt.Errorf("block %d: invalid receipts: %v", number, err)
+	}
+	receipts := make(types.Receipts, len(storage))
+	for i, receipt := range storage {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles), receipts, td, nil
+}
+
+// Verify checks the integrity of the archive without any external data: headers
+// must link into a chain, bodies and receipts must match the roots in their
+// headers, total difficulties must add up and the accumulator must match.
+func (e *Era) Verify() error {
+	var (
+		hashes = make([]common.Hash, 0, len(e.offsets))
+		tds    = make([]*big.Int, 0, len(e.offsets))
+		parent *types.Block
+		ptd    *big.Int
+	)
+	for number := e.start; number < e.start+uint64(len(e.offsets)); number++ {
+		block, receipts, td, err := e.GetBlockByNumber(number)
+		if err != nil {
+			return err
+		}
+		switch {
+		case block.NumberU64() != number:
+			return fmt.Errorf("block %d: number mismatch: have %d", number, block.NumberU64())
+		case parent != nil && block.ParentHash() != parent.Hash():
+			return fmt.Errorf("block %d: parent hash mismatch: have %x, want %x", number, block.ParentHash(), parent.Hash())
+		case parent != nil && td.Cmp(new(big.Int).Add(ptd, block.Difficulty())) != 0:
+			return fmt.Errorf("block %d: total difficulty mismatch: have %v, want %v", number, td, new(big.Int).Add(ptd, block.Difficulty()))
+		}
+		if root := types.DeriveSha(block.Transactions()); root != block.TxHash() {
+			return fmt.Errorf("block %d: transaction root mismatch: have %x, want %x", number, root, block.TxHash())
+		}
+		if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+			return fmt.Errorf("block %d: uncle hash mismatch: have %x, want %x", number, hash, block.UncleHash())
+		}
+		if root := types.DeriveSha(receipts); root != block.ReceiptHash() {
+			return fmt.Errorf("block %d: receipt root mismatch: have %x, want %x", number, root, block.ReceiptHash())
+		}
+		hashes, tds = append(hashes, block.Hash()), append(tds, td)
+		parent, ptd = block, td
+	}
+	want, err := e.Accumulator()
+	if err != nil {
+		return err
+	}
+	root, err := ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return err
+	}
+	if root != want {
+		return fmt.Errorf("accumulator mismatch: have %x, want %x", root, want)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/types"
+)
+
+// makeTestChain creates a chain segment of blocks with a transaction and a
+// receipt each, along with the total difficulties.
+func makeTestChain(start uint64, count int) ([]*types.Block, []types.Receipts, []*big.Int) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		parent   = common.Hash{0xff}
+		td       = big.NewInt(1000)
+	)
+	for i := 0; i < count; i++ {
+		var (
+			number = start + uint64(i)
+			header = &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(int64(i + 1))}
+			tx     = types.NewTransaction(number, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		)
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt})
+		td = new(big.Int).Add(td, block.Difficulty())
+
+		blocks, receipts, tds = append(blocks, block), append(receipts, types.Receipts{receipt}), append(tds, td)
+		parent = block.Hash()
+	}
+	return blocks, receipts, tds
+}
+
+// Tests that archives can be written and read back.
+func TestEraRoundtrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	blocks, receipts, tds := makeTestChain(100, 128)
+
+	buf := new(bytes.Buffer)
+	builder := NewBuilder(buf)
+	for i := range blocks {
+		if err := builder.Add(blocks[i], receipts[i], tds[i]); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+	}
+	if err := builder.Add(blocks[0], receipts[0], tds[0]); err == nil {
+		t.Fatalf("non contiguous block accepted")
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize archive: %v", err)
+	}
+	path := filepath.Join(dir, Filename("test", 0, root))
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	e, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != 100 || e.Count() != 128 {
+		t.Fatalf("range mismatch: have [%d, +%d), want [%d, +%d)", e.Start(), e.Count(), 100, 128)
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("accumulator mismatch: have %x (%v), want %x", have, err, root)
+	}
+	for i, want := range blocks {
+		block, rs, td, err := e.GetBlockByNumber(want.NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", want.NumberU64(), err)
+		}
+		if block.Hash() != want.Hash() || td.Cmp(tds[i]) != 0 || types.DeriveSha(rs) != want.ReceiptHash() {
+			t.Fatalf("block %d mismatch", want.NumberU64())
+		}
+	}
+	if _, _, _, err := e.GetBlockByNumber(99); err == nil {
+		t.Fatalf("retrieved block before the archive range")
+	}
+	if _, _, _, err := e.GetBlockByNumber(228); err == nil {
+		t.Fatalf("retrieved block after the archive range")
+	}
+	if err := e.Verify(); err != nil {
+		t.Fatalf("failed to verify archive: %v", err)
+	}
+}
+
+// Tests that corrupted archives are detected by verification.
+func TestEraVerify(t *testing.T) {
+	blocks, receipts, tds := makeTestChain(0, 16)
+
+	build := func(blocks []*types.Block, receipts []types.Receipts, tds []*big.Int) []byte {
+		buf := new(bytes.Buffer)
+		builder := NewBuilder(buf)
+		for i := range blocks {
+			if err := builder.Add(blocks[i], receipts[i], tds[i]); err != nil {
+				t.Fatalf("failed to add block %d: %v", i, err)
+			}
+		}
+		if _, err := builder.Finalize(); err != nil {
+			t.Fatalf("failed to finalize archive: %v", err)
+		}
+		return buf.Bytes()
+	}
+	verify := func(blob []byte) error {
+		e, err := From(nopCloser{bytes.NewReader(blob)})
+		if err != nil {
+			return err
+		}
+		return e.Verify()
+	}
+	if err := verify(build(blocks, receipts, tds)); err != nil {
+		t.Fatalf("failed to verify intact archive: %v", err)
+	}
+	// Mismatching receipts
+	corrupt := append([]types.Receipts{}, receipts...)
+	corrupt[5] = types.Receipts{}
+	if err := verify(build(blocks, corrupt, tds)); err == nil {
+		t.Fatalf("archive with mismatching receipts verified")
+	}
+	// Mismatching total difficulty
+	wrong := append([]*big.Int{}, tds...)
+	wrong[7] = new(big.Int).Add(wrong[7], common.Big1)
+	if err := verify(build(blocks, receipts, wrong)); err == nil {
+		t.Fatalf("archive with mismatching total difficulty verified")
+	}
+	// Mismatching accumulator
+	blob := build(blocks, receipts, tds)
+	e, err := From(nopCloser{bytes.NewReader(blob)})
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	root, _ := e.Accumulator()
+	blob = bytes.Replace(blob, root.Bytes(), make([]byte, common.HashLength), 1)
+	if err := verify(blob); err == nil {
+		t.Fatalf("archive with mismatching accumulator verified")
+	}
+	// Truncated archive
+	if err := verify(build(blocks, receipts, tds)[:1000]); err == nil {
+		t.Fatalf("truncated archive verified")
+	}
+}
+
+// Tests that e2store entries can be read back sequentially and by offset.
+func TestE2Store(t *testing.T) {
+	entries := []Entry{
+		{0xffff, nil},
+		{42, []byte{0xde, 0xad}},
+		{TypeVersion, []byte{}},
+		{7, bytes.Repeat([]byte{0x01}, 1000)},
+	}
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf)
+	for _, entry := range entries {
+		if _, err := w.Write(entry.Type, entry.Value); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+	}
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	for i, want := range entries {
+		have, err := r.Read()
+		if err != nil {
+			t.Fatalf("entry %d: failed to read: %v", i, err)
+		}
+		if have.Type != want.Type || !bytes.Equal(have.Value, want.Value) {
+			t.Fatalf("entry %d mismatch: have %#x %x, want %#x %x", i, have.Type, have.Value, want.Type, want.Value)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Fatalf("read past the last entry: %v", err)
+	}
+	if entry, err := r.Find(7); err != nil || len(entry.Value) != 1000 {
+		t.Fatalf("failed to find entry: %v", err)
+	}
+	if _, err := r.Find(8); err != io.EOF {
+		t.Fatalf("found missing entry: %v", err)
+	}
+}
+
+// nopCloser turns a bytes reader into a ReadAtSeekCloser.
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
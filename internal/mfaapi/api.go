@@ -109,6 +109,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	content := map[string]map[string]map[string]*RPCTransaction{
 		"pending": make(map[string]map[string]*RPCTransaction),
 		"queued":  make(map[string]map[string]*RPCTransaction),
+		"private": make(map[string]map[string]*RPCTransaction),
 	}
 	pending, queue := s.b.TxPoolContent()
 
@@ -128,6 +129,14 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 		}
 		content["queued"][account.Hex()] = dump
 	}
+	// Flatten the private transactions
+	for account, txs := range s.b.TxPoolPrivateContent() {
+		dump := make(map[string]*RPCTransaction)
+		for _, tx := range txs {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+		}
+		content["private"][account.Hex()] = dump
+	}
 	return content
 }
 
@@ -525,8 +534,9 @@ func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
 }
 
 // GetBalance returns the amount of wei for the given address in the state of the
-// given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
-// block numbers are also allowed.
+// given block number. The rpc.LatestBlockNumber, rpc.PendingBlockNumber,
+// rpc.SafeBlockNumber and rpc.FinalizedBlockNumber meta block numbers are also
+// allowed.
 func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
 	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
@@ -604,6 +614,7 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 // GetHeaderByNumber returns the requested canonical block header.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
+// * When blockNr is -3 or -4 the finalized or safe block is returned.
 func (s *PublicBlockChainAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
 	header, err := s.b.HeaderByNumber(ctx, number)
 	if header != nil && err == nil {
@@ -631,6 +642,7 @@ func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.H
 // GetBlockByNumber returns the requested canonical block.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
+// * When blockNr is -3 or -4 the finalized or safe block is returned.
 // * When fullTx is true all transactions in the block are returned, otherwise
 //   only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -719,8 +731,9 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 }
 
 // GetStorageAt returns the storage from the state at the given address, key and
-// block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
-// numbers are also allowed.
+// block number. The rpc.LatestBlockNumber, rpc.PendingBlockNumber,
+// rpc.SafeBlockNumber and rpc.FinalizedBlockNumber meta block numbers are also
+// allowed.
 func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
 	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
@@ -975,7 +988,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 		// call or transaction will never be accepted no matter how much gas it is
 		// assigened. Return the error directly, don't struggle any more.
 		if err != nil {
-			return 0, err
+			return 0, sizeLimitError(ctx, b, blockNrOrHash, args, err, 0)
 		}
 		if failed {
 			lo = mid
@@ -987,9 +1000,12 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 	if hi == cap {
 		failed, result, err := executable(hi)
 		if err != nil {
-			return 0, err
+			return 0, sizeLimitError(ctx, b, blockNrOrHash, args, err, 0)
 		}
 		if failed {
+			if result != nil && result.Err == vm.ErrMaxCodeSizeExceeded {
+				return 0, sizeLimitError(ctx, b, blockNrOrHash, args, result.Err, len(result.ReturnData))
+			}
 			if result != nil && result.Err != vm.ErrOutOfGas {
 				var revert string
 				if len(result.Revert()) > 0 {
@@ -1013,6 +1029,29 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 	return hexutil.Uint64(hi), nil
 }
 
+// sizeLimitError describes the contract size limit exceeded by a creation, as
+// configured for the block the gas is estimated at. Other errors are returned
+// as is.
+func sizeLimitError(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args CallArgs, err error, codeSize int) error {
+	if err != core.ErrMaxInitCodeSizeExceeded && err != vm.ErrMaxCodeSizeExceeded {
+		return err
+	}
+	header, herr := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if herr != nil || header == nil {
+		return err
+	}
+	if err == core.ErrMaxInitCodeSizeExceeded {
+		var size int
+		if args.Data != nil {
+			size = len(*args.Data)
+		}
+		limit := b.ChainConfig().InitCodeSizeLimit(header.Number)
+		return estimateGasError{error: fmt.Sprintf("initcode size %d exceeds limit (%d)", size, limit)}
+	}
+	limit := b.ChainConfig().CodeSizeLimit(header.Number)
+	return estimateGasError{error: fmt.Sprintf("always failing transaction, contract code size %d exceeds limit (%d)", codeSize, limit)}
+}
+
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
@@ -1588,6 +1627,35 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// privateTxDefaultLifetime is the number of blocks a private transaction is held
+// for if the caller does not specify a maximum block number.
+const privateTxDefaultLifetime = 25
+
+// SendPrivateTxArgs represents the arguments to submit a private transaction.
+type SendPrivateTxArgs struct {
+	Tx             hexutil.Bytes   `json:"tx"`
+	MaxBlockNumber *hexutil.Uint64 `json:"maxBlockNumber"`
+}
+
+// SendPrivateTransaction adds a signed transaction to the transaction pool
+// without announcing it to the network. The transaction is only forwarded to
+// trusted peers and is dropped if not mined by the given maximum block number.
+func (s *PublicTransactionPoolAPI) SendPrivateTransaction(ctx context.Context, args SendPrivateTxArgs) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(args.Tx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	maxBlock := s.b.CurrentBlock().NumberU64() + privateTxDefaultLifetime
+	if args.MaxBlockNumber != nil {
+		maxBlock = uint64(*args.MaxBlockNumber)
+	}
+	if err := s.b.SendPrivateTx(ctx, tx, maxBlock); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To(), "maxblock", maxBlock)
+	return tx.Hash(), nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19MFA Signed Message:\n" + len(message) + message).
 //
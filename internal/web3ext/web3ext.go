@@ -428,6 +428,62 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'profileTransaction',
+			call: 'debug_profileTransaction',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'registerSources',
+			call: 'debug_registerSources',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debuggerStart',
+			call: 'debug_debuggerStart',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'debuggerState',
+			call: 'debug_debuggerState',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debuggerStep',
+			call: 'debug_debuggerStep',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debuggerContinue',
+			call: 'debug_debuggerContinue',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debuggerStorage',
+			call: 'debug_debuggerStorage',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'debuggerAddBreakpoint',
+			call: 'debug_debuggerAddBreakpoint',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'debuggerRemoveBreakpoint',
+			call: 'debug_debuggerRemoveBreakpoint',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'debuggerBreakpoints',
+			call: 'debug_debuggerBreakpoints',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debuggerClose',
+			call: 'debug_debuggerClose',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -464,6 +520,23 @@ web3._extend({
 	],
 	properties: []
 });
+
+// debugger starts an interactive debugging session of a transaction, returning
+// an object to drive it.
+web3.debug.debugger = function(txHash, config) {
+	var id = web3.debug.debuggerStart(txHash, config || null);
+	return {
+		id: id,
+		state: function() { return web3.debug.debuggerState(id); },
+		step: function() { return web3.debug.debuggerStep(id); },
+		cont: function() { return web3.debug.debuggerContinue(id); },
+		storage: function(slot, address) { return web3.debug.debuggerStorage(id, slot, address || null); },
+		addBreakpoint: function(bp) { return web3.debug.debuggerAddBreakpoint(id, bp); },
+		removeBreakpoint: function(bp) { return web3.debug.debuggerRemoveBreakpoint(id, bp); },
+		breakpoints: function() { return web3.debug.debuggerBreakpoints(id); },
+		close: function() { return web3.debug.debuggerClose(id); }
+	};
+};
 `
 
 const EthJs = `
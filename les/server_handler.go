@@ -419,7 +419,10 @@ func (h *serverHandler) handleMsg(p *clientPeer, wg *sync.WaitGroup) error {
 					}
 					body := h.blockchain.GetBodyRLP(hash)
 					if body == nil {
-						atomic.AddUint32(&p.invalidCount, 1)
+						// Requesting expired history is not a misbehaviour
+						if !h.historyPruned(hash) {
+							atomic.AddUint32(&p.invalidCount, 1)
+						}
 						continue
 					}
 					bodies = append(bodies, body)
@@ -542,7 +545,9 @@ func (h *serverHandler) handleMsg(p *clientPeer, wg *sync.WaitGroup) error {
 					results := h.blockchain.GetReceiptsByHash(hash)
 					if results == nil {
 						if header := h.blockchain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
-							atomic.AddUint32(&p.invalidCount, 1)
+							if !h.historyPruned(hash) {
+								atomic.AddUint32(&p.invalidCount, 1)
+							}
 							continue
 						}
 					}
@@ -962,3 +967,10 @@ func (h *serverHandler) broadcastHeaders() {
 		}
 	}
 }
+
+// historyPruned reports whether the block with the given hash is known, but its
+// body and receipts have been deleted by history expiry.
+func (h *serverHandler) historyPruned(hash common.Hash) bool {
+	number := rawdb.ReadHeaderNumber(h.chainDb, hash)
+	return number != nil && h.blockchain.CheckHistory(*number) != nil
+}
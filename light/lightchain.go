@@ -413,6 +413,20 @@ func (lc *LightChain) CurrentHeader() *types.Header {
 	return lc.hc.CurrentHeader()
 }
 
+// CurrentSafeHeader retrieves the most recent canonical header that the
+// consensus engine considers safe relative to the current head, or nil if there
+// is none.
+func (lc *LightChain) CurrentSafeHeader() *types.Header {
+	return lc.hc.SafeHeader(lc.hc.CurrentHeader())
+}
+
+// CurrentFinalizedHeader retrieves the most recent canonical header that the
+// consensus engine considers finalized relative to the current head, or nil if
+// there is none.
+func (lc *LightChain) CurrentFinalizedHeader() *types.Header {
+	return lc.hc.FinalizedHeader(lc.hc.CurrentHeader())
+}
+
 // GetTd retrieves a block's total difficulty in the canonical chain from the
 // database by hash and number, caching it if found.
 func (lc *LightChain) GetTd(hash common.Hash, number uint64) *big.Int {
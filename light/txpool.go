@@ -68,7 +68,8 @@ type TxPool struct {
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
 
-	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	istanbul        bool   // Fork indicator whether we are in the istanbul stage.
+	maxInitCodeSize uint64 // Initcode size limit of the next block, zero if unlimited
 }
 
 // TxRelayBackend provides an interface to the mechanism that forwards transacions
@@ -314,6 +315,7 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	// Update fork indicator by next pending block number
 	next := new(big.Int).Add(head.Number, big.NewInt(1))
 	pool.istanbul = pool.config.IsIstanbul(next)
+	pool.maxInitCodeSize = pool.config.InitCodeSizeLimit(next)
 }
 
 // Stop stops the light transaction pool
@@ -380,8 +382,13 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		return core.ErrInsufficientFunds
 	}
 
+	// Contract creations must fit the initcode limit of the chain
+	if tx.To() == nil && pool.maxInitCodeSize != 0 && uint64(len(tx.Data())) > pool.maxInitCodeSize {
+		return core.ErrMaxInitCodeSizeExceeded
+	}
+
 	// Should supply enough intrinsic gas
-	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul, pool.maxInitCodeSize != 0)
 	if err != nil {
 		return err
 	}
@@ -286,9 +286,14 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		return stateDb.RawDump(false, false, true), nil
 	}
 	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		block = api.eth.blockchain.CurrentBlock()
-	} else {
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
+	default:
 		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
@@ -304,13 +309,19 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 // PrivateDebugAPI is the collection of MFA full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
-	eth *MFA
+	eth       *MFA
+	sources   *sourceRegistry
+	debuggers *debugSessions
 }
 
 // NewPrivateDebugAPI creates a new API definition for the full node-related
 // private debug methods of the MFA service.
 func NewPrivateDebugAPI(eth *MFA) *PrivateDebugAPI {
-	return &PrivateDebugAPI{eth: eth}
+	return &PrivateDebugAPI{
+		eth:       eth,
+		sources:   newSourceRegistry(),
+		debuggers: newDebugSessions(),
+	}
 }
 
 // Preimage is a debug API function that returns the preimage for a sha3 hash, if known.
@@ -367,9 +378,14 @@ func (api *PublicDebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, sta
 			_, stateDb = api.eth.miner.Pending()
 		} else {
 			var block *types.Block
-			if number == rpc.LatestBlockNumber {
+			switch number {
+			case rpc.LatestBlockNumber:
 				block = api.eth.blockchain.CurrentBlock()
-			} else {
+			case rpc.SafeBlockNumber:
+				block = api.eth.blockchain.CurrentSafeBlock()
+			case rpc.FinalizedBlockNumber:
+				block = api.eth.blockchain.CurrentFinalizedBlock()
+			default:
 				block = api.eth.blockchain.GetBlockByNumber(uint64(number))
 			}
 			if block == nil {
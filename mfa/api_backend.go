@@ -19,6 +19,7 @@ package eth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/MFAChain/mfachain/accounts"
@@ -68,6 +69,13 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 	if number == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
 	}
+	if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+		block, err := b.settledBlock(number)
+		if err != nil {
+			return nil, err
+		}
+		return block.Header(), nil
+	}
 	return b.eth.blockchain.GetHeaderByNumber(uint64(number)), nil
 }
 
@@ -102,11 +110,44 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	if number == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
+	if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+		return b.settledBlock(number)
+	}
+	if block := b.eth.blockchain.GetBlockByNumber(uint64(number)); block != nil {
+		return block, nil
+	}
+	return nil, b.eth.blockchain.CheckHistory(uint64(number))
+}
+
+// settledBlock resolves the safe or finalized block tag through the finality
+// rules of the consensus engine.
+func (b *EthAPIBackend) settledBlock(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+	if number == rpc.SafeBlockNumber {
+		block = b.eth.blockchain.CurrentSafeBlock()
+	} else {
+		block = b.eth.blockchain.CurrentFinalizedBlock()
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%s block not found", number)
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	if block := b.eth.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, b.checkHistory(hash)
+}
+
+// checkHistory returns core.ErrHistoryPruned if the block with the given hash is
+// known, but its body and receipts have been deleted by history expiry.
+func (b *EthAPIBackend) checkHistory(hash common.Hash) error {
+	if number := rawdb.ReadHeaderNumber(b.eth.ChainDb(), hash); number != nil {
+		return b.eth.blockchain.CheckHistory(*number)
+	}
+	return nil
 }
 
 func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
@@ -123,6 +164,9 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
+			if err := b.eth.blockchain.CheckHistory(header.Number.Uint64()); err != nil {
+				return nil, err
+			}
 			return nil, errors.New("header found, but block body is missing")
 		}
 		return block, nil
@@ -170,13 +214,16 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	if receipts := b.eth.blockchain.GetReceiptsByHash(hash); receipts != nil {
+		return receipts, nil
+	}
+	return nil, b.checkHistory(hash)
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
 	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
 	if receipts == nil {
-		return nil, nil
+		return nil, b.checkHistory(hash)
 	}
 	logs := make([][]*types.Log, len(receipts))
 	for i, receipt := range receipts {
@@ -221,9 +268,19 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if b.eth.isReplica() {
+		return errReplicaReadOnly
+	}
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, maxBlock uint64) error {
+	if b.eth.isReplica() {
+		return errReplicaReadOnly
+	}
+	return b.eth.txPool.AddPrivate(signedTx, maxBlock)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending, err := b.eth.txPool.Pending()
 	if err != nil {
@@ -242,6 +299,14 @@ func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.eth.ChainDb(), txHash)
+	if tx == nil {
+		// The transaction might still be indexed after its block body expired
+		if number := rawdb.ReadTxLookupEntry(b.eth.ChainDb(), txHash); number != nil {
+			if err := b.eth.blockchain.CheckHistory(*number); err != nil {
+				return nil, common.Hash{}, 0, 0, err
+			}
+		}
+	}
 	return tx, blockHash, blockNumber, index, nil
 }
 
@@ -257,6 +322,10 @@ func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.TxPool().Content()
 }
 
+func (b *EthAPIBackend) TxPoolPrivateContent() map[common.Address]types.Transactions {
+	return b.eth.TxPool().Private()
+}
+
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
@@ -0,0 +1,144 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/miner"
+	"github.com/MFAChain/mfachain/rlp"
+	"github.com/MFAChain/mfachain/rpc"
+)
+
+// PublicBundleAPI provides an API to submit and simulate bundles of transactions
+// to be included atomically at the top of a block.
+type PublicBundleAPI struct {
+	e *MFA
+}
+
+// NewPublicBundleAPI creates a new bundle API.
+func NewPublicBundleAPI(e *MFA) *PublicBundleAPI {
+	return &PublicBundleAPI{e}
+}
+
+// decodeBundleTxs decodes a list of RLP encoded signed transactions.
+func decodeBundleTxs(encodedTxs []hexutil.Bytes) (types.Transactions, error) {
+	if len(encodedTxs) == 0 {
+		return nil, errors.New("bundle contains no transactions")
+	}
+	txs := make(types.Transactions, 0, len(encodedTxs))
+	for _, encodedTx := range encodedTxs {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// SendBundle submits a bundle of signed transactions to the miner, to be included
+// in order at the top of the given block, all of them or none. The optional
+// timestamps restrict the blocks the bundle is eligible for.
+func (api *PublicBundleAPI) SendBundle(ctx context.Context, encodedTxs []hexutil.Bytes, blockNumber hexutil.Uint64, minTimestamp *hexutil.Uint64, maxTimestamp *hexutil.Uint64) (common.Hash, error) {
+	if api.e.isReplica() {
+		return common.Hash{}, errReplicaReadOnly
+	}
+	txs, err := decodeBundleTxs(encodedTxs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	bundle := &miner.Bundle{
+		Txs:         txs,
+		BlockNumber: uint64(blockNumber),
+	}
+	if minTimestamp != nil {
+		bundle.MinTimestamp = uint64(*minTimestamp)
+	}
+	if maxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*maxTimestamp)
+	}
+	if err := api.e.Miner().AddBundle(bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
+// CallBundle simulates a bundle of signed transactions as the content of the
+// given block, on top of the state of another one, without submitting it. The
+// timestamp defaults to the current time. The block to simulate must come after
+// the one providing the state.
+func (api *PublicBundleAPI) CallBundle(ctx context.Context, encodedTxs []hexutil.Bytes, blockNumber hexutil.Uint64, stateBlockNrOrHash rpc.BlockNumberOrHash, timestamp *hexutil.Uint64) (map[string]interface{}, error) {
+	txs, err := decodeBundleTxs(encodedTxs)
+	if err != nil {
+		return nil, err
+	}
+	statedb, parent, err := api.e.APIBackend.StateAndHeaderByNumberOrHash(ctx, stateBlockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	if uint64(blockNumber) <= parent.Number.Uint64() {
+		return nil, fmt.Errorf("bundle block #%d not after state block #%d", blockNumber, parent.Number)
+	}
+	coinbase, _ := api.e.Etherbase()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).SetUint64(uint64(blockNumber)),
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(time.Now().Unix()),
+		Coinbase:   coinbase,
+	}
+	if timestamp != nil {
+		header.Time = uint64(*timestamp)
+	}
+	if header.Time <= parent.Time {
+		header.Time = parent.Time + 1
+	}
+	header.Difficulty = api.e.engine.CalcDifficulty(api.e.blockchain, header.Time, parent)
+
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+	sim, err := miner.SimulateBundle(api.e.blockchain.Config(), api.e.blockchain, *api.e.blockchain.GetVMConfig(), statedb, header, coinbase, gasPool, 0, txs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]map[string]interface{}, 0, len(txs))
+	for i, receipt := range sim.Receipts {
+		results = append(results, map[string]interface{}{
+			"txHash":   txs[i].Hash(),
+			"gasUsed":  hexutil.Uint64(receipt.GasUsed),
+			"reverted": receipt.Status == types.ReceiptStatusFailed,
+			"logs":     receipt.Logs,
+		})
+	}
+	return map[string]interface{}{
+		"bundleHash":       (&miner.Bundle{Txs: txs}).Hash(),
+		"results":          results,
+		"gasUsed":          hexutil.Uint64(sim.GasUsed),
+		"coinbaseDiff":     (*hexutil.Big)(sim.CoinbaseDiff),
+		"bundleGasPrice":   (*hexutil.Big)(sim.GasPrice()),
+		"stateBlockNumber": hexutil.Uint64(parent.Number.Uint64()),
+	}, nil
+}
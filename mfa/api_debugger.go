@@ -0,0 +1,230 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/rpc"
+)
+
+// debugSessionTimeout is the time after which an idle debugging session is
+// closed and its execution aborted.
+const debugSessionTimeout = 5 * time.Minute
+
+// maxDebugSessions is the maximum number of debugging sessions open at once.
+const maxDebugSessions = 16
+
+// errTooManyDebugSessions is returned if a debugging session is started while
+// the maximum number of sessions is already open.
+var errTooManyDebugSessions = errors.New("too many debugging sessions")
+
+// DebuggerConfig holds extra parameters to debugging sessions.
+type DebuggerConfig struct {
+	Reexec      *uint64
+	Breakpoints []vm.Breakpoint // Breakpoints set before the execution starts
+}
+
+// debugSession is a transaction being re-executed under a debugger.
+type debugSession struct {
+	debugger *vm.Debugger
+	evm      *vm.EVM     // Cancelled when the session is closed
+	timer    *time.Timer // Closes the session once idle
+}
+
+// debugSessions tracks the open debugging sessions.
+type debugSessions struct {
+	sessions map[rpc.ID]*debugSession
+	lock     sync.Mutex
+}
+
+func newDebugSessions() *debugSessions {
+	return &debugSessions{sessions: make(map[rpc.ID]*debugSession)}
+}
+
+// add registers a new session, unless too many are open already.
+func (s *debugSessions) add(id rpc.ID, session *debugSession) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.sessions) >= maxDebugSessions {
+		return errTooManyDebugSessions
+	}
+	s.sessions[id] = session
+	return nil
+}
+
+// get returns the debugger of a session, postponing its idle timeout.
+func (s *debugSessions) get(id rpc.ID) (*vm.Debugger, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("debugging session %s not found", id)
+	}
+	session.timer.Reset(debugSessionTimeout)
+	return session.debugger, nil
+}
+
+// close closes a session and aborts its execution, returning whether it
+// existed.
+func (s *debugSessions) close(id rpc.ID) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	session, ok := s.sessions[id]
+	if ok {
+		session.timer.Stop()
+		session.evm.Cancel()
+		session.debugger.Close()
+		delete(s.sessions, id)
+	}
+	return ok
+}
+
+// DebuggerStart re-executes a transaction under an interactive debugger, paused
+// before its first instruction, and returns the id of the debugging session.
+// Sessions idle for five minutes are closed, and at most maxDebugSessions may
+// be open at once.
+func (api *PrivateDebugAPI) DebuggerStart(ctx context.Context, hash common.Hash, config *DebuggerConfig) (rpc.ID, error) {
+	// Retrieve the transaction and assemble its EVM context
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return "", fmt.Errorf("transaction %#x not found", hash)
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	msg, vmctx, statedb, err := api.computeTxEnv(blockHash, int(index), reexec)
+	if err != nil {
+		return "", err
+	}
+	debugger := vm.NewDebugger()
+	if config != nil {
+		for _, bp := range config.Breakpoints {
+			if _, err := debugger.AddBreakpoint(bp); err != nil {
+				return "", err
+			}
+		}
+	}
+	// Run the transaction in the background, paused by the debugger
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debugger: debugger})
+
+	id := rpc.NewID()
+	session := &debugSession{debugger: debugger, evm: vmenv}
+	session.timer = time.AfterFunc(debugSessionTimeout, func() { api.debuggers.close(id) })
+	if err := api.debuggers.add(id, session); err != nil {
+		session.timer.Stop()
+		return "", err
+	}
+	go func() {
+		result, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+		switch {
+		case err != nil:
+			debugger.Finish(nil, fmt.Errorf("execution failed: %v", err))
+		case result.Failed():
+			debugger.Finish(result.Revert(), result.Err)
+		default:
+			debugger.Finish(result.Return(), nil)
+		}
+	}()
+	return id, nil
+}
+
+// DebuggerState returns the state the execution of a debugging session is
+// paused in, or its outcome once done.
+func (api *PrivateDebugAPI) DebuggerState(id rpc.ID) (*vm.DebugState, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return debugger.State()
+}
+
+// DebuggerStep runs the next instruction of a debugging session.
+func (api *PrivateDebugAPI) DebuggerStep(id rpc.ID) (*vm.DebugState, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return debugger.Step()
+}
+
+// DebuggerContinue runs a debugging session until its next breakpoint, or
+// until its execution finishes.
+func (api *PrivateDebugAPI) DebuggerContinue(id rpc.ID) (*vm.DebugState, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return debugger.Continue()
+}
+
+// DebuggerStorage returns the value of a storage slot of an account, or of the
+// executing contract if no address is given, as seen by the paused execution.
+func (api *PrivateDebugAPI) DebuggerStorage(id rpc.ID, slot common.Hash, address *common.Address) (common.Hash, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return debugger.Storage(address, slot)
+}
+
+// DebuggerAddBreakpoint adds a breakpoint to a debugging session, returning
+// its id.
+func (api *PrivateDebugAPI) DebuggerAddBreakpoint(id rpc.ID, bp vm.Breakpoint) (int, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return 0, err
+	}
+	return debugger.AddBreakpoint(bp)
+}
+
+// DebuggerRemoveBreakpoint removes a breakpoint from a debugging session,
+// returning whether it existed.
+func (api *PrivateDebugAPI) DebuggerRemoveBreakpoint(id rpc.ID, bp int) (bool, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return false, err
+	}
+	return debugger.RemoveBreakpoint(bp), nil
+}
+
+// DebuggerBreakpoints returns the breakpoints of a debugging session.
+func (api *PrivateDebugAPI) DebuggerBreakpoints(id rpc.ID) ([]vm.Breakpoint, error) {
+	debugger, err := api.debuggers.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return debugger.Breakpoints(), nil
+}
+
+// DebuggerClose closes a debugging session, aborting its execution, and returns
+// whether it existed.
+func (api *PrivateDebugAPI) DebuggerClose(id rpc.ID) bool {
+	return api.debuggers.close(id)
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/params"
+	"github.com/MFAChain/mfachain/rpc"
+)
+
+// Tests that no more than maxDebugSessions sessions can be open at once, and
+// that closing a session aborts its execution.
+func TestDebugSessionsLimit(t *testing.T) {
+	sessions := newDebugSessions()
+
+	var ids []rpc.ID
+	for i := 0; i < maxDebugSessions; i++ {
+		id := rpc.NewID()
+		if err := sessions.add(id, newTestDebugSession()); err != nil {
+			t.Fatalf("session %d: failed to add: %v", i, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := sessions.add(rpc.NewID(), newTestDebugSession()); err != errTooManyDebugSessions {
+		t.Fatalf("session above limit: error mismatch: have %v, want %v", err, errTooManyDebugSessions)
+	}
+	evm := sessions.sessions[ids[0]].evm
+	if !sessions.close(ids[0]) {
+		t.Fatalf("failed to close session")
+	}
+	if !evm.Cancelled() {
+		t.Fatalf("execution of closed session not aborted")
+	}
+	if err := sessions.add(rpc.NewID(), newTestDebugSession()); err != nil {
+		t.Fatalf("failed to add session after closing one: %v", err)
+	}
+}
+
+func newTestDebugSession() *debugSession {
+	debugger := vm.NewDebugger()
+	return &debugSession{
+		debugger: debugger,
+		evm:      vm.NewEVM(vm.Context{BlockNumber: new(big.Int)}, nil, params.TestChainConfig, vm.Config{Debugger: debugger}),
+		timer:    time.NewTimer(debugSessionTimeout),
+	}
+}
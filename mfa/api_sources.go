@@ -0,0 +1,396 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/MFAChain/mfachain/accounts/abi"
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/compiler"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/internal/ethapi"
+)
+
+// SourceArtifact is a compiled contract to annotate the traces with, matched
+// against the executed code by address or by code hash. Without either, the
+// hash of the runtime code of the contract is used.
+type SourceArtifact struct {
+	Address  *common.Address       `json:"address"`
+	CodeHash *common.Hash          `json:"codeHash"`
+	Contract *compiler.Contract    `json:"contract"`
+	Sources  []compiler.SourceFile `json:"sources"` // Source files by index, defaults to the contract source
+}
+
+// SourceStructLog is a structured log annotated with its source location.
+type SourceStructLog struct {
+	ethapi.StructLogRes
+	Source *compiler.SourceLocation `json:"source,omitempty"`
+}
+
+// SourceCallArg is an ABI-decoded argument or return value of a call.
+type SourceCallArg struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// SourceCallFrame is a call made during a traced execution, along with its
+// method and arguments if its contract has a registered artifact.
+type SourceCallFrame struct {
+	Type    string             `json:"type"`
+	From    common.Address     `json:"from"`
+	To      common.Address     `json:"to"`
+	Value   *hexutil.Big       `json:"value,omitempty"`
+	Gas     hexutil.Uint64     `json:"gas"`
+	Input   hexutil.Bytes      `json:"input"`
+	Output  hexutil.Bytes      `json:"output,omitempty"`
+	Error   string             `json:"error,omitempty"`
+	Method  string             `json:"method,omitempty"`
+	Args    []SourceCallArg    `json:"args,omitempty"`
+	Returns []SourceCallArg    `json:"returns,omitempty"`
+	Calls   []*SourceCallFrame `json:"calls,omitempty"`
+
+	artifact *sourceArtifact // Artifact of the called code, if registered
+}
+
+// SourceTraceResult is the result of a trace annotated with the registered
+// source artifacts.
+type SourceTraceResult struct {
+	Gas         uint64            `json:"gas"`
+	Failed      bool              `json:"failed"`
+	ReturnValue string            `json:"returnValue"`
+	StructLogs  []SourceStructLog `json:"structLogs"`
+	Calls       *SourceCallFrame  `json:"calls"`
+}
+
+// sourceArtifact is a registered artifact, prepared for the lookups.
+type sourceArtifact struct {
+	runtime  *compiler.SourceMapper
+	creation *compiler.SourceMapper
+	abi      *abi.ABI
+}
+
+// sourceRegistry holds the source artifacts registered for tracing.
+type sourceRegistry struct {
+	byAddress  map[common.Address]*sourceArtifact
+	byCodeHash map[common.Hash]*sourceArtifact
+	lock       sync.RWMutex
+}
+
+func newSourceRegistry() *sourceRegistry {
+	return &sourceRegistry{
+		byAddress:  make(map[common.Address]*sourceArtifact),
+		byCodeHash: make(map[common.Hash]*sourceArtifact),
+	}
+}
+
+// lookup returns the artifact of the code executed by a contract, preferring
+// the one registered for its address.
+func (r *sourceRegistry) lookup(addr common.Address, codeHash common.Hash) *sourceArtifact {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if artifact, ok := r.byAddress[addr]; ok {
+		return artifact
+	}
+	return r.byCodeHash[codeHash]
+}
+
+// RegisterSources registers the compiled artifact of a contract, so the traces
+// requested with the sourceMap option annotate its steps with their source
+// location and decode its calls.
+func (api *PrivateDebugAPI) RegisterSources(artifact SourceArtifact) error {
+	if artifact.Contract == nil {
+		return errors.New("missing contract")
+	}
+	contract := artifact.Contract
+	sources := artifact.Sources
+	if len(sources) == 0 {
+		sources = []compiler.SourceFile{{Content: contract.Info.Source}}
+	}
+	var (
+		code    = common.FromHex(contract.RuntimeCode)
+		entry   = new(sourceArtifact)
+		err     error
+		initMap string
+	)
+	if entry.runtime, err = compiler.NewSourceMapper(code, contract.Info.SrcMapRuntime, sources); err != nil {
+		return fmt.Errorf("invalid runtime source map: %v", err)
+	}
+	if srcmap, ok := contract.Info.SrcMap.(string); ok {
+		initMap = srcmap
+	}
+	if entry.creation, err = compiler.NewSourceMapper(common.FromHex(contract.Code), initMap, sources); err != nil {
+		return fmt.Errorf("invalid source map: %v", err)
+	}
+	if contract.Info.AbiDefinition != nil {
+		blob, err := json.Marshal(contract.Info.AbiDefinition)
+		if err != nil {
+			return err
+		}
+		parsed, err := abi.JSON(bytes.NewReader(blob))
+		if err != nil {
+			return fmt.Errorf("invalid abi: %v", err)
+		}
+		entry.abi = &parsed
+	}
+	registry := api.sources
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	switch {
+	case artifact.Address != nil:
+		registry.byAddress[*artifact.Address] = entry
+	case artifact.CodeHash != nil:
+		registry.byCodeHash[*artifact.CodeHash] = entry
+	default:
+		registry.byCodeHash[crypto.Keccak256Hash(code)] = entry
+	}
+	return nil
+}
+
+// sourceStep is the context a structured log was captured in.
+type sourceStep struct {
+	artifact *sourceArtifact
+	create   bool
+}
+
+// sourceTracer is a structured logger which additionally records the code each
+// step executes and the call tree, to annotate them with the registered
+// source artifacts.
+type sourceTracer struct {
+	*vm.StructLogger
+	registry *sourceRegistry
+
+	steps  []sourceStep
+	root   *SourceCallFrame
+	frames []*SourceCallFrame // Frames of the calls in progress
+	calls  []*SourceCallFrame // Last call made by each frame, pending its result
+}
+
+func newSourceTracer(logger *vm.StructLogger, registry *sourceRegistry) *sourceTracer {
+	return &sourceTracer{StructLogger: logger, registry: registry}
+}
+
+// CaptureStart implements the Tracer interface to start the call tree.
+func (t *sourceTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	t.root = &SourceCallFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Value: (*hexutil.Big)(value),
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	return t.StructLogger.CaptureStart(from, to, create, input, gas, value)
+}
+
+// CaptureState implements the Tracer interface, recording the code executed by
+// the step and the calls it makes.
+func (t *sourceTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// Return from the calls which ended, and enter the new one if needed
+	if len(t.frames) > depth {
+		t.frames, t.calls = t.frames[:depth], t.calls[:depth]
+	}
+	if len(t.frames) == depth && t.calls[depth-1] != nil {
+		t.resolveCall(t.calls[depth-1], stack)
+		t.calls[depth-1] = nil
+	}
+	if len(t.frames) < depth {
+		frame := t.root
+		if depth > 1 {
+			if frame = t.calls[depth-2]; frame == nil {
+				// Entered without a call instruction, which shouldn't happen
+				frame = &SourceCallFrame{Type: "CALL", To: contract.Address()}
+			}
+		}
+		addr := contract.Address()
+		if contract.CodeAddr != nil {
+			addr = *contract.CodeAddr
+		}
+		frame.To = contract.Address()
+		frame.Gas = hexutil.Uint64(gas)
+		frame.artifact = t.registry.lookup(addr, contract.CodeHash)
+
+		t.frames = append(t.frames, frame)
+		t.calls = append(t.calls, nil)
+	}
+	frame := t.frames[depth-1]
+
+	logs := len(t.StructLogs())
+	if err := t.StructLogger.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err); err != nil {
+		return err
+	}
+	if len(t.StructLogs()) > logs {
+		create := frame.Type == "CREATE" || frame.Type == "CREATE2"
+		t.steps = append(t.steps, sourceStep{artifact: frame.artifact, create: create})
+	}
+	if err != nil {
+		return nil
+	}
+	// Record the calls and the outputs of the frame
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		call := &SourceCallFrame{
+			Type: op.String(),
+			From: contract.Address(),
+			To:   common.BigToAddress(stack.Back(1)),
+		}
+		args := 2
+		if op == vm.CALL || op == vm.CALLCODE {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(stack.Back(2)))
+			args = 3
+		}
+		call.Input = memory.GetCopy(stack.Back(args).Int64(), stack.Back(args+1).Int64())
+		t.addCall(depth, call)
+
+	case vm.CREATE, vm.CREATE2:
+		call := &SourceCallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			Value: (*hexutil.Big)(new(big.Int).Set(stack.Back(0))),
+		}
+		call.Input = memory.GetCopy(stack.Back(1).Int64(), stack.Back(2).Int64())
+		t.addCall(depth, call)
+
+	case vm.RETURN, vm.REVERT:
+		frame.Output = memory.GetCopy(stack.Back(0).Int64(), stack.Back(1).Int64())
+		if op == vm.REVERT {
+			frame.Error = vm.ErrExecutionReverted.Error()
+		}
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface, recording the error of the
+// call in progress.
+func (t *sourceTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if depth <= len(t.frames) && err != nil && t.frames[depth-1].Error == "" {
+		t.frames[depth-1].Error = err.Error()
+	}
+	return t.StructLogger.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// CaptureEnd implements the Tracer interface, recording the outcome of the
+// execution.
+func (t *sourceTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.root.Output = common.CopyBytes(output)
+	if err != nil {
+		t.root.Error = err.Error()
+	}
+	return t.StructLogger.CaptureEnd(output, gasUsed, d, err)
+}
+
+// addCall appends a call made by the frame at the given depth, pending its
+// result.
+func (t *sourceTracer) addCall(depth int, call *SourceCallFrame) {
+	parent := t.frames[depth-1]
+	parent.Calls = append(parent.Calls, call)
+	t.calls[depth-1] = call
+}
+
+// resolveCall records the result of a call, as pushed on the stack of the caller.
+func (t *sourceTracer) resolveCall(call *SourceCallFrame, stack *vm.Stack) {
+	if len(stack.Data()) == 0 {
+		return
+	}
+	result := stack.Back(0)
+	switch call.Type {
+	case "CREATE", "CREATE2":
+		if result.Sign() != 0 {
+			call.To = common.BigToAddress(result)
+		}
+	}
+	if result.Sign() == 0 && call.Error == "" {
+		call.Error = "call failed"
+	}
+}
+
+// result assembles the annotated trace of the execution.
+func (t *sourceTracer) result(gas uint64, failed bool, output []byte) *SourceTraceResult {
+	logs := ethapi.FormatLogs(t.StructLogs())
+	steps := make([]SourceStructLog, len(logs))
+	for i, log := range logs {
+		steps[i].StructLogRes = log
+		if step := t.steps[i]; step.artifact != nil {
+			if step.create {
+				steps[i].Source = step.artifact.creation.Locate(log.Pc)
+			} else {
+				steps[i].Source = step.artifact.runtime.Locate(log.Pc)
+			}
+		}
+	}
+	if t.root != nil {
+		decodeCalls(t.root)
+	}
+	return &SourceTraceResult{
+		Gas:         gas,
+		Failed:      failed,
+		ReturnValue: fmt.Sprintf("%x", output),
+		StructLogs:  steps,
+		Calls:       t.root,
+	}
+}
+
+// decodeCalls decodes the method, arguments and return values of the calls of
+// a tree having an ABI registered.
+func decodeCalls(call *SourceCallFrame) {
+	if call.artifact != nil && call.artifact.abi != nil && len(call.Input) >= 4 && call.Type != "CREATE" && call.Type != "CREATE2" {
+		if method, err := call.artifact.abi.MethodById(call.Input[:4]); err == nil {
+			call.Method = method.Sig
+			call.Args = decodeArgs(method.Inputs, call.Input[4:])
+			if call.Error == "" {
+				call.Returns = decodeArgs(method.Outputs, call.Output)
+			}
+		}
+	}
+	if call.Error == vm.ErrExecutionReverted.Error() {
+		if reason, err := abi.UnpackRevert(call.Output); err == nil {
+			call.Error = fmt.Sprintf("%s: %s", call.Error, reason)
+		}
+	}
+	for _, child := range call.Calls {
+		decodeCalls(child)
+	}
+}
+
+// decodeArgs decodes ABI-encoded values, returning nil if they don't match the
+// arguments.
+func decodeArgs(args abi.Arguments, data []byte) []SourceCallArg {
+	values, err := args.UnpackValues(data)
+	if err != nil || len(values) != len(args) {
+		return nil
+	}
+	decoded := make([]SourceCallArg, len(args))
+	for i, arg := range args {
+		decoded[i] = SourceCallArg{Name: arg.Name, Type: arg.Type.String(), Value: values[i]}
+	}
+	return decoded
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/compiler"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/params"
+)
+
+// Tests that the traces are annotated with the sources of the registered
+// contracts, and that their calls are decoded.
+func TestSourceTrace(t *testing.T) {
+	var (
+		caller = common.HexToAddress("0x1337")
+		outer  = common.HexToAddress("0xaa")
+		inner  = common.HexToAddress("0xbb")
+
+		source  = "contract Inner {\n    function get(uint v) public returns (uint) {\n        return v;\n    }\n}\n"
+		abiJSON = `[{"type":"function","name":"get","inputs":[{"name":"v","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]}]`
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	// Forward the input to the inner contract, which returns its argument
+	statedb.SetCode(outer, common.FromHex("36600060003760206000366000600060bb5af100"))
+	statedb.SetCode(inner, common.FromHex("60043560005260206000f3"))
+
+	var definition interface{}
+	if err := json.Unmarshal([]byte(abiJSON), &definition); err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	api := &PrivateDebugAPI{sources: newSourceRegistry()}
+	err := api.RegisterSources(SourceArtifact{
+		Address: &inner,
+		Contract: &compiler.Contract{
+			RuntimeCode: "0x60043560005260206000f3",
+			Info: compiler.ContractInfo{
+				Source:        source,
+				SrcMapRuntime: fmt.Sprintf("%d:8:0;;;;;;", strings.Index(source, "return v")),
+				AbiDefinition: definition,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register sources: %v", err)
+	}
+	tracer := newSourceTracer(vm.NewStructLogger(nil), api.sources)
+	vmctx := vm.Context{
+		CanTransfer: func(vm.StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(vm.StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	evm := vm.NewEVM(vmctx, statedb, params.AllEthashProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+
+	input := append(crypto.Keccak256([]byte("get(uint256)"))[:4], common.LeftPadBytes([]byte{5}, 32)...)
+	if _, _, err := evm.Call(vm.AccountRef(caller), outer, input, 1000000, new(big.Int)); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	result := tracer.result(0, false, nil)
+
+	// Check the steps of the inner contract, and only them, are annotated
+	var annotated int
+	for _, log := range result.StructLogs {
+		if log.Depth == 1 {
+			if log.Source != nil {
+				t.Errorf("pc %d: unexpected source location in the outer contract: %+v", log.Pc, log.Source)
+			}
+			continue
+		}
+		if log.Source == nil || log.Source.Line != 3 || log.Source.Column != 9 || log.Source.Contract != "Inner" || log.Source.Function != "get" {
+			t.Errorf("pc %d: source location mismatch: have %+v", log.Pc, log.Source)
+		}
+		annotated++
+	}
+	if annotated != 7 {
+		t.Errorf("annotated steps mismatch: have %d, want 7", annotated)
+	}
+	// Check the call to the inner contract is decoded
+	root := result.Calls
+	if root == nil || root.To != outer || len(root.Calls) != 1 {
+		t.Fatalf("call tree mismatch: have %+v", root)
+	}
+	call := root.Calls[0]
+	if call.Type != "CALL" || call.From != outer || call.To != inner || call.Error != "" {
+		t.Errorf("call mismatch: have %s from %x to %x (error %q)", call.Type, call.From, call.To, call.Error)
+	}
+	if call.Method != "get(uint256)" {
+		t.Errorf("method mismatch: have %q, want %q", call.Method, "get(uint256)")
+	}
+	if len(call.Args) != 1 || call.Args[0].Name != "v" || fmt.Sprint(call.Args[0].Value) != "5" {
+		t.Errorf("arguments mismatch: have %+v", call.Args)
+	}
+	if len(call.Returns) != 1 || fmt.Sprint(call.Returns[0].Value) != "5" {
+		t.Errorf("return values mismatch: have %+v", call.Returns)
+	}
+}
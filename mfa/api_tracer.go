@@ -57,9 +57,10 @@ const (
 // TraceConfig holds extra parameters to trace functions.
 type TraceConfig struct {
 	*vm.LogConfig
-	Tracer  *string
-	Timeout *string
-	Reexec  *uint64
+	Tracer    *string
+	Timeout   *string
+	Reexec    *uint64
+	SourceMap bool // Annotates the structured logs with the registered sources
 }
 
 // StdTraceConfig holds extra parameters to standard-json trace functions.
@@ -69,6 +70,22 @@ type StdTraceConfig struct {
 	TxHash common.Hash
 }
 
+// ProfileConfig holds extra parameters to profile functions.
+type ProfileConfig struct {
+	Timeout *string
+	Reexec  *uint64
+	Pprof   bool // Whether to include the profile in pprof format
+}
+
+// ProfileResult is the opcode level gas profile of a transaction.
+type ProfileResult struct {
+	Gas        uint64             `json:"gas"`
+	Failed     bool               `json:"failed"`
+	Entries    []vm.ProfileEntry  `json:"entries"`
+	FlameGraph *vm.FlameGraphNode `json:"flameGraph"`
+	Pprof      hexutil.Bytes      `json:"pprof,omitempty"`
+}
+
 // txTraceResult is the result of a single transaction trace.
 type txTraceResult struct {
 	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
@@ -110,6 +127,10 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		from = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		from = api.eth.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -118,6 +139,10 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		to = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		to = api.eth.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -361,6 +386,10 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
 	}
@@ -752,6 +781,9 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	case config == nil:
 		tracer = vm.NewStructLogger(nil)
 
+	case config.SourceMap:
+		tracer = newSourceTracer(vm.NewStructLogger(config.LogConfig), api.sources)
+
 	default:
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
@@ -772,6 +804,9 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
+	case *sourceTracer:
+		return tracer.result(result.UsedGas, result.Failed(), result.Return()), nil
+
 	case *tracers.Tracer:
 		return tracer.GetResult()
 
@@ -780,6 +815,66 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	}
 }
 
+// ProfileTransaction re-executes a transaction and returns the gas, execution
+// count and wall-clock time it spent by contract, program counter and opcode,
+// along with a flame graph of its calls and optionally a pprof profile. The
+// execution is aborted after the configured timeout or when the request ends.
+func (api *PrivateDebugAPI) ProfileTransaction(ctx context.Context, hash common.Hash, config *ProfileConfig) (*ProfileResult, error) {
+	// Retrieve the transaction and assemble its EVM context
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	msg, vmctx, statedb, err := api.computeTxEnv(blockHash, int(index), reexec)
+	if err != nil {
+		return nil, err
+	}
+	// Define a meaningful timeout of a single transaction profile
+	timeout := defaultTraceTimeout
+	if config != nil && config.Timeout != nil {
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	// Run the transaction with the profiler enabled
+	profiler := vm.NewProfiler()
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: profiler})
+
+	// Handle timeouts and RPC cancellations
+	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
+	go func() {
+		<-deadlineCtx.Done()
+		vmenv.Cancel()
+	}()
+	defer cancel()
+
+	result, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if vmenv.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("profiling failed: %v", err)
+	}
+	profile := &ProfileResult{
+		Gas:        result.UsedGas,
+		Failed:     result.Failed(),
+		Entries:    profiler.Entries(),
+		FlameGraph: profiler.FlameGraph(),
+	}
+	if config != nil && config.Pprof {
+		var buf bytes.Buffer
+		if err := profiler.WritePprof(&buf); err != nil {
+			return nil, err
+		}
+		profile.Pprof = buf.Bytes()
+	}
+	return profile, nil
+}
+
 // computeTxEnv returns the execution environment of a certain transaction.
 func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
 	// Create the parent state database
@@ -41,6 +41,7 @@ import (
 	"github.com/MFAChain/mfachain/eth/filters"
 	"github.com/MFAChain/mfachain/eth/gasprice"
 	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/remotedb"
 	"github.com/MFAChain/mfachain/event"
 	"github.com/MFAChain/mfachain/internal/ethapi"
 	"github.com/MFAChain/mfachain/log"
@@ -84,6 +85,7 @@ type MFA struct {
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
+	closeReplica      chan struct{}
 
 	APIBackend *EthAPIBackend
 
@@ -135,8 +137,25 @@ func New(ctx *node.ServiceContext, config *Config) (*MFA, error) {
 	}
 	log.Info("Allocated trie memory caches", "clean", common.StorageSize(config.TrieCleanCache)*1024*1024, "dirty", common.StorageSize(config.TrieDirtyCache)*1024*1024)
 
+	if config.RemoteDBServe && config.RemoteDBSecret == "" {
+		return nil, errors.New("serving the remote database requires a secret")
+	}
+	if config.RemoteDBServe && !config.NoPruning {
+		log.Warn("Serving remote database without archive mode, replicas will miss recent state")
+	}
 	// Assemble the MFA object
-	chainDb, err := ctx.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "mfa/db/chaindata/")
+	var (
+		chainDb mfadb.Database
+		err     error
+	)
+	if config.RemoteDBPrimary != "" {
+		// Replicas never process blocks, only follow the head of the primary, so
+		// avoid maintaining any derived data which would shadow the remote one
+		config.SnapshotCache = 0
+		chainDb, err = openReplicaDatabase(config)
+	} else {
+		chainDb, err = ctx.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "mfa/db/chaindata/")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +172,7 @@ func New(ctx *node.ServiceContext, config *Config) (*MFA, error) {
 		accountManager:    ctx.AccountManager,
 		engine:            CreateConsensusEngine(ctx, chainConfig, &config.Ethash, config.Miner.Notify, config.Miner.Noverify, chainDb),
 		closeBloomHandler: make(chan struct{}),
+		closeReplica:      make(chan struct{}),
 		networkID:         config.NetworkId,
 		gasPrice:          config.Miner.GasPrice,
 		etherbase:         config.Miner.Etherbase,
@@ -170,7 +190,7 @@ func New(ctx *node.ServiceContext, config *Config) (*MFA, error) {
 	if !config.SkipBcVersionCheck {
 		if bcVersion != nil && *bcVersion > core.BlockChainVersion {
 			return nil, fmt.Errorf("database version is v%d, mfachain %s only supports v%d", *bcVersion, params.VersionWithMeta, core.BlockChainVersion)
-		} else if bcVersion == nil || *bcVersion < core.BlockChainVersion {
+		} else if (bcVersion == nil || *bcVersion < core.BlockChainVersion) && !eth.isReplica() {
 			log.Warn("Upgrade blockchain database version", "from", dbVer, "to", core.BlockChainVersion)
 			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 		}
@@ -188,9 +208,17 @@ func New(ctx *node.ServiceContext, config *Config) (*MFA, error) {
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
+			HistoryCutoff:       config.HistoryCutoff,
+			ParallelTxWorkers:   config.ParallelTxWorkers,
 		}
 	)
-	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
+	txLookupLimit := &config.TxLookupLimit
+	if eth.isReplica() {
+		cacheConfig.TrieDirtyDisabled = true
+		cacheConfig.HistoryCutoff = 0
+		txLookupLimit = nil
+	}
+	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, txLookupLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -200,11 +228,18 @@ func New(ctx *node.ServiceContext, config *Config) (*MFA, error) {
 		eth.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
-	eth.bloomIndexer.Start(eth.blockchain)
+	// Replicas read the bloom bits indexed by the primary instead of maintaining
+	// their own, which would only pile up in the local write layer
+	if !eth.isReplica() {
+		eth.bloomIndexer.Start(eth.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -301,6 +336,14 @@ func (s *MFA) APIs() []rpc.API {
 		apis = append(apis, s.lesServer.APIs()...)
 	}
 
+	// Append the database API if serving read-only replicas
+	if s.config.RemoteDBServe {
+		apis = append(apis, rpc.API{
+			Namespace: remotedb.Namespace,
+			Version:   "1.0",
+			Service:   remotedb.NewAPI(s.chainDb, s.config.RemoteDBSecret),
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -313,6 +356,11 @@ func (s *MFA) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicMinerAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "mfa",
+			Version:   "1.0",
+			Service:   NewPublicBundleAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "mfa",
 			Version:   "1.0",
@@ -444,6 +492,9 @@ func (s *MFA) SetEtherbase(etherbase common.Address) {
 // is already running, this method adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
 func (s *MFA) StartMining(threads int) error {
+	if s.isReplica() {
+		return errReplicaReadOnly
+	}
 	// Update the thread count within the consensus engine
 	type threaded interface {
 		SetThreads(threads int)
@@ -519,6 +570,10 @@ func (s *MFA) ArchiveMode() bool                  { return s.config.NoPruning }
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *MFA) Protocols() []p2p.Protocol {
+	// Read-only replicas get their chain from the primary, not the network
+	if s.isReplica() {
+		return nil
+	}
 	protos := make([]p2p.Protocol, len(ProtocolVersions))
 	for i, vsn := range ProtocolVersions {
 		protos[i] = s.protocolManager.makeProtocol(vsn)
@@ -555,6 +610,9 @@ func (s *MFA) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.isReplica() {
+		go s.replicaLoop()
+	}
 	return nil
 }
 
@@ -568,6 +626,7 @@ func (s *MFA) Stop() error {
 	}
 
 	// Then stop everything else.
+	close(s.closeReplica)
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
@@ -58,6 +58,8 @@ var DefaultConfig = Config{
 		GasCeil:  8000000,
 		GasPrice: big.NewInt(params.GWei),
 		Recommit: 3 * time.Second,
+
+		MaxBundleTxs: 64,
 	},
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -105,7 +107,10 @@ type Config struct {
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
+	ParallelTxWorkers int `toml:",omitempty"` // Number of workers executing block transactions in parallel (0 = sequential)
+
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	HistoryCutoff uint64 `toml:",omitempty"` // Block number below which ancient bodies and receipts are deleted.
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
@@ -132,6 +137,11 @@ type Config struct {
 	TrieTimeout    time.Duration
 	SnapshotCache  int
 
+	// Remote database options
+	RemoteDBServe   bool   `toml:",omitempty"` // Whether to serve the chain database to read-only replicas
+	RemoteDBPrimary string `toml:",omitempty"` // HTTP RPC endpoint of the primary node to run on as a read-only replica
+	RemoteDBSecret  string `toml:"-"`          // Shared secret authenticating replicas to the primary
+
 	// Mining options
 	Miner miner.Config
 
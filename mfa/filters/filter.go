@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/MFAChain/mfachain/common"
@@ -135,18 +136,24 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
+	begin, err := f.resolveSettled(ctx, f.begin)
+	if err != nil {
+		return nil, err
+	}
+	f.begin = begin
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
-	end := uint64(f.end)
-	if f.end == -1 {
+	last, err := f.resolveSettled(ctx, f.end)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(last)
+	if last == -1 {
 		end = head
 	}
 	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
+	var logs []*types.Log
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
 		if indexed > end {
@@ -163,6 +170,22 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	return logs, err
 }
 
+// resolveSettled converts the safe and finalized block tags into the numbers of
+// the blocks they currently point to, leaving any other number untouched.
+func (f *Filter) resolveSettled(ctx context.Context, number int64) (int64, error) {
+	if number != rpc.SafeBlockNumber.Int64() && number != rpc.FinalizedBlockNumber.Int64() {
+		return number, nil
+	}
+	header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("%s block not found", rpc.BlockNumber(number))
+	}
+	return header.Number.Int64(), nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -20,6 +20,7 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -200,6 +201,10 @@ func (es *EventSystem) SubscribeLogs(crit MFA.FilterQuery, logs chan []*types.Lo
 		to = rpc.BlockNumber(crit.ToBlock.Int64())
 	}
 
+	// settled blocks lag behind the head and cannot be followed live
+	if from == rpc.SafeBlockNumber || from == rpc.FinalizedBlockNumber || to == rpc.SafeBlockNumber || to == rpc.FinalizedBlockNumber {
+		return nil, errors.New("safe and finalized block tags are not supported in log subscriptions")
+	}
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
 		return es.subscribePendingLogs(crit, logs), nil
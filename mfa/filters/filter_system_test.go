@@ -292,6 +292,9 @@ func TestLogFilterCreation(t *testing.T) {
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(100)}, false},
 			// from block "higher" than to block
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, false},
+			// settled blocks cannot be followed live
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}, false},
+			{FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(rpc.SafeBlockNumber.Int64())}, false},
 		}
 	)
 
@@ -24,7 +24,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		DiscoveryURLs           []string
 		NoPruning               bool
 		NoPrefetch              bool
+		ParallelTxWorkers       int                    `toml:",omitempty"`
 		TxLookupLimit           uint64                 `toml:",omitempty"`
+		HistoryCutoff           uint64                 `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
@@ -40,6 +42,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieCleanCache          int
 		TrieDirtyCache          int
 		TrieTimeout             time.Duration
+		RemoteDBServe           bool   `toml:",omitempty"`
+		RemoteDBPrimary         string `toml:",omitempty"`
+		RemoteDBSecret          string `toml:"-"`
 		Miner                   miner.Config
 		Ethash                  mfa.Config
 		TxPool                  core.TxPoolConfig
@@ -61,7 +66,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DiscoveryURLs = c.DiscoveryURLs
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
+	enc.ParallelTxWorkers = c.ParallelTxWorkers
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.HistoryCutoff = c.HistoryCutoff
 	enc.Whitelist = c.Whitelist
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -77,6 +84,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
+	enc.RemoteDBServe = c.RemoteDBServe
+	enc.RemoteDBPrimary = c.RemoteDBPrimary
+	enc.RemoteDBSecret = c.RemoteDBSecret
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
@@ -100,7 +110,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		DiscoveryURLs           []string
 		NoPruning               *bool
 		NoPrefetch              *bool
+		ParallelTxWorkers       *int                   `toml:",omitempty"`
 		TxLookupLimit           *uint64                `toml:",omitempty"`
+		HistoryCutoff           *uint64                `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
@@ -116,6 +128,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieCleanCache          *int
 		TrieDirtyCache          *int
 		TrieTimeout             *time.Duration
+		RemoteDBServe           *bool   `toml:",omitempty"`
+		RemoteDBPrimary         *string `toml:",omitempty"`
+		RemoteDBSecret          *string `toml:"-"`
 		Miner                   *miner.Config
 		Ethash                  *mfa.Config
 		TxPool                  *core.TxPoolConfig
@@ -152,9 +167,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NoPrefetch != nil {
 		c.NoPrefetch = *dec.NoPrefetch
 	}
+	if dec.ParallelTxWorkers != nil {
+		c.ParallelTxWorkers = *dec.ParallelTxWorkers
+	}
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.HistoryCutoff != nil {
+		c.HistoryCutoff = *dec.HistoryCutoff
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
@@ -200,6 +221,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieTimeout != nil {
 		c.TrieTimeout = *dec.TrieTimeout
 	}
+	if dec.RemoteDBServe != nil {
+		c.RemoteDBServe = *dec.RemoteDBServe
+	}
+	if dec.RemoteDBPrimary != nil {
+		c.RemoteDBPrimary = *dec.RemoteDBPrimary
+	}
+	if dec.RemoteDBSecret != nil {
+		c.RemoteDBSecret = *dec.RemoteDBSecret
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
@@ -83,6 +83,8 @@ type ProtocolManager struct {
 	eventMux      *event.TypeMux
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
+	privateTxsCh  chan core.NewPrivateTxsEvent
+	privateTxsSub event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
 	whitelist map[uint64]common.Hash
@@ -259,6 +261,12 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.txsSub = pm.txpool.SubscribeNewTxsEvent(pm.txsCh)
 	go pm.txBroadcastLoop()
 
+	// forward private transactions to trusted peers
+	pm.wg.Add(1)
+	pm.privateTxsCh = make(chan core.NewPrivateTxsEvent, txChanSize)
+	pm.privateTxsSub = pm.txpool.SubscribePrivateTxsEvent(pm.privateTxsCh)
+	go pm.privateTxForwardLoop()
+
 	// broadcast mined blocks
 	pm.wg.Add(1)
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
@@ -272,6 +280,7 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 
 func (pm *ProtocolManager) Stop() {
 	pm.txsSub.Unsubscribe()        // quits txBroadcastLoop
+	pm.privateTxsSub.Unsubscribe() // quits privateTxForwardLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
 
 	// Quit chainSync and txsync64.
@@ -800,6 +809,26 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txFetcher.Enqueue(p.id, txs, msg.Code == PooledTransactionsMsg)
 
+	case msg.Code == PrivateTransactionsMsg && p.version >= eth66:
+		// Private transactions are only accepted from trusted peers, which
+		// in turn are the only ones they are forwarded to
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 || !p.Peer.Info().Network.Trusted {
+			break
+		}
+		var request privateTxsData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, tx := range request.Txs {
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			p.MarkTransaction(tx.Hash())
+			if err := pm.txpool.AddPrivate(tx, request.MaxBlock); err != nil {
+				p.Log().Trace("Rejected private transaction", "hash", tx.Hash(), "err", err)
+			}
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -912,6 +941,41 @@ func (pm *ProtocolManager) txBroadcastLoop() {
 	}
 }
 
+// ForwardPrivateTransactions sends a batch of private transactions directly to
+// the trusted peers not yet knowing about them. Untrusted peers are never sent
+// nor announced private transactions, and the trusted ones receive them on a
+// dedicated message, pooling them as private in turn.
+func (pm *ProtocolManager) ForwardPrivateTransactions(txs types.Transactions, maxBlock uint64) {
+	txset := make(map[*peer]types.Transactions)
+	for _, tx := range txs {
+		peers := pm.peers.TrustedPeersWithoutTx(tx.Hash())
+		for _, peer := range peers {
+			txset[peer] = append(txset[peer], tx)
+		}
+		log.Trace("Forwarded private transaction", "hash", tx.Hash(), "recipients", len(peers))
+	}
+	for peer, txs := range txset {
+		if err := peer.SendPrivateTransactions(txs, maxBlock); err != nil {
+			peer.Log().Debug("Failed to forward private transactions", "count", len(txs), "err", err)
+		}
+	}
+}
+
+// privateTxForwardLoop forwards new private transactions to trusted peers.
+func (pm *ProtocolManager) privateTxForwardLoop() {
+	defer pm.wg.Done()
+
+	for {
+		select {
+		case event := <-pm.privateTxsCh:
+			pm.ForwardPrivateTransactions(event.Txs, event.MaxBlock)
+
+		case <-pm.privateTxsSub.Err():
+			return
+		}
+	}
+}
+
 // NodeInfo represents a short summary of the MFA sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
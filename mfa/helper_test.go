@@ -90,9 +90,11 @@ func newTestProtocolManagerMust(t *testing.T, mode downloader.SyncMode, blocks i
 
 // testTxPool is a fake, helper transaction pool for testing purposes
 type testTxPool struct {
-	txFeed event.Feed
-	pool   map[common.Hash]*types.Transaction // Hash map of collected transactions
-	added  chan<- []*types.Transaction        // Notification channel for new transactions
+	txFeed      event.Feed
+	privateFeed event.Feed
+	pool        map[common.Hash]*types.Transaction // Hash map of collected transactions
+	private     map[common.Hash]*types.Transaction // Hash map of collected private transactions
+	added       chan<- []*types.Transaction        // Notification channel for new transactions
 
 	lock sync.RWMutex // Protects the transaction pool
 }
@@ -131,6 +133,20 @@ func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// AddPrivate collects a private transaction apart from the public ones, and
+// notifies any private transaction listeners.
+func (p *testTxPool) AddPrivate(tx *types.Transaction, maxBlock uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.private == nil {
+		p.private = make(map[common.Hash]*types.Transaction)
+	}
+	p.private[tx.Hash()] = tx
+	p.privateFeed.Send(core.NewPrivateTxsEvent{Txs: []*types.Transaction{tx}, MaxBlock: maxBlock})
+	return nil
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
 	p.lock.RLock()
@@ -151,6 +167,12 @@ func (p *testTxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subs
 	return p.txFeed.Subscribe(ch)
 }
 
+// SubscribePrivateTxsEvent should return an event subscription of
+// NewPrivateTxsEvent and send events to the given channel.
+func (p *testTxPool) SubscribePrivateTxsEvent(ch chan<- core.NewPrivateTxsEvent) event.Subscription {
+	return p.privateFeed.Subscribe(ch)
+}
+
 // newTestTransaction create a new dummy transaction.
 func newTestTransaction(from *ecdsa.PrivateKey, nonce uint64, datasize int) *types.Transaction {
 	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), make([]byte, datasize))
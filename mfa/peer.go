@@ -355,6 +355,20 @@ func (p *peer) sendTransactions(txs types.Transactions) error {
 	return p2p.Send(p.rw, TransactionMsg, txs)
 }
 
+// SendPrivateTransactions sends private transactions to the peer and includes
+// the hashes in its transaction hash set for future reference. The peer must be
+// a trusted one, as it is relied upon not to gossip the transactions further.
+func (p *peer) SendPrivateTransactions(txs types.Transactions, maxBlock uint64) error {
+	// Mark all the transactions as known, but ensure we don't overflow our limits
+	for p.knownTxs.Cardinality() > max(0, maxKnownTxs-len(txs)) {
+		p.knownTxs.Pop()
+	}
+	for _, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+	}
+	return p2p.Send(p.rw, PrivateTransactionsMsg, &privateTxsData{Txs: txs, MaxBlock: maxBlock})
+}
+
 // AsyncSendTransactions queues a list of transactions (by hash) to eventually
 // propagate to a remote peer. The number of pending sends are capped (new ones
 // will force old sends to be dropped)
@@ -788,6 +802,22 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// TrustedPeersWithoutTx retrieves a list of trusted peers supporting private
+// transactions (eth/66 and above) that do not have a given transaction in their
+// set of known hashes.
+func (ps *peerSet) TrustedPeersWithoutTx(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= eth66 && p.Peer.Info().Network.Trusted && !p.knownTxs.Contains(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
@@ -34,16 +34,17 @@ const (
 	eth63 = 63
 	eth64 = 64
 	eth65 = 65
+	eth66 = 66
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
 const protocolName = "mfa"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth65, eth64, eth63}
+var ProtocolVersions = []uint{eth66, eth65, eth64, eth63}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = map[uint]uint64{eth65: 17, eth64: 17, eth63: 17}
+var protocolLengths = map[uint]uint64{eth66: 17, eth65: 17, eth64: 17, eth63: 17}
 
 const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -69,6 +70,12 @@ const (
 	NewPooledTransactionHashesMsg = 0x08
 	GetPooledTransactionsMsg      = 0x09
 	PooledTransactionsMsg         = 0x0a
+
+	// New protocol message codes introduced in eth66
+	//
+	// Private transactions are only ever exchanged between trusted peers and
+	// are never gossiped further.
+	PrivateTransactionsMsg = 0x0b
 )
 
 type errCode int
@@ -114,6 +121,10 @@ type txPool interface {
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// AddPrivate should add the given transaction to the pool without ever
+	// announcing it to the network, until the given maximum block number.
+	AddPrivate(tx *types.Transaction, maxBlock uint64) error
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -121,6 +132,10 @@ type txPool interface {
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+
+	// SubscribePrivateTxsEvent should return an event subscription of
+	// NewPrivateTxsEvent and send events to the given channel.
+	SubscribePrivateTxsEvent(chan<- core.NewPrivateTxsEvent) event.Subscription
 }
 
 // statusData63 is the network packet for the status message for eth/63.
@@ -219,3 +234,9 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// privateTxsData is the network packet for private transaction forwarding.
+type privateTxsData struct {
+	Txs      []*types.Transaction // Transactions withheld from the public network
+	MaxBlock uint64               // Last block the transactions may be included in
+}
@@ -248,6 +248,7 @@ func TestForkIDSplit(t *testing.T) {
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
 func TestRecvTransactions64(t *testing.T) { testRecvTransactions(t, 64) }
 func TestRecvTransactions65(t *testing.T) { testRecvTransactions(t, 65) }
+func TestRecvTransactions66(t *testing.T) { testRecvTransactions(t, 66) }
 
 func testRecvTransactions(t *testing.T, protocol int) {
 	txAdded := make(chan []*types.Transaction)
@@ -273,10 +274,49 @@ func testRecvTransactions(t *testing.T, protocol int) {
 	}
 }
 
+// This test checks that private transactions are only accepted from peers that
+// negotiated eth/66, and are silently dropped from untrusted ones.
+func TestRecvPrivateTransactions65(t *testing.T) { testRecvPrivateTransactions(t, 65) }
+func TestRecvPrivateTransactions66(t *testing.T) { testRecvPrivateTransactions(t, 66) }
+
+func testRecvPrivateTransactions(t *testing.T, protocol int) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	p, errc := newTestPeer("peer", protocol, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, PrivateTransactionsMsg, &privateTxsData{Txs: []*types.Transaction{tx}}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if protocol >= eth66 {
+			t.Fatalf("peer dropped: %v", err)
+		}
+		if want := errResp(ErrInvalidMsgCode, "%v", PrivateTransactionsMsg); err.Error() != want.Error() {
+			t.Fatalf("drop error mismatch: have %v, want %v", err, want)
+		}
+	case <-time.After(250 * time.Millisecond):
+		if protocol < eth66 {
+			t.Fatalf("private transactions accepted on eth/%d", protocol)
+		}
+	}
+	pool := pm.txpool.(*testTxPool)
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	if len(pool.private) != 0 {
+		t.Fatalf("private transaction accepted from untrusted peer")
+	}
+}
+
 // This test checks that pending transactions are sent.
 func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
 func TestSendTransactions64(t *testing.T) { testSendTransactions(t, 64) }
 func TestSendTransactions65(t *testing.T) { testSendTransactions(t, 65) }
+func TestSendTransactions66(t *testing.T) { testSendTransactions(t, 66) }
 
 func testSendTransactions(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
@@ -326,7 +366,7 @@ func testSendTransactions(t *testing.T, protocol int) {
 						callback(tx.Hash())
 					}
 				}
-			case 65:
+			case 65, 66:
 				msg, err := p.app.ReadMsg()
 				if err != nil {
 					t.Errorf("%v: read error: %v", p.Peer, err)
@@ -0,0 +1,85 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/remotedb"
+)
+
+// replicaHeadRefresh is the interval at which a read-only replica checks for a
+// new chain head on its primary.
+const replicaHeadRefresh = time.Second
+
+// errReplicaReadOnly is returned if an operation modifying the chain or the
+// transaction pool is requested from a read-only replica.
+var errReplicaReadOnly = errors.New("operation not supported on a read-only replica")
+
+// isReplica reports whether the node runs as a read-only replica on the database
+// of a remote primary.
+func (s *MFA) isReplica() bool {
+	return s.config.RemoteDBPrimary != ""
+}
+
+// openReplicaDatabase connects to the database of the configured primary node.
+func openReplicaDatabase(config *Config) (mfadb.Database, error) {
+	if config.RemoteDBSecret == "" {
+		return nil, errors.New("read-only replica requires a remote database secret")
+	}
+	db, err := remotedb.Dial(config.RemoteDBPrimary, config.RemoteDBSecret)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the primary is reachable and has a chain to serve, otherwise the
+	// chain would be initialised locally, shadowing the remote one
+	if rawdb.ReadHeadBlockHash(db) == (common.Hash{}) {
+		db.Close()
+		return nil, fmt.Errorf("primary %s unreachable or without chain head", config.RemoteDBPrimary)
+	}
+	log.Info("Running as read-only replica", "primary", config.RemoteDBPrimary)
+	return db, nil
+}
+
+// replicaLoop follows the chain head of the primary node until the node stops.
+func (s *MFA) replicaLoop() {
+	ticker := time.NewTicker(replicaHeadRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.blockchain.ReloadHead(); err != nil {
+				log.Warn("Failed to follow primary chain head", "err", err)
+			}
+			// Drop any local writes the primary caught up with meanwhile
+			if db, ok := s.chainDb.(*remotedb.Database); ok {
+				if err := db.Prune(); err != nil {
+					log.Warn("Failed to prune replica write layer", "err", err)
+				}
+			}
+		case <-s.closeReplica:
+			return
+		}
+	}
+}
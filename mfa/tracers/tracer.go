@@ -299,6 +299,8 @@ type Tracer struct {
 	contractWrapper *contractWrapper // Wrapper around the contract object
 	dbWrapper       *dbWrapper       // Wrapper around the VM environment
 
+	env *vm.EVM // VM environment, resolving the active precompiles
+
 	pcValue     *uint   // Swappable pc value wrapped by a log accessor
 	gasValue    *uint   // Swappable gas value wrapped by a log accessor
 	costValue   *uint   // Swappable cost value wrapped by a log accessor
@@ -399,7 +401,14 @@ func New(code string) (*Tracer, error) {
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("isPrecompiled", func(ctx *duktape.Context) int {
-		_, ok := vm.PrecompiledContractsIstanbul[common.BytesToAddress(popSlice(ctx))]
+		addr := common.BytesToAddress(popSlice(ctx))
+
+		var ok bool
+		if tracer.env != nil {
+			ok = tracer.env.IsPrecompile(addr)
+		} else {
+			_, ok = vm.PrecompiledContractsIstanbul[addr]
+		}
 		ctx.PushBoolean(ok)
 		return 1
 	})
@@ -546,6 +555,7 @@ func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 		// Initialize the context if it wasn't done yet
 		if !jst.inited {
 			jst.ctx["block"] = env.BlockNumber.Uint64()
+			jst.env = env
 			jst.inited = true
 		}
 		// If tracing was interrupted, set the error and stop
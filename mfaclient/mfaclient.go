@@ -80,7 +80,8 @@ func (ec *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Blo
 }
 
 // BlockByNumber returns a block from the current canonical chain. If number is nil, the
-// latest known block is returned.
+// latest known block is returned. The safe and finalized blocks can be requested by
+// passing big.NewInt(int64(rpc.SafeBlockNumber)) or rpc.FinalizedBlockNumber likewise.
 //
 // Note that loading full blocks requires two requests. Use HeaderByNumber
 // if you don't need all transactions or uncle headers.
@@ -282,6 +283,10 @@ func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
 	}
+	if number.Sign() < 0 && number.IsInt64() {
+		// Negative numbers select the meta blocks, e.g. rpc.FinalizedBlockNumber
+		return rpc.BlockNumber(number.Int64()).String()
+	}
 	return hexutil.EncodeBig(number)
 }
 
@@ -35,6 +35,7 @@ import (
 	"github.com/MFAChain/mfachain/eth"
 	"github.com/MFAChain/mfachain/node"
 	"github.com/MFAChain/mfachain/params"
+	"github.com/MFAChain/mfachain/rpc"
 )
 
 // Verify that Client implements the MFA interfaces.
@@ -83,6 +84,22 @@ func TestToFilterArg(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"with finalized fromBlock and safe toBlock",
+			MFA.FilterQuery{
+				Addresses: addresses,
+				FromBlock: big.NewInt(int64(rpc.FinalizedBlockNumber)),
+				ToBlock:   big.NewInt(int64(rpc.SafeBlockNumber)),
+				Topics:    [][]common.Hash{},
+			},
+			map[string]interface{}{
+				"address":   addresses,
+				"fromBlock": "finalized",
+				"toBlock":   "safe",
+				"topics":    [][]common.Hash{},
+			},
+			nil,
+		},
 		{
 			"with nil fromBlock and nil toBlock",
 			MFA.FilterQuery{
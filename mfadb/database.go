@@ -92,6 +92,10 @@ type AncientWriter interface {
 	// TruncateAncients discards all but the first n ancient data from the ancient store.
 	TruncateAncients(n uint64) error
 
+	// TruncateAncientTail discards the prunable history (block bodies and receipts)
+	// of the ancient data below n, retaining the headers.
+	TruncateAncientTail(n uint64) error
+
 	// Sync flushes all in-memory ancient store data to disk.
 	Sync() error
 }
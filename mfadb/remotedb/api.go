@@ -0,0 +1,169 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotedb
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/mfadb"
+)
+
+const (
+	// Namespace is the RPC namespace the database is served under.
+	Namespace = "remotedb"
+
+	// maxPageItems is the maximum number of entries returned in a single
+	// iteration page.
+	maxPageItems = 1024
+
+	// maxPageBytes is the soft limit of key and value bytes returned in a single
+	// iteration page.
+	maxPageBytes = 1024 * 1024
+)
+
+// errUnauthorized is returned if a request is made with an invalid secret.
+var errUnauthorized = errors.New("unauthorized")
+
+// authScheme is the Authorization header prefix of the shared secret.
+const authScheme = "Bearer "
+
+// Page is a batch of consecutive entries returned by a remote iteration.
+type Page struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	More   bool            `json:"more"`
+}
+
+// API serves the read side of a database to remote replicas. Every request must
+// be made over HTTP, carrying the shared secret the API was created with as a
+// bearer token in its Authorization header.
+type API struct {
+	db     mfadb.Database
+	secret []byte
+}
+
+// NewAPI creates a new API serving the given database to clients presenting the
+// given secret.
+func NewAPI(db mfadb.Database, secret string) *API {
+	return &API{db: db, secret: []byte(secret)}
+}
+
+// authorize checks the bearer token of a request against the configured secret.
+func (api *API) authorize(ctx context.Context) error {
+	auth, _ := ctx.Value("Authorization").(string)
+	if len(api.secret) == 0 || subtle.ConstantTimeCompare([]byte(authScheme+string(api.secret)), []byte(auth)) != 1 {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// Has retrieves if a key is present in the key-value data store.
+func (api *API) Has(ctx context.Context, key hexutil.Bytes) (bool, error) {
+	if err := api.authorize(ctx); err != nil {
+		return false, err
+	}
+	return api.db.Has(key)
+}
+
+// Get retrieves the given key from the key-value data store, returning null if
+// it is not present.
+func (api *API) Get(ctx context.Context, key hexutil.Bytes) (*hexutil.Bytes, error) {
+	if err := api.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if has, err := api.db.Has(key); err != nil || !has {
+		return nil, err
+	}
+	value, err := api.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	blob := hexutil.Bytes(value)
+	return &blob, nil
+}
+
+// Iterate returns a page of entries with the given prefix, starting at the given
+// position relative to the prefix (or after, if it does not exist).
+func (api *API) Iterate(ctx context.Context, prefix hexutil.Bytes, start hexutil.Bytes) (*Page, error) {
+	if err := api.authorize(ctx); err != nil {
+		return nil, err
+	}
+	it := api.db.NewIterator(prefix, start)
+	defer it.Release()
+
+	var (
+		page = new(Page)
+		size int
+	)
+	for it.Next() {
+		if len(page.Keys) >= maxPageItems || size >= maxPageBytes {
+			page.More = true
+			break
+		}
+		page.Keys = append(page.Keys, common.CopyBytes(it.Key()))
+		page.Values = append(page.Values, common.CopyBytes(it.Value()))
+		size += len(it.Key()) + len(it.Value())
+	}
+	return page, it.Error()
+}
+
+// HasAncient returns an indicator whether the specified data exists in the
+// ancient store.
+func (api *API) HasAncient(ctx context.Context, kind string, number hexutil.Uint64) (bool, error) {
+	if err := api.authorize(ctx); err != nil {
+		return false, err
+	}
+	return api.db.HasAncient(kind, uint64(number))
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (api *API) Ancient(ctx context.Context, kind string, number hexutil.Uint64) (hexutil.Bytes, error) {
+	if err := api.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return api.db.Ancient(kind, uint64(number))
+}
+
+// Ancients returns the ancient item numbers in the ancient store.
+func (api *API) Ancients(ctx context.Context) (hexutil.Uint64, error) {
+	if err := api.authorize(ctx); err != nil {
+		return 0, err
+	}
+	n, err := api.db.Ancients()
+	return hexutil.Uint64(n), err
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (api *API) AncientSize(ctx context.Context, kind string) (hexutil.Uint64, error) {
+	if err := api.authorize(ctx); err != nil {
+		return 0, err
+	}
+	size, err := api.db.AncientSize(kind)
+	return hexutil.Uint64(size), err
+}
+
+// Stat returns a particular internal stat of the database.
+func (api *API) Stat(ctx context.Context, property string) (string, error) {
+	if err := api.authorize(ctx); err != nil {
+		return "", err
+	}
+	return api.db.Stat(property)
+}
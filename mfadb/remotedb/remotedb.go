@@ -0,0 +1,554 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package remotedb implements a database layer proxying reads to the database
+// of a remote node over HTTP RPC, for running read-only replicas.
+//
+// Writes are never sent to the remote node. They are kept in a bounded local memory
+// layer shadowing the remote content, so that the few markers written by a replica
+// keep working on top of a read-only database. Entries are dropped from the layer
+// once the remote node caught up with them.
+package remotedb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/common/hexutil"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/rpc"
+)
+
+var (
+	// errNotFound is returned if a key is requested that is not found in the
+	// remote or local database.
+	errNotFound = errors.New("not found")
+
+	// errReadOnly is returned if the ancient store of the remote database is
+	// attempted to be modified.
+	errReadOnly = errors.New("remote database is read-only")
+
+	// errLocalFull is returned if a write would grow the local layer beyond its
+	// maximum number of entries.
+	errLocalFull = errors.New("remote database local layer full")
+)
+
+// maxLocalEntries is the maximum number of keys shadowed by the local layer.
+// Replicas are not expected to write more than a handful of markers, so hitting
+// this limit points to a local writer that should be disabled in replica mode.
+const maxLocalEntries = 4096
+
+// Database is a key-value and ancient store proxying reads to a remote node.
+type Database struct {
+	client *rpc.Client
+
+	local map[string][]byte // Local writes shadowing the remote content, nil for deletions
+	keys  []string          // Keys of the local layer, kept sorted for iteration
+	lock  sync.RWMutex
+}
+
+// New creates a database reading from the remote node behind the given client.
+// The client must already authenticate its requests, see Dial.
+func New(client *rpc.Client) *Database {
+	return &Database{
+		client: client,
+		local:  make(map[string][]byte),
+	}
+}
+
+// Dial connects to the remote node at the given HTTP endpoint and creates a
+// database reading from it, authenticating with the given secret.
+func Dial(endpoint string, secret string) (*Database, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("remote database endpoint %q is not HTTP", endpoint)
+	}
+	client, err := rpc.DialHTTPWithClient(endpoint, &http.Client{
+		Transport: &authTransport{secret: secret, base: http.DefaultTransport},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return New(client), nil
+}
+
+// authTransport is an HTTP transport presenting a shared secret as a bearer
+// token, keeping it out of the RPC parameters.
+type authTransport struct {
+	secret string
+	base   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper, setting the Authorization header.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", authScheme+t.secret)
+	return t.base.RoundTrip(req)
+}
+
+// call invokes a method of the remote database API.
+func (db *Database) call(result interface{}, method string, args ...interface{}) error {
+	return db.client.Call(result, Namespace+"_"+method, args...)
+}
+
+// Close closes the connection to the remote node and drops all local writes.
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.client.Close()
+	db.local = make(map[string][]byte)
+	db.keys = nil
+	return nil
+}
+
+// Has retrieves if a key is present in the local layer or the remote database.
+func (db *Database) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	value, ok := db.local[string(key)]
+	db.lock.RUnlock()
+
+	if ok {
+		return value != nil, nil
+	}
+	var has bool
+	if err := db.call(&has, "has", hexutil.Bytes(key)); err != nil {
+		return false, err
+	}
+	return has, nil
+}
+
+// Get retrieves the given key from the local layer or the remote database.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	value, ok := db.local[string(key)]
+	db.lock.RUnlock()
+
+	if ok {
+		if value == nil {
+			return nil, errNotFound
+		}
+		return common.CopyBytes(value), nil
+	}
+	var blob *hexutil.Bytes
+	if err := db.call(&blob, "get", hexutil.Bytes(key)); err != nil {
+		return nil, err
+	}
+	if blob == nil {
+		return nil, errNotFound
+	}
+	return *blob, nil
+}
+
+// Put inserts the given value into the local layer.
+func (db *Database) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if err := db.reserve([][]byte{key}); err != nil {
+		return err
+	}
+	db.store(string(key), append([]byte{}, value...))
+	return nil
+}
+
+// Delete hides the key from the database by recording its removal in the
+// local layer.
+func (db *Database) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if err := db.reserve([][]byte{key}); err != nil {
+		return err
+	}
+	db.store(string(key), nil)
+	return nil
+}
+
+// reserve checks whether the given keys fit into the local layer, counting only
+// the ones not yet present in it.
+//
+// Note, this method assumes the lock is held!
+func (db *Database) reserve(keys [][]byte) error {
+	fresh := make(map[string]struct{})
+	for _, key := range keys {
+		if _, ok := db.local[string(key)]; !ok {
+			fresh[string(key)] = struct{}{}
+		}
+	}
+	if len(db.local)+len(fresh) > maxLocalEntries {
+		return errLocalFull
+	}
+	return nil
+}
+
+// store inserts a value (or a deletion marker if nil) into the local layer.
+//
+// Note, this method assumes the lock is held!
+func (db *Database) store(key string, value []byte) {
+	if _, ok := db.local[key]; !ok {
+		i := sort.SearchStrings(db.keys, key)
+		db.keys = append(db.keys, "")
+		copy(db.keys[i+1:], db.keys[i:])
+		db.keys[i] = key
+	}
+	db.local[key] = value
+}
+
+// drop removes a key from the local layer, exposing the remote content again.
+//
+// Note, this method assumes the lock is held!
+func (db *Database) drop(key string) {
+	if _, ok := db.local[key]; !ok {
+		return
+	}
+	delete(db.local, key)
+
+	i := sort.SearchStrings(db.keys, key)
+	db.keys = append(db.keys[:i], db.keys[i+1:]...)
+}
+
+// Prune drops all the entries of the local layer that the remote database caught
+// up with, either by storing the same value or by lacking the locally deleted key.
+// It is meant to be called periodically while following the remote node.
+func (db *Database) Prune() error {
+	db.lock.RLock()
+	local := make(map[string][]byte, len(db.local))
+	for key, value := range db.local {
+		local[key] = value
+	}
+	db.lock.RUnlock()
+
+	var synced []string
+	for key, value := range local {
+		var blob *hexutil.Bytes
+		if err := db.call(&blob, "get", hexutil.Bytes(key)); err != nil {
+			return err
+		}
+		if (blob == nil && value == nil) || (blob != nil && value != nil && bytes.Equal(*blob, value)) {
+			synced = append(synced, key)
+		}
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for _, key := range synced {
+		// Only drop the entry if it wasn't overwritten in the meantime
+		if value, ok := db.local[key]; ok && bytes.Equal(value, local[key]) && (value == nil) == (local[key] == nil) {
+			db.drop(key)
+		}
+	}
+	return nil
+}
+
+// NewBatch creates a write-only batch that commits into the local layer.
+func (db *Database) NewBatch() mfadb.Batch {
+	return &batch{db: db}
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, starting at a particular initial key (or
+// after, if it does not exist). Remote entries are retrieved in pages as the
+// iteration progresses, merged with a snapshot of the local layer.
+func (db *Database) NewIterator(prefix []byte, start []byte) mfadb.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var local []keyvalue
+	for i := sort.SearchStrings(db.keys, string(append(prefix, start...))); i < len(db.keys); i++ {
+		key := db.keys[i]
+		if !bytes.HasPrefix([]byte(key), prefix) {
+			break
+		}
+		value := db.local[key]
+		local = append(local, keyvalue{key: []byte(key), value: value, delete: value == nil})
+	}
+
+	return &iterator{
+		db:     db,
+		prefix: common.CopyBytes(prefix),
+		next:   common.CopyBytes(start),
+		more:   true,
+		local:  local,
+	}
+}
+
+// Stat returns a particular internal stat of the remote database.
+func (db *Database) Stat(property string) (string, error) {
+	var stat string
+	if err := db.call(&stat, "stat", property); err != nil {
+		return "", err
+	}
+	return stat, nil
+}
+
+// Compact is not supported on a remote database, the remote node maintains its
+// own storage.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// HasAncient returns an indicator whether the specified data exists in the
+// remote ancient store.
+func (db *Database) HasAncient(kind string, number uint64) (bool, error) {
+	var has bool
+	if err := db.call(&has, "hasAncient", kind, hexutil.Uint64(number)); err != nil {
+		return false, err
+	}
+	return has, nil
+}
+
+// Ancient retrieves an ancient binary blob from the remote ancient store.
+func (db *Database) Ancient(kind string, number uint64) ([]byte, error) {
+	var blob hexutil.Bytes
+	if err := db.call(&blob, "ancient", kind, hexutil.Uint64(number)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// Ancients returns the ancient item numbers in the remote ancient store.
+func (db *Database) Ancients() (uint64, error) {
+	var n hexutil.Uint64
+	if err := db.call(&n, "ancients"); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+// AncientSize returns the ancient size of the specified category in the remote
+// ancient store.
+func (db *Database) AncientSize(kind string) (uint64, error) {
+	var size hexutil.Uint64
+	if err := db.call(&size, "ancientSize", kind); err != nil {
+		return 0, err
+	}
+	return uint64(size), nil
+}
+
+// AppendAncient is not supported, the remote ancient store is read-only.
+func (db *Database) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	return errReadOnly
+}
+
+// TruncateAncients is not supported, the remote ancient store is read-only.
+func (db *Database) TruncateAncients(items uint64) error {
+	return errReadOnly
+}
+
+// TruncateAncientTail is not supported, the remote ancient store is read-only.
+func (db *Database) TruncateAncientTail(items uint64) error {
+	return errReadOnly
+}
+
+// Sync is a noop, there is nothing to flush locally.
+func (db *Database) Sync() error {
+	return nil
+}
+
+// keyvalue is a key-value tuple tagged with a deletion field to allow creating
+// write batches and merging local deletions into iterations.
+type keyvalue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch is a write-only batch that commits changes into the local layer of its
+// host database when Write is called. A batch cannot be used concurrently.
+type batch struct {
+	db     *Database
+	writes []keyvalue
+	size   int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), append([]byte{}, value...), false})
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data into the local layer.
+func (b *batch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	keys := make([][]byte, len(b.writes))
+	for i, keyvalue := range b.writes {
+		keys[i] = keyvalue.key
+	}
+	if err := b.db.reserve(keys); err != nil {
+		return err
+	}
+	for _, keyvalue := range b.writes {
+		if keyvalue.delete {
+			b.db.store(string(keyvalue.key), nil)
+			continue
+		}
+		b.db.store(string(keyvalue.key), keyvalue.value)
+	}
+	return nil
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w mfadb.KeyValueWriter) error {
+	for _, keyvalue := range b.writes {
+		if keyvalue.delete {
+			if err := w.Delete(keyvalue.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(keyvalue.key, keyvalue.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator walks over the remote keyspace page by page, merging in the entries
+// of the local layer and skipping the ones deleted locally.
+type iterator struct {
+	db     *Database
+	prefix []byte
+
+	remote []keyvalue // Current page of remote entries not yet consumed
+	next   []byte     // Start position of the next remote page, relative to the prefix
+	more   bool       // Whether there are further remote pages to retrieve
+
+	local []keyvalue // Local entries not yet consumed, sorted by key
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+// fill retrieves the next page of remote entries if the current one has been
+// consumed and there are more available.
+func (it *iterator) fill() bool {
+	for len(it.remote) == 0 && it.more {
+		var page Page
+		if err := it.db.call(&page, "iterate", hexutil.Bytes(it.prefix), hexutil.Bytes(it.next)); err != nil {
+			it.err = err
+			return false
+		}
+		if len(page.Keys) != len(page.Values) {
+			it.err = errors.New("remote iteration page corrupted")
+			return false
+		}
+		for i, key := range page.Keys {
+			it.remote = append(it.remote, keyvalue{key: key, value: page.Values[i]})
+		}
+		it.more = page.More
+		if n := len(page.Keys); n > 0 {
+			it.next = append(common.CopyBytes(page.Keys[n-1][len(it.prefix):]), 0x00)
+		}
+	}
+	return true
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	for it.err == nil {
+		if !it.fill() {
+			return false
+		}
+		var entry keyvalue
+		switch {
+		case len(it.remote) == 0 && len(it.local) == 0:
+			it.key, it.value = nil, nil
+			return false
+
+		case len(it.local) == 0:
+			entry, it.remote = it.remote[0], it.remote[1:]
+
+		case len(it.remote) == 0:
+			entry, it.local = it.local[0], it.local[1:]
+
+		default:
+			switch bytes.Compare(it.remote[0].key, it.local[0].key) {
+			case -1:
+				entry, it.remote = it.remote[0], it.remote[1:]
+			case 0:
+				entry, it.remote, it.local = it.local[0], it.remote[1:], it.local[1:]
+			default:
+				entry, it.local = it.local[0], it.local[1:]
+			}
+		}
+		if entry.delete {
+			continue
+		}
+		it.key, it.value = entry.key, entry.value
+		return true
+	}
+	return false
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done. The caller
+// should not modify the contents of the returned slice, and its contents may
+// change on the next call to Next.
+func (it *iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key/value pair, or nil if done. The
+// caller should not modify the contents of the returned slice, and its contents
+// may change on the next call to Next.
+func (it *iterator) Value() []byte {
+	return it.value
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *iterator) Release() {
+	it.remote, it.local, it.more = nil, nil, false
+	it.key, it.value = nil, nil
+}
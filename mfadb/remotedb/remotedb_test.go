@@ -0,0 +1,218 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotedb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/mfadb"
+	"github.com/MFAChain/mfachain/mfadb/dbtest"
+	"github.com/MFAChain/mfachain/rpc"
+)
+
+// newTestDatabase creates a remote database connected to an in-process primary
+// serving the given database.
+func newTestDatabase(t *testing.T, primary mfadb.Database, secret string) *Database {
+	server := rpc.NewServer()
+	if err := server.RegisterName(Namespace, NewAPI(primary, "secret")); err != nil {
+		t.Fatalf("failed to register remote database API: %v", err)
+	}
+	client, err := rpc.DialHTTPWithClient("http://primary", &http.Client{
+		Transport: &authTransport{secret: secret, base: handlerTransport{server}},
+	})
+	if err != nil {
+		t.Fatalf("failed to dial primary: %v", err)
+	}
+	return New(client)
+}
+
+// handlerTransport is an HTTP transport serving requests in-process.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+func TestRemoteDB(t *testing.T) {
+	t.Run("DatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() mfadb.KeyValueStore {
+			return newTestDatabase(t, rawdb.NewMemoryDatabase(), "secret")
+		})
+	})
+}
+
+// Tests that reads are served from the primary, with local writes and deletions
+// shadowing its content, including across multiple iteration pages.
+func TestRemoteDBLayering(t *testing.T) {
+	primary := rawdb.NewMemoryDatabase()
+	for i := 0; i < 2*maxPageItems+10; i++ {
+		primary.Put([]byte(fmt.Sprintf("key-%05d", i)), []byte(fmt.Sprintf("remote-%d", i)))
+	}
+	primary.Put([]byte("other"), []byte("value"))
+
+	db := newTestDatabase(t, primary, "secret")
+	defer db.Close()
+
+	if value, err := db.Get([]byte("key-00001")); err != nil || string(value) != "remote-1" {
+		t.Fatalf("remote value mismatch: have %q (%v), want %q", value, err, "remote-1")
+	}
+	if has, err := db.Has([]byte("missing")); err != nil || has {
+		t.Fatalf("missing key reported present: %v", err)
+	}
+	if _, err := db.Get([]byte("missing")); err == nil {
+		t.Fatalf("missing key retrieved")
+	}
+	// Shadow some of the remote content locally
+	db.Put([]byte("key-00002"), []byte("local"))
+	db.Delete([]byte("key-00003"))
+	db.Put([]byte("key-01500a"), []byte("inserted"))
+
+	if value, err := db.Get([]byte("key-00002")); err != nil || string(value) != "local" {
+		t.Fatalf("local value mismatch: have %q (%v), want %q", value, err, "local")
+	}
+	if has, _ := db.Has([]byte("key-00003")); has {
+		t.Fatalf("locally deleted key reported present")
+	}
+	if value, _ := primary.Get([]byte("key-00002")); string(value) != "remote-2" {
+		t.Fatalf("local write leaked to primary: %q", value)
+	}
+	// Iterate over all the content and check the merged view
+	it := db.NewIterator([]byte("key-"), []byte("00001"))
+	defer it.Release()
+
+	var count int
+	for it.Next() {
+		switch key := string(it.Key()); key {
+		case "key-00002":
+			if !bytes.Equal(it.Value(), []byte("local")) {
+				t.Errorf("shadowed value mismatch: have %q, want %q", it.Value(), "local")
+			}
+		case "key-00003":
+			t.Errorf("deleted key iterated")
+		}
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if want := 2*maxPageItems + 10 - 1 - 1 + 1; count != want {
+		t.Fatalf("iterated entry count mismatch: have %d, want %d", count, want)
+	}
+}
+
+// Tests that the local layer is bounded, and that entries the primary caught up
+// with are pruned from it.
+func TestRemoteDBLocalLimit(t *testing.T) {
+	primary := rawdb.NewMemoryDatabase()
+	primary.Put([]byte("deleted"), []byte("remote"))
+
+	db := newTestDatabase(t, primary, "secret")
+	defer db.Close()
+
+	db.Delete([]byte("deleted"))
+	for i := 0; i < maxLocalEntries-1; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("key-%05d", i)), []byte("local")); err != nil {
+			t.Fatalf("failed to write local entry %d: %v", i, err)
+		}
+	}
+	if err := db.Put([]byte("overflow"), []byte("local")); err != errLocalFull {
+		t.Fatalf("overflowing write error mismatch: have %v, want %v", err, errLocalFull)
+	}
+	batch := db.NewBatch()
+	batch.Put([]byte("key-00000"), []byte("updated"))
+	batch.Put([]byte("overflow"), []byte("local"))
+	if err := batch.Write(); err != errLocalFull {
+		t.Fatalf("overflowing batch error mismatch: have %v, want %v", err, errLocalFull)
+	}
+	if value, _ := db.Get([]byte("key-00000")); string(value) != "local" {
+		t.Fatalf("rejected batch partially applied: have %q", value)
+	}
+	if err := db.Put([]byte("key-00000"), []byte("updated")); err != nil {
+		t.Fatalf("failed to overwrite local entry: %v", err)
+	}
+	// Let the primary catch up with some of the local entries and prune them
+	primary.Delete([]byte("deleted"))
+	primary.Put([]byte("key-00001"), []byte("local"))
+	primary.Put([]byte("key-00002"), []byte("other"))
+
+	if err := db.Prune(); err != nil {
+		t.Fatalf("failed to prune local layer: %v", err)
+	}
+	if have, want := len(db.local), maxLocalEntries-2; have != want {
+		t.Fatalf("local entry count mismatch: have %d, want %d", have, want)
+	}
+	if value, _ := db.Get([]byte("key-00002")); string(value) != "local" {
+		t.Fatalf("diverging local entry pruned: have %q", value)
+	}
+	if err := db.Put([]byte("overflow"), []byte("local")); err != nil {
+		t.Fatalf("failed to write after pruning: %v", err)
+	}
+	it := db.NewIterator([]byte("key-0000"), nil)
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if want := []string{"key-00000", "key-00001", "key-00002", "key-00003", "key-00004", "key-00005", "key-00006", "key-00007", "key-00008", "key-00009"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("iterated keys mismatch: have %v, want %v", keys, want)
+	}
+}
+
+// Tests that requests with an invalid secret are rejected.
+func TestRemoteDBUnauthorized(t *testing.T) {
+	primary := rawdb.NewMemoryDatabase()
+	primary.Put([]byte("key"), []byte("value"))
+
+	db := newTestDatabase(t, primary, "wrong")
+	defer db.Close()
+
+	if _, err := db.Get([]byte("key")); err == nil {
+		t.Fatalf("unauthorized read succeeded")
+	}
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	if it.Next() || it.Error() == nil {
+		t.Fatalf("unauthorized iteration succeeded")
+	}
+	if _, err := NewAPI(primary, "").Has(context.Background(), []byte("key")); err == nil {
+		t.Fatalf("read succeeded without configured secret")
+	}
+	// Requests not carrying the secret in their headers, such as in-process or
+	// websocket ones, must be rejected even with a valid secret configured
+	server := rpc.NewServer()
+	if err := server.RegisterName(Namespace, NewAPI(primary, "secret")); err != nil {
+		t.Fatalf("failed to register remote database API: %v", err)
+	}
+	if _, err := New(rpc.DialInProc(server)).Get([]byte("key")); err == nil {
+		t.Fatalf("read succeeded without authorization header")
+	}
+	if _, err := Dial("ws://localhost:8546", "secret"); err == nil {
+		t.Fatalf("dialed non-HTTP endpoint")
+	}
+}
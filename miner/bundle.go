@@ -0,0 +1,290 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/MFAChain/mfachain/common"
+	"github.com/MFAChain/mfachain/core"
+	"github.com/MFAChain/mfachain/core/state"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/core/vm"
+	"github.com/MFAChain/mfachain/crypto"
+	"github.com/MFAChain/mfachain/log"
+	"github.com/MFAChain/mfachain/params"
+)
+
+const (
+	// maxBundlesPerBlock is the maximum number of bundles accepted for a single
+	// target block, protecting the miner from simulating an unbounded amount.
+	maxBundlesPerBlock = 256
+
+	// maxBundleFutureBlocks is the maximum distance from the chain head a bundle
+	// may target.
+	maxBundleFutureBlocks = 128
+
+	// defaultMaxBundleTxs is the maximum number of transactions in a bundle if
+	// none is configured.
+	defaultMaxBundleTxs = 64
+)
+
+var (
+	errBundleEmpty     = errors.New("bundle contains no transactions")
+	errBundleStale     = errors.New("bundle targets a past block")
+	errBundleFuture    = errors.New("bundle targets a block too far in the future")
+	errBundleTimestamp = errors.New("bundle timestamp range is empty")
+	errBundleOverflow  = errors.New("too many bundles for target block")
+	errBundleTooLarge  = errors.New("bundle exceeds transaction limit")
+	errBundleGasLimit  = errors.New("bundle exceeds block gas limit")
+)
+
+// Bundle is an ordered group of transactions that must be included atomically
+// at the top of a specific block, or not at all.
+type Bundle struct {
+	Txs          types.Transactions // Transactions to include, in order
+	BlockNumber  uint64             // Number of the block the bundle targets
+	MinTimestamp uint64             // Earliest block timestamp to include the bundle at, 0 for unbounded
+	MaxTimestamp uint64             // Latest block timestamp to include the bundle at, 0 for unbounded
+}
+
+// Hash returns the identifier of the bundle, the hash of its transaction hashes.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// eligible reports whether the bundle may be included in a block with the given
+// timestamp.
+func (b *Bundle) eligible(timestamp uint64) bool {
+	if b.MinTimestamp != 0 && timestamp < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && timestamp > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// BundleSimulation is the outcome of executing a bundle on top of a state.
+type BundleSimulation struct {
+	Receipts     []*types.Receipt // Receipts of the bundle transactions, in order
+	GasUsed      uint64           // Total gas used by the bundle
+	CoinbaseDiff *big.Int         // Total value (fees and direct payments) paid to the coinbase
+}
+
+// Reverted reports whether any of the bundle transactions failed execution.
+func (s *BundleSimulation) Reverted() bool {
+	for _, receipt := range s.Receipts {
+		if receipt.Status == types.ReceiptStatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// GasPrice returns the effective gas price of the bundle, the value it pays to
+// the coinbase per unit of gas used.
+func (s *BundleSimulation) GasPrice() *big.Int {
+	if s.GasUsed == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(s.CoinbaseDiff, new(big.Int).SetUint64(s.GasUsed))
+}
+
+// SimulateBundle executes the transactions of a bundle in order on top of the
+// given state, which is modified in place. The gas used is accumulated into the
+// header, and the transactions are indexed starting from txIndex. An error is
+// returned if any of the transactions cannot be applied at all; transactions
+// failing execution are reported via their receipts.
+func SimulateBundle(config *params.ChainConfig, chain core.ChainContext, vmConfig vm.Config, statedb *state.StateDB, header *types.Header, coinbase common.Address, gasPool *core.GasPool, txIndex int, txs types.Transactions) (*BundleSimulation, error) {
+	var (
+		balance = statedb.GetBalance(coinbase)
+		gasUsed = header.GasUsed
+		sim     = &BundleSimulation{Receipts: make([]*types.Receipt, 0, len(txs))}
+	)
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), common.Hash{}, txIndex+i)
+
+		receipt, err := core.ApplyTransaction(config, chain, &coinbase, gasPool, statedb, header, tx, &header.GasUsed, vmConfig)
+		if err != nil {
+			return nil, err
+		}
+		sim.Receipts = append(sim.Receipts, receipt)
+	}
+	sim.GasUsed = header.GasUsed - gasUsed
+	sim.CoinbaseDiff = new(big.Int).Sub(statedb.GetBalance(coinbase), balance)
+	return sim, nil
+}
+
+// bundleStore is the set of bundles submitted to the miner, indexed by the
+// number of the block they target.
+type bundleStore struct {
+	bundles map[uint64][]*Bundle
+	maxTxs  int // Maximum number of transactions in a bundle
+	lock    sync.Mutex
+}
+
+// newBundleStore creates an empty bundle store accepting bundles of up to maxTxs
+// transactions.
+func newBundleStore(maxTxs int) *bundleStore {
+	return &bundleStore{
+		bundles: make(map[uint64][]*Bundle),
+		maxTxs:  maxTxs,
+	}
+}
+
+// add inserts a new bundle into the store, rejecting it if it cannot be included
+// on top of the given chain head.
+func (s *bundleStore) add(bundle *Bundle, head *types.Header) error {
+	number := head.Number.Uint64()
+	switch {
+	case len(bundle.Txs) == 0:
+		return errBundleEmpty
+	case len(bundle.Txs) > s.maxTxs:
+		return errBundleTooLarge
+	case bundle.BlockNumber <= number:
+		return errBundleStale
+	case bundle.BlockNumber > number+maxBundleFutureBlocks:
+		return errBundleFuture
+	case bundle.MaxTimestamp != 0 && bundle.MinTimestamp > bundle.MaxTimestamp:
+		return errBundleTimestamp
+	}
+	var gas uint64
+	for _, tx := range bundle.Txs {
+		gas += tx.Gas()
+		if gas > head.GasLimit {
+			return errBundleGasLimit
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.bundles[bundle.BlockNumber]) >= maxBundlesPerBlock {
+		return errBundleOverflow
+	}
+	s.bundles[bundle.BlockNumber] = append(s.bundles[bundle.BlockNumber], bundle)
+	return nil
+}
+
+// eligible returns all the bundles that may be included in a block with the
+// given number and timestamp. Bundles targeting earlier blocks are dropped.
+func (s *bundleStore) eligible(number, timestamp uint64) []*Bundle {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for target := range s.bundles {
+		if target < number {
+			delete(s.bundles, target)
+		}
+	}
+	var bundles []*Bundle
+	for _, bundle := range s.bundles[number] {
+		if bundle.eligible(timestamp) {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles
+}
+
+// commitBundles simulates all the bundles eligible for the current block and
+// commits the most profitable ones at the top of it, each either entirely or not
+// at all. The number of included bundles is returned.
+func (w *worker) commitBundles(coinbase common.Address) int {
+	bundles := w.bundles.eligible(w.current.header.Number.Uint64(), w.current.header.Time)
+	if len(bundles) == 0 {
+		return 0
+	}
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	// Simulate every bundle in isolation to rank them by profitability
+	type rankedBundle struct {
+		bundle *Bundle
+		profit *big.Int
+	}
+	var ranked []rankedBundle
+	for _, bundle := range bundles {
+		var (
+			header  = types.CopyHeader(w.current.header)
+			gasPool = new(core.GasPool).AddGas(w.current.gasPool.Gas())
+		)
+		sim, err := SimulateBundle(w.chainConfig, w.chain, *w.chain.GetVMConfig(), w.current.state.Copy(), header, coinbase, gasPool, w.current.tcount, bundle.Txs)
+		if err != nil {
+			log.Debug("Discarding invalid bundle", "hash", bundle.Hash(), "err", err)
+			continue
+		}
+		if sim.Reverted() {
+			log.Debug("Discarding reverting bundle", "hash", bundle.Hash())
+			continue
+		}
+		ranked = append(ranked, rankedBundle{bundle: bundle, profit: sim.CoinbaseDiff})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].profit.Cmp(ranked[j].profit) > 0
+	})
+	// Commit the bundles in order of profitability, each one against the state
+	// left by the previous ones, dropping those that got invalidated
+	var (
+		included      int
+		coalescedLogs []*types.Log
+	)
+	for _, r := range ranked {
+		if logs, ok := w.commitBundle(r.bundle, coinbase); ok {
+			coalescedLogs = append(coalescedLogs, logs...)
+			included++
+		}
+	}
+	w.postPendingLogs(coalescedLogs)
+	return included
+}
+
+// commitBundle applies all the transactions of a bundle to the current block,
+// reverting all of them if any fails. The logs of the bundle are returned if it
+// got included.
+func (w *worker) commitBundle(bundle *Bundle, coinbase common.Address) ([]*types.Log, bool) {
+	var (
+		snap    = w.current.state.Snapshot()
+		gas     = w.current.gasPool.Gas()
+		gasUsed = w.current.header.GasUsed
+	)
+	sim, err := SimulateBundle(w.chainConfig, w.chain, *w.chain.GetVMConfig(), w.current.state, w.current.header, coinbase, w.current.gasPool, w.current.tcount, bundle.Txs)
+	if err != nil || sim.Reverted() {
+		w.current.state.RevertToSnapshot(snap)
+		*w.current.gasPool = core.GasPool(gas)
+		w.current.header.GasUsed = gasUsed
+
+		log.Debug("Dropping conflicting bundle", "hash", bundle.Hash(), "err", err)
+		return nil, false
+	}
+	w.current.txs = append(w.current.txs, bundle.Txs...)
+	w.current.receipts = append(w.current.receipts, sim.Receipts...)
+	w.current.tcount += len(bundle.Txs)
+
+	var logs []*types.Log
+	for _, receipt := range sim.Receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	log.Debug("Included bundle", "hash", bundle.Hash(), "txs", len(bundle.Txs), "gas", sim.GasUsed, "profit", sim.CoinbaseDiff)
+	return logs, true
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The MFA Authors
+// This file is part of this library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MFAChain/mfachain/consensus/mfa"
+	"github.com/MFAChain/mfachain/core/rawdb"
+	"github.com/MFAChain/mfachain/core/types"
+	"github.com/MFAChain/mfachain/params"
+)
+
+func bundleTx(nonce uint64, gasPrice int64) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, testUserAddress, big.NewInt(1000), params.TxGas, big.NewInt(gasPrice), nil), types.HomesteadSigner{}, testBankKey)
+	return tx
+}
+
+// Tests that the bundle store rejects unincludable bundles and only returns the
+// ones eligible for a given block.
+func TestBundleStore(t *testing.T) {
+	store := newBundleStore(2)
+	head := &types.Header{Number: big.NewInt(10), GasLimit: 3 * params.TxGas / 2}
+
+	tests := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{&Bundle{BlockNumber: 11}, errBundleEmpty},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1)}, BlockNumber: 10}, errBundleStale},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1)}, BlockNumber: 10 + maxBundleFutureBlocks + 1}, errBundleFuture},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1)}, BlockNumber: 11, MinTimestamp: 20, MaxTimestamp: 10}, errBundleTimestamp},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1), bundleTx(1, 1), bundleTx(2, 1)}, BlockNumber: 11}, errBundleTooLarge},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1), bundleTx(1, 1)}, BlockNumber: 11}, errBundleGasLimit},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 1)}, BlockNumber: 11}, nil},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 2)}, BlockNumber: 11, MinTimestamp: 100}, nil},
+		{&Bundle{Txs: types.Transactions{bundleTx(0, 3)}, BlockNumber: 12}, nil},
+	}
+	for i, tt := range tests {
+		if err := store.add(tt.bundle, head); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if bundles := store.eligible(11, 50); len(bundles) != 1 {
+		t.Errorf("eligible bundle count mismatch: have %d, want %d", len(bundles), 1)
+	}
+	if bundles := store.eligible(11, 150); len(bundles) != 2 {
+		t.Errorf("eligible bundle count mismatch: have %d, want %d", len(bundles), 2)
+	}
+	if bundles := store.eligible(12, 150); len(bundles) != 1 {
+		t.Errorf("eligible bundle count mismatch: have %d, want %d", len(bundles), 1)
+	}
+	if len(store.bundles) != 1 {
+		t.Errorf("stale bundles not pruned: have %d targets, want %d", len(store.bundles), 1)
+	}
+}
+
+// Tests that the worker includes the most profitable bundle at the top of the
+// block, and drops bundles conflicting with it entirely.
+func TestCommitBundles(t *testing.T) {
+	var (
+		engine = mfa.NewFaker()
+		db     = rawdb.NewMemoryDatabase()
+	)
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, mfaashChainConfig, engine, db, 0)
+	defer w.close()
+
+	// Pay the fees to a third party so that profits are observable
+	w.setEtherbase(testUserAddress)
+
+	cheap := &Bundle{Txs: types.Transactions{bundleTx(0, 1), bundleTx(1, 1)}, BlockNumber: 1}
+	rich := &Bundle{Txs: types.Transactions{bundleTx(0, 5), bundleTx(1, 5)}, BlockNumber: 1}
+	broken := &Bundle{Txs: types.Transactions{bundleTx(2, 10), bundleTx(4, 10)}, BlockNumber: 1}
+
+	for _, bundle := range []*Bundle{cheap, rich, broken} {
+		if err := w.bundles.add(bundle, w.chain.CurrentBlock().Header()); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	w.commitNewWork(nil, true, time.Now().Unix())
+
+	block := w.pendingBlock()
+	if block == nil {
+		t.Fatalf("no pending block")
+	}
+	txs := block.Transactions()
+	if len(txs) != 2 {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), 2)
+	}
+	for i, tx := range rich.Txs {
+		if txs[i].Hash() != tx.Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, txs[i].Hash(), tx.Hash())
+		}
+	}
+}
@@ -51,6 +51,8 @@ type Config struct {
 	GasPrice  *big.Int       // Minimum gas price for mining a transaction
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 	Noverify  bool           // Disable remote mining solution verification(only useful in mfa).
+
+	MaxBundleTxs int // Maximum number of transactions accepted in a bundle
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -178,6 +180,13 @@ func (miner *Miner) PendingBlock() *types.Block {
 	return miner.worker.pendingBlock()
 }
 
+// AddBundle submits a bundle of transactions to be included atomically at the
+// top of the block it targets. Bundles with more transactions than configured
+// or more gas than the current block gas limit are rejected.
+func (miner *Miner) AddBundle(bundle *Bundle) error {
+	return miner.worker.bundles.add(bundle, miner.eth.BlockChain().CurrentBlock().Header())
+}
+
 func (miner *Miner) SetEtherbase(addr common.Address) {
 	miner.coinbase = addr
 	miner.worker.setEtherbase(addr)
@@ -153,6 +153,7 @@ type worker struct {
 	localUncles  map[common.Hash]*types.Block // A set of side blocks generated locally as the possible uncle blocks.
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
+	bundles      *bundleStore                 // A set of transaction bundles to include atomically at the top of blocks.
 
 	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
 	coinbase common.Address
@@ -187,6 +188,11 @@ type worker struct {
 }
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(*types.Block) bool, init bool) *worker {
+	// Sanitize the bundle size limit if the user-specified one is invalid.
+	maxBundleTxs := config.MaxBundleTxs
+	if maxBundleTxs <= 0 {
+		maxBundleTxs = defaultMaxBundleTxs
+	}
 	worker := &worker{
 		config:             config,
 		chainConfig:        chainConfig,
@@ -198,6 +204,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
+		bundles:            newBundleStore(maxBundleTxs),
 		pendingTasks:       make(map[common.Hash]*task),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
@@ -728,6 +735,26 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
+// postPendingLogs sends the logs of the transactions added to the pending block
+// to the pending log subscribers.
+func (w *worker) postPendingLogs(logs []*types.Log) {
+	if !w.isRunning() && len(logs) > 0 {
+		// We don't push the pendingLogsEvent while we are mining. The reason is that
+		// when we are mining, the worker will regenerate a mining block every 3 seconds.
+		// In order to avoid pushing the repeated pendingLog, we disable the pending log pushing.
+
+		// make a copy, the state caches the logs and these logs get "upgraded" from pending to mined
+		// logs by filling in the block hash when the block was mined by the local miner. This can
+		// cause a race condition if a log was "upgraded" before the PendingLogsEvent is processed.
+		cpy := make([]*types.Log, len(logs))
+		for i, l := range logs {
+			cpy[i] = new(types.Log)
+			*cpy[i] = *l
+		}
+		w.pendingLogsFeed.Send(cpy)
+	}
+}
+
 func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
@@ -818,21 +845,8 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		}
 	}
 
-	if !w.isRunning() && len(coalescedLogs) > 0 {
-		// We don't push the pendingLogsEvent while we are mining. The reason is that
-		// when we are mining, the worker will regenerate a mining block every 3 seconds.
-		// In order to avoid pushing the repeated pendingLog, we disable the pending log pushing.
+	w.postPendingLogs(coalescedLogs)
 
-		// make a copy, the state caches the logs and these logs get "upgraded" from pending to mined
-		// logs by filling in the block hash when the block was mined by the local miner. This can
-		// cause a race condition if a log was "upgraded" before the PendingLogsEvent is processed.
-		cpy := make([]*types.Log, len(coalescedLogs))
-		for i, l := range coalescedLogs {
-			cpy[i] = new(types.Log)
-			*cpy[i] = *l
-		}
-		w.pendingLogsFeed.Send(cpy)
-	}
 	// Notify resubmit loop to decrease resubmitting interval if current interval is larger
 	// than the user-specified one.
 	if interrupt != nil {
@@ -934,16 +948,21 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		w.commit(uncles, nil, false, tstart)
 	}
 
-	// Fill the block with all available pending transactions.
+	// Fill the top of the block with the most profitable bundles.
+	bundled := w.commitBundles(w.coinbase)
+
+	// Fill the rest of the block with all available pending transactions.
 	pending, err := w.eth.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
+	private := w.eth.TxPool().Private()
+
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
-	if len(pending) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
+	if len(pending) == 0 && len(private) == 0 && bundled == 0 && atomic.LoadUint32(&w.noempty) == 0 {
 		w.updateSnapshot()
 		return
 	}
@@ -955,6 +974,12 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 			localTxs[account] = txs
 		}
 	}
+	// Private transactions are local by definition, merge them into the nonce
+	// sequence of their senders
+	for account, txs := range private {
+		localTxs[account] = mergeTxsByNonce(txs, append(localTxs[account], remoteTxs[account]...))
+		delete(remoteTxs, account)
+	}
 	if len(localTxs) > 0 {
 		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
@@ -970,6 +995,25 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	w.commit(uncles, w.fullTaskHook, true, tstart)
 }
 
+// mergeTxsByNonce merges two nonce sorted transaction lists of the same account
+// into a single nonce sorted one. If both lists contain a transaction with the
+// same nonce, the one from the preferred list is kept.
+func mergeTxsByNonce(preferred, others types.Transactions) types.Transactions {
+	merged := make(types.Transactions, 0, len(preferred)+len(others))
+	for len(preferred) > 0 && len(others) > 0 {
+		switch {
+		case preferred[0].Nonce() < others[0].Nonce():
+			merged, preferred = append(merged, preferred[0]), preferred[1:]
+		case preferred[0].Nonce() > others[0].Nonce():
+			merged, others = append(merged, others[0]), others[1:]
+		default:
+			merged, preferred, others = append(merged, preferred[0]), preferred[1:], others[1:]
+		}
+	}
+	merged = append(merged, preferred...)
+	return append(merged, others...)
+}
+
 // commit runs any post-transaction state modifications, assembles the final block
 // and commits new work if consensus engine is running.
 func (w *worker) commit(uncles []*types.Header, interval func(), update bool, start time.Time) error {
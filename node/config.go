@@ -70,6 +70,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value store implementation backing the databases in the
+	// data directory ("leveldb" or "pebble"). If empty, existing databases are
+	// opened with the engine they were created with and new ones use leveldb.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -17,7 +17,9 @@
 package params
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 
@@ -215,16 +217,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, 0, nil, 0, nil, new(EthashConfig), nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the MFA core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, 0, nil, 0, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, 0, nil, 0, nil, new(EthashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -295,8 +297,19 @@ type ChainConfig struct {
 	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`     // Petersburg switch block (nil = same as Constantinople)
 	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`       // Istanbul switch block (nil = no fork, 0 = already on istanbul)
 	MuirGlacierBlock    *big.Int `json:"muirGlacierBlock,omitempty"`    // Eip-2384 (bomb delay) switch block (nil = no fork, 0 = already activated)
+	BLSBlock            *big.Int `json:"blsBlock,omitempty"`            // BLS12-381 precompiles (EIP-2537) switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	// Contract size limits, overriding the EIP-170 code size limit and limiting
+	// and metering the initcode of contract creations from their switch blocks
+	CodeSizeLimitBlock *big.Int `json:"codeSizeLimitBlock,omitempty"` // Code size limit switch block (nil = EIP-170 limit from EIP158, 0 = already activated)
+	MaxCodeSize        uint64   `json:"maxCodeSize,omitempty"`        // Maximum size of the contract code once switched (0 = params.MaxCodeSize)
+	InitCodeLimitBlock *big.Int `json:"initCodeLimitBlock,omitempty"` // Initcode limit and metering switch block (nil = no limit, 0 = already activated)
+	MaxInitCodeSize    uint64   `json:"maxInitCodeSize,omitempty"`    // Maximum size of the initcode once switched (0 = twice the code size limit)
+
+	// Custom precompiled contracts, implemented by factories registered in the vm
+	Precompiles []*PrecompileConfig `json:"precompiles,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"mfaash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -321,6 +334,20 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// PrecompileConfig is the configuration of a custom precompiled contract, made
+// available at an address from a block on.
+type PrecompileConfig struct {
+	Name    string          `json:"name"`             // Name the contract factory is registered with
+	Address common.Address  `json:"address"`          // Address to make the contract available at
+	Block   *big.Int        `json:"block"`            // Activation block (nil = never, 0 = already activated)
+	Config  json.RawMessage `json:"config,omitempty"` // Contract specific configuration passed to the factory
+}
+
+// IsActive returns whether the precompiled contract is available at num.
+func (c *PrecompileConfig) IsActive(num *big.Int) bool {
+	return isForked(c.Block, num)
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -332,7 +359,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, BLS: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -345,6 +372,7 @@ func (c *ChainConfig) String() string {
 		c.PetersburgBlock,
 		c.IstanbulBlock,
 		c.MuirGlacierBlock,
+		c.BLSBlock,
 		engine,
 	)
 }
@@ -401,11 +429,45 @@ func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
 	return isForked(c.IstanbulBlock, num)
 }
 
+// IsBLS returns whether num is either equal to the BLS12-381 precompiles fork
+// block or greater.
+func (c *ChainConfig) IsBLS(num *big.Int) bool {
+	return isForked(c.BLSBlock, num)
+}
+
 // IsEWASM returns whether num represents a block number after the EWASM fork
 func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// CodeSizeLimit returns the maximum size of the code of the contracts created
+// at num, or zero if unlimited.
+func (c *ChainConfig) CodeSizeLimit(num *big.Int) uint64 {
+	switch {
+	case isForked(c.CodeSizeLimitBlock, num) && c.MaxCodeSize != 0:
+		return c.MaxCodeSize
+	case isForked(c.CodeSizeLimitBlock, num) || c.IsEIP158(num):
+		return MaxCodeSize
+	default:
+		return 0
+	}
+}
+
+// InitCodeSizeLimit returns the maximum size of the initcode of the contract
+// creations at num, or zero if unlimited. The initcode is metered once limited.
+func (c *ChainConfig) InitCodeSizeLimit(num *big.Int) uint64 {
+	switch {
+	case !isForked(c.InitCodeLimitBlock, num):
+		return 0
+	case c.MaxInitCodeSize != 0:
+		return c.MaxInitCodeSize
+	case c.CodeSizeLimit(num) != 0:
+		return 2 * c.CodeSizeLimit(num)
+	default:
+		return MaxInitCodeSize
+	}
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -498,9 +560,59 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.MuirGlacierBlock, newcfg.MuirGlacierBlock, head) {
 		return newCompatError("Muir Glacier fork block", c.MuirGlacierBlock, newcfg.MuirGlacierBlock)
 	}
+	if isForkIncompatible(c.BLSBlock, newcfg.BLSBlock, head) {
+		return newCompatError("BLS fork block", c.BLSBlock, newcfg.BLSBlock)
+	}
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.CodeSizeLimitBlock, newcfg.CodeSizeLimitBlock, head) {
+		return newCompatError("code size limit block", c.CodeSizeLimitBlock, newcfg.CodeSizeLimitBlock)
+	}
+	if isForked(c.CodeSizeLimitBlock, head) && c.MaxCodeSize != newcfg.MaxCodeSize {
+		return newCompatError("max code size", c.CodeSizeLimitBlock, newcfg.CodeSizeLimitBlock)
+	}
+	if isForkIncompatible(c.InitCodeLimitBlock, newcfg.InitCodeLimitBlock, head) {
+		return newCompatError("initcode limit block", c.InitCodeLimitBlock, newcfg.InitCodeLimitBlock)
+	}
+	if isForked(c.InitCodeLimitBlock, head) && c.MaxInitCodeSize != newcfg.MaxInitCodeSize {
+		return newCompatError("max initcode size", c.InitCodeLimitBlock, newcfg.InitCodeLimitBlock)
+	}
+	return checkPrecompilesCompatible(c.Precompiles, newcfg.Precompiles, head)
+}
+
+// checkPrecompilesCompatible checks whether the custom precompiled contracts of
+// two configs are compatible, i.e. none of them is rescheduled or changed once
+// active at head.
+func checkPrecompilesCompatible(stored, updated []*PrecompileConfig, head *big.Int) *ConfigCompatError {
+	find := func(configs []*PrecompileConfig, addr common.Address) *PrecompileConfig {
+		for _, c := range configs {
+			if c.Address == addr {
+				return c
+			}
+		}
+		return &PrecompileConfig{Address: addr}
+	}
+	check := func(s, n *PrecompileConfig) *ConfigCompatError {
+		what := fmt.Sprintf("precompile %s activation block", s.Address.Hex())
+		if isForkIncompatible(s.Block, n.Block, head) {
+			return newCompatError(what, s.Block, n.Block)
+		}
+		if s.IsActive(head) && (s.Name != n.Name || !bytes.Equal(s.Config, n.Config)) {
+			return newCompatError(what, s.Block, n.Block)
+		}
+		return nil
+	}
+	for _, s := range stored {
+		if err := check(s, find(updated, s.Address)); err != nil {
+			return err
+		}
+	}
+	for _, n := range updated {
+		if err := check(find(stored, n.Address), n); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -568,6 +680,9 @@ type Rules struct {
 	ChainID                                                 *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
+	IsBLS                                                   bool
+
+	MaxCodeSize, MaxInitCodeSize uint64 // Contract size limits, zero if unlimited
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -586,5 +701,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsConstantinople: c.IsConstantinople(num),
 		IsPetersburg:     c.IsPetersburg(num),
 		IsIstanbul:       c.IsIstanbul(num),
+		IsBLS:            c.IsBLS(num),
+		MaxCodeSize:      c.CodeSizeLimit(num),
+		MaxInitCodeSize:  c.InitCodeSizeLimit(num),
 	}
 }
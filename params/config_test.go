@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/MFAChain/mfachain/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +72,73 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(10)}}},
+			new:     &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(20)}}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(10)}}},
+			new:    &ChainConfig{},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0x1000000000000000000000000000000000000000 activation block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    nil,
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(10)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0x1000000000000000000000000000000000000000 activation block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(10)}}},
+			new:    &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "test", Address: common.Address{0x10}, Block: big.NewInt(10), Config: []byte(`{}`)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0x1000000000000000000000000000000000000000 activation block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{CodeSizeLimitBlock: big.NewInt(10), MaxCodeSize: 0x8000},
+			new:     &ChainConfig{CodeSizeLimitBlock: big.NewInt(10), MaxCodeSize: 0x10000},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{CodeSizeLimitBlock: big.NewInt(10), MaxCodeSize: 0x8000},
+			new:    &ChainConfig{CodeSizeLimitBlock: big.NewInt(10), MaxCodeSize: 0x10000},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "max code size",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{InitCodeLimitBlock: big.NewInt(10)},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "initcode limit block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +148,30 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestContractSizeLimits(t *testing.T) {
+	tests := []struct {
+		config         *ChainConfig
+		block          int64
+		code, initcode uint64
+	}{
+		{config: &ChainConfig{}, block: 0, code: 0, initcode: 0},
+		{config: &ChainConfig{EIP158Block: big.NewInt(5)}, block: 5, code: MaxCodeSize, initcode: 0},
+		{config: &ChainConfig{CodeSizeLimitBlock: big.NewInt(5), MaxCodeSize: 0x8000}, block: 4, code: 0, initcode: 0},
+		{config: &ChainConfig{CodeSizeLimitBlock: big.NewInt(5), MaxCodeSize: 0x8000}, block: 5, code: 0x8000, initcode: 0},
+		{config: &ChainConfig{CodeSizeLimitBlock: big.NewInt(5)}, block: 5, code: MaxCodeSize, initcode: 0},
+		{config: &ChainConfig{EIP158Block: big.NewInt(0), InitCodeLimitBlock: big.NewInt(5)}, block: 5, code: MaxCodeSize, initcode: MaxInitCodeSize},
+		{config: &ChainConfig{CodeSizeLimitBlock: big.NewInt(0), MaxCodeSize: 0x8000, InitCodeLimitBlock: big.NewInt(5)}, block: 5, code: 0x8000, initcode: 0x10000},
+		{config: &ChainConfig{InitCodeLimitBlock: big.NewInt(5), MaxInitCodeSize: 0x20000}, block: 5, code: 0, initcode: 0x20000},
+		{config: &ChainConfig{InitCodeLimitBlock: big.NewInt(5)}, block: 5, code: 0, initcode: MaxInitCodeSize},
+	}
+	for i, test := range tests {
+		num := big.NewInt(test.block)
+		if code := test.config.CodeSizeLimit(num); code != test.code {
+			t.Errorf("test %d: code size limit mismatch: have %d, want %d", i, code, test.code)
+		}
+		if initcode := test.config.InitCodeSizeLimit(num); initcode != test.initcode {
+			t.Errorf("test %d: initcode size limit mismatch: have %d, want %d", i, initcode, test.initcode)
+		}
+	}
+}
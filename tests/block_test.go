@@ -18,6 +18,8 @@ package tests
 
 import (
 	"testing"
+
+	"github.com/MFAChain/mfachain/core/vm"
 )
 
 func TestBlockchain(t *testing.T) {
@@ -45,10 +47,10 @@ func TestBlockchain(t *testing.T) {
 	bt.skipLoad(`.*randomStatetest94.json.*`)
 
 	bt.walk(t, blockTestDir, func(t *testing.T, name string, test *BlockTest) {
-		if err := bt.checkFailure(t, name+"/trie", test.Run(false)); err != nil {
+		if err := bt.checkFailure(t, name+"/trie", test.Run(false, vm.Config{})); err != nil {
 			t.Errorf("test without snapshotter failed: %v", err)
 		}
-		if err := bt.checkFailure(t, name+"/snap", test.Run(true)); err != nil {
+		if err := bt.checkFailure(t, name+"/snap", test.Run(true, vm.Config{})); err != nil {
 			t.Errorf("test with snapshotter failed: %v", err)
 		}
 	})
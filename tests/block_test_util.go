@@ -95,7 +95,15 @@ type btHeaderMarshaling struct {
 	Timestamp  math.HexOrDecimal64
 }
 
-func (t *BlockTest) Run(snapshotter bool) error {
+// Network returns the name of the fork rules the test runs with.
+func (t *BlockTest) Network() string {
+	return t.json.Network
+}
+
+// Run imports the blocks of the test into a fresh chain and checks the
+// resulting chain head and state, executing the transactions with the given
+// VM configuration.
+func (t *BlockTest) Run(snapshotter bool, vmConfig vm.Config) error {
 	config, ok := Forks[t.json.Network]
 	if !ok {
 		return UnsupportedForkError{t.json.Network}
@@ -124,7 +132,7 @@ func (t *BlockTest) Run(snapshotter bool) error {
 		cache.SnapshotLimit = 1
 		cache.SnapshotWait = true
 	}
-	chain, err := core.NewBlockChain(db, cache, config, engine, vm.Config{}, nil, nil)
+	chain, err := core.NewBlockChain(db, cache, config, engine, vmConfig, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,7 @@ package tests
 import (
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/MFAChain/mfachain/params"
 )
@@ -142,6 +143,16 @@ var Forks = map[string]*params.ChainConfig{
 	},
 }
 
+// AvailableForks returns the sorted names of the supported forks.
+func AvailableForks() []string {
+	var forks []string
+	for k := range Forks {
+		forks = append(forks, k)
+	}
+	sort.Strings(forks)
+	return forks
+}
+
 // UnsupportedForkError is returned when a test requests a fork that isn't implemented.
 type UnsupportedForkError struct {
 	Name string